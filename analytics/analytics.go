@@ -0,0 +1,403 @@
+// Package analytics keep the performance and rewards of snapshot block producers of every consensus cycle.
+// Planned and produced blocks are replayed from the plans of consensus and the snapshot chain,
+// latency of blocks is observed by the node itself, so it is missing for cycles the node was not online.
+package analytics
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	// the default count of past cycles analysed at the first start
+	defaultBackfill = 7
+	// blocks received later than it are synced, not broadcast by producers
+	maxLatency = 75 * time.Second
+
+	checkInterval = time.Minute
+)
+
+// MissedSlot is a planned slot without the block of the producer
+type MissedSlot struct {
+	Timestamp int64  `json:"timestamp"`
+	Period    uint64 `json:"period"`
+}
+
+// ProducerStats is the performance and reward of a producer in a cycle.
+// Latency is in milliseconds, from the timestamp of the block to the time it was inserted by the node.
+type ProducerStats struct {
+	Name     string        `json:"name"`
+	Address  types.Address `json:"address"`
+	Planned  uint64        `json:"planned"`
+	Produced uint64        `json:"produced"`
+	Missed   []*MissedSlot `json:"missed"`
+
+	LatencySamples uint64 `json:"latencySamples"`
+	LatencySum     int64  `json:"latencySum"`
+	MaxLatency     int64  `json:"maxLatency"`
+
+	Reward *big.Int `json:"reward"`
+}
+
+// AvgLatency return the average latency in milliseconds, 0 if no block is observed
+func (p *ProducerStats) AvgLatency() int64 {
+	if p.LatencySamples == 0 {
+		return 0
+	}
+	return p.LatencySum / int64(p.LatencySamples)
+}
+
+// CycleStats is the stats of all producers planned in a cycle, in order of name
+type CycleStats struct {
+	Cycle     uint64           `json:"cycle"`
+	STime     int64            `json:"stime"`
+	ETime     int64            `json:"etime"`
+	Producers []*ProducerStats `json:"producers"`
+}
+
+// Chain is the part of chain used by Collector
+type Chain interface {
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSnapshotHeaderBeforeTime(timestamp *time.Time) (*ledger.SnapshotBlock, error)
+	GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error)
+	GetAllRegisterList(snapshotHash types.Hash, gid types.Gid) ([]*types.Registration, error)
+	Register(listener chain.EventListener)
+	UnRegister(listener chain.EventListener)
+}
+
+// RewardReader read the total rewards of producers by name in a cycle,
+// return util.ErrRewardNotDue if the cycle is too recent
+type RewardReader interface {
+	Rewards(cycle uint64) (map[string]*big.Int, error)
+}
+
+type latency struct {
+	samples uint64
+	sum     int64
+	max     int64
+}
+
+// Collector analyse every finished cycle once, and keep the results in store
+type Collector struct {
+	chain    Chain
+	cs       consensus.Reader
+	cycles   core.TimeIndex
+	rewards  RewardReader
+	store    *store
+	backfill uint64
+
+	mu      sync.Mutex
+	latency map[uint64]map[types.Address]*latency
+
+	term chan struct{}
+	wg   sync.WaitGroup
+
+	log log15.Logger
+}
+
+// NewCollector open the store in dir, cycles is the time index of consensus cycles (days).
+// Backfill is the count of past cycles analysed at the first start, use the default if 0.
+func NewCollector(chain Chain, cs consensus.Reader, cycles core.TimeIndex, rewards RewardReader, dir string, backfill uint64) (*Collector, error) {
+	st, err := newStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if backfill == 0 {
+		backfill = defaultBackfill
+	}
+
+	return &Collector{
+		chain:    chain,
+		cs:       cs,
+		cycles:   cycles,
+		rewards:  rewards,
+		store:    st,
+		backfill: backfill,
+		latency:  make(map[uint64]map[types.Address]*latency),
+		log:      log15.New("module", "analytics"),
+	}, nil
+}
+
+func (c *Collector) Start() {
+	c.term = make(chan struct{})
+	c.chain.Register(c)
+
+	c.wg.Add(1)
+	go c.loop()
+}
+
+func (c *Collector) Stop() {
+	c.chain.UnRegister(c)
+
+	close(c.term)
+	c.wg.Wait()
+
+	if err := c.store.close(); err != nil {
+		c.log.Error("failed to close analytics store", "err", err)
+	}
+}
+
+func (c *Collector) loop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		c.process()
+
+		select {
+		case <-c.term:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process analyse the finished cycles not in store
+func (c *Collector) process() {
+	latest := c.chain.GetLatestSnapshotBlock()
+	current := c.cycles.Time2Index(*latest.Timestamp)
+
+	next, ok := c.store.last()
+	if ok {
+		next++
+	} else if current > c.backfill {
+		next = current - c.backfill
+	} else {
+		next = 0
+	}
+
+	for ; next < current; next++ {
+		select {
+		case <-c.term:
+			return
+		default:
+		}
+
+		stats, err := c.Analyse(next)
+		if err == util.ErrRewardNotDue {
+			return
+		}
+		if err != nil {
+			c.log.Error("failed to analyse cycle", "cycle", next, "err", err)
+			return
+		}
+
+		if err = c.store.put(stats); err != nil {
+			c.log.Error("failed to store analytics", "cycle", next, "err", err)
+			return
+		}
+
+		c.mu.Lock()
+		delete(c.latency, next)
+		c.mu.Unlock()
+	}
+}
+
+// Analyse replay the plans and blocks of a finished cycle
+func (c *Collector) Analyse(cycle uint64) (*CycleStats, error) {
+	rewards, err := c.rewards.Rewards(cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	stime, etime := c.cycles.Index2Time(cycle)
+	produced, err := c.producers(stime, etime)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := c.names()
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := c.cs.VoteTimeToIndex(types.SNAPSHOT_GID, stime)
+	if err != nil {
+		return nil, err
+	}
+	last, err := c.cs.VoteTimeToIndex(types.SNAPSHOT_GID, etime.Add(-time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[types.Address]*ProducerStats)
+	for period := first; period <= last; period++ {
+		events, _, err := c.cs.ReadByIndex(types.SNAPSHOT_GID, period)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range events {
+			if e.Timestamp.Before(stime) || !e.Timestamp.Before(etime) {
+				continue
+			}
+
+			p, ok := m[e.Address]
+			if !ok {
+				p = &ProducerStats{Name: names[e.Address], Address: e.Address, Reward: big.NewInt(0)}
+				m[e.Address] = p
+			}
+
+			p.Planned++
+			if producer, ok := produced[e.Timestamp.Unix()]; ok && producer == e.Address {
+				p.Produced++
+			} else {
+				p.Missed = append(p.Missed, &MissedSlot{Timestamp: e.Timestamp.Unix(), Period: period})
+			}
+		}
+	}
+
+	c.mu.Lock()
+	for addr, l := range c.latency[cycle] {
+		if p, ok := m[addr]; ok {
+			p.LatencySamples, p.LatencySum, p.MaxLatency = l.samples, l.sum, l.max
+		}
+	}
+	c.mu.Unlock()
+
+	stats := &CycleStats{Cycle: cycle, STime: stime.Unix(), ETime: etime.Unix()}
+	for _, p := range m {
+		if reward, ok := rewards[p.Name]; ok && p.Name != "" {
+			p.Reward.Set(reward)
+		}
+		stats.Producers = append(stats.Producers, p)
+	}
+	sort.Slice(stats.Producers, func(i, j int) bool {
+		a, b := stats.Producers[i], stats.Producers[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Address.String() < b.Address.String()
+	})
+
+	return stats, nil
+}
+
+// producers return the producers of snapshot blocks in [stime, etime), by the timestamps of blocks
+func (c *Collector) producers(stime, etime time.Time) (map[int64]types.Address, error) {
+	produced := make(map[int64]types.Address)
+
+	block, err := c.chain.GetSnapshotHeaderBeforeTime(&etime)
+	for err == nil && block != nil && !block.Timestamp.Before(stime) {
+		produced[block.Timestamp.Unix()] = block.Producer()
+		if block.Height <= 1 {
+			break
+		}
+		block, err = c.chain.GetSnapshotHeaderByHeight(block.Height - 1)
+	}
+
+	return produced, err
+}
+
+// names return the names of producing addresses, include the addresses used before
+func (c *Collector) names() (map[types.Address]string, error) {
+	latest := c.chain.GetLatestSnapshotBlock()
+	list, err := c.chain.GetAllRegisterList(latest.Hash, types.SNAPSHOT_GID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[types.Address]string)
+	for _, r := range list {
+		for _, addr := range r.HisAddrList {
+			names[addr] = r.Name
+		}
+		names[r.BlockProducingAddress] = r.Name
+	}
+	return names, nil
+}
+
+// observe the latency of blocks just produced
+func (c *Collector) observe(block *ledger.SnapshotBlock, now time.Time) {
+	d := now.Sub(*block.Timestamp)
+	if d < 0 {
+		d = 0
+	}
+	if d > maxLatency {
+		return
+	}
+
+	cycle := c.cycles.Time2Index(*block.Timestamp)
+	ms := int64(d / time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m, ok := c.latency[cycle]
+	if !ok {
+		m = make(map[types.Address]*latency)
+		c.latency[cycle] = m
+	}
+	l, ok := m[block.Producer()]
+	if !ok {
+		l = &latency{}
+		m[block.Producer()] = l
+	}
+	l.samples++
+	l.sum += ms
+	if ms > l.max {
+		l.max = ms
+	}
+}
+
+// Cycles return the stats of cycles in [start, end] in store
+func (c *Collector) Cycles(start, end uint64) ([]*CycleStats, error) {
+	return c.store.list(start, end)
+}
+
+// CurrentCycle return the index of the cycle in progress
+func (c *Collector) CurrentCycle() uint64 {
+	return c.cycles.Time2Index(*c.chain.GetLatestSnapshotBlock().Timestamp)
+}
+
+func (c *Collector) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (c *Collector) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (c *Collector) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (c *Collector) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	now := time.Now()
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil {
+			c.observe(chunk.SnapshotBlock, now)
+		}
+	}
+	return nil
+}
+
+func (c *Collector) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (c *Collector) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (c *Collector) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (c *Collector) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
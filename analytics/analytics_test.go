@@ -0,0 +1,201 @@
+package analytics
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+// slots of 1 second, periods of 5 slots, cycles of 10 slots
+var genesis = time.Unix(1541640427, 0)
+
+const periodSlots = 5
+
+type memChain struct {
+	blocks []*ledger.SnapshotBlock
+	regs   []*types.Registration
+}
+
+func (c *memChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *memChain) GetSnapshotHeaderBeforeTime(timestamp *time.Time) (*ledger.SnapshotBlock, error) {
+	var result *ledger.SnapshotBlock
+	for _, b := range c.blocks {
+		if b.Timestamp.Before(*timestamp) {
+			result = b
+		}
+	}
+	return result, nil
+}
+
+func (c *memChain) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height == 0 || height > uint64(len(c.blocks)) {
+		return nil, nil
+	}
+	return c.blocks[height-1], nil
+}
+
+func (c *memChain) GetAllRegisterList(snapshotHash types.Hash, gid types.Gid) ([]*types.Registration, error) {
+	return c.regs, nil
+}
+
+func (c *memChain) Register(listener chain.EventListener)   {}
+func (c *memChain) UnRegister(listener chain.EventListener) {}
+
+// producers take turns in every period
+type memConsensus []types.Address
+
+func (cs memConsensus) ReadByIndex(gid types.Gid, index uint64) ([]*consensus.Event, uint64, error) {
+	var events []*consensus.Event
+	for k := 0; k < periodSlots; k++ {
+		ts := genesis.Add(time.Duration(int(index)*periodSlots+k) * time.Second)
+		events = append(events, &consensus.Event{Address: cs[k%len(cs)], Timestamp: ts})
+	}
+	return events, index, nil
+}
+
+func (cs memConsensus) VoteTimeToIndex(gid types.Gid, t2 time.Time) (uint64, error) {
+	return uint64(t2.Sub(genesis)/time.Second) / periodSlots, nil
+}
+
+func (cs memConsensus) VoteIndexToTime(gid types.Gid, i uint64) (*time.Time, *time.Time, error) {
+	return nil, nil, nil
+}
+
+type memRewards map[uint64]map[string]*big.Int
+
+func (r memRewards) Rewards(cycle uint64) (map[string]*big.Int, error) {
+	m, ok := r[cycle]
+	if !ok {
+		return nil, util.ErrRewardNotDue
+	}
+	return m, nil
+}
+
+// newCollector make a chain of 30 slots, slot 3 of b and slot 7 of a are missed
+func newCollector(t *testing.T) (*Collector, *memChain, []types.Address) {
+	var keys []ed25519.PrivateKey
+	var addrs []types.Address
+	for i := 0; i < 2; i++ {
+		_, key, _ := ed25519.GenerateKey(rand.Reader)
+		keys = append(keys, key)
+		addrs = append(addrs, types.PubkeyToAddress(key.PubByte()))
+	}
+
+	c := &memChain{regs: []*types.Registration{
+		{Name: "a", BlockProducingAddress: addrs[0]},
+		{Name: "b", BlockProducingAddress: addrs[1]},
+	}}
+	for slot := 0; slot < 30; slot++ {
+		if slot == 3 || slot == 7 {
+			continue
+		}
+		ts := genesis.Add(time.Duration(slot) * time.Second)
+		c.blocks = append(c.blocks, &ledger.SnapshotBlock{
+			Height:    uint64(len(c.blocks) + 1),
+			Timestamp: &ts,
+			PublicKey: keys[slot%periodSlots%2].PubByte(),
+		})
+	}
+
+	rewards := memRewards{0: {"a": big.NewInt(10)}, 1: {}}
+	collector, err := NewCollector(c, memConsensus(addrs), core.NewTimeIndex(genesis, 10*time.Second), rewards, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return collector, c, addrs
+}
+
+func TestCollector_Analyse(t *testing.T) {
+	collector, _, addrs := newCollector(t)
+	defer collector.store.close()
+
+	stats, err := collector.Analyse(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Producers) != 2 {
+		t.Fatalf("%d producers", len(stats.Producers))
+	}
+
+	expected := []struct {
+		name     string
+		addr     types.Address
+		planned  uint64
+		produced uint64
+		missed   int64
+		reward   int64
+	}{{"a", addrs[0], 6, 5, 7, 10}, {"b", addrs[1], 4, 3, 3, 0}}
+	for i, e := range expected {
+		p := stats.Producers[i]
+		if p.Name != e.name || p.Address != e.addr || p.Planned != e.planned || p.Produced != e.produced {
+			t.Errorf("producer %d: %s planned %d produced %d", i, p.Name, p.Planned, p.Produced)
+		}
+		if len(p.Missed) != 1 || p.Missed[0].Timestamp != genesis.Unix()+e.missed {
+			t.Errorf("producer %s missed %v", p.Name, p.Missed)
+		}
+		if p.Reward.Int64() != e.reward {
+			t.Errorf("reward of %s is %s", p.Name, p.Reward)
+		}
+	}
+
+	if _, err = collector.Analyse(2); err != util.ErrRewardNotDue {
+		t.Errorf("reward of cycle 2 is not due: %v", err)
+	}
+}
+
+func TestCollector_process(t *testing.T) {
+	collector, c, addrs := newCollector(t)
+	defer collector.store.close()
+
+	// block of slot 11 is received in time, the block of slot 12 is synced
+	collector.observe(c.blocks[9], c.blocks[9].Timestamp.Add(200*time.Millisecond))
+	collector.observe(c.blocks[10], c.blocks[10].Timestamp.Add(2*time.Minute))
+
+	collector.process()
+	if last, ok := collector.store.last(); !ok || last != 1 {
+		t.Fatalf("last analysed cycle is %d", last)
+	}
+
+	list, err := collector.Cycles(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Cycle != 0 || list[1].Cycle != 1 {
+		t.Fatalf("%d cycles", len(list))
+	}
+	b := list[1].Producers[1]
+	if b.Address != addrs[1] || b.LatencySamples != 1 || b.MaxLatency != 200 || b.AvgLatency() != 200 {
+		t.Errorf("latency of b is %d/%d", b.LatencySum, b.LatencySamples)
+	}
+	if len(collector.latency) != 0 {
+		t.Error("latency of analysed cycles should be pruned")
+	}
+
+	summaries := Summarize(list)
+	if len(summaries) != 2 || summaries[0].Name != "a" || summaries[0].Planned != 12 || summaries[0].Missed != 1 {
+		t.Errorf("wrong summaries: %+v", summaries[0])
+	}
+
+	var buf bytes.Buffer
+	if err = WriteCSV(&buf, list); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[1], "0,") || !strings.HasSuffix(lines[1], ",10") {
+		t.Errorf("wrong csv:\n%s", buf.String())
+	}
+}
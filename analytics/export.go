@@ -0,0 +1,135 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+var csvHeader = []string{"cycle", "stime", "etime", "name", "address", "planned", "produced", "missed",
+	"missedTimestamps", "avgLatency", "maxLatency", "reward"}
+
+// WriteCSV write a row for every producer of every cycle, missed timestamps are separated by ';'
+func WriteCSV(w io.Writer, list []*CycleStats) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, c := range list {
+		for _, p := range c.Producers {
+			missed := make([]string, len(p.Missed))
+			for i, m := range p.Missed {
+				missed[i] = strconv.FormatInt(m.Timestamp, 10)
+			}
+
+			err := cw.Write([]string{
+				strconv.FormatUint(c.Cycle, 10),
+				strconv.FormatInt(c.STime, 10),
+				strconv.FormatInt(c.ETime, 10),
+				p.Name,
+				p.Address.String(),
+				strconv.FormatUint(p.Planned, 10),
+				strconv.FormatUint(p.Produced, 10),
+				strconv.Itoa(len(p.Missed)),
+				strings.Join(missed, ";"),
+				strconv.FormatInt(p.AvgLatency(), 10),
+				strconv.FormatInt(p.MaxLatency, 10),
+				p.Reward.String(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// Summary is the performance and reward of a producer over cycles
+type Summary struct {
+	Name     string
+	Address  types.Address
+	Cycles   int
+	Planned  uint64
+	Produced uint64
+	Missed   uint64
+
+	LatencySamples uint64
+	LatencySum     int64
+	MaxLatency     int64
+
+	Reward *big.Int
+}
+
+// ProduceRate return produced / planned, 0 if nothing planned
+func (s *Summary) ProduceRate() float64 {
+	if s.Planned == 0 {
+		return 0
+	}
+	return float64(s.Produced) / float64(s.Planned)
+}
+
+// AvgLatency return the average latency in milliseconds, 0 if no block is observed
+func (s *Summary) AvgLatency() int64 {
+	if s.LatencySamples == 0 {
+		return 0
+	}
+	return s.LatencySum / int64(s.LatencySamples)
+}
+
+// Summarize add up the stats of producers by name, or by address if not registered.
+// The summaries are in order of produce rate, the most reliable first.
+func Summarize(list []*CycleStats) []*Summary {
+	m := make(map[string]*Summary)
+	for _, c := range list {
+		for _, p := range c.Producers {
+			key := p.Name
+			if key == "" {
+				key = p.Address.String()
+			}
+
+			s, ok := m[key]
+			if !ok {
+				s = &Summary{Name: p.Name, Reward: big.NewInt(0)}
+				m[key] = s
+			}
+			s.Address = p.Address
+			s.Cycles++
+			s.Planned += p.Planned
+			s.Produced += p.Produced
+			s.Missed += uint64(len(p.Missed))
+			s.LatencySamples += p.LatencySamples
+			s.LatencySum += p.LatencySum
+			if p.MaxLatency > s.MaxLatency {
+				s.MaxLatency = p.MaxLatency
+			}
+			if p.Reward != nil {
+				s.Reward.Add(s.Reward, p.Reward)
+			}
+		}
+	}
+
+	result := make([]*Summary, 0, len(m))
+	for _, s := range m {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.ProduceRate() != b.ProduceRate() {
+			return a.ProduceRate() > b.ProduceRate()
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Address.String() < b.Address.String()
+	})
+
+	return result
+}
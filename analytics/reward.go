@@ -0,0 +1,49 @@
+package analytics
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/vm/contracts"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+type chainRewards struct {
+	chain  chain.Chain
+	reader util.ConsensusReader
+}
+
+// NewRewardReader calculate rewards by the governance contract at the latest snapshot block
+func NewRewardReader(c chain.Chain, sbp core.SBPStatReader) RewardReader {
+	return &chainRewards{chain: c, reader: util.NewVMConsensusReader(sbp)}
+}
+
+func (r *chainRewards) Rewards(cycle uint64) (map[string]*big.Int, error) {
+	prevHash := &types.Hash{}
+	prev, err := r.chain.GetLatestAccountBlock(types.AddressGovernance)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		prevHash = &prev.Hash
+	}
+
+	db, err := vm_db.NewVmDb(r.chain, &types.AddressGovernance, &r.chain.GetLatestSnapshotBlock().Hash, prevHash)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := contracts.CalcRewardByIndex(db, r.reader, cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	rewards := make(map[string]*big.Int, len(m))
+	for name, reward := range m {
+		rewards[name] = reward.TotalReward
+	}
+	return rewards, nil
+}
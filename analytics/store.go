@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var cyclePrefix = []byte("cycle:")
+
+// store keep the stats of cycles in json, by the index of cycle
+type store struct {
+	db *leveldb.DB
+}
+
+// newStore open the store in dir, use memory if dir is empty
+func newStore(dir string) (*store, error) {
+	var db *leveldb.DB
+	var err error
+	if dir == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(dir, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "open analytics store")
+	}
+
+	return &store{db: db}, nil
+}
+
+func cycleKey(cycle uint64) []byte {
+	key := make([]byte, len(cyclePrefix)+8)
+	copy(key, cyclePrefix)
+	binary.BigEndian.PutUint64(key[len(cyclePrefix):], cycle)
+	return key
+}
+
+func (s *store) put(stats *CycleStats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Put(cycleKey(stats.Cycle), data, nil)
+}
+
+// last return the index of the last cycle in store
+func (s *store) last() (uint64, bool) {
+	it := s.db.NewIterator(util.BytesPrefix(cyclePrefix), nil)
+	defer it.Release()
+
+	if !it.Last() {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(it.Key()[len(cyclePrefix):]), true
+}
+
+// list the cycles in [start, end]
+func (s *store) list(start, end uint64) ([]*CycleStats, error) {
+	limit := util.BytesPrefix(cyclePrefix).Limit
+	if end < math.MaxUint64 {
+		limit = cycleKey(end + 1)
+	}
+
+	it := s.db.NewIterator(&util.Range{Start: cycleKey(start), Limit: limit}, nil)
+	defer it.Release()
+
+	var list []*CycleStats
+	for it.Next() {
+		stats := new(CycleStats)
+		if err := json.Unmarshal(it.Value(), stats); err != nil {
+			return nil, err
+		}
+		list = append(list, stats)
+	}
+
+	return list, it.Error()
+}
+
+func (s *store) close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,466 @@
+package gvite_plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/pool/lock"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	consensusCommand = cli.Command{
+		Name:     "consensus",
+		Usage:    "consensus simulate",
+		Category: "CONSENSUS COMMANDS",
+		Description: `
+Tools of the snapshot consensus group.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(simulateConsensusAction),
+				Name:      "simulate",
+				Usage:     "replay the election of snapshot producers over a range of indexes",
+				ArgsUsage: " ",
+				Flags:     append(consensusFlags, configFlags...),
+				Description: `
+Replay the election of snapshot producers: filter votes, replace the producers of low success rate,
+promote random producers, and shuffle. Print the producers of every index and the slots of every producer.
+
+The votes, seed and success rate are read from the chain of the data dir, stop gvite first.
+Or use a synthetic vote set by --votes, in JSON:
+
+  {
+    "group": {"nodeCount": 25, "interval": 1, "perCount": 3, "randCount": 2, "randRank": 100, "repeat": 1},
+    "genesisTime": 1558411200,
+    "votes": [{"name": "s1", "address": "vite_...", "balance": "1000000"}],
+    "successRate": {"vite_...": 900000},
+    "seed": 0
+  }
+
+--whatif compares with hypothetical vote changes, in JSON:
+
+  [{"name": "s1", "delta": "-500"}, {"name": "s2", "address": "vite_...", "balance": "2000"}, {"name": "s3", "remove": true}]
+`,
+			},
+		},
+	}
+)
+
+// electionSource provide the inputs of elections
+type electionSource interface {
+	groupInfo() *core.GroupInfo
+	defaultIndex() uint64
+	election(index uint64) (*core.Election, error)
+	close()
+}
+
+type chainElectionSource struct {
+	chain  chain.Chain
+	reader consensus.ElectionInputReader
+}
+
+func newChainElectionSource(ctx *cli.Context) (*chainElectionSource, error) {
+	node, err := nodemanager.FullNodeMaker{}.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	viteConfig := node.ViteConfig()
+	fork.SetForkPoints(viteConfig.ForkPoints)
+
+	c := chain.NewChain(viteConfig.DataDir, viteConfig.Chain, viteConfig.Genesis)
+	if err = c.Init(); err != nil {
+		return nil, err
+	}
+	if err = c.Start(); err != nil {
+		return nil, err
+	}
+
+	cs := consensus.NewConsensus(c, &lock.EasyImpl{})
+	if err = cs.Init(); err != nil {
+		c.Stop()
+		return nil, err
+	}
+
+	reader, ok := cs.SBPReader().(consensus.ElectionInputReader)
+	if !ok {
+		c.Stop()
+		return nil, errors.New("snapshot consensus can not be simulated")
+	}
+
+	return &chainElectionSource{chain: c, reader: reader}, nil
+}
+
+func (s *chainElectionSource) groupInfo() *core.GroupInfo {
+	return s.reader.GetInfo()
+}
+
+func (s *chainElectionSource) defaultIndex() uint64 {
+	return s.reader.Time2Index(*s.chain.GetLatestSnapshotBlock().Timestamp) + 1
+}
+
+func (s *chainElectionSource) election(index uint64) (*core.Election, error) {
+	return s.reader.ElectionInput(index)
+}
+
+func (s *chainElectionSource) close() {
+	s.chain.Stop()
+}
+
+type syntheticGroup struct {
+	NodeCount uint8  `json:"nodeCount"`
+	Interval  int64  `json:"interval"`
+	PerCount  int64  `json:"perCount"`
+	RandCount uint8  `json:"randCount"`
+	RandRank  uint8  `json:"randRank"`
+	Repeat    uint16 `json:"repeat"`
+}
+
+type syntheticVote struct {
+	Name    string        `json:"name"`
+	Address types.Address `json:"address"`
+	Balance string        `json:"balance"`
+	Delta   string        `json:"delta"`
+	Remove  bool          `json:"remove"`
+}
+
+type syntheticVoteSet struct {
+	Group       *syntheticGroup         `json:"group"`
+	GenesisTime int64                   `json:"genesisTime"`
+	Votes       []*syntheticVote        `json:"votes"`
+	SuccessRate map[types.Address]int32 `json:"successRate"`
+	Seed        uint64                  `json:"seed"`
+}
+
+// syntheticElectionSource use the same votes for every index, the height of proof is the index
+type syntheticElectionSource struct {
+	info  *core.GroupInfo
+	set   *syntheticVoteSet
+	votes []*core.Vote
+}
+
+func parseBalance(str string) (*big.Int, error) {
+	if str == "" {
+		return nil, nil
+	}
+	b, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %q", str)
+	}
+	return b, nil
+}
+
+func newSyntheticElectionSource(file string) (*syntheticElectionSource, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &syntheticVoteSet{}
+	if err = json.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+
+	group := types.ConsensusGroupInfo{
+		Gid:             types.SNAPSHOT_GID,
+		NodeCount:       25,
+		Interval:        1,
+		PerCount:        3,
+		RandCount:       2,
+		RandRank:        100,
+		Repeat:          1,
+		CountingTokenId: ledger.ViteTokenId,
+	}
+	if g := set.Group; g != nil {
+		group.NodeCount, group.Interval, group.PerCount = g.NodeCount, g.Interval, g.PerCount
+		group.RandCount, group.RandRank, group.Repeat = g.RandCount, g.RandRank, g.Repeat
+		if group.NodeCount == 0 || group.Interval <= 0 || group.PerCount <= 0 || group.Repeat == 0 {
+			return nil, errors.New("nodeCount, interval, perCount and repeat of group should be positive")
+		}
+	}
+
+	genesisTime := time.Now()
+	if set.GenesisTime > 0 {
+		genesisTime = time.Unix(set.GenesisTime, 0)
+	}
+
+	votes := make([]*core.Vote, 0, len(set.Votes))
+	for _, v := range set.Votes {
+		balance, err := parseBalance(v.Balance)
+		if err != nil {
+			return nil, err
+		}
+		if balance == nil {
+			balance = big.NewInt(0)
+		}
+		votes = append(votes, &core.Vote{Name: v.Name, Addr: v.Address, Balance: balance})
+	}
+	sort.Sort(core.ByBalance(votes))
+
+	return &syntheticElectionSource{
+		info:  core.NewGroupInfo(genesisTime, group),
+		set:   set,
+		votes: votes,
+	}, nil
+}
+
+func (s *syntheticElectionSource) groupInfo() *core.GroupInfo {
+	return s.info
+}
+
+func (s *syntheticElectionSource) defaultIndex() uint64 {
+	return 0
+}
+
+func (s *syntheticElectionSource) election(index uint64) (*core.Election, error) {
+	return &core.Election{
+		Index:       index,
+		Proof:       ledger.HashHeight{Height: index},
+		Votes:       s.votes,
+		SuccessRate: s.set.SuccessRate,
+		Seed:        s.set.Seed,
+	}, nil
+}
+
+func (s *syntheticElectionSource) close() {
+}
+
+func readVoteChanges(file string) ([]*core.VoteChange, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*syntheticVote
+	if err = json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	changes := make([]*core.VoteChange, len(list))
+	for i, v := range list {
+		c := &core.VoteChange{Name: v.Name, Addr: v.Address, Remove: v.Remove}
+		if c.Balance, err = parseBalance(v.Balance); err != nil {
+			return nil, err
+		}
+		if c.Delta, err = parseBalance(v.Delta); err != nil {
+			return nil, err
+		}
+		changes[i] = c
+	}
+
+	return changes, nil
+}
+
+type simulatedMember struct {
+	Name    string        `json:"name"`
+	Address types.Address `json:"address"`
+	Balance string        `json:"balance"`
+	Types   []string      `json:"types,omitempty"`
+}
+
+type simulatedPlan struct {
+	STime  time.Time     `json:"sTime"`
+	Name   string        `json:"name"`
+	Member types.Address `json:"member"`
+}
+
+type simulatedElection struct {
+	Index       uint64             `json:"index"`
+	STime       time.Time          `json:"sTime"`
+	ETime       time.Time          `json:"eTime"`
+	ProofHeight uint64             `json:"proofHeight"`
+	ProofHash   types.Hash         `json:"proofHash"`
+	Seed        uint64             `json:"seed"`
+	Members     []*simulatedMember `json:"members"`
+	Plans       []*simulatedPlan   `json:"plans,omitempty"`
+}
+
+type simulatedSlots struct {
+	Name    string        `json:"name"`
+	Address types.Address `json:"address"`
+	Slots   int           `json:"slots"`
+	WhatIf  *int          `json:"whatIf,omitempty"`
+}
+
+type simulation struct {
+	Elections []*simulatedElection `json:"elections"`
+	WhatIf    []*simulatedElection `json:"whatIf,omitempty"`
+	Slots     []*simulatedSlots    `json:"slots"`
+}
+
+var voteTypeNames = map[core.VoteType]string{
+	core.NORMAL:                 "normal",
+	core.SUCCESS_RATE_PROMOTION: "successRatePromotion",
+	core.SUCCESS_RATE_DEMOTION:  "successRateDemotion",
+	core.RANDOM_PROMOTION:       "randomPromotion",
+}
+
+func toSimulatedElection(e *core.Election, r *core.ElectionResult, plans bool) *simulatedElection {
+	result := &simulatedElection{
+		Index:       r.Index,
+		STime:       r.STime,
+		ETime:       r.ETime,
+		ProofHeight: e.Proof.Height,
+		ProofHash:   e.Proof.Hash,
+		Seed:        e.Seed,
+	}
+
+	for _, v := range r.Members {
+		m := &simulatedMember{Name: v.Name, Address: v.Addr, Balance: v.Balance.String()}
+		for _, t := range v.Type {
+			m.Types = append(m.Types, voteTypeNames[t])
+		}
+		result.Members = append(result.Members, m)
+	}
+
+	if plans {
+		for _, p := range r.Plans {
+			result.Plans = append(result.Plans, &simulatedPlan{STime: p.STime, Name: p.Name, Member: p.Member})
+		}
+	}
+
+	return result
+}
+
+func simulateConsensusAction(ctx *cli.Context) error {
+	var source electionSource
+	var err error
+	if file := ctx.String(utils.ConsensusVotesFlag.Name); file != "" {
+		source, err = newSyntheticElectionSource(file)
+	} else {
+		source, err = newChainElectionSource(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer source.close()
+
+	var changes []*core.VoteChange
+	if file := ctx.String(utils.ConsensusWhatIfFlag.Name); file != "" {
+		if changes, err = readVoteChanges(file); err != nil {
+			return err
+		}
+	}
+
+	from := source.defaultIndex()
+	if ctx.IsSet(utils.ConsensusFromIndexFlag.Name) {
+		from = ctx.Uint64(utils.ConsensusFromIndexFlag.Name)
+	}
+	to := from
+	if ctx.IsSet(utils.ConsensusToIndexFlag.Name) {
+		to = ctx.Uint64(utils.ConsensusToIndexFlag.Name)
+	}
+	if to < from {
+		return fmt.Errorf("to %d is less than from %d", to, from)
+	}
+
+	plans := ctx.Bool(utils.ConsensusPlansFlag.Name)
+	info := source.groupInfo()
+	sim := &simulation{}
+	var results, whatIfResults []*core.ElectionResult
+	names := make(map[types.Address]string)
+
+	for index := from; index <= to; index++ {
+		e, err := source.election(index)
+		if err != nil {
+			return fmt.Errorf("failed to read election %d: %v", index, err)
+		}
+		for _, v := range e.Votes {
+			names[v.Addr] = v.Name
+		}
+
+		r := core.Elect(info, e)
+		results = append(results, r)
+		sim.Elections = append(sim.Elections, toSimulatedElection(e, r, plans))
+
+		if changes != nil {
+			e2 := *e
+			e2.Votes = core.ApplyVoteChanges(e.Votes, changes)
+			for _, v := range e2.Votes {
+				names[v.Addr] = v.Name
+			}
+
+			r2 := core.Elect(info, &e2)
+			whatIfResults = append(whatIfResults, r2)
+			sim.WhatIf = append(sim.WhatIf, toSimulatedElection(&e2, r2, plans))
+		}
+	}
+
+	slots := core.CountSlots(results)
+	whatIfSlots := core.CountSlots(whatIfResults)
+	for addr := range names {
+		s := &simulatedSlots{Name: names[addr], Address: addr, Slots: slots[addr]}
+		if changes != nil {
+			n := whatIfSlots[addr]
+			s.WhatIf = &n
+		}
+		if s.Slots == 0 && (s.WhatIf == nil || *s.WhatIf == 0) {
+			continue
+		}
+		sim.Slots = append(sim.Slots, s)
+	}
+	sort.Slice(sim.Slots, func(i, j int) bool {
+		if sim.Slots[i].Slots != sim.Slots[j].Slots {
+			return sim.Slots[i].Slots > sim.Slots[j].Slots
+		}
+		return sim.Slots[i].Name < sim.Slots[j].Name
+	})
+
+	if ctx.Bool(utils.ConsensusJSONFlag.Name) {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(sim)
+	}
+
+	printSimulation(sim)
+	return nil
+}
+
+func printElection(e *simulatedElection) {
+	fmt.Printf("index %d\t%s ~ %s\tproof %d/%s\tseed %d\n", e.Index, e.STime, e.ETime, e.ProofHeight, e.ProofHash, e.Seed)
+	for i, m := range e.Members {
+		fmt.Printf("  %d\t%s\t%s\t%s\t%s\n", i, m.Name, m.Address, m.Balance, strings.Join(m.Types, ","))
+	}
+	for _, p := range e.Plans {
+		fmt.Printf("    %s\t%s\t%s\n", p.STime, p.Name, p.Member)
+	}
+}
+
+func printSimulation(sim *simulation) {
+	fmt.Println("------election------")
+	for _, e := range sim.Elections {
+		printElection(e)
+	}
+
+	if sim.WhatIf != nil {
+		fmt.Println("------what if------")
+		for _, e := range sim.WhatIf {
+			printElection(e)
+		}
+	}
+
+	fmt.Println("------slots------")
+	for _, s := range sim.Slots {
+		if s.WhatIf != nil {
+			fmt.Printf("%s\t%s\t%d\t->\t%d\n", s.Name, s.Address, s.Slots, *s.WhatIf)
+		} else {
+			fmt.Printf("%s\t%s\t%d\n", s.Name, s.Address, s.Slots)
+		}
+	}
+}
@@ -127,6 +127,24 @@ var (
 	exportFlags = []cli.Flag{
 		utils.ExportSbHeightFlags,
 	}
+
+	// Consensus simulate
+	consensusFlags = []cli.Flag{
+		utils.ConsensusFromIndexFlag,
+		utils.ConsensusToIndexFlag,
+		utils.ConsensusVotesFlag,
+		utils.ConsensusWhatIfFlag,
+		utils.ConsensusPlansFlag,
+		utils.ConsensusJSONFlag,
+	}
+
+	walletFlags = []cli.Flag{
+		utils.WalletKDFFlag,
+		utils.WalletScryptNFlag,
+		utils.WalletArgon2TimeFlag,
+		utils.WalletArgon2MemoryFlag,
+		utils.WalletArgon2ThreadsFlag,
+	}
 )
 
 func init() {
@@ -155,6 +173,9 @@ func init() {
 		exportCommand,
 		pluginDataCommand,
 		checkChainCommand,
+		protectionCommand,
+		consensusCommand,
+		walletCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
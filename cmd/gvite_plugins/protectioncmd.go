@@ -0,0 +1,103 @@
+package gvite_plugins
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/producer"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	protectionCommand = cli.Command{
+		Name:     "protection",
+		Usage:    "protection export|import <file>",
+		Category: "PRODUCER COMMANDS",
+		Description: `
+Export or import the slashing protection of snapshot block producers, in JSON.
+Stop gvite first. Import the file exported from the old machine before producing on the new one.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(exportProtectionAction),
+				Name:      "export",
+				Usage:     "export signed snapshot blocks to file",
+				ArgsUsage: "<file>",
+				Flags:     append(generalFlags, configFlags...),
+			},
+			{
+				Action:    utils.MigrateFlags(importProtectionAction),
+				Name:      "import",
+				Usage:     "import signed snapshot blocks from file",
+				ArgsUsage: "<file>",
+				Flags:     append(generalFlags, configFlags...),
+			},
+		},
+	}
+)
+
+func openProtection(ctx *cli.Context) (*producer.SlashingProtection, error) {
+	cfg, err := nodemanager.FullNodeMaker{}.MakeNodeConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return producer.OpenSlashingProtection(filepath.Join(cfg.DataDir, config.DefaultProducerDirName, producer.ProtectionDirName))
+}
+
+func exportProtectionAction(ctx *cli.Context) error {
+	file := ctx.Args().First()
+	if file == "" {
+		return errors.New("missing file")
+	}
+
+	p, err := openProtection(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = p.Export(f); err != nil {
+		return err
+	}
+
+	fmt.Printf("slashing protection exported to %s\n", file)
+	return nil
+}
+
+func importProtectionAction(ctx *cli.Context) error {
+	file := ctx.Args().First()
+	if file == "" {
+		return errors.New("missing file")
+	}
+
+	p, err := openProtection(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = p.Import(f); err != nil {
+		return err
+	}
+
+	fmt.Printf("slashing protection imported from %s\n", file)
+	return nil
+}
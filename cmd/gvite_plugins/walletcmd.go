@@ -0,0 +1,127 @@
+package gvite_plugins
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/cmd/console"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	walletCommand = cli.Command{
+		Name:     "wallet",
+		Usage:    "wallet passwd|upgrade <entropyStore>",
+		Category: "WALLET COMMANDS",
+		Description: `
+Manage the entropy stores in the keystore dir. The entropy store is the file name, or the primary address.
+The file is replaced atomically, the old one is kept if interrupted.
+"passwd" keeps the format of the file. "upgrade" rewrites it in version 2, which can not be read by
+the old gvite any more, back up the file before upgrading if you may downgrade.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(changePassphraseAction),
+				Name:      "passwd",
+				Usage:     "change the passphrase of the entropy store",
+				ArgsUsage: "<entropyStore>",
+				Flags:     append(generalFlags, configFlags...),
+			},
+			{
+				Action:    utils.MigrateFlags(upgradeKDFAction),
+				Name:      "upgrade",
+				Usage:     "re-encrypt the entropy store in version 2 with the key derivation function and its params",
+				ArgsUsage: "<entropyStore>",
+				Flags:     append(append(walletFlags, generalFlags...), configFlags...),
+			},
+		},
+	}
+)
+
+func openWallet(ctx *cli.Context) (*wallet.Manager, string, error) {
+	entropyStore := ctx.Args().First()
+	if entropyStore == "" {
+		return nil, "", errors.New("missing entropy store")
+	}
+
+	cfg, err := nodemanager.FullNodeMaker{}.MakeNodeConfig(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	wt := wallet.New(&wallet.Config{DataDir: cfg.KeyStoreDir})
+	if err = wt.Start(); err != nil {
+		return nil, "", err
+	}
+	if err = wt.AddEntropyStore(entropyStore); err != nil {
+		wt.Stop()
+		return nil, "", err
+	}
+	return wt, entropyStore, nil
+}
+
+func changePassphraseAction(ctx *cli.Context) error {
+	wt, entropyStore, err := openWallet(ctx)
+	if err != nil {
+		return err
+	}
+	defer wt.Stop()
+
+	passphrase, err := console.Stdin.PromptPassword("Passphrase: ")
+	if err != nil {
+		return err
+	}
+	newPassphrase, err := console.Stdin.PromptPassword("New passphrase: ")
+	if err != nil {
+		return err
+	}
+	confirm, err := console.Stdin.PromptPassword("Repeat new passphrase: ")
+	if err != nil {
+		return err
+	}
+	if newPassphrase != confirm {
+		return errors.New("passphrases do not match")
+	}
+
+	if err = wt.ChangePassphrase(entropyStore, passphrase, newPassphrase, nil); err != nil {
+		return err
+	}
+	fmt.Printf("passphrase of %s changed\n", entropyStore)
+	return nil
+}
+
+func upgradeKDFAction(ctx *cli.Context) error {
+	params := entropystore.KDFParams{KDF: ctx.String(utils.WalletKDFFlag.Name)}
+	if params.KDF == entropystore.DefaultArgon2idParams.KDF {
+		if ctx.Uint(utils.WalletArgon2ThreadsFlag.Name) > 255 {
+			return errors.New("argon2id threads should be less than 256")
+		}
+		params.Time = uint32(ctx.Uint(utils.WalletArgon2TimeFlag.Name))
+		params.Memory = uint32(ctx.Uint(utils.WalletArgon2MemoryFlag.Name))
+		params.Threads = uint8(ctx.Uint(utils.WalletArgon2ThreadsFlag.Name))
+	} else {
+		params.N = ctx.Int(utils.WalletScryptNFlag.Name)
+		params.R = entropystore.DefaultKDFParams.R
+		params.P = entropystore.DefaultKDFParams.P
+	}
+
+	wt, entropyStore, err := openWallet(ctx)
+	if err != nil {
+		return err
+	}
+	defer wt.Stop()
+
+	passphrase, err := console.Stdin.PromptPassword("Passphrase: ")
+	if err != nil {
+		return err
+	}
+	if err = wt.ChangePassphrase(entropyStore, passphrase, passphrase, &params); err != nil {
+		return err
+	}
+	fmt.Printf("%s upgraded to %s\n", entropyStore, params.KDF)
+	return nil
+}
@@ -2,11 +2,15 @@
 package vitejsext
 
 var Modules = map[string]string{
-	"wallet":    Wallet_JS,
-	"p2p":       P2P_JS,
-	"onroad":    OnRoad_JS,
-	"contracts": Contracts_JS,
-	"ledger":    Ledger_JS,
+	"wallet":         Wallet_JS,
+	"p2p":            P2P_JS,
+	"onroad":         OnRoad_JS,
+	"contracts":      Contracts_JS,
+	"ledger":         Ledger_JS,
+	"consensusGroup": ConsensusGroup_JS,
+	"txpool":         TxPool_JS,
+	"pow":            Pow_JS,
+	"multisig":       Multisig_JS,
 }
 
 const Wallet_JS = `
@@ -53,6 +57,71 @@ web3._extend({
 			name: 'createTxWithPassphrase',
 			call: 'wallet_createTxWithPassphrase',
 			params: 6
+		}),
+		new web3._extend.Method({
+			name: 'sendTransaction',
+			call: 'wallet_sendTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getBackends',
+			call: 'wallet_getBackends',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'addExternalSigner',
+			call: 'wallet_addExternalSigner',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'removeBackend',
+			call: 'wallet_removeBackend',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'changeEntropyStorePassphrase',
+			call: 'wallet_changeEntropyStorePassphrase',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'upgradeEntropyStoreKDF',
+			call: 'wallet_upgradeEntropyStoreKDF',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'addWatchAddresses',
+			call: 'wallet_addWatchAddresses',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'removeWatchAddresses',
+			call: 'wallet_removeWatchAddresses',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'removeWatchSet',
+			call: 'wallet_removeWatchSet',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'listWatchSets',
+			call: 'wallet_listWatchSets',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getWatchSet',
+			call: 'wallet_getWatchSet',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getWatchSetBalance',
+			call: 'wallet_getWatchSetBalance',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getWatchSetActivity',
+			call: 'wallet_getWatchSetActivity',
+			params: 2
 		})
 	]
 });
@@ -88,7 +157,8 @@ web3._extend({
 		}),
 		new web3._extend.Method({
 			name: 'listWorkingAutoReceiveWorker',
-			getter: 'onroad_listWorkingAutoReceiveWorker'
+			call: 'onroad_listWorkingAutoReceiveWorker',
+			params: 0
 		}),
 		new web3._extend.Method({
 			name: 'startAutoReceive',
@@ -100,6 +170,11 @@ web3._extend({
 			call: 'onroad_stopAutoReceive',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getContractScheduleStats',
+			call: 'onroad_getContractScheduleStats',
+			params: 1
+		}),
 	]
 });
 `
@@ -203,3 +278,160 @@ web3._extend({
 	]
 });
 `
+
+const ConsensusGroup_JS = `
+web3._extend({
+	property: 'consensusGroup',
+	methods: [
+		new web3._extend.Method({
+			name: 'getConsensusGroupList',
+			call: 'consensusGroup_getConsensusGroupList',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getConsensusGroupById',
+			call: 'consensusGroup_getConsensusGroupById',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getGroupTx',
+			call: 'consensusGroup_getGroupTx',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getConsensusGroupDashboard',
+			call: 'consensusGroup_getConsensusGroupDashboard',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getCreateGroupTx',
+			call: 'consensusGroup_getCreateGroupTx',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getUpdateGroupTx',
+			call: 'consensusGroup_getUpdateGroupTx',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getCancelGroupTx',
+			call: 'consensusGroup_getCancelGroupTx',
+			params: 1
+		}),
+	]
+});
+`
+
+const TxPool_JS = `
+web3._extend({
+	property: 'txpool',
+	methods: [
+		new web3._extend.Method({
+			name: 'status',
+			call: 'txpool_status',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'inspect',
+			call: 'txpool_inspect',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'content',
+			call: 'txpool_content',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'evictAccount',
+			call: 'txpool_evictAccount',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'blacklistAccountBranch',
+			call: 'txpool_blacklistAccountBranch',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'unblacklistAccount',
+			call: 'txpool_unblacklistAccount',
+			params: 1
+		}),
+	]
+});
+`
+
+const Pow_JS = `
+web3._extend({
+	property: 'pow',
+	methods: [
+		new web3._extend.Method({
+			name: 'getPowNonce',
+			call: 'pow_getPowNonce',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getPowNonces',
+			call: 'pow_getPowNonces',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'cancelPow',
+			call: 'pow_cancelPow',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getSolverStatus',
+			call: 'pow_getSolverStatus',
+			params: 0
+		}),
+	]
+});
+`
+
+const Multisig_JS = `
+web3._extend({
+	property: 'multisig',
+	methods: [
+		new web3._extend.Method({
+			name: 'getCreateWalletData',
+			call: 'multisig_getCreateWalletData',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getDepositData',
+			call: 'multisig_getDepositData',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getProposeTransferData',
+			call: 'multisig_getProposeTransferData',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getProposeOwnerChangeData',
+			call: 'multisig_getProposeOwnerChangeData',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'getApproveData',
+			call: 'multisig_getApproveData',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getWallet',
+			call: 'multisig_getWallet',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getWalletIdsByOwner',
+			call: 'multisig_getWalletIdsByOwner',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getPendingProposals',
+			call: 'multisig_getPendingProposals',
+			params: 1
+		}),
+	]
+});
+`
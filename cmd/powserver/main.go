@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/vitelabs/go-vite/pow/remote"
+)
+
+// powserver computes PoW for gvite nodes and wallets configured with PowServerUrl
+
+var (
+	listen    = flag.String("listen", "127.0.0.1:6007", "listen address")
+	certFile  = flag.String("cert", "", "TLS certificate file, serve HTTP if empty")
+	keyFile   = flag.String("key", "", "TLS key file")
+	solvers   = flag.Int("solvers", 1, "count of works solved at the same time")
+	workers   = flag.Int("workers", 0, "count of goroutines of every solver, all CPUs if 0")
+	queueSize = flag.Int("queue", 100, "max count of works waiting for a solver")
+	timeout   = flag.Duration("timeout", 5*time.Minute, "max time to solve a work, no limit if 0")
+	rateLimit = flag.Float64("rateLimit", 0, "requests per second allowed for a client, no limit if 0")
+	rateBurst = flag.Int("rateBurst", 10, "requests a client can send at once")
+)
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func main() {
+	flag.Parse()
+
+	server := remote.NewServer(remote.ServerConfig{
+		Solvers:   *solvers,
+		Workers:   *workers,
+		QueueSize: *queueSize,
+		Timeout:   *timeout,
+		RateLimit: *rateLimit,
+		RateBurst: *rateBurst,
+	})
+
+	fmt.Printf("pow server listening on %s\n", *listen)
+	var err error
+	if *certFile != "" {
+		err = http.ListenAndServeTLS(*listen, *certFile, *keyFile, server)
+	} else {
+		err = http.ListenAndServe(*listen, server)
+	}
+	fail(err)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
+)
+
+// signer keep the keys of SBP on a hardened host, and sign blocks for gvite configured with RemoteSigner
+
+var (
+	keyStoreDir  = flag.String("keystore", "wallet", "keystore dir")
+	entropyStore = flag.String("entropyStore", "", "entropy store file, or primary address")
+	passwordFile = flag.String("passwordFile", "", "file of the password of entropy store")
+	secretFile   = flag.String("secretFile", "", "file of the secret shared with gvite")
+	listen       = flag.String("listen", "127.0.0.1:48140", "listen address")
+	certFile     = flag.String("cert", "", "TLS certificate file, serve HTTP if empty")
+	keyFile      = flag.String("key", "", "TLS key file")
+	addresses    = flag.String("addresses", "", "comma separated addresses can be signed, all addresses of the entropy store if empty")
+	maxTimeDrift = flag.Duration("maxTimeDrift", time.Minute, "max difference between timestamp of snapshot block and local time")
+	allowSend    = flag.Bool("allowSend", false, "allow to sign send blocks")
+)
+
+func readFile(name string) string {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		fail(err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func main() {
+	flag.Parse()
+
+	if *entropyStore == "" || *passwordFile == "" || *secretFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	wt := wallet.New(&wallet.Config{DataDir: *keyStoreDir})
+	if err := wt.Start(); err != nil {
+		fail(err)
+	}
+	defer wt.Stop()
+
+	if err := wt.AddEntropyStore(*entropyStore); err != nil {
+		fail(err)
+	}
+	if err := wt.Unlock(*entropyStore, readFile(*passwordFile)); err != nil {
+		fail(err)
+	}
+
+	policy := signer.Policy{
+		MaxTimeDrift:    *maxTimeDrift,
+		AllowSendBlocks: *allowSend,
+	}
+	if *addresses != "" {
+		for _, str := range strings.Split(*addresses, ",") {
+			addr, err := types.HexToAddress(strings.TrimSpace(str))
+			if err != nil {
+				fail(err)
+			}
+			policy.Addresses = append(policy.Addresses, addr)
+		}
+	}
+
+	server := signer.NewServer(signer.NewLocal(wt), readFile(*secretFile), policy)
+
+	fmt.Printf("signer listening on %s\n", *listen)
+	var err error
+	if *certFile != "" {
+		err = http.ListenAndServeTLS(*listen, *certFile, *keyFile, server)
+	} else {
+		err = http.ListenAndServe(*listen, server)
+	}
+	fail(err)
+}
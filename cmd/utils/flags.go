@@ -190,6 +190,59 @@ var (
 		Usage: "The snapshot block height",
 	}
 
+	// Consensus simulate
+	ConsensusFromIndexFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "The first consensus index to simulate, the next index of the chain by default",
+	}
+	ConsensusToIndexFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "The last consensus index to simulate, the same as from by default",
+	}
+	ConsensusVotesFlag = cli.StringFlag{
+		Name:  "votes",
+		Usage: "JSON file of a synthetic vote set, the chain is used if empty",
+	}
+	ConsensusWhatIfFlag = cli.StringFlag{
+		Name:  "whatif",
+		Usage: "JSON file of hypothetical vote changes, compared with the actual votes",
+	}
+	ConsensusPlansFlag = cli.BoolFlag{
+		Name:  "plans",
+		Usage: "Print the producer of every slot",
+	}
+	ConsensusJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the result in JSON",
+	}
+
+	// Wallet
+	WalletKDFFlag = cli.StringFlag{
+		Name:  "kdf",
+		Usage: "Key derivation function of the entropy store, scrypt or argon2id",
+		Value: "scrypt",
+	}
+	WalletScryptNFlag = cli.IntFlag{
+		Name:  "scrypt.n",
+		Usage: "CPU/memory cost of scrypt, a power of 2",
+		Value: 1 << 18,
+	}
+	WalletArgon2TimeFlag = cli.UintFlag{
+		Name:  "argon2.time",
+		Usage: "Passes over the memory of argon2id",
+		Value: 3,
+	}
+	WalletArgon2MemoryFlag = cli.UintFlag{
+		Name:  "argon2.memory",
+		Usage: "Memory of argon2id in KiB",
+		Value: 64 * 1024,
+	}
+	WalletArgon2ThreadsFlag = cli.UintFlag{
+		Name:  "argon2.threads",
+		Usage: "Threads of argon2id",
+		Value: 4,
+	}
+
 	//Net
 	SingleFlag = cli.BoolFlag{
 		Name:  "single",
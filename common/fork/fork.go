@@ -181,6 +181,45 @@ func IsDexMiningFork(snapshotHeight uint64) bool {
 	return snapshotHeight >= dexMiningForkPoint.Height && IsForkActive(*dexMiningForkPoint)
 }
 
+/*
+IsExpiryFork checks whether current snapshot block height is over expiry hard fork.
+Contents:
+  1. A call to a user contract which is not received in time expires, it is received as failed and refunded.
+*/
+func IsExpiryFork(snapshotHeight uint64) bool {
+	expiryForkPoint, ok := forkPointMap["ExpiryFork"]
+	if !ok {
+		panic("check expiry fork failed. ExpiryFork is not existed.")
+	}
+	return snapshotHeight >= expiryForkPoint.Height && IsForkActive(*expiryForkPoint)
+}
+
+/*
+IsMultisigFork checks whether current snapshot block height is over multisig hard fork.
+Contents:
+  1. Built-in contract of multi-signature wallets.
+*/
+func IsMultisigFork(snapshotHeight uint64) bool {
+	multisigForkPoint, ok := forkPointMap["MultisigFork"]
+	if !ok {
+		panic("check multisig fork failed. MultisigFork is not existed.")
+	}
+	return snapshotHeight >= multisigForkPoint.Height && IsForkActive(*multisigForkPoint)
+}
+
+/*
+IsDelegateGroupFork checks whether current snapshot block height is over delegate group hard fork.
+Contents:
+  1. Create, update and cancel delegate consensus groups in governance contract.
+*/
+func IsDelegateGroupFork(snapshotHeight uint64) bool {
+	delegateGroupForkPoint, ok := forkPointMap["DelegateGroupFork"]
+	if !ok {
+		panic("check delegate group fork failed. DelegateGroupFork is not existed.")
+	}
+	return snapshotHeight >= delegateGroupForkPoint.Height && IsForkActive(*delegateGroupForkPoint)
+}
+
 func GetLeafForkPoint() *ForkPointItem {
 	leafForkPoint, ok := forkPointMap["LeafFork"]
 	if !ok {
@@ -190,6 +229,24 @@ func GetLeafForkPoint() *ForkPointItem {
 	return leafForkPoint
 }
 
+func GetExpiryForkPoint() *ForkPointItem {
+	expiryForkPoint, ok := forkPointMap["ExpiryFork"]
+	if !ok {
+		panic("check expiry fork failed. ExpiryFork is not existed.")
+	}
+
+	return expiryForkPoint
+}
+
+func GetMultisigForkPoint() *ForkPointItem {
+	multisigForkPoint, ok := forkPointMap["MultisigFork"]
+	if !ok {
+		panic("check multisig fork failed. MultisigFork is not existed.")
+	}
+
+	return multisigForkPoint
+}
+
 func IsActiveForkPoint(snapshotHeight uint64) bool {
 	// assume that fork point list is sorted by height asc
 	for i := len(forkPointList) - 1; i >= 0; i-- {
@@ -0,0 +1,11 @@
+package config
+
+// DefaultAnalyticsDirName is the directory of the SBP analytics of consensus cycles
+const DefaultAnalyticsDirName = "analytics"
+
+type Analytics struct {
+	Enabled bool `json:"Enabled"`
+
+	// Backfill is the count of past cycles analysed at the first start
+	Backfill uint64 `json:"Backfill"`
+}
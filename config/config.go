@@ -17,6 +17,8 @@ type Config struct {
 	*Net        `json:"Net"`
 	*biz.Reward `json:"Reward"`
 	*Genesis    `json:"Genesis"`
+	*Analytics  `json:"Analytics"`
+	*OnRoad     `json:"OnRoad"`
 
 	// global keys
 	DataDir string `json:"DataDir"`
@@ -0,0 +1,29 @@
+package config
+
+import "github.com/vitelabs/go-vite/common/types"
+
+const (
+	// ConsensusEngineDPoS elect snapshot block producers by votes in the governance contract
+	ConsensusEngineDPoS = "dpos"
+	// ConsensusEnginePoA take turns of a static list of snapshot block producers
+	ConsensusEnginePoA = "poa"
+)
+
+// Consensus select the engine of snapshot consensus, DPoS if Engine is empty
+type Consensus struct {
+	Engine string
+	PoA    *PoA
+}
+
+// PoA is the static round-robin of snapshot block producers, every producer produces PerCount blocks in turn,
+// one block every Interval seconds
+type PoA struct {
+	Producers []types.Address
+	Interval  int64
+	PerCount  int64
+}
+
+// IsPoA return true if the engine of snapshot consensus is PoA
+func (c *Consensus) IsPoA() bool {
+	return c != nil && c.Engine == ConsensusEnginePoA
+}
@@ -3,6 +3,7 @@ package config_gen
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -78,6 +79,24 @@ func makeForkPointsConfig(genesisConfig *config.Genesis) *config.ForkPoints {
 				Height:  17142720,
 				Version: 7,
 			},
+
+			// not scheduled on mainnet yet
+			ExpiryFork: &config.ForkPoint{
+				Height:  math.MaxUint64,
+				Version: 8,
+			},
+
+			// not scheduled on mainnet yet
+			MultisigFork: &config.ForkPoint{
+				Height:  math.MaxUint64,
+				Version: 9,
+			},
+
+			// not scheduled on mainnet yet
+			DelegateGroupFork: &config.ForkPoint{
+				Height:  math.MaxUint64,
+				Version: 10,
+			},
 		}
 	}
 }
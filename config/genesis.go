@@ -16,6 +16,8 @@ type Genesis struct {
 	PledgeInfo            *QuotaContractInfo // Deprecated
 	QuotaInfo             *QuotaContractInfo
 	AccountBalanceMap     map[string]map[string]*big.Int // address - tokenId - balanceAmount
+	Consensus             *Consensus                     // engine of snapshot consensus, DPoS if nil
+	UnreceivedExpiry      uint64                         // snapshot blocks after which a call to a contract expires, default if 0
 }
 
 func (g *Genesis) UnmarshalJSON(data []byte) error {
@@ -66,11 +68,17 @@ func (g *Genesis) UnmarshalJSON(data []byte) error {
 func IsCompleteGenesisConfig(genesisConfig *Genesis) bool {
 	if genesisConfig == nil || genesisConfig.GenesisAccountAddress == nil ||
 		genesisConfig.GovernanceInfo == nil || len(genesisConfig.GovernanceInfo.ConsensusGroupInfoMap) == 0 ||
-		len(genesisConfig.GovernanceInfo.RegistrationInfoMap) == 0 ||
 		genesisConfig.AssetInfo == nil || len(genesisConfig.AssetInfo.TokenInfoMap) == 0 ||
 		len(genesisConfig.AccountBalanceMap) == 0 {
 		return false
 	}
+	// producers of PoA are in the genesis config, no registration is needed
+	if len(genesisConfig.GovernanceInfo.RegistrationInfoMap) == 0 && !genesisConfig.Consensus.IsPoA() {
+		return false
+	}
+	if genesisConfig.Consensus.IsPoA() && (genesisConfig.Consensus.PoA == nil || len(genesisConfig.Consensus.PoA.Producers) == 0) {
+		return false
+	}
 	return true
 }
 
@@ -87,6 +95,10 @@ type ForkPoints struct {
 	LeafFork      *ForkPoint
 	EarthFork     *ForkPoint
 	DexMiningFork *ForkPoint
+	ExpiryFork    *ForkPoint
+	MultisigFork  *ForkPoint
+	// DelegateGroupFork enables creating, updating and cancelling delegate consensus groups
+	DelegateGroupFork *ForkPoint
 }
 
 type GenesisVmLog struct {
@@ -25,12 +25,18 @@ const (
 	DefaultMaxInboundRatio = 2
 	DefaultMinPeers        = 5
 	DefaultMaxPendingPeers = 10
+	DefaultMaxSubnetPeers  = 2
+	DefaultMaxBucketPeers  = 8
+	DefaultAnchorPeers     = 2
 
 	DefaultNetDirName = "net"
 	PeerKeyFileName   = "peerKey"
 
 	DefaultForwardStrategy = "cross"
 	DefaultAccessControl   = "any"
+
+	DefaultAccountBlockPeerRate    = 200
+	DefaultAccountBlockAccountRate = 1
 )
 
 type Net struct {
@@ -84,8 +90,40 @@ type Net struct {
 	// this value is for defend DDOS attack, default 10
 	MaxPendingPeers int
 
+	// MaxSubnetPeers is how many peers can be connected from the same /24 (IPv4) or /48 (IPv6) subnet,
+	// SBP peers, whitelist peers and loopback addresses are not limited, default 2
+	MaxSubnetPeers int
+
+	// MaxBucketPeers is how many peers can be connected from the same /16 (IPv4) or /32 (IPv6) prefix,
+	// addresses in the same prefix usually belong to the same network operator, default 8
+	MaxBucketPeers int
+
+	// AnchorPeers is how many outbound slots are reserved for long-lived reliable peers,
+	// anchors are stored and connected first after restart, default 2
+	AnchorPeers int
+
+	// OutboundBandwidth is the max bytes per second sent to all peers and file clients, 0 means no limit.
+	// Block broadcasts are sent first, then requests, bulk responses of sync and fetch are the last
+	OutboundBandwidth int
+
 	ForwardStrategy string
 
+	// Roles are the services our node provides, advertised through discovery, eg. `archive`, `light-server`.
+	// `full` and `file-server` are always advertised, `sbp` is advertised if MineKey is set
+	Roles []string
+
+	// AnnounceAccountBlock means forward account blocks by hash, peers will request the bodies they have not seen,
+	// blocks produced by ourself are always sent with body
+	AnnounceAccountBlock bool
+
+	// AccountBlockPeerRate is how many account blocks per second can be received from one peer, except SBP peers,
+	// default 200
+	AccountBlockPeerRate int
+
+	// AccountBlockAccountRate is how many account blocks per second can be received from one account without stake quota,
+	// accounts staked for quota can send more, default 1
+	AccountBlockAccountRate int
+
 	AccessControl   string
 	AccessAllowKeys []string
 	AccessDenyKeys  []string
@@ -0,0 +1,25 @@
+package config
+
+import "github.com/vitelabs/go-vite/common/types"
+
+const (
+	// OnRoadPolicyQuota processes the contract with more stake quota first, it is the default policy
+	OnRoadPolicyQuota = "quota"
+	// OnRoadPolicyFair shares the receive blocks among contracts in proportion to their weights
+	OnRoadPolicyFair = "fair"
+)
+
+// OnRoad is the config of contract onroad processing of a contract producer
+type OnRoad struct {
+	SchedulePolicy string `json:"SchedulePolicy"`
+
+	// ContractPriority is processed before the policy, a contract with higher priority goes first, 0 by default
+	ContractPriority map[types.Address]int `json:"ContractPriority"`
+	// ContractWeight is the share of a contract in the fair policy, 1 by default
+	ContractWeight map[types.Address]uint64 `json:"ContractWeight"`
+
+	// CallerCap is the max count of receive blocks of a caller to a contract in a snapshot block, 0 means no limit
+	CallerCap uint64 `json:"CallerCap"`
+	// MaxLatency is the max seconds a contract waits in the queue, it is processed before others after that, 0 means no limit
+	MaxLatency uint64 `json:"MaxLatency"`
+}
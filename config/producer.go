@@ -1,9 +1,28 @@
 package config
 
+// DefaultProducerDirName is the directory of producer data, such as seeds and slashing protection
+const DefaultProducerDirName = "producer"
+
+// DefaultFinalityDirName is the directory of the final snapshot block and the finality vote of self
+const DefaultFinalityDirName = "finality"
+
 type Producer struct {
 	Producer         bool   `json:"Producer"`
 	Coinbase         string `json:"Coinbase"`
 	EntropyStorePath string `json:"EntropyStorePath"`
+
+	// RemoteSigner is the url of remote signer, blocks are signed by local keystore if empty
+	RemoteSigner       string `json:"RemoteSigner"`
+	RemoteSignerSecret string `json:"RemoteSignerSecret"`
+
+	// FailoverLease is the lease file on storage shared by an active/standby pair with the same coinbase,
+	// failover is disabled if empty
+	FailoverLease       string `json:"FailoverLease"`
+	FailoverNodeId      string `json:"FailoverNodeId"`
+	FailoverStandby     bool   `json:"FailoverStandby"`
+	FailoverMissedSlots int    `json:"FailoverMissedSlots"`
+	// FailoverLeaseTTL is in seconds
+	FailoverLeaseTTL int `json:"FailoverLeaseTTL"`
 }
 
 //func MergeMinerConfig(cfg *Miner) *Miner {
@@ -93,14 +93,15 @@ func newChainRw(rw Chain, log log15.Logger, rollbackLock lock.ChainRollback) *ch
 
 }
 
-func (cRw *chainRw) init(cs *snapshotCs) {
+// init the stats of producers, the plans of periods are generated by engine and the votes are read by stats
+func (cRw *chainRw) init(cs *snapshotCs, engine Engine, stats engineStats) {
 	if cs == nil {
 		panic("snapshot cs is nil.")
 	}
 	proof := newRollbackProof(cRw.rw)
-	cRw.periodPoints = newPeriodPointArray(cRw.rw, cs, proof, cRw.log)
+	cRw.periodPoints = newPeriodPointArray(cRw.rw, engine, proof, cRw.log)
 	cRw.hourPoints = newHourLinkedArray(cRw.periodPoints, cRw.dbCache, proof, time.Duration(cs.GetInfo().PlanInterval)*time.Second, cRw.genesisTime, cRw.log)
-	cRw.dayPoints = newDayLinkedArray(cRw.hourPoints, cRw.dbCache, proof, cs.dayVoteStat, cRw.genesisTime, cRw.log)
+	cRw.dayPoints = newDayLinkedArray(cRw.hourPoints, cRw.dbCache, proof, stats.dayVoteStat, cRw.genesisTime, cRw.log)
 	cRw.snapshot = cs
 	cRw.snapshotLoadCh = make(chan *ledger.SnapshotBlock)
 }
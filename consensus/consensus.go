@@ -6,6 +6,7 @@ import (
 
 	"github.com/vitelabs/go-vite/common"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/consensus/cdb"
 	"github.com/vitelabs/go-vite/consensus/core"
 	"github.com/vitelabs/go-vite/ledger"
@@ -77,6 +78,7 @@ type Consensus interface {
 	Life
 	API() APIReader
 	SBPReader() core.SBPStatReader
+	Engine() Engine
 }
 
 // update committee result
@@ -90,7 +92,10 @@ type consensus struct {
 	rw       *chainRw
 	rollback lock.ChainRollback
 
+	cfg       *config.Consensus
+	engine    Engine
 	snapshot  *snapshotCs
+	stats     engineStats
 	contracts *contractsCs
 
 	dposWrapper *dposReader
@@ -105,18 +110,27 @@ type consensus struct {
 }
 
 func (cs *consensus) SBPReader() core.SBPStatReader {
-	return cs.snapshot
+	return cs.stats
 }
 
 func (cs *consensus) API() APIReader {
 	return cs.api
 }
 
-// NewConsensus instantiates a new consensus object
+func (cs *consensus) Engine() Engine {
+	return cs.engine
+}
+
+// NewConsensus instantiates a new consensus object with the DPoS engine
 func NewConsensus(ch Chain, rollback lock.ChainRollback) Consensus {
+	return NewConsensusWithConfig(ch, rollback, nil)
+}
+
+// NewConsensusWithConfig instantiates a new consensus object with the engine selected by cfg, DPoS if cfg is nil
+func NewConsensusWithConfig(ch Chain, rollback lock.ChainRollback, cfg *config.Consensus) Consensus {
 	log := log15.New("module", "consensus")
 	rw := newChainRw(ch, log, rollback)
-	self := &consensus{rw: rw, rollback: rollback}
+	self := &consensus{rw: rw, rollback: rollback, cfg: cfg}
 	self.mLog = log
 
 	return self
@@ -38,7 +38,7 @@ func (contract *contractsCs) LoadGid(gid types.Gid) error {
 	return nil
 }
 
-func (contract *contractsCs) ElectionTime(gid types.Gid, t time.Time) (*electionResult, error) {
+func (contract *contractsCs) ElectionTime(gid types.Gid, t time.Time) (*Schedule, error) {
 	result, err := contract.getOrLoadGid(gid)
 	if err != nil {
 		return nil, err
@@ -49,7 +49,7 @@ func (contract *contractsCs) ElectionTime(gid types.Gid, t time.Time) (*election
 	return result.electionTime(t)
 }
 
-func (contract *contractsCs) ElectionIndex(gid types.Gid, index uint64) (*electionResult, error) {
+func (contract *contractsCs) ElectionIndex(gid types.Gid, index uint64) (*Schedule, error) {
 	result, err := contract.getOrLoadGid(gid)
 	if err != nil {
 		return nil, err
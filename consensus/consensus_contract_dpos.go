@@ -31,12 +31,12 @@ func newContractDposCs(info *core.GroupInfo, rw *chainRw, log log15.Logger) *con
 	return cs
 }
 
-func (contract *contractDposCs) electionTime(t time.Time) (*electionResult, error) {
+func (contract *contractDposCs) electionTime(t time.Time) (*Schedule, error) {
 	index := contract.Time2Index(t)
 	return contract.ElectionIndex(index)
 }
 
-func (contract *contractDposCs) ElectionIndex(index uint64) (*electionResult, error) {
+func (contract *contractDposCs) ElectionIndex(index uint64) (*Schedule, error) {
 	voteResults, _, err := contract.electionAddrsIndex(index)
 	if err != nil {
 		return nil, err
@@ -164,7 +164,7 @@ func (contract *contractDposCs) VerifyProducer(address types.Address, t time.Tim
 	return contract.verifyProducer(address, electionResult), nil
 }
 
-func (contract *contractDposCs) verifyProducer(address types.Address, result *electionResult) bool {
+func (contract *contractDposCs) verifyProducer(address types.Address, result *Schedule) bool {
 	if result == nil {
 		return false
 	}
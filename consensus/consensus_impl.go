@@ -23,8 +23,10 @@ type producerSubscribeEvent struct {
 }
 
 func (cs *consensus) VerifySnapshotProducer(header *ledger.SnapshotBlock) (bool, error) {
-	cs.snapshot.verifyProducerAndSeed(header)
-	return cs.snapshot.VerifyProducer(header.Producer(), *header.Timestamp)
+	if _, ok := cs.engine.(*snapshotCs); ok {
+		cs.snapshot.verifyProducerAndSeed(header)
+	}
+	return cs.engine.VerifyProducer(header.Producer(), *header.Timestamp)
 }
 
 func (cs *consensus) VerifyABsProducer(abs map[types.Gid][]*ledger.AccountBlock) ([]*ledger.AccountBlock, error) {
@@ -82,7 +84,7 @@ func (cs *consensus) ReadByIndex(gid types.Gid, index uint64) ([]*Event, uint64,
 		return nil, 0, err
 	}
 
-	voteTime := cs.snapshot.GenProofTime(index)
+	voteTime := cs.engine.GenProofTime(index)
 	var result []*Event
 	for _, p := range eResult.Plans {
 		e := newConsensusEvent(eResult, p, gid, voteTime)
@@ -117,18 +119,22 @@ func (cs *consensus) Init() error {
 	}
 	defer cs.PostInit()
 
-	snapshot := newSnapshotCs(cs.rw, cs.mLog)
+	engine, snapshot, err := newEngine(cs.cfg, cs.rw, cs.mLog)
+	if err != nil {
+		return errors.Wrap(err, "consensus engine")
+	}
+	cs.engine = engine
 	cs.snapshot = snapshot
-	cs.rw.init(snapshot)
+	cs.stats, cs.api = newEngineStats(engine, snapshot)
+	cs.rw.init(snapshot, engine, cs.stats)
 
 	cs.contracts = newContractCs(cs.rw, cs.mLog)
-	err := cs.contracts.LoadGid(types.DELEGATE_GID)
+	err = cs.contracts.LoadGid(types.DELEGATE_GID)
 
 	if err != nil {
-		panic(err)
+		return errors.Wrap(err, "load delegate consensus group")
 	}
-	cs.dposWrapper = &dposReader{cs.snapshot, cs.contracts, cs.mLog}
-	cs.api = &APISnapshot{snapshot: snapshot}
+	cs.dposWrapper = &dposReader{cs.engine, cs.contracts, cs.mLog}
 	return nil
 }
 
@@ -142,7 +148,7 @@ func (cs *consensus) Start() {
 	common.Go(func() {
 		cs.wg.Add(1)
 		defer cs.wg.Done()
-		cs.update(types.SNAPSHOT_GID, cs.snapshot, snapshotSubs.(*sync.Map))
+		cs.update(types.SNAPSHOT_GID, cs.engine, snapshotSubs.(*sync.Map))
 	})
 
 	contractSubs, _ := cs.subscribes.LoadOrStore(types.DELEGATE_GID, &sync.Map{})
@@ -272,7 +278,7 @@ func copyMap(m *sync.Map) (map[string]*subscribeEvent, map[string]*producerSubsc
 	})
 	return r1, r2
 }
-func (cs *consensus) eventProducer(e *producerSubscribeEvent, result *electionResult, voteTime time.Time) {
+func (cs *consensus) eventProducer(e *producerSubscribeEvent, result *Schedule, voteTime time.Time) {
 	cs.wg.Add(1)
 	defer cs.wg.Done()
 	var r []types.Address
@@ -282,7 +288,7 @@ func (cs *consensus) eventProducer(e *producerSubscribeEvent, result *electionRe
 	e.fn(ProducersEvent{Addrs: r, Index: result.Index, Gid: e.gid})
 }
 
-func (cs *consensus) event(e *subscribeEvent, result *electionResult, voteTime time.Time) {
+func (cs *consensus) event(e *subscribeEvent, result *Schedule, voteTime time.Time) {
 	cs.wg.Add(1)
 	defer cs.wg.Done()
 	if e.addr == nil {
@@ -293,7 +299,7 @@ func (cs *consensus) event(e *subscribeEvent, result *electionResult, voteTime t
 	}
 }
 
-func (cs *consensus) eventAll(e *subscribeEvent, result *electionResult, voteTime time.Time) {
+func (cs *consensus) eventAll(e *subscribeEvent, result *Schedule, voteTime time.Time) {
 	for _, p := range result.Plans {
 		now := time.Now()
 		sub := p.STime.Sub(now)
@@ -310,7 +316,7 @@ func (cs *consensus) eventAll(e *subscribeEvent, result *electionResult, voteTim
 		e.fn(newConsensusEvent(result, p, e.gid, voteTime))
 	}
 }
-func (cs *consensus) eventAddr(e *subscribeEvent, result *electionResult, voteTime time.Time) {
+func (cs *consensus) eventAddr(e *subscribeEvent, result *Schedule, voteTime time.Time) {
 	for _, p := range result.Plans {
 		if p.Member == *e.addr {
 			now := time.Now()
@@ -330,7 +336,7 @@ func (cs *consensus) eventAddr(e *subscribeEvent, result *electionResult, voteTi
 	}
 }
 
-func newConsensusEvent(r *electionResult, p *core.MemberPlan, gid types.Gid, voteTime time.Time) Event {
+func newConsensusEvent(r *Schedule, p *core.MemberPlan, gid types.Gid, voteTime time.Time) Event {
 	return Event{
 		Gid:         gid,
 		Address:     p.Member,
@@ -290,7 +290,7 @@ func (period *periodLinkedArray) getByIndexWithProofFromChain(index uint64, proo
 	return period.genPeriodPoint(index, &stime, &etime, proofHash, blocks, result)
 }
 
-func (period *periodLinkedArray) genPeriodPoint(index uint64, stime *time.Time, etime *time.Time, proofHash types.Hash, blocks []*ledger.SnapshotBlock, result *electionResult) (*cdb.Point, error) {
+func (period *periodLinkedArray) genPeriodPoint(index uint64, stime *time.Time, etime *time.Time, proofHash types.Hash, blocks []*ledger.SnapshotBlock, result *Schedule) (*cdb.Point, error) {
 	if proofHash != blocks[0].Hash {
 		return nil, errors.Errorf("gen period point fail[%s][%s]", proofHash, blocks[0].Hash)
 	}
@@ -77,12 +77,12 @@ func (simple *simpleCs) GenProofTime(h uint64) time.Time {
 	return end
 }
 
-func (simple *simpleCs) ElectionTime(t time.Time) (*electionResult, error) {
+func (simple *simpleCs) ElectionTime(t time.Time) (*Schedule, error) {
 	index := simple.Time2Index(t)
 	return simple.ElectionIndex(index)
 }
 
-func (simple *simpleCs) ElectionIndex(index uint64) (*electionResult, error) {
+func (simple *simpleCs) ElectionIndex(index uint64) (*Schedule, error) {
 	plans := genElectionResult(&simple.GroupInfo, index, simpleAddrs)
 	return plans, nil
 }
@@ -96,7 +96,7 @@ func (simple *simpleCs) VerifyProducer(address types.Address, t time.Time) (bool
 	return simple.verifyProducer(t, address, electionResult), nil
 }
 
-func (simple *simpleCs) verifyProducer(t time.Time, address types.Address, result *electionResult) bool {
+func (simple *simpleCs) verifyProducer(t time.Time, address types.Address, result *Schedule) bool {
 	if result == nil {
 		return false
 	}
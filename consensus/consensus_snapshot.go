@@ -8,6 +8,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/consensus/cdb"
 	"github.com/vitelabs/go-vite/consensus/core"
 	"github.com/vitelabs/go-vite/ledger"
@@ -16,7 +17,7 @@ import (
 
 type snapshotCs struct {
 	core.GroupInfo
-	//voteCache map[int32]*electionResult
+	//voteCache map[int32]*Schedule
 	//voteCache *lru.Cache
 	rw   *chainRw
 	algo core.Algo
@@ -137,6 +138,27 @@ func (snapshot *snapshotCs) GetDayTimeIndex() core.TimeIndex {
 }
 
 func (snapshot *snapshotCs) DayStats(startIndex uint64, endIndex uint64) ([]*core.DayStats, error) {
+	return snapshot.dayStats(startIndex, endIndex, snapshot.registerNames)
+}
+
+// registerNames return the names of all addresses ever registered
+func (snapshot *snapshotCs) registerNames() (map[types.Address]string, error) {
+	registerMap := make(map[types.Address]string)
+	lastHash := snapshot.rw.GetLatestSnapshotBlock().Hash
+	registers, err := snapshot.rw.rw.GetAllRegisterList(lastHash, types.SNAPSHOT_GID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range registers {
+		for _, vv := range v.HisAddrList {
+			registerMap[vv] = v.Name
+		}
+	}
+	return registerMap, nil
+}
+
+// dayStats convert the day points to stats, the blocks of producers are counted by the names of votes
+func (snapshot *snapshotCs) dayStats(startIndex uint64, endIndex uint64, names func() (map[types.Address]string, error)) ([]*core.DayStats, error) {
 	// get points from linked array
 	points := make(map[uint64]*cdb.Point)
 
@@ -171,17 +193,10 @@ func (snapshot *snapshotCs) DayStats(startIndex uint64, endIndex uint64) ([]*cor
 	var result []*core.DayStats
 
 	// get register map for address->name
-	registerMap := make(map[types.Address]string)
-	lastHash := snapshot.rw.GetLatestSnapshotBlock().Hash
-	registers, err := snapshot.rw.rw.GetAllRegisterList(lastHash, types.SNAPSHOT_GID)
+	registerMap, err := names()
 	if err != nil {
 		return nil, err
 	}
-	for _, v := range registers {
-		for _, vv := range v.HisAddrList {
-			registerMap[vv] = v.Name
-		}
-	}
 	// convert Points to DayStats
 	for i := startIndex; i <= endIndex; i++ {
 		p := points[i]
@@ -233,25 +248,29 @@ func (snapshot *snapshotCs) dayVoteStat(b byte, index uint64, proofHash types.Ha
 	return result, nil
 }
 
-func newSnapshotCs(rw *chainRw, log log15.Logger) *snapshotCs {
+func newSnapshotCs(rw *chainRw, info *core.GroupInfo, log log15.Logger) *snapshotCs {
 	cs := &snapshotCs{}
 	cs.rw = rw
 	cs.log = log.New("gid", "snapshot")
-	info, err := rw.GetMemberInfo(types.SNAPSHOT_GID)
-	if err != nil {
-		panic(err)
-	}
 	cs.algo = core.NewAlgo(info)
 	cs.GroupInfo = *info
 	return cs
 }
 
-func (snapshot *snapshotCs) ElectionTime(t time.Time) (*electionResult, error) {
+func (snapshot *snapshotCs) Name() string {
+	return config.ConsensusEngineDPoS
+}
+
+func (snapshot *snapshotCs) IrreversibleCount() uint64 {
+	return irreversibleCount(snapshot.GetNodeCount())
+}
+
+func (snapshot *snapshotCs) ElectionTime(t time.Time) (*Schedule, error) {
 	index := snapshot.Time2Index(t)
 	return snapshot.ElectionIndex(index)
 }
 
-func (snapshot *snapshotCs) ElectionIndex(index uint64) (*electionResult, error) {
+func (snapshot *snapshotCs) ElectionIndex(index uint64) (*Schedule, error) {
 	proofTime, _ := snapshot.genSnapshotProofTimeIndx(index)
 
 	proofBlock, e := snapshot.rw.GetSnapshotBeforeTime(proofTime)
@@ -323,6 +342,40 @@ func (snapshot *snapshotCs) calVotes(proofBlock *ledger.SnapshotBlock, index uin
 	snapshot.rw.updateSnapshotVoteCache(hashH.Hash, address)
 	return address, nil
 }
+
+// ElectionInput return the votes, seed and success rate the election of index is based on
+func (snapshot *snapshotCs) ElectionInput(index uint64) (*core.Election, error) {
+	proofTime, _ := snapshot.genSnapshotProofTimeIndx(index)
+
+	proofBlock, err := snapshot.rw.GetSnapshotBeforeTime(proofTime)
+	if err != nil {
+		return nil, err
+	}
+	hashH := ledger.HashHeight{Hash: proofBlock.Hash, Height: proofBlock.Height}
+
+	votes, err := snapshot.rw.CalVotes(&snapshot.GroupInfo, hashH)
+	if err != nil {
+		return nil, err
+	}
+
+	var successRate map[types.Address]int32
+	_, proofIndex := snapshot.genSnapshotProofTimeIndx(snapshot.Time2Index(*proofBlock.Timestamp))
+	if proofIndex > 0 {
+		successRate, err = snapshot.rw.GetSuccessRateByHour(proofIndex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &core.Election{
+		Index:       index,
+		Proof:       hashH,
+		Votes:       votes,
+		SuccessRate: successRate,
+		Seed:        snapshot.rw.GetSeedsBeforeHashH(hashH.Hash),
+	}, nil
+}
+
 func (snapshot *snapshotCs) triggerLoad(proofBlock *ledger.SnapshotBlock) {
 	select {
 	case snapshot.rw.snapshotLoadCh <- proofBlock:
@@ -426,7 +479,7 @@ func (snapshot *snapshotCs) VerifyProducer(address types.Address, t time.Time) (
 	return snapshot.verifyProducer(t, address, electionResult), nil
 }
 
-func (snapshot *snapshotCs) verifyProducer(t time.Time, address types.Address, result *electionResult) bool {
+func (snapshot *snapshotCs) verifyProducer(t time.Time, address types.Address, result *Schedule) bool {
 	if result == nil {
 		return false
 	}
@@ -57,7 +57,11 @@ func TestSnapshotCs_ElectionIndex(t *testing.T) {
 	mock_chain.EXPECT().GetLatestSnapshotBlock().Return(b1)
 	rw := newChainRw(mock_chain, log15.New(), &lock.EasyImpl{})
 
-	cs := newSnapshotCs(rw, log15.New())
+	info, err := rw.GetMemberInfo(types.SNAPSHOT_GID)
+	if err != nil {
+		panic(err)
+	}
+	cs := newSnapshotCs(rw, info, log15.New())
 
 	voteTime := cs.GenProofTime(0)
 	mock_chain.EXPECT().GetSnapshotHeaderBeforeTime(gomock.Eq(&voteTime)).Return(b1, nil)
@@ -148,9 +152,13 @@ func TestSnapshotCs_Tools(t *testing.T) {
 		assert.FailNow(t, err.Error())
 	}
 	rw := newChainRw(c, log15.New(), &lock.EasyImpl{})
-	cs := newSnapshotCs(rw, log15.New())
+	info, err := rw.GetMemberInfo(types.SNAPSHOT_GID)
+	if err != nil {
+		panic(err)
+	}
+	cs := newSnapshotCs(rw, info, log15.New())
 
-	rw.init(cs)
+	rw.init(cs, cs, cs)
 
 	result, err := cs.ElectionIndex(251694)
 	if err != nil {
@@ -185,9 +193,13 @@ func TestChainSnapshotAAAA(t *testing.T) {
 	}
 
 	rw := newChainRw(c, log15.New(), &lock.EasyImpl{})
-	cs := newSnapshotCs(rw, log15.New())
+	info, err := rw.GetMemberInfo(types.SNAPSHOT_GID)
+	if err != nil {
+		panic(err)
+	}
+	cs := newSnapshotCs(rw, info, log15.New())
 
-	rw.init(cs)
+	rw.init(cs, cs, cs)
 
 	point, err := rw.dayPoints.GetByIndex(95)
 	if err != nil {
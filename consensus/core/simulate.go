@@ -0,0 +1,136 @@
+package core
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// Election is the input of a snapshot election, read from the chain or made up for simulation
+type Election struct {
+	Index       uint64
+	Proof       ledger.HashHeight
+	Votes       []*Vote
+	SuccessRate map[types.Address]int32
+	Seed        uint64
+}
+
+// ElectionResult is the members elected in order of producing, and the plans of the index
+type ElectionResult struct {
+	Index   uint64
+	STime   time.Time
+	ETime   time.Time
+	Members []*Vote
+	Plans   []*MemberPlan
+}
+
+// Elect replay the election: filter by balance and success rate, promote randomly, and shuffle the members.
+// Votes of the input are not modified.
+func Elect(info *GroupInfo, e *Election) *ElectionResult {
+	votes := CopyVotes(e.Votes)
+	seed := NewSeedInfo(e.Seed)
+	proof := e.Proof
+
+	ag := NewAlgo(info)
+	members := ag.FilterVotes(NewVoteAlgoContext(votes, &proof, e.SuccessRate, seed))
+	members = ag.ShuffleVotes(members, &proof, seed)
+
+	result := &ElectionResult{
+		Index:   e.Index,
+		Members: members,
+		Plans:   info.GenPlanByAddress(e.Index, ConvertVoteToAddress(members)),
+	}
+	result.STime, result.ETime = info.Index2Time(e.Index)
+
+	names := make(map[types.Address]string, len(members))
+	for _, v := range members {
+		names[v.Addr] = v.Name
+	}
+	for _, p := range result.Plans {
+		p.Name = names[p.Member]
+	}
+
+	return result
+}
+
+// CountSlots return the count of slots of every producer in the results
+func CountSlots(results []*ElectionResult) map[types.Address]int {
+	slots := make(map[types.Address]int)
+	for _, r := range results {
+		for _, p := range r.Plans {
+			slots[p.Member]++
+		}
+	}
+	return slots
+}
+
+// CopyVotes copy the votes and their balances, the algorithm sorts and marks the votes in place
+func CopyVotes(votes []*Vote) []*Vote {
+	result := make([]*Vote, len(votes))
+	for i, v := range votes {
+		result[i] = &Vote{Name: v.Name, Addr: v.Addr, Balance: new(big.Int).Set(v.Balance)}
+	}
+	return result
+}
+
+// VoteChange is a hypothetical change of the votes of a producer.
+// Balance replace the votes if not nil, then Delta is added, votes below zero are zero.
+// A producer not in the votes is registered by the change, and removed if Remove is true.
+type VoteChange struct {
+	Name    string
+	Addr    types.Address
+	Balance *big.Int
+	Delta   *big.Int
+	Remove  bool
+}
+
+// ApplyVoteChanges return a copy of the votes with changes applied, in order of balance
+func ApplyVoteChanges(votes []*Vote, changes []*VoteChange) []*Vote {
+	result := CopyVotes(votes)
+	removed := make(map[string]bool)
+
+	for _, c := range changes {
+		if c.Remove {
+			removed[c.Name] = true
+			continue
+		}
+		delete(removed, c.Name)
+
+		var vote *Vote
+		for _, v := range result {
+			if v.Name == c.Name {
+				vote = v
+				break
+			}
+		}
+		if vote == nil {
+			vote = &Vote{Name: c.Name, Addr: c.Addr, Balance: big.NewInt(0)}
+			result = append(result, vote)
+		}
+		if c.Addr != (types.Address{}) {
+			vote.Addr = c.Addr
+		}
+		if c.Balance != nil {
+			vote.Balance.Set(c.Balance)
+		}
+		if c.Delta != nil {
+			vote.Balance.Add(vote.Balance, c.Delta)
+		}
+		if vote.Balance.Sign() < 0 {
+			vote.Balance.SetInt64(0)
+		}
+	}
+
+	filtered := result[:0]
+	for _, v := range result {
+		if !removed[v.Name] {
+			filtered = append(filtered, v)
+		}
+	}
+	sort.Sort(ByBalance(filtered))
+
+	return filtered
+}
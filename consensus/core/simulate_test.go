@@ -0,0 +1,106 @@
+package core
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func newSimulateGroup() *GroupInfo {
+	return NewGroupInfo(time.Unix(1541640427, 0), types.ConsensusGroupInfo{
+		Gid:             types.SNAPSHOT_GID,
+		NodeCount:       25,
+		Interval:        1,
+		PerCount:        3,
+		RandCount:       2,
+		RandRank:        100,
+		Repeat:          1,
+		CountingTokenId: ledger.ViteTokenId,
+	})
+}
+
+func newSimulateVotes(n int) []*Vote {
+	var votes []*Vote
+	for i := 0; i < n; i++ {
+		votes = append(votes, &Vote{
+			Name:    "s" + strconv.Itoa(i),
+			Addr:    common.MockAddress(i),
+			Balance: big.NewInt(int64(1000 - i)),
+		})
+	}
+	return votes
+}
+
+func TestElect(t *testing.T) {
+	info := newSimulateGroup()
+	votes := newSimulateVotes(30)
+	e := &Election{Index: 10, Proof: ledger.HashHeight{Height: 100}, Votes: votes}
+
+	r := Elect(info, e)
+	if len(r.Members) != 25 {
+		t.Fatalf("elected %d members", len(r.Members))
+	}
+	if len(r.Plans) != 25*3 {
+		t.Fatalf("%d plans", len(r.Plans))
+	}
+	for _, p := range r.Plans {
+		if p.Name == "" {
+			t.Fatal("name of plan is missing")
+		}
+	}
+
+	// replay is deterministic, and the votes are not modified
+	r2 := Elect(info, e)
+	for i := range r.Members {
+		if r.Members[i].Addr != r2.Members[i].Addr {
+			t.Fatal("different result of the same election")
+		}
+	}
+	for i, v := range votes {
+		if v.Name != "s"+strconv.Itoa(i) || len(v.Type) > 0 {
+			t.Fatal("votes are modified")
+		}
+	}
+
+	slots := CountSlots([]*ElectionResult{r, r2})
+	total := 0
+	for _, n := range slots {
+		total += n
+	}
+	if total != 2*25*3 {
+		t.Errorf("count %d slots", total)
+	}
+}
+
+func TestApplyVoteChanges(t *testing.T) {
+	votes := newSimulateVotes(3)
+
+	changed := ApplyVoteChanges(votes, []*VoteChange{
+		{Name: "s0", Remove: true},
+		{Name: "s2", Delta: big.NewInt(100)},
+		{Name: "s1", Delta: big.NewInt(-2000)},
+		{Name: "new", Addr: common.MockAddress(10), Balance: big.NewInt(500)},
+	})
+
+	expected := []struct {
+		name    string
+		balance int64
+	}{{"s2", 1098}, {"new", 500}, {"s1", 0}}
+	if len(changed) != len(expected) {
+		t.Fatalf("%d votes", len(changed))
+	}
+	for i, v := range expected {
+		if changed[i].Name != v.name || changed[i].Balance.Int64() != v.balance {
+			t.Errorf("vote %d is %s %s", i, changed[i].Name, changed[i].Balance)
+		}
+	}
+
+	if votes[2].Balance.Int64() != 998 || len(votes) != 3 {
+		t.Error("votes are modified")
+	}
+}
@@ -24,7 +24,7 @@ func (self *dposReader) getDposConsensus(gid types.Gid) (DposReader, error) {
 }
 
 type DposReader interface {
-	ElectionIndex(index uint64) (*electionResult, error)
+	ElectionIndex(index uint64) (*Schedule, error)
 	GetInfo() *core.GroupInfo
 	Time2Index(t time.Time) uint64
 	Index2Time(i uint64) (time.Time, time.Time)
@@ -32,5 +32,11 @@ type DposReader interface {
 	VerifyProducer(address types.Address, t time.Time) (bool, error)
 }
 
+// ElectionInputReader read the input of snapshot elections from the chain, for simulation
+type ElectionInputReader interface {
+	DposReader
+	ElectionInput(index uint64) (*core.Election, error)
+}
+
 type DposVerifier interface {
 }
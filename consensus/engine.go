@@ -0,0 +1,70 @@
+package consensus
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus/cdb"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+// Engine is the consensus of snapshot blocks.
+// It generates the schedule of producers for every period, verifies the producer of blocks,
+// and decides how many producers should confirm a block to make it irreversible.
+type Engine interface {
+	DposReader
+
+	// Name is the name of engine in config, such as dpos and poa
+	Name() string
+	// IrreversibleCount return the count of different producers confirming a block to make it irreversible
+	IrreversibleCount() uint64
+}
+
+// engineStats is the stats of producers depending on the engine, such as votes
+type engineStats interface {
+	core.SBPStatReader
+
+	// dayVoteStat return the votes of producers for the day stats
+	dayVoteStat(b byte, index uint64, proofHash types.Hash) (*cdb.VoteContent, error)
+	// triggerLoad prepare the schedule of the period after proofBlock in the background
+	triggerLoad(proofBlock *ledger.SnapshotBlock)
+}
+
+// irreversibleCount is more than 2/3 of producers
+func irreversibleCount(nodeCount int) uint64 {
+	return uint64(nodeCount/3*2 + 1)
+}
+
+// newEngine create the engine selected by cfg, DPoS if cfg is nil.
+// The blocks of producers are counted by snapshotCs for every engine.
+func newEngine(cfg *config.Consensus, rw *chainRw, log log15.Logger) (Engine, *snapshotCs, error) {
+	if !cfg.IsPoA() {
+		if cfg != nil && cfg.Engine != "" && cfg.Engine != config.ConsensusEngineDPoS {
+			return nil, nil, errors.Errorf("unknown consensus engine %s", cfg.Engine)
+		}
+
+		info, err := rw.GetMemberInfo(types.SNAPSHOT_GID)
+		if err != nil {
+			return nil, nil, err
+		}
+		snapshot := newSnapshotCs(rw, info, log)
+		return snapshot, snapshot, nil
+	}
+
+	poa, err := newPoAEngine(rw.genesisTime, cfg.PoA, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	return poa, newSnapshotCs(rw, poa.GetInfo(), log), nil
+}
+
+// newEngineStats return the stats and the api of the engine
+func newEngineStats(engine Engine, snapshot *snapshotCs) (engineStats, APIReader) {
+	if poa, ok := engine.(*poaEngine); ok {
+		stats := newPoAStats(snapshot, poa)
+		return stats, stats
+	}
+	return snapshot, &APISnapshot{snapshot: snapshot}
+}
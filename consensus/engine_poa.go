@@ -0,0 +1,163 @@
+package consensus
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus/cdb"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+const (
+	defaultPoAInterval = 1
+	defaultPoAPerCount = 3
+)
+
+// poaEngine take turns of producers configured in genesis, in the same order for every period.
+// It reads nothing from the governance contract, so no registration or vote is needed.
+type poaEngine struct {
+	core.GroupInfo
+	producers []types.Address
+
+	log log15.Logger
+}
+
+func newPoAEngine(genesisTime time.Time, cfg *config.PoA, log log15.Logger) (*poaEngine, error) {
+	if cfg == nil || len(cfg.Producers) == 0 {
+		return nil, errors.New("no producer of PoA")
+	}
+	if len(cfg.Producers) > math.MaxUint8 {
+		return nil, errors.Errorf("too many producers of PoA, max is %d", math.MaxUint8)
+	}
+
+	seen := make(map[types.Address]bool)
+	for _, addr := range cfg.Producers {
+		if seen[addr] {
+			return nil, errors.Errorf("duplicate producer %s of PoA", addr)
+		}
+		seen[addr] = true
+	}
+
+	interval, perCount := cfg.Interval, cfg.PerCount
+	if interval <= 0 {
+		interval = defaultPoAInterval
+	}
+	if perCount <= 0 {
+		perCount = defaultPoAPerCount
+	}
+
+	info := core.NewGroupInfo(genesisTime, types.ConsensusGroupInfo{
+		Gid:       types.SNAPSHOT_GID,
+		NodeCount: uint8(len(cfg.Producers)),
+		Interval:  interval,
+		PerCount:  perCount,
+		Repeat:    1,
+	})
+
+	return &poaEngine{
+		GroupInfo: *info,
+		producers: append([]types.Address{}, cfg.Producers...),
+		log:       log.New("gid", "snapshot", "engine", config.ConsensusEnginePoA),
+	}, nil
+}
+
+func (poa *poaEngine) Name() string {
+	return config.ConsensusEnginePoA
+}
+
+func (poa *poaEngine) IrreversibleCount() uint64 {
+	return irreversibleCount(len(poa.producers))
+}
+
+func (poa *poaEngine) GetInfo() *core.GroupInfo {
+	return &poa.GroupInfo
+}
+
+func (poa *poaEngine) ElectionIndex(index uint64) (*Schedule, error) {
+	return genElectionResult(&poa.GroupInfo, index, poa.producers), nil
+}
+
+// GenProofTime is the same as DPoS, though there is nothing to prove
+func (poa *poaEngine) GenProofTime(index uint64) time.Time {
+	if index < 2 {
+		return poa.GenesisTime.Add(time.Second)
+	}
+	_, etime := poa.Index2Time(index - 2)
+	return etime
+}
+
+func (poa *poaEngine) VerifyProducer(address types.Address, t time.Time) (bool, error) {
+	result, _ := poa.ElectionIndex(poa.Time2Index(t))
+	for _, plan := range result.Plans {
+		if plan.Member == address && plan.STime == t {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// poaStats is the stats of producers for PoA. The blocks of producers are counted by snapshotCs as DPoS,
+// but there are no votes or registrations, the producers are named by their addresses.
+type poaStats struct {
+	*snapshotCs
+	poa *poaEngine
+}
+
+func newPoAStats(snapshot *snapshotCs, poa *poaEngine) *poaStats {
+	return &poaStats{snapshotCs: snapshot, poa: poa}
+}
+
+func (stats *poaStats) names() (map[types.Address]string, error) {
+	names := make(map[types.Address]string, len(stats.poa.producers))
+	for _, addr := range stats.poa.producers {
+		names[addr] = addr.String()
+	}
+	return names, nil
+}
+
+func (stats *poaStats) DayStats(startIndex uint64, endIndex uint64) ([]*core.DayStats, error) {
+	return stats.dayStats(startIndex, endIndex, stats.names)
+}
+
+// dayVoteStat list every producer without votes
+func (stats *poaStats) dayVoteStat(b byte, index uint64, proofHash types.Hash) (*cdb.VoteContent, error) {
+	details := make(map[string]*big.Int, len(stats.poa.producers))
+	for _, addr := range stats.poa.producers {
+		details[addr.String()] = big.NewInt(0)
+	}
+	return &cdb.VoteContent{Details: details, Total: big.NewInt(0)}, nil
+}
+
+// triggerLoad do nothing since the schedule needs no votes
+func (stats *poaStats) triggerLoad(proofBlock *ledger.SnapshotBlock) {
+}
+
+// ReadVoteMap list the producers without votes at the snapshot block before ti
+func (stats *poaStats) ReadVoteMap(ti time.Time) ([]*VoteDetails, *ledger.HashHeight, error) {
+	block, err := stats.rw.GetSnapshotBeforeTime(ti)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var details []*VoteDetails
+	for _, addr := range stats.poa.producers {
+		details = append(details, &VoteDetails{
+			Vote:         core.Vote{Name: addr.String(), Addr: addr, Balance: big.NewInt(0)},
+			CurrentAddr:  addr,
+			RegisterList: []types.Address{addr},
+			Addr:         make(map[types.Address]*big.Int),
+		})
+	}
+	return details, &ledger.HashHeight{Height: block.Height, Hash: block.Hash}, nil
+}
+
+// ReadSuccessRate is the same as DPoS
+func (stats *poaStats) ReadSuccessRate(start, end uint64) ([]map[types.Address]*cdb.Content, error) {
+	return (&APISnapshot{snapshot: stats.snapshotCs}).ReadSuccessRate(start, end)
+}
@@ -0,0 +1,129 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool/lock"
+)
+
+func TestPoAEngine_schedule(t *testing.T) {
+	genesis := time.Unix(1541640427, 0)
+	producers := []types.Address{common.MockAddress(0), common.MockAddress(1), common.MockAddress(2)}
+
+	poa, err := newPoAEngine(genesis, &config.PoA{Producers: producers, PerCount: 2}, log15.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poa.Name() != config.ConsensusEnginePoA || poa.IrreversibleCount() != 3 {
+		t.Errorf("engine %s, irreversible count %d", poa.Name(), poa.IrreversibleCount())
+	}
+
+	for index := uint64(0); index < 3; index++ {
+		schedule, err := poa.ElectionIndex(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schedule.Plans) != 6 || schedule.Index != index {
+			t.Fatalf("%d plans in period %d", len(schedule.Plans), schedule.Index)
+		}
+		for i, plan := range schedule.Plans {
+			if plan.Member != producers[i/2] {
+				t.Errorf("plan %d of period %d is %s", i, index, plan.Member)
+			}
+
+			ok, err := poa.VerifyProducer(plan.Member, plan.STime)
+			if err != nil || !ok {
+				t.Errorf("producer of plan %d should be verified: %v", i, err)
+			}
+			ok, _ = poa.VerifyProducer(common.MockAddress(3), plan.STime)
+			if ok {
+				t.Errorf("stranger of plan %d should not be verified", i)
+			}
+		}
+	}
+
+	if ok, _ := poa.VerifyProducer(producers[0], genesis.Add(500*time.Millisecond)); ok {
+		t.Error("time out of slot should not be verified")
+	}
+}
+
+func TestPoAEngine_config(t *testing.T) {
+	genesis := time.Unix(1541640427, 0)
+	cases := []*config.PoA{
+		nil,
+		{},
+		{Producers: []types.Address{common.MockAddress(0), common.MockAddress(0)}},
+	}
+	for i, c := range cases {
+		if _, err := newPoAEngine(genesis, c, log15.New()); err == nil {
+			t.Errorf("case %d: config should be refused", i)
+		}
+	}
+
+	poa, err := newPoAEngine(genesis, &config.PoA{Producers: []types.Address{common.MockAddress(0)}}, log15.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poa.Interval != defaultPoAInterval || poa.PerCount != defaultPoAPerCount {
+		t.Errorf("interval %d, per count %d", poa.Interval, poa.PerCount)
+	}
+}
+
+func TestPoAStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	// no votes or registrations are read from the chain
+	mock_chain := NewMockChain(ctrl)
+	mock_chain.EXPECT().GetGenesisSnapshotBlock().Return(&ledger.SnapshotBlock{
+		Timestamp: &simpleGenesis,
+	})
+	db := NewDb(t, UnitTestDir)
+	defer ClearDb(t, UnitTestDir)
+	mock_chain.EXPECT().NewDb(gomock.Any()).Return(db, nil)
+
+	b1 := &ledger.SnapshotBlock{Height: 1, Hash: types.Hash{1}, Timestamp: &simpleGenesis}
+	mock_chain.EXPECT().GetSnapshotHeaderBeforeTime(gomock.Any()).Return(b1, nil)
+
+	rw := newChainRw(mock_chain, log15.New(), &lock.EasyImpl{})
+	producers := []types.Address{common.MockAddress(0), common.MockAddress(1), common.MockAddress(2)}
+	poa, err := newPoAEngine(simpleGenesis, &config.PoA{Producers: producers}, log15.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := newSnapshotCs(rw, poa.GetInfo(), log15.New())
+	stats, api := newEngineStats(poa, snapshot)
+	rw.init(snapshot, poa, stats)
+
+	if _, ok := stats.(*poaStats); !ok {
+		t.Fatalf("stats of PoA is %T", stats)
+	}
+	stats.triggerLoad(b1)
+
+	votes, err := stats.dayVoteStat(0, 0, b1.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(votes.Details) != len(producers) || votes.Total.Sign() != 0 {
+		t.Errorf("votes of producers: %+v", votes)
+	}
+
+	details, hashH, err := api.ReadVoteMap(simpleGenesis.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details) != len(producers) || hashH.Hash != b1.Hash {
+		t.Errorf("%d producers at %v", len(details), hashH)
+	}
+	for i, d := range details {
+		if d.CurrentAddr != producers[i] || d.Name != producers[i].String() {
+			t.Errorf("producer %d is %s", i, d.Name)
+		}
+	}
+}
@@ -37,10 +37,10 @@ func (m *MockDposReader) EXPECT() *MockDposReaderMockRecorder {
 }
 
 // ElectionIndex mocks base method
-func (m *MockDposReader) ElectionIndex(arg0 uint64) (*electionResult, error) {
+func (m *MockDposReader) ElectionIndex(arg0 uint64) (*Schedule, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ElectionIndex", arg0)
-	ret0, _ := ret[0].(*electionResult)
+	ret0, _ := ret[0].(*Schedule)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
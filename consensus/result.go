@@ -7,15 +7,16 @@ import (
 	"github.com/vitelabs/go-vite/consensus/core"
 )
 
-type electionResult struct {
+// Schedule is the plans of producers in a period, generated by the consensus engine
+type Schedule struct {
 	Plans []*core.MemberPlan
 	STime time.Time
 	ETime time.Time
 	Index uint64
 }
 
-func genElectionResult(info *core.GroupInfo, index uint64, members []types.Address) *electionResult {
-	self := &electionResult{}
+func genElectionResult(info *core.GroupInfo, index uint64, members []types.Address) *Schedule {
+	self := &Schedule{}
 	self.STime, self.ETime = info.Index2Time(index)
 	self.Plans = info.GenPlanByAddress(index, members)
 	self.Index = index
@@ -65,7 +65,7 @@ func (cs *consensus) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error
 				cs.mLog.Error("can't get block", "height", block.Height-1)
 				return nil
 			}
-			cs.snapshot.triggerLoad(snapshotBlock)
+			cs.stats.triggerLoad(snapshotBlock)
 		}
 	}
 	return nil
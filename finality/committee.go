@@ -0,0 +1,75 @@
+package finality
+
+import (
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// keep the members of a few periods, votes are always about the recent blocks
+const committeeCacheSize = 4
+
+// Committee decide who can vote the snapshot blocks
+type Committee interface {
+	// Members return the producers of the period of the block, and the count of votes to finalize a block
+	Members(block *ledger.SnapshotBlock) (members map[types.Address]bool, threshold int, err error)
+}
+
+// Threshold return the count of votes more than 2/3 of nodeCount
+func Threshold(nodeCount int) int {
+	return nodeCount*2/3 + 1
+}
+
+type consensusCommittee struct {
+	cs consensus.Consensus
+
+	mu      sync.Mutex
+	members map[uint64]map[types.Address]bool
+}
+
+// NewCommittee read the producers of snapshot blocks from consensus
+func NewCommittee(cs consensus.Consensus) Committee {
+	return &consensusCommittee{
+		cs:      cs,
+		members: make(map[uint64]map[types.Address]bool),
+	}
+}
+
+func (c *consensusCommittee) Members(block *ledger.SnapshotBlock) (map[types.Address]bool, int, error) {
+	threshold := Threshold(c.cs.SBPReader().GetNodeCount())
+
+	index, err := c.cs.VoteTimeToIndex(types.SNAPSHOT_GID, *block.Timestamp)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if members, ok := c.members[index]; ok {
+		return members, threshold, nil
+	}
+
+	events, _, err := c.cs.ReadByIndex(types.SNAPSHOT_GID, index)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	members := make(map[types.Address]bool)
+	for _, e := range events {
+		members[e.Address] = true
+	}
+
+	if len(c.members) >= committeeCacheSize {
+		for i := range c.members {
+			if i < index {
+				delete(c.members, i)
+			}
+		}
+	}
+	c.members[index] = members
+
+	return members, threshold, nil
+}
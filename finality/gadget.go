@@ -0,0 +1,416 @@
+// Package finality finalize snapshot blocks by the votes of snapshot block producers.
+// Every producer signs a vote for its latest snapshot block, the vote supports the block and all its ancestors.
+// A block is final when more than 2/3 of producers voted it or its descendants,
+// final blocks will never be rolled back.
+package finality
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/vm_db"
+	"github.com/vitelabs/go-vite/wallet/signer"
+)
+
+const (
+	// votes too far beyond the latest snapshot block are dropped
+	maxVoteAhead = 75
+	// check finality periodically, votes may arrive before blocks
+	checkInterval = 3 * time.Second
+)
+
+var (
+	errNotProducer = errors.New("voter is not a snapshot block producer")
+	errVoteTooHigh = errors.New("vote is too far beyond the latest snapshot block")
+)
+
+// Chain is the part of chain used by Gadget
+type Chain interface {
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error)
+	Register(listener chain.EventListener)
+	UnRegister(listener chain.EventListener)
+}
+
+// FinalizedCallback will be invoked when a higher snapshot block is final
+type FinalizedCallback = func(block *ledger.SnapshotBlock)
+
+// Gadget collect finality votes, vote by coinbase if it is a producer, and track the final snapshot block
+type Gadget struct {
+	chain     Chain
+	committee Committee
+	signer    signer.Signer
+	coinbase  *types.Address
+	store     *store
+
+	relay      net.FinalityVoteRelay
+	relaySubId int
+
+	mu    sync.RWMutex
+	votes map[types.Address]*ledger.FinalityVote // the latest vote of every voter
+	final *ledger.SnapshotBlock
+	voted *ledger.HashHeight
+
+	subMu sync.Mutex
+	subs  map[int]FinalizedCallback
+	subId int
+
+	trigger chan struct{}
+	term    chan struct{}
+	wg      sync.WaitGroup
+
+	log log15.Logger
+}
+
+// NewGadget load the final block from dir, coinbase is nil if the node is not a producer
+func NewGadget(chain Chain, committee Committee, s signer.Signer, coinbase *types.Address, dir string) (*Gadget, error) {
+	st, err := newStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gadget{
+		chain:     chain,
+		committee: committee,
+		signer:    s,
+		coinbase:  coinbase,
+		store:     st,
+		votes:     make(map[types.Address]*ledger.FinalityVote),
+		voted:     st.getVoted(),
+		subs:      make(map[int]FinalizedCallback),
+		trigger:   make(chan struct{}, 1),
+		log:       log15.New("module", "finality"),
+	}
+
+	if hh := st.getFinal(); hh != nil {
+		block, err := chain.GetSnapshotHeaderByHeight(hh.Height)
+		if err != nil || block == nil || block.Hash != hh.Hash {
+			g.log.Warn("final snapshot block is not in chain", "height", hh.Height, "hash", hh.Hash)
+		} else {
+			g.final = block
+		}
+	}
+
+	return g, nil
+}
+
+// Init set the relay to send and receive votes
+func (g *Gadget) Init(relay net.FinalityVoteRelay) {
+	g.relay = relay
+}
+
+func (g *Gadget) Start() {
+	g.term = make(chan struct{})
+	g.chain.Register(g)
+	if g.relay != nil {
+		g.relaySubId = g.relay.SubscribeFinalityVote(func(vote *ledger.FinalityVote) error {
+			err := g.AddVote(vote)
+			if err != nil {
+				g.log.Debug("drop finality vote", "vote", vote, "err", err)
+			}
+			return err
+		})
+	}
+
+	g.wg.Add(1)
+	go g.loop()
+}
+
+func (g *Gadget) Stop() {
+	if g.relay != nil {
+		g.relay.UnsubscribeFinalityVote(g.relaySubId)
+	}
+	g.chain.UnRegister(g)
+
+	close(g.term)
+	g.wg.Wait()
+
+	if err := g.store.close(); err != nil {
+		g.log.Error("failed to close finality store", "err", err)
+	}
+}
+
+func (g *Gadget) loop() {
+	defer g.wg.Done()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.term:
+			return
+		case <-g.trigger:
+		case <-ticker.C:
+		}
+
+		g.vote()
+		g.update()
+	}
+}
+
+func (g *Gadget) notify() {
+	select {
+	case g.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// FinalizedSnapshotBlock return the highest final snapshot block, nil if no block is final
+func (g *Gadget) FinalizedSnapshotBlock() *ledger.SnapshotBlock {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.final
+}
+
+// AddVote keep the latest vote of every producer, the signature should be verified before
+func (g *Gadget) AddVote(vote *ledger.FinalityVote) error {
+	head := g.chain.GetLatestSnapshotBlock()
+	if vote.Height > head.Height+maxVoteAhead {
+		return errVoteTooHigh
+	}
+
+	members, _, err := g.committee.Members(head)
+	if err != nil {
+		return err
+	}
+	voter := vote.Voter()
+	if !members[voter] {
+		return errNotProducer
+	}
+
+	g.mu.Lock()
+	if g.final != nil && vote.Height <= g.final.Height {
+		g.mu.Unlock()
+		return nil
+	}
+	if old, ok := g.votes[voter]; ok && old.Height >= vote.Height {
+		g.mu.Unlock()
+		return nil
+	}
+	g.votes[voter] = vote
+	g.mu.Unlock()
+
+	g.notify()
+	return nil
+}
+
+// vote the latest snapshot block by coinbase.
+// Never vote a block conflicting with the last vote, unless the last vote is below the final block.
+func (g *Gadget) vote() {
+	if g.coinbase == nil || g.relay == nil {
+		return
+	}
+
+	head := g.chain.GetLatestSnapshotBlock()
+
+	g.mu.RLock()
+	voted, final := g.voted, g.final
+	g.mu.RUnlock()
+
+	if voted != nil {
+		if head.Height <= voted.Height {
+			return
+		}
+		if final == nil || final.Height < voted.Height {
+			block, err := g.chain.GetSnapshotHeaderByHeight(voted.Height)
+			if err != nil || block == nil || block.Hash != voted.Hash {
+				return
+			}
+		}
+	}
+
+	members, _, err := g.committee.Members(head)
+	if err != nil || !members[*g.coinbase] {
+		return
+	}
+
+	vote := &ledger.FinalityVote{Height: head.Height, Hash: head.Hash}
+	vote.Signature, vote.PublicKey, err = g.signer.SignFinalityVote(*g.coinbase, vote)
+	if err != nil {
+		g.log.Error("failed to sign finality vote", "height", head.Height, "err", err)
+		return
+	}
+
+	hh := ledger.HashHeight{Height: head.Height, Hash: head.Hash}
+	if err = g.store.putVoted(hh); err != nil {
+		g.log.Error("failed to store finality vote", "height", head.Height, "err", err)
+		return
+	}
+
+	g.mu.Lock()
+	g.voted = &hh
+	g.mu.Unlock()
+
+	g.relay.BroadcastFinalityVote(vote)
+	if err = g.AddVote(vote); err != nil {
+		g.log.Warn("failed to add finality vote of self", "err", err)
+	}
+}
+
+// update count the votes of producers of the latest period, votes for blocks not in chain are ignored
+func (g *Gadget) update() {
+	head := g.chain.GetLatestSnapshotBlock()
+	members, threshold, err := g.committee.Members(head)
+	if err != nil {
+		g.log.Warn("failed to read producers", "err", err)
+		return
+	}
+
+	g.mu.RLock()
+	final := g.final
+	votes := make([]*ledger.FinalityVote, 0, len(g.votes))
+	for _, v := range g.votes {
+		votes = append(votes, v)
+	}
+	g.mu.RUnlock()
+
+	var heights []uint64
+	for _, v := range votes {
+		if v.Height > head.Height || (final != nil && v.Height <= final.Height) || !members[v.Voter()] {
+			continue
+		}
+		block, err := g.chain.GetSnapshotHeaderByHeight(v.Height)
+		if err != nil || block == nil || block.Hash != v.Hash {
+			continue
+		}
+		heights = append(heights, v.Height)
+	}
+
+	height, ok := FinalHeight(heights, threshold)
+	if !ok {
+		return
+	}
+	block, err := g.chain.GetSnapshotHeaderByHeight(height)
+	if err != nil || block == nil {
+		return
+	}
+
+	if err = g.store.putFinal(ledger.HashHeight{Height: block.Height, Hash: block.Hash}); err != nil {
+		g.log.Error("failed to store final snapshot block", "height", block.Height, "err", err)
+		return
+	}
+
+	g.mu.Lock()
+	if g.final != nil && g.final.Height >= block.Height {
+		g.mu.Unlock()
+		return
+	}
+	g.final = block
+	for addr, v := range g.votes {
+		if v.Height <= block.Height {
+			delete(g.votes, addr)
+		}
+	}
+	g.mu.Unlock()
+
+	g.log.Info("snapshot block is final", "height", block.Height, "hash", block.Hash)
+
+	g.subMu.Lock()
+	subs := make([]FinalizedCallback, 0, len(g.subs))
+	for _, fn := range g.subs {
+		subs = append(subs, fn)
+	}
+	g.subMu.Unlock()
+
+	for _, fn := range subs {
+		fn(block)
+	}
+}
+
+// FinalHeight return the highest height voted by at least threshold votes, every vote supports lower heights
+func FinalHeight(heights []uint64, threshold int) (uint64, bool) {
+	if threshold <= 0 || len(heights) < threshold {
+		return 0, false
+	}
+
+	sorted := append([]uint64{}, heights...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] > sorted[j]
+	})
+
+	return sorted[threshold-1], true
+}
+
+// SubscribeFinalized return the subId, always larger than 0, use to unsubscribe
+func (g *Gadget) SubscribeFinalized(fn FinalizedCallback) (subId int) {
+	g.subMu.Lock()
+	defer g.subMu.Unlock()
+
+	g.subId++
+	g.subs[g.subId] = fn
+	return g.subId
+}
+
+// UnsubscribeFinalized if subId is 0, then ignore
+func (g *Gadget) UnsubscribeFinalized(subId int) {
+	g.subMu.Lock()
+	defer g.subMu.Unlock()
+
+	delete(g.subs, subId)
+}
+
+type irreversibleReader struct {
+	g        *Gadget
+	fallback net.IrreversibleReader
+}
+
+func (r irreversibleReader) GetIrreversibleBlock() *ledger.SnapshotBlock {
+	var block *ledger.SnapshotBlock
+	if r.fallback != nil {
+		block = r.fallback.GetIrreversibleBlock()
+	}
+
+	if final := r.g.FinalizedSnapshotBlock(); final != nil && (block == nil || final.Height > block.Height) {
+		return final
+	}
+	return block
+}
+
+// IrreversibleReader return the higher one of the final block and the irreversible block of fallback
+func (g *Gadget) IrreversibleReader(fallback net.IrreversibleReader) net.IrreversibleReader {
+	return irreversibleReader{g: g, fallback: fallback}
+}
+
+func (g *Gadget) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (g *Gadget) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (g *Gadget) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (g *Gadget) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	g.notify()
+	return nil
+}
+
+func (g *Gadget) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (g *Gadget) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+// PrepareDeleteSnapshotBlocks never vetoes, the chain exits on a failed listener.
+// Rollbacks of the final block are refused by the pool and net before the chain deletes blocks.
+func (g *Gadget) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (g *Gadget) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
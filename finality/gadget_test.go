@@ -0,0 +1,222 @@
+package finality
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/net"
+)
+
+type memChain struct {
+	blocks []*ledger.SnapshotBlock
+}
+
+func newMemChain(height uint64) *memChain {
+	c := &memChain{}
+	now := time.Now()
+	for i := uint64(0); i <= height; i++ {
+		c.blocks = append(c.blocks, &ledger.SnapshotBlock{Height: i, Hash: types.DataHash([]byte{byte(i)}), Timestamp: &now})
+	}
+	return c
+}
+
+func (c *memChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *memChain) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height >= uint64(len(c.blocks)) {
+		return nil, nil
+	}
+	return c.blocks[height], nil
+}
+
+func (c *memChain) Register(listener chain.EventListener)   {}
+func (c *memChain) UnRegister(listener chain.EventListener) {}
+
+type fixedCommittee map[types.Address]bool
+
+func (c fixedCommittee) Members(block *ledger.SnapshotBlock) (map[types.Address]bool, int, error) {
+	return c, Threshold(len(c)), nil
+}
+
+type keySigner map[types.Address]ed25519.PrivateKey
+
+func (s keySigner) Addresses() ([]types.Address, error) {
+	return nil, nil
+}
+
+func (s keySigner) SignSnapshotBlock(addr types.Address, block *ledger.SnapshotBlock) ([]byte, []byte, error) {
+	return nil, nil, nil
+}
+
+func (s keySigner) SignAccountBlock(addr types.Address, block *ledger.AccountBlock) ([]byte, []byte, error) {
+	return nil, nil, nil
+}
+
+func (s keySigner) SignFinalityVote(addr types.Address, vote *ledger.FinalityVote) ([]byte, []byte, error) {
+	key := s[addr]
+	return ed25519.Sign(key, vote.DataHash().Bytes()), key.PubByte(), nil
+}
+
+type memRelay struct {
+	votes []*ledger.FinalityVote
+}
+
+func (r *memRelay) BroadcastFinalityVote(vote *ledger.FinalityVote) {
+	r.votes = append(r.votes, vote)
+}
+
+func (r *memRelay) SubscribeFinalityVote(fn net.FinalityVoteCallback) (subId int) {
+	return 1
+}
+
+func (r *memRelay) UnsubscribeFinalityVote(subId int) {}
+
+func newProducers(n int) (keySigner, fixedCommittee, []types.Address) {
+	keys := make(keySigner)
+	committee := make(fixedCommittee)
+	var addrs []types.Address
+	for i := 0; i < n; i++ {
+		_, key, _ := ed25519.GenerateKey(rand.Reader)
+		addr := types.PubkeyToAddress(key.PubByte())
+		keys[addr] = key
+		committee[addr] = true
+		addrs = append(addrs, addr)
+	}
+	return keys, committee, addrs
+}
+
+func vote(keys keySigner, addr types.Address, block *ledger.SnapshotBlock) *ledger.FinalityVote {
+	v := &ledger.FinalityVote{Height: block.Height, Hash: block.Hash}
+	v.Signature, v.PublicKey, _ = keys.SignFinalityVote(addr, v)
+	return v
+}
+
+func TestFinalHeight(t *testing.T) {
+	cases := []struct {
+		heights   []uint64
+		threshold int
+		height    uint64
+		ok        bool
+	}{
+		{nil, 3, 0, false},
+		{[]uint64{10, 20}, 3, 0, false},
+		{[]uint64{10, 30, 20}, 3, 10, true},
+		{[]uint64{10, 30, 20, 25}, 3, 20, true},
+		{[]uint64{5, 5, 5}, 0, 0, false},
+	}
+
+	for i, c := range cases {
+		height, ok := FinalHeight(c.heights, c.threshold)
+		if height != c.height || ok != c.ok {
+			t.Errorf("case %d: final height %d %v", i, height, ok)
+		}
+	}
+}
+
+func TestGadget_update(t *testing.T) {
+	dir, err := ioutil.TempDir("", "finality")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := newMemChain(20)
+	keys, committee, addrs := newProducers(4)
+
+	g, err := NewGadget(c, committee, keys, nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var finalized []*ledger.SnapshotBlock
+	g.SubscribeFinalized(func(block *ledger.SnapshotBlock) {
+		finalized = append(finalized, block)
+	})
+
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	stranger := types.PubkeyToAddress(other.PubByte())
+	keys[stranger] = other
+	if err = g.AddVote(vote(keys, stranger, c.blocks[20])); err != errNotProducer {
+		t.Errorf("vote of stranger should be refused: %v", err)
+	}
+
+	// votes of another fork are not counted
+	fork := &ledger.SnapshotBlock{Height: 19, Hash: types.DataHash([]byte("fork"))}
+	for i, block := range []*ledger.SnapshotBlock{c.blocks[15], c.blocks[18], fork} {
+		if err = g.AddVote(vote(keys, addrs[i], block)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.update()
+	if g.FinalizedSnapshotBlock() != nil {
+		t.Fatal("should not be final with 2 valid votes")
+	}
+
+	if err = g.AddVote(vote(keys, addrs[2], c.blocks[20])); err != nil {
+		t.Fatal(err)
+	}
+	g.update()
+	if final := g.FinalizedSnapshotBlock(); final == nil || final.Height != 15 {
+		t.Fatalf("final block is %v", final)
+	}
+	if len(finalized) != 1 {
+		t.Errorf("notified %d times", len(finalized))
+	}
+
+	// the chain exits on a failed listener, rollbacks of the final block are refused by the pool
+	if err = g.PrepareDeleteSnapshotBlocks([]*ledger.SnapshotChunk{{SnapshotBlock: c.blocks[15]}}); err != nil {
+		t.Error(err)
+	}
+
+	irreader := g.IrreversibleReader(nil)
+	if block := irreader.GetIrreversibleBlock(); block == nil || block.Height != 15 {
+		t.Errorf("irreversible block is %v", block)
+	}
+
+	// the final block is loaded from store
+	g.store.close()
+	g, err = NewGadget(c, committee, keys, nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.store.close()
+	if final := g.FinalizedSnapshotBlock(); final == nil || final.Hash != c.blocks[15].Hash {
+		t.Errorf("failed to load the final block: %v", final)
+	}
+}
+
+func TestGadget_vote(t *testing.T) {
+	c := newMemChain(10)
+	keys, committee, addrs := newProducers(4)
+	relay := &memRelay{}
+
+	g, err := NewGadget(c, committee, keys, &addrs[0], "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.store.close()
+	g.Init(relay)
+
+	g.vote()
+	g.vote()
+	if len(relay.votes) != 1 || relay.votes[0].Height != 10 || !relay.votes[0].VerifySignature() {
+		t.Fatalf("should broadcast one valid vote: %v", relay.votes)
+	}
+
+	// the chain switched to a fork conflicting with the last vote
+	c.blocks[10] = &ledger.SnapshotBlock{Height: 10, Hash: types.DataHash([]byte("fork")), Timestamp: c.blocks[9].Timestamp}
+	c.blocks = append(c.blocks, &ledger.SnapshotBlock{Height: 11, Hash: types.DataHash([]byte("fork 11")), Timestamp: c.blocks[9].Timestamp})
+	g.vote()
+	if len(relay.votes) != 1 {
+		t.Error("should not vote a block conflicting with the last vote")
+	}
+}
@@ -0,0 +1,80 @@
+package finality
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var (
+	finalKey = []byte("final")
+	votedKey = []byte("voted")
+)
+
+const hashHeightLength = 8 + types.HashSize
+
+// store persist the final snapshot block, and the latest block voted by self.
+// The vote must be written to disk before it is broadcast, otherwise after a crash
+// the producer may vote another block at the same height.
+type store struct {
+	db *leveldb.DB
+}
+
+// newStore open the store in dir, use memory if dir is empty
+func newStore(dir string) (*store, error) {
+	var db *leveldb.DB
+	var err error
+	if dir == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(dir, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "open finality store")
+	}
+
+	return &store{db: db}, nil
+}
+
+func (s *store) put(key []byte, hh ledger.HashHeight) error {
+	value := make([]byte, hashHeightLength)
+	binary.BigEndian.PutUint64(value, hh.Height)
+	copy(value[8:], hh.Hash.Bytes())
+
+	return s.db.Put(key, value, &opt.WriteOptions{Sync: true})
+}
+
+func (s *store) get(key []byte) *ledger.HashHeight {
+	value, err := s.db.Get(key, nil)
+	if err != nil || len(value) != hashHeightLength {
+		return nil
+	}
+
+	hash, _ := types.BytesToHash(value[8:])
+	return &ledger.HashHeight{Height: binary.BigEndian.Uint64(value), Hash: hash}
+}
+
+func (s *store) putFinal(hh ledger.HashHeight) error {
+	return s.put(finalKey, hh)
+}
+
+func (s *store) getFinal() *ledger.HashHeight {
+	return s.get(finalKey)
+}
+
+func (s *store) putVoted(hh ledger.HashHeight) error {
+	return s.put(votedKey, hh)
+}
+
+func (s *store) getVoted() *ledger.HashHeight {
+	return s.get(votedKey)
+}
+
+func (s *store) close() error {
+	return s.db.Close()
+}
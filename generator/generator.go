@@ -28,8 +28,8 @@ type chain interface {
 }
 
 // SignFunc is the function type defining the callback when a block requires a
-// method to sign the transaction in generator.
-type SignFunc func(addr types.Address, data []byte) (signedData, pubkey []byte, err error)
+// method to sign the transaction in generator, the hash of block is computed before signing.
+type SignFunc func(addr types.Address, block *ledger.AccountBlock) (signedData, pubkey []byte, err error)
 
 // Generator implements the logic to generate a account transaction block.
 type Generator struct {
@@ -182,7 +182,7 @@ func (gen *Generator) generateBlock(block *ledger.AccountBlock, fromBlock *ledge
 			if producer == nil {
 				return nil, errors.New("producer address is uncertain, can't sign")
 			}
-			signature, publicKey, e := signFunc(*producer, vb.Hash.Bytes())
+			signature, publicKey, e := signFunc(*producer, vb)
 			if e != nil {
 				return nil, e
 			}
@@ -0,0 +1,94 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+)
+
+// finalityVotePrefix separate the signed data of votes from the hashes of blocks
+var finalityVotePrefix = []byte("vite finality vote")
+
+var errFinalityVoteMalformed = errors.New("malformed finality vote")
+
+const finalityVoteSize = 8 + types.HashSize + ed25519.PublicKeySize + ed25519.SignatureSize
+
+// FinalityVote is signed by a snapshot block producer, to vote the snapshot block and all its ancestors are final
+type FinalityVote struct {
+	Height    uint64
+	Hash      types.Hash
+	PublicKey []byte
+	Signature []byte
+
+	voter *types.Address
+}
+
+// DataHash is the hash to be signed
+func (v *FinalityVote) DataHash() types.Hash {
+	source := make([]byte, 0, len(finalityVotePrefix)+8+types.HashSize)
+	source = append(source, finalityVotePrefix...)
+
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, v.Height)
+	source = append(source, heightBytes...)
+	source = append(source, v.Hash.Bytes()...)
+
+	hash, _ := types.BytesToHash(crypto.Hash256(source))
+	return hash
+}
+
+func (v *FinalityVote) Voter() types.Address {
+	if v.voter == nil {
+		voter := types.PubkeyToAddress(v.PublicKey)
+		v.voter = &voter
+	}
+	return *v.voter
+}
+
+func (v *FinalityVote) VerifySignature() bool {
+	if len(v.PublicKey) != ed25519.PublicKeySize || len(v.Signature) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(v.PublicKey, v.DataHash().Bytes(), v.Signature)
+}
+
+func (v *FinalityVote) String() string {
+	return fmt.Sprintf("%s voted %s/%d", v.Voter(), v.Hash, v.Height)
+}
+
+// Serialize is height, hash, public key and signature, in fixed size
+func (v *FinalityVote) Serialize() ([]byte, error) {
+	if len(v.PublicKey) != ed25519.PublicKeySize || len(v.Signature) != ed25519.SignatureSize {
+		return nil, errFinalityVoteMalformed
+	}
+
+	buf := make([]byte, finalityVoteSize)
+	binary.BigEndian.PutUint64(buf, v.Height)
+	n := 8
+	n += copy(buf[n:], v.Hash.Bytes())
+	n += copy(buf[n:], v.PublicKey)
+	copy(buf[n:], v.Signature)
+
+	return buf, nil
+}
+
+func (v *FinalityVote) Deserialize(buf []byte) error {
+	if len(buf) != finalityVoteSize {
+		return errFinalityVoteMalformed
+	}
+
+	v.Height = binary.BigEndian.Uint64(buf)
+	n := 8
+	v.Hash, _ = types.BytesToHash(buf[n : n+types.HashSize])
+	n += types.HashSize
+	v.PublicKey = append([]byte{}, buf[n:n+ed25519.PublicKeySize]...)
+	n += ed25519.PublicKeySize
+	v.Signature = append([]byte{}, buf[n:]...)
+	v.voter = nil
+
+	return nil
+}
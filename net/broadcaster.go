@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -405,6 +406,10 @@ type broadcaster struct {
 
 	store blockStore
 
+	gossip *accountGossip
+
+	evidence *evidenceCollector
+
 	mu        sync.Mutex
 	statistic circle.List // statistic latency of block propagation
 	chain     broadChainReader
@@ -413,7 +418,7 @@ type broadcaster struct {
 }
 
 func newBroadcaster(peers *peerSet, verifier Verifier, feed blockNotifier,
-	store blockStore, strategy forwardStrategy, chain broadChainReader) *broadcaster {
+	store blockStore, strategy forwardStrategy, chain broadChainReader, gossip *accountGossip) *broadcaster {
 	return &broadcaster{
 		peers:     peers,
 		statistic: circle.NewList(records24h),
@@ -424,6 +429,7 @@ func newBroadcaster(peers *peerSet, verifier Verifier, feed blockNotifier,
 		strategy:  strategy,
 		chain:     chain,
 		rings:     newRingStatic(8, 2),
+		gossip:    gossip,
 		log:       netLog.New("module", "broadcaster"),
 	}
 }
@@ -433,7 +439,7 @@ func (b *broadcaster) name() string {
 }
 
 func (b *broadcaster) codes() []Code {
-	return []Code{CodeNewAccountBlock, CodeNewSnapshotBlock}
+	return []Code{CodeNewAccountBlock, CodeNewSnapshotBlock, CodeAccountBlockHashes, CodeGetAccountBlockBodies}
 }
 
 func (b *broadcaster) handle(msg Msg) (err error) {
@@ -479,6 +485,8 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 			return err
 		}
 
+		b.evidence.observe(block)
+
 		if nb.TTL > 0 {
 			nb.TTL--
 			b.forwardSnapshotBlock(nb, msg.Sender)
@@ -508,15 +516,15 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 		b.log.Info(fmt.Sprintf("receive new accountblock %s from %s", block.Hash, msg.Sender))
 
 		// check if block has exist first
-		if exist := b.filter.Test(block.Hash[:]); exist {
+		if exist := b.gossip.seen.has(block.Hash); exist {
 			return nil
 		}
 
 		// use the compute hash, because computeHash can`t be forged
 		hash := block.ComputeHash()
 
-		// check if has exist or record, return true if has exist
-		if exist := b.filter.TestAndAdd(hash[:]); exist {
+		// check if has exist or record, drop the block if the sender or the account is over limit
+		if ok := b.gossip.receive(block, hash, msg.Sender); !ok {
 			return nil
 		}
 
@@ -544,6 +552,8 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 			return err
 		}
 
+		b.gossip.setBody(block)
+
 		if nb.TTL > 0 {
 			nb.TTL--
 			b.forwardAccountBlock(nb, msg.Sender)
@@ -555,6 +565,54 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 			b.store.enqueueAccountBlock(block)
 			b.log.Info(fmt.Sprintf("syncing, don`t give %s/%d to pool", hash, block.Height))
 		}
+
+	case CodeAccountBlockHashes:
+		ann := &AccountBlockHashes{}
+		if err = ann.Deserialize(msg.Payload); err != nil {
+			msg.Recycle()
+			return err
+		}
+		msg.Recycle()
+
+		for _, ab := range ann.Blocks {
+			msg.Sender.knownBlocks.Add(ab.Hash.Bytes())
+		}
+
+		hashes := b.gossip.wanted(ann, msg.Sender)
+		if len(hashes) == 0 {
+			return nil
+		}
+
+		err = msg.Sender.send(CodeGetAccountBlockBodies, 0, &GetAccountBlockBodies{
+			TTL:    ann.TTL,
+			Hashes: hashes,
+		})
+		if err != nil {
+			b.log.Error(fmt.Sprintf("failed to request %d accountblocks from %s: %v", len(hashes), msg.Sender, err))
+		}
+
+	case CodeGetAccountBlockBodies:
+		req := &GetAccountBlockBodies{}
+		if err = req.Deserialize(msg.Payload); err != nil {
+			msg.Recycle()
+			return err
+		}
+		msg.Recycle()
+
+		for _, hash := range req.Hashes {
+			block := b.gossip.body(hash)
+			if block == nil {
+				continue
+			}
+
+			if err = msg.Sender.send(CodeNewAccountBlock, 0, &NewAccountBlock{
+				Block: block,
+				TTL:   req.TTL,
+			}); err != nil {
+				b.log.Error(fmt.Sprintf("failed to send accountblock %s to %s: %v", hash, msg.Sender, err))
+				break
+			}
+		}
 	}
 
 	return nil
@@ -648,6 +706,7 @@ func (b *broadcaster) BroadcastSnapshotBlock(block *ledger.SnapshotBlock) {
 	}
 
 	b.filter.Add(block.Hash.Bytes())
+	b.evidence.observe(block)
 
 	var rawMsg = Msg{
 		Code:    CodeNewSnapshotBlock,
@@ -690,7 +749,8 @@ func (b *broadcaster) BroadcastAccountBlock(block *ledger.AccountBlock) {
 		return
 	}
 
-	b.filter.Add(block.Hash.Bytes())
+	b.gossip.seen.add(block.Hash)
+	b.gossip.setBody(block)
 
 	var rawMsg = Msg{
 		Code:    CodeNewAccountBlock,
@@ -756,6 +816,11 @@ func (b *broadcaster) forwardSnapshotBlock(msg *NewSnapshotBlock, sender *Peer)
 }
 
 func (b *broadcaster) forwardAccountBlock(msg *NewAccountBlock, sender *Peer) {
+	if b.gossip.announce {
+		b.announceAccountBlock(msg, sender)
+		return
+	}
+
 	data, err := msg.Serialize()
 	if err != nil {
 		b.log.Error(fmt.Sprintf("failed to forward accountblock %d: %v", msg.Block.Hash, err))
@@ -783,14 +848,57 @@ func (b *broadcaster) forwardAccountBlock(msg *NewAccountBlock, sender *Peer) {
 	}
 }
 
+// announceAccountBlock send the block hash to peers, superior peers first,
+// peers will request the body by GetAccountBlockBodies if they have not seen it
+func (b *broadcaster) announceAccountBlock(msg *NewAccountBlock, sender *Peer) {
+	ann := &AccountBlockHashes{
+		TTL: msg.TTL,
+		Blocks: []AnnouncedBlock{
+			{msg.Block.Hash, msg.Block.AccountAddress},
+		},
+	}
+
+	data, err := ann.Serialize()
+	if err != nil {
+		b.log.Error(fmt.Sprintf("failed to announce accountblock %s: %v", msg.Block.Hash, err))
+		return
+	}
+
+	var rawMsg = Msg{
+		Code:    CodeAccountBlockHashes,
+		Id:      0,
+		Payload: data,
+	}
+
+	pl := b.strategy.choosePeers(sender)
+	sort.SliceStable(pl, func(i, j int) bool {
+		return pl[i].Superior && !pl[j].Superior
+	})
+
+	for _, p := range pl {
+		if p.knownBlocks.TestAndAdd(msg.Block.Hash.Bytes()) {
+			continue
+		}
+
+		if err = p.WriteMsg(rawMsg); err != nil {
+			p.catch(err)
+			b.log.Error(fmt.Sprintf("failed to announce accountblock %s to %s: %v", msg.Block.Hash, p, err))
+		} else {
+			b.log.Info(fmt.Sprintf("announce accountblock %s to %s", msg.Block.Hash, p))
+		}
+	}
+}
+
 type broadcastStatus struct {
 	checkFailedRatio float32
 	latency          []int64
+	gossip           GossipStatus
 }
 
 func (b *broadcaster) status() broadcastStatus {
 	return broadcastStatus{
 		checkFailedRatio: b.rings.failedRatio(),
 		latency:          b.Statistic(),
+		gossip:           b.gossip.status(),
 	}
 }
@@ -145,6 +145,7 @@ type transport struct {
 	readHeadBuf       [4]byte
 	writeHeadBuf      [9]byte
 	writeBuf          []byte
+	traffic           *traffic // nil means no need to count
 }
 
 func (t *transport) Address() _net.Addr {
@@ -155,10 +156,17 @@ type transportFactory struct {
 	minCompressLength int
 	readTimeout       time.Duration
 	writeTimeout      time.Duration
+	traffic           *traffic // every codec count traffic, and add to this one
 }
 
 func (tf *transportFactory) CreateCodec(conn _net.Conn) Codec {
-	return NewTransport(conn, tf.minCompressLength, tf.readTimeout, tf.writeTimeout)
+	return &transport{
+		Conn:              conn,
+		minCompressLength: tf.minCompressLength,
+		readTimeout:       tf.readTimeout,
+		writeTimeout:      tf.writeTimeout,
+		traffic:           newTraffic(tf.traffic),
+	}
 }
 
 func NewTransport(conn _net.Conn, minCompressLength int, readTimeout, writeTimeout time.Duration) Codec {
@@ -231,6 +239,8 @@ func (t *transport) ReadMsg() (msg Msg, err error) {
 		}
 	}
 
+	t.traffic.in(msg.Code, 2+int(isize)+int(lsize)+len(msg.Payload))
+
 	if compressed {
 		var payloadReadLength int
 		payloadReadLength, err = snappy.DecodedLen(msg.Payload)
@@ -302,14 +312,18 @@ func (t *transport) WriteMsg(msg Msg) (err error) {
 	}
 
 	// send payload
-	wsize, err = t.Conn.Write(msg.Payload)
-	if err != nil {
-		return
-	}
-	if wsize != payloadLen {
-		return errWriteTooShort
+	if payloadLen > 0 {
+		wsize, err = t.Conn.Write(msg.Payload)
+		if err != nil {
+			return
+		}
+		if wsize != payloadLen {
+			return errWriteTooShort
+		}
 	}
 
+	t.traffic.out(msg.Code, int(headLen)+payloadLen)
+
 	return
 }
 
@@ -45,9 +45,12 @@ var (
 	nodeActivePrefix = []byte("node:active:") // activeAt
 	nodeCheckPrefix  = []byte("node:check:")  // checkAt
 	nodeMarkPrefix   = []byte("node:mark:")   // mark
+	nodeAnchorPrefix = []byte("node:anchor:") // anchor node data
 
 	nodeBlockIPPrefix = []byte("node:block:ip:") // block expiration
 	nodeBlockIDPrefix = []byte("node:block:id:") // block expiration
+
+	evidencePrefix = []byte("evidence:") // serialized evidence
 )
 
 func New(path string, version int, id vnode.NodeID) (db *DB, err error) {
@@ -376,6 +379,45 @@ func (db *DB) ReadMarkNodes(n int) (nodes []*vnode.Node) {
 	return nodes
 }
 
+// StoreAnchors replace all anchor nodes
+func (db *DB) StoreAnchors(nodes []*vnode.Node) {
+	batch := new(leveldb.Batch)
+
+	itr := db.NewIterator(util.BytesPrefix(nodeAnchorPrefix), nil)
+	for itr.Next() {
+		batch.Delete(append([]byte{}, itr.Key()...))
+	}
+	itr.Release()
+
+	for _, node := range nodes {
+		data, err := node.Serialize()
+		if err != nil {
+			continue
+		}
+		batch.Put(append(nodeAnchorPrefix, node.ID.Bytes()...), data)
+	}
+
+	_ = db.Write(batch, nil)
+}
+
+// ReadAnchors return anchor nodes stored last time
+func (db *DB) ReadAnchors() (nodes []*vnode.Node) {
+	itr := db.NewIterator(util.BytesPrefix(nodeAnchorPrefix), nil)
+	defer itr.Release()
+
+	for itr.Next() {
+		node := new(vnode.Node)
+		if err := node.Deserialize(itr.Value()); err != nil {
+			_ = db.Delete(itr.Key(), nil)
+			continue
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return
+}
+
 func (db *DB) Iterate(prefix []byte, fn func(key, value []byte) bool) {
 	itr := db.NewIterator(util.BytesPrefix(nodeMarkPrefix), nil)
 	defer itr.Release()
@@ -417,3 +459,25 @@ func (pdb *prefixDB) Remove(key []byte) {
 	key = append(pdb.prefix, key...)
 	_ = pdb.db.Delete(key, nil)
 }
+
+// StoreEvidence store the serialized evidence of double production
+func (db *DB) StoreEvidence(key, data []byte) {
+	_ = db.Put(append(evidencePrefix, key...), data, nil)
+}
+
+// DeleteEvidence delete the evidence pruned
+func (db *DB) DeleteEvidence(key []byte) {
+	_ = db.Delete(append(evidencePrefix, key...), nil)
+}
+
+// ReadEvidences return all serialized evidences
+func (db *DB) ReadEvidences() (list [][]byte) {
+	itr := db.NewIterator(util.BytesPrefix(evidencePrefix), nil)
+	defer itr.Release()
+
+	for itr.Next() {
+		list = append(list, append([]byte{}, itr.Value()...))
+	}
+
+	return
+}
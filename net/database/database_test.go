@@ -58,3 +58,31 @@ func TestNodeDB_Store(t *testing.T) {
 		t.Error("diff net")
 	}
 }
+
+func TestDB_Anchors(t *testing.T) {
+	mdb, err := New("", 1, id)
+	if err != nil {
+		panic(err)
+	}
+
+	if nodes := mdb.ReadAnchors(); len(nodes) != 0 {
+		t.Errorf("should have no anchors: %d", len(nodes))
+	}
+
+	n1, n2, n3 := vnode.MockNode(false, true), vnode.MockNode(false, true), vnode.MockNode(false, true)
+
+	mdb.StoreAnchors([]*vnode.Node{n1, n2})
+	if nodes := mdb.ReadAnchors(); len(nodes) != 2 {
+		t.Errorf("should have 2 anchors: %d", len(nodes))
+	}
+
+	// replace
+	mdb.StoreAnchors([]*vnode.Node{n3})
+	nodes := mdb.ReadAnchors()
+	if len(nodes) != 1 {
+		t.Fatalf("should have 1 anchor: %d", len(nodes))
+	}
+	if nodes[0].ID != n3.ID || nodes[0].EndPoint.String() != n3.EndPoint.String() {
+		t.Errorf("wrong anchor: %s", nodes[0])
+	}
+}
@@ -454,6 +454,15 @@ func (d *Discovery) handle(pkt *packet) {
 
 	case codeNeighbors:
 		// nothing
+
+	case codeTopicQuery:
+		query := pkt.body.(*topicQuery)
+		nodes := d.topicNeighbors(query.role, query.count)
+		_ = d.socket.sendTopicNodes(nodes, pkt.from)
+
+	case codeTopicNodes:
+		// nothing
+		// topicNodes will be handle by requestPool
 	}
 }
 
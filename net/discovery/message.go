@@ -48,6 +48,8 @@ const (
 	codeFindnode
 	codeNeighbors
 	codeException
+	codeTopicQuery
+	codeTopicNodes
 )
 
 var errDiffVersion = errors.New("different packet version")
@@ -426,6 +428,10 @@ func decode(code code, payload []byte) (m body, err error) {
 		m = new(findnode)
 	case codeNeighbors:
 		m = new(neighbors)
+	case codeTopicQuery:
+		m = new(topicQuery)
+	case codeTopicNodes:
+		m = new(topicNodes)
 	default:
 		return m, fmt.Errorf("decode packet error: unknown code %d", code)
 	}
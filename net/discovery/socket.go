@@ -52,6 +52,10 @@ type sender interface {
 	// sendNodes to addr, if eps is too many, the response message will be split to multiple message,
 	// every message is small than maxPacketLength.
 	sendNodes(eps []*vnode.EndPoint, addr *net.UDPAddr) (err error)
+	// findTopic find count nodes which have the role from n, put the responsive nodes into ch
+	findTopic(role vnode.Role, count int, n *Node) (ch <-chan []*vnode.Node, err error)
+	// sendTopicNodes to addr, like sendNodes, the response maybe split to multiple message
+	sendTopicNodes(nodes []*vnode.Node, addr *net.UDPAddr) (err error)
 }
 
 type receiver interface {
@@ -258,6 +262,78 @@ func (a *agent) sendNodes(eps []*vnode.EndPoint, to *net.UDPAddr) (err error) {
 	return
 }
 
+func (a *agent) findTopic(role vnode.Role, count int, n *Node) (ch <-chan []*vnode.Node, err error) {
+	udp, err := n.udpAddr()
+	if err != nil {
+		return
+	}
+
+	if count > maxTopicNodes {
+		count = maxTopicNodes
+	}
+
+	now := time.Now()
+	_, err = a.write(message{
+		c:  codeTopicQuery,
+		id: a.node.ID,
+		body: &topicQuery{
+			role:  role,
+			count: count,
+			time:  now,
+		},
+	}, udp)
+
+	if err != nil {
+		return
+	}
+
+	ch2 := make(chan []*vnode.Node, 1)
+	a.pool.add(&request{
+		expectID:   n.ID,
+		expectFrom: udp.String(),
+		expectCode: codeTopicNodes,
+		handler: &topicRequest{
+			role:  role,
+			count: count,
+			ch:    ch2,
+		},
+		expiration: now.Add(2 * expiration),
+	})
+
+	return ch2, nil
+}
+
+func (a *agent) sendTopicNodes(nodes []*vnode.Node, to *net.UDPAddr) (err error) {
+	tn := &topicNodes{
+		time: time.Now(),
+	}
+
+	msg := message{
+		c:    codeTopicNodes,
+		id:   a.node.ID,
+		body: tn,
+	}
+
+	nodess := splitNodes(nodes)
+	if len(nodess) == 0 {
+		tn.last = true
+		_, err = a.write(msg, to)
+		return
+	}
+
+	for i, ns := range nodess {
+		tn.nodes = ns
+		tn.last = i == len(nodess)-1
+
+		_, err = a.write(msg, to)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func splitEndPoints(eps []*vnode.EndPoint) (ept [][]*vnode.EndPoint) {
 	var sent, bytes int
 	for i, ep := range eps {
@@ -324,7 +400,7 @@ func (a *agent) readLoop() {
 		}
 
 		want := true
-		if p.c == codePong || p.c == codeNeighbors {
+		if p.c == codePong || p.c == codeNeighbors || p.c == codeTopicNodes {
 			want = a.pool.rec(p)
 		}
 
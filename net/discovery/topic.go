@@ -0,0 +1,284 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// maxTopicNodes is the max count of nodes can be responded to one topicQuery
+const maxTopicNodes = 32
+const topicLookupSources = 3
+
+var errMalformedTopic = errors.New("malformed topic message")
+
+// topicQuery ask a node for nodes in its table which have the role
+type topicQuery struct {
+	role  vnode.Role
+	count int
+	time  time.Time
+}
+
+func (q *topicQuery) serialize() ([]byte, error) {
+	buf := make([]byte, 10)
+	buf[0] = byte(q.role)
+	buf[1] = byte(q.count)
+	binary.BigEndian.PutUint64(buf[2:], uint64(q.time.Unix()))
+
+	return buf, nil
+}
+
+func (q *topicQuery) deserialize(buf []byte) error {
+	if len(buf) != 10 {
+		return errMalformedTopic
+	}
+
+	q.role = vnode.Role(buf[0])
+	q.count = int(buf[1])
+	q.time = time.Unix(int64(binary.BigEndian.Uint64(buf[2:])), 0)
+
+	return nil
+}
+
+func (q *topicQuery) expired() bool {
+	now := time.Now()
+	if now.Before(q.time) {
+		return false
+	}
+	return now.Sub(q.time) > 2*expiration
+}
+
+// topicNodes is the response of topicQuery, carry the whole node, include Ext, because the record is needed.
+// nodes will be split to multiple messages if too many.
+type topicNodes struct {
+	nodes []*vnode.Node
+	last  bool
+	time  time.Time
+}
+
+func (t *topicNodes) serialize() ([]byte, error) {
+	buf := make([]byte, 9, maxPayloadLength)
+	binary.BigEndian.PutUint64(buf, uint64(t.time.Unix()))
+	if t.last {
+		buf[8] = 1
+	}
+
+	var lb [2]byte
+	for _, n := range t.nodes {
+		data, err := n.Serialize()
+		if err != nil {
+			continue
+		}
+
+		binary.BigEndian.PutUint16(lb[:], uint16(len(data)))
+		buf = append(buf, lb[:]...)
+		buf = append(buf, data...)
+	}
+
+	return buf, nil
+}
+
+func (t *topicNodes) deserialize(buf []byte) (err error) {
+	if len(buf) < 9 {
+		return errMalformedTopic
+	}
+
+	t.time = time.Unix(int64(binary.BigEndian.Uint64(buf)), 0)
+	t.last = buf[8] == 1
+
+	buf = buf[9:]
+	for len(buf) > 0 {
+		if len(buf) < 2 {
+			return errMalformedTopic
+		}
+		size := int(binary.BigEndian.Uint16(buf))
+		buf = buf[2:]
+		if len(buf) < size {
+			return errMalformedTopic
+		}
+
+		n := new(vnode.Node)
+		if err = n.Deserialize(buf[:size]); err != nil {
+			return
+		}
+		t.nodes = append(t.nodes, n)
+
+		buf = buf[size:]
+	}
+
+	return nil
+}
+
+func (t *topicNodes) expired() bool {
+	now := time.Now()
+	if now.Before(t.time) {
+		return false
+	}
+	return now.Sub(t.time) > 2*expiration
+}
+
+// splitNodes make sure every topicNodes message is small than maxPayloadLength
+func splitNodes(nodes []*vnode.Node) (nodess [][]*vnode.Node) {
+	var sent, bytes int
+	for i, n := range nodes {
+		// id, endpoint, net, ext and the protobuf overhead
+		length := len(n.ID) + n.EndPoint.Length() + len(n.Ext) + 20
+		if bytes+length+100 > maxPayloadLength && i > sent {
+			nodess = append(nodess, nodes[sent:i])
+			bytes = 0
+			sent = i
+		}
+		bytes += length
+	}
+
+	if sent != len(nodes) {
+		nodess = append(nodess, nodes[sent:])
+	}
+
+	return
+}
+
+type topicRequest struct {
+	role     vnode.Role
+	count    int
+	received int
+	ch       chan<- []*vnode.Node
+	closed   int32
+}
+
+func (f *topicRequest) handle(pkt *packet, err error) bool {
+	if err != nil {
+		f.close()
+		return true
+	}
+
+	if tn, ok := pkt.body.(*topicNodes); ok {
+		var nodes []*vnode.Node
+		for _, n := range tn.nodes {
+			// only trust nodes have a valid record
+			if hasRole(n, f.role) {
+				nodes = append(nodes, n)
+			}
+		}
+
+		f.received += len(tn.nodes)
+		f.ch <- nodes
+		if f.received >= f.count || tn.last {
+			f.close()
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *topicRequest) close() {
+	if atomic.CompareAndSwapInt32(&f.closed, 0, 1) {
+		close(f.ch)
+	}
+}
+
+func hasRole(n *vnode.Node, role vnode.Role) bool {
+	r, err := vnode.ParseRecord(n)
+	if err != nil {
+		return false
+	}
+
+	return r.Roles.Is(role)
+}
+
+// topicNeighbors return nodes in table which have the role
+func (d *Discovery) topicNeighbors(role vnode.Role, count int) (nodes []*vnode.Node) {
+	if count > maxTopicNodes {
+		count = maxTopicNodes
+	}
+
+	for _, n := range d.table.nodes(0) {
+		if hasRole(&n.Node, role) {
+			nodes = append(nodes, &n.Node)
+			if len(nodes) >= count {
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// Lookup find nodes which have the role, eg. light client find light servers, syncing nodes find file servers.
+// nodes in the table will be returned first, if not enough, query nodes in table for more.
+// It will be blocked until enough nodes found or all queries timeout.
+func (d *Discovery) Lookup(role vnode.Role, count int) (nodes []*vnode.Node) {
+	nodes = d.topicNeighbors(role, count)
+	if len(nodes) >= count {
+		return
+	}
+
+	var mu sync.Mutex
+	var found = make(map[vnode.NodeID]struct{}, count)
+	for _, n := range nodes {
+		found[n.ID] = struct{}{}
+	}
+
+	var wg sync.WaitGroup
+	for _, source := range d.table.findSource(vnode.RandomNodeID(), topicLookupSources) {
+		ch, err := d.socket.findTopic(role, count, source)
+		if err != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for ns := range ch {
+				mu.Lock()
+				for _, n := range ns {
+					if n.ID == d.node.ID || n.Net != d.node.Net {
+						continue
+					}
+					if _, ok := found[n.ID]; ok {
+						continue
+					}
+					found[n.ID] = struct{}{}
+					nodes = append(nodes, n)
+
+					go d.receiveNode(&Node{
+						Node: *n,
+					})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(nodes) > count {
+		nodes = nodes[:count]
+	}
+
+	return
+}
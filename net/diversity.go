@@ -0,0 +1,257 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	_net "net"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// peers in the same subnet are probably run by one operator.
+// there is no ASN database, so bucket is a rough replacement: most operators own a /16 IPv4 or /32 IPv6 prefix.
+const (
+	subnetBits4 = 24
+	subnetBits6 = 48
+	bucketBits4 = 16
+	bucketBits6 = 32
+)
+
+// anchorMinAge is how long an outbound peer should keep connected before it becomes an anchor, unit is second
+const anchorMinAge = 30 * 60
+
+var diversityRegistry = metrics.NewPrefixedChildRegistry(metrics.DefaultRegistry, "/net/peers")
+
+func peerIP(addr _net.Addr) _net.IP {
+	if tcpAddr, ok := addr.(*_net.TCPAddr); ok {
+		return tcpAddr.IP
+	}
+
+	return nil
+}
+
+// netKey is the prefix of ip in bits, the first byte distinguish IPv4 and IPv6
+func netKey(ip _net.IP, bits4, bits6 int) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return "4" + string(ip4.Mask(_net.CIDRMask(bits4, 32)))
+	}
+
+	return "6" + string(ip.To16().Mask(_net.CIDRMask(bits6, 128)))
+}
+
+func subnetKey(ip _net.IP) string {
+	return netKey(ip, subnetBits4, subnetBits6)
+}
+
+func bucketKey(ip _net.IP) string {
+	return netKey(ip, bucketBits4, bucketBits6)
+}
+
+// DiversityStatus is the distribution of peers in subnets and buckets, for api and metrics
+type DiversityStatus struct {
+	Subnets        int `json:"subnets"`        // count of distinct subnets
+	Buckets        int `json:"buckets"`        // count of distinct buckets
+	MaxSubnetPeers int `json:"maxSubnetPeers"` // peers count of the largest subnet
+	MaxBucketPeers int `json:"maxBucketPeers"` // peers count of the largest bucket
+	Anchors        int `json:"anchors"`        // connected anchors
+}
+
+// diversity limit peers from the same subnet and bucket, so a single operator cannot occupy all peer slots.
+// SBP peers are not counted, loopback addresses are not limited.
+type diversity struct {
+	maxSubnet int
+	maxBucket int
+}
+
+// check return PeerTooManySameNetPeers if ip cannot be added to ps
+func (d diversity) check(ip _net.IP, ps peers) error {
+	if ip == nil || ip.IsLoopback() {
+		return nil
+	}
+
+	subnet, bucket := subnetKey(ip), bucketKey(ip)
+
+	var subnetPeers, bucketPeers int
+	for _, p := range ps {
+		if p.Superior || p.ip == nil {
+			continue
+		}
+
+		if subnetKey(p.ip) == subnet {
+			subnetPeers++
+		}
+		if bucketKey(p.ip) == bucket {
+			bucketPeers++
+		}
+	}
+
+	if subnetPeers >= d.maxSubnet || bucketPeers >= d.maxBucket {
+		return PeerTooManySameNetPeers
+	}
+
+	return nil
+}
+
+func diversityStatus(ps peers, anchors *anchors) (st DiversityStatus) {
+	subnets := make(map[string]int)
+	buckets := make(map[string]int)
+
+	for _, p := range ps {
+		if anchors.has(p.Id) {
+			st.Anchors++
+		}
+
+		if p.ip == nil {
+			continue
+		}
+
+		subnet, bucket := subnetKey(p.ip), bucketKey(p.ip)
+		subnets[subnet]++
+		buckets[bucket]++
+
+		if subnets[subnet] > st.MaxSubnetPeers {
+			st.MaxSubnetPeers = subnets[subnet]
+		}
+		if buckets[bucket] > st.MaxBucketPeers {
+			st.MaxBucketPeers = buckets[bucket]
+		}
+	}
+
+	st.Subnets = len(subnets)
+	st.Buckets = len(buckets)
+
+	return
+}
+
+func updateDiversityMetrics(st DiversityStatus) {
+	if !metrics.MetricsEnabled {
+		return
+	}
+
+	metrics.GetOrRegisterGauge("/subnets", diversityRegistry).Update(int64(st.Subnets))
+	metrics.GetOrRegisterGauge("/buckets", diversityRegistry).Update(int64(st.Buckets))
+	metrics.GetOrRegisterGauge("/maxsubnet", diversityRegistry).Update(int64(st.MaxSubnetPeers))
+	metrics.GetOrRegisterGauge("/maxbucket", diversityRegistry).Update(int64(st.MaxBucketPeers))
+	metrics.GetOrRegisterGauge("/anchors", diversityRegistry).Update(int64(st.Anchors))
+}
+
+// anchors are long-lived reliable outbound peers. They have reserved slots, and will be stored and
+// connected first after restart, so a node cannot be isolated by flooding its slots with new connections.
+type anchors struct {
+	max int
+
+	rw sync.RWMutex
+	m  map[peerId]*vnode.Node
+}
+
+func newAnchors(max int) *anchors {
+	return &anchors{
+		max: max,
+		m:   make(map[peerId]*vnode.Node),
+	}
+}
+
+func (a *anchors) has(id peerId) bool {
+	a.rw.RLock()
+	defer a.rw.RUnlock()
+
+	_, ok := a.m[id]
+	return ok
+}
+
+func (a *anchors) set(nodes []*vnode.Node) {
+	a.rw.Lock()
+	defer a.rw.Unlock()
+
+	a.m = make(map[peerId]*vnode.Node, len(nodes))
+	for _, node := range nodes {
+		if len(a.m) == a.max {
+			break
+		}
+		a.m[node.ID] = node
+	}
+}
+
+func (a *anchors) nodes() (nodes []*vnode.Node) {
+	a.rw.RLock()
+	defer a.rw.RUnlock()
+
+	nodes = make([]*vnode.Node, 0, len(a.m))
+	for _, node := range a.m {
+		nodes = append(nodes, node)
+	}
+
+	return
+}
+
+// reserved is the count of slots for the stored anchors not connected, nothing is reserved before anchors are elected
+func (a *anchors) reserved(ps peers) int {
+	var connected int
+	for _, p := range ps {
+		if a.has(p.Id) {
+			connected++
+		}
+	}
+
+	a.rw.RLock()
+	stored := len(a.m)
+	a.rw.RUnlock()
+
+	if connected >= stored {
+		return 0
+	}
+
+	return stored - connected
+}
+
+// elect keep the connected anchors, and fill the rest with the oldest reliable outbound peers
+// which have been connected longer than anchorMinAge. SBP peers have their own slots, so they are not anchors.
+func (a *anchors) elect(ps peers, now int64) (l peers) {
+	var candidates peers
+	for _, p := range ps {
+		if a.has(p.Id) {
+			l = append(l, p)
+			continue
+		}
+
+		if p.Superior || !p.Flag.is(PeerFlagOutbound) || atomic.LoadInt32(&p.reliable) != 1 {
+			continue
+		}
+		if now-p.connectAt < anchorMinAge {
+			continue
+		}
+
+		candidates = append(candidates, p)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].connectAt < candidates[j].connectAt
+	})
+
+	l = append(l, candidates...)
+	if len(l) > a.max {
+		l = l[:a.max]
+	}
+
+	return
+}
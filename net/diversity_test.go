@@ -0,0 +1,103 @@
+package net
+
+import (
+	_net "net"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+func mockDiversityPeer(ip string, flag PeerFlag, superior bool) *Peer {
+	return &Peer{
+		Id:        vnode.RandomNodeID(),
+		ip:        _net.ParseIP(ip),
+		Flag:      flag,
+		Superior:  superior,
+		connectAt: time.Now().Unix(),
+	}
+}
+
+func TestDiversity_check(t *testing.T) {
+	d := diversity{
+		maxSubnet: 2,
+		maxBucket: 3,
+	}
+
+	ps := peers{
+		mockDiversityPeer("1.2.3.4", PeerFlagInbound, false),
+		mockDiversityPeer("1.2.3.5", PeerFlagInbound, false),
+		mockDiversityPeer("1.2.4.4", PeerFlagInbound, false),
+		// SBP is not counted
+		mockDiversityPeer("5.6.7.8", PeerFlagInbound, true),
+		mockDiversityPeer("5.6.7.9", PeerFlagInbound, true),
+	}
+
+	if err := d.check(_net.ParseIP("1.2.3.6"), ps); err != PeerTooManySameNetPeers {
+		t.Errorf("subnet should be full: %v", err)
+	}
+	if err := d.check(_net.ParseIP("1.2.5.1"), ps); err != PeerTooManySameNetPeers {
+		t.Errorf("bucket should be full: %v", err)
+	}
+	if err := d.check(_net.ParseIP("5.6.7.10"), ps); err != nil {
+		t.Errorf("SBP peers should not be counted: %v", err)
+	}
+	if err := d.check(_net.ParseIP("127.0.0.1"), append(ps,
+		mockDiversityPeer("127.0.0.1", PeerFlagInbound, false),
+		mockDiversityPeer("127.0.0.1", PeerFlagInbound, false),
+	)); err != nil {
+		t.Errorf("loopback should not be limited: %v", err)
+	}
+	if err := d.check(_net.ParseIP("2001:db8::1"), ps); err != nil {
+		t.Errorf("IPv6 should be in different bucket: %v", err)
+	}
+
+	st := diversityStatus(ps, newAnchors(2))
+	if st.Subnets != 3 || st.Buckets != 2 || st.MaxSubnetPeers != 2 || st.MaxBucketPeers != 3 {
+		t.Errorf("wrong status: %+v", st)
+	}
+}
+
+func TestAnchors_elect(t *testing.T) {
+	a := newAnchors(2)
+	now := time.Now().Unix()
+
+	young := mockDiversityPeer("1.1.1.1", PeerFlagOutbound, false)
+	young.reliable = 1
+	old := mockDiversityPeer("2.2.2.2", PeerFlagOutbound, false)
+	old.reliable = 1
+	old.connectAt = now - anchorMinAge - 10
+	older := mockDiversityPeer("3.3.3.3", PeerFlagOutbound, false)
+	older.reliable = 1
+	older.connectAt = now - anchorMinAge - 20
+	inbound := mockDiversityPeer("4.4.4.4", PeerFlagInbound, false)
+	inbound.reliable = 1
+	inbound.connectAt = now - anchorMinAge - 30
+	unreliable := mockDiversityPeer("5.5.5.5", PeerFlagOutbound, false)
+	unreliable.connectAt = now - anchorMinAge - 30
+
+	ps := peers{young, old, older, inbound, unreliable}
+
+	// no anchors stored on first run
+	if n := a.reserved(ps); n != 0 {
+		t.Errorf("should reserve no slots: %d", n)
+	}
+
+	l := a.elect(ps, now)
+	if len(l) != 2 || l[0] != older || l[1] != old {
+		t.Fatalf("wrong anchors: %v", l)
+	}
+
+	// anchors of last time are kept even if they are young
+	a.set([]*vnode.Node{{ID: young.Id}})
+	if n := a.reserved(ps); n != 0 {
+		t.Errorf("should reserve no slots: %d", n)
+	}
+	if n := a.reserved(peers{old, older}); n != 1 {
+		t.Errorf("should reserve 1 slot: %d", n)
+	}
+	l = a.elect(ps, now)
+	if len(l) != 2 || l[0] != young || l[1] != older {
+		t.Errorf("wrong anchors: %v", l)
+	}
+}
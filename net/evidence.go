@@ -0,0 +1,457 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+const (
+	// slots older than evidenceWindow are forgotten, competing blocks of them can not be detected
+	evidenceWindow       = int64(time.Hour / time.Second)
+	evidencePruneSeconds = 60
+	maxEvidences         = 1000
+	maxEvidenceSize      = 4 << 20
+	// evidences of blocks lower than the latest snapshot block by evidenceRetainHeight are pruned, about 30 days
+	evidenceRetainHeight = uint64(3600 * 24 * 30)
+)
+
+var errEvidenceMalformed = errors.New("malformed evidence")
+var errEvidenceInvalid = errors.New("invalid evidence")
+var errEvidenceNotProducer = errors.New("evidence producer is not the SBP of the slot")
+
+// Evidence is two valid snapshot blocks signed by the same producer for the same slot
+type Evidence struct {
+	Producer  types.Address
+	Timestamp int64 // unix time of the slot
+	Blocks    [2]*ledger.SnapshotBlock
+}
+
+// EvidenceCallback will be invoked when a new evidence is found locally or received from peers
+type EvidenceCallback = func(e *Evidence)
+
+type slotKey struct {
+	producer  types.Address
+	timestamp int64
+}
+
+func (k slotKey) bytes() []byte {
+	buf := make([]byte, types.AddressSize+8)
+	copy(buf, k.producer[:])
+	binary.BigEndian.PutUint64(buf[types.AddressSize:], uint64(k.timestamp))
+	return buf
+}
+
+func newEvidence(a, b *ledger.SnapshotBlock) *Evidence {
+	// order blocks by hash, so the same pair has the same encoding
+	if b.Hash.Hex() < a.Hash.Hex() {
+		a, b = b, a
+	}
+
+	return &Evidence{
+		Producer:  a.Producer(),
+		Timestamp: a.Timestamp.Unix(),
+		Blocks:    [2]*ledger.SnapshotBlock{a, b},
+	}
+}
+
+func (e *Evidence) key() slotKey {
+	return slotKey{e.Producer, e.Timestamp}
+}
+
+// height is the higher one of the two blocks
+func (e *Evidence) height() uint64 {
+	if e.Blocks[0].Height > e.Blocks[1].Height {
+		return e.Blocks[0].Height
+	}
+	return e.Blocks[1].Height
+}
+
+func (e *Evidence) String() string {
+	return fmt.Sprintf("producer %s signed %s/%d and %s/%d at %d", e.Producer,
+		e.Blocks[0].Hash, e.Blocks[0].Height, e.Blocks[1].Hash, e.Blocks[1].Height, e.Timestamp)
+}
+
+// Serialize is length of the first block, and the two serialized blocks
+func (e *Evidence) Serialize() ([]byte, error) {
+	a, err := e.Blocks[0].Serialize()
+	if err != nil {
+		return nil, err
+	}
+	b, err := e.Blocks[1].Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 4+len(a)+len(b))
+	binary.BigEndian.PutUint32(buf, uint32(len(a)))
+	copy(buf[4:], a)
+	copy(buf[4+len(a):], b)
+
+	return buf, nil
+}
+
+func (e *Evidence) Deserialize(buf []byte) error {
+	if len(buf) < 4 || len(buf) > maxEvidenceSize {
+		return errEvidenceMalformed
+	}
+
+	n := int(binary.BigEndian.Uint32(buf))
+	if n > len(buf)-4 {
+		return errEvidenceMalformed
+	}
+
+	a, b := new(ledger.SnapshotBlock), new(ledger.SnapshotBlock)
+	if err := a.Deserialize(buf[4 : 4+n]); err != nil {
+		return err
+	}
+	if err := b.Deserialize(buf[4+n:]); err != nil {
+		return err
+	}
+
+	*e = *newEvidence(a, b)
+	return nil
+}
+
+// producerVerifier check the producer of snapshot block against the SBP schedule
+type producerVerifier interface {
+	VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error)
+}
+
+// verify both blocks are valid, and signed by the same producer for the same slot
+func (e *Evidence) verify(verifier Verifier, producers producerVerifier) error {
+	a, b := e.Blocks[0], e.Blocks[1]
+	if a == nil || b == nil || a.Hash == b.Hash {
+		return errEvidenceInvalid
+	}
+
+	for _, block := range e.Blocks {
+		if err := verifier.VerifyNetSnapshotBlock(block); err != nil {
+			return err
+		}
+	}
+
+	if a.Producer() != b.Producer() || a.Timestamp.Unix() != b.Timestamp.Unix() {
+		return errEvidenceInvalid
+	}
+
+	return e.verifyProducer(producers)
+}
+
+// verifyProducer check the producer was the SBP scheduled for the slot, blocks signed by other keys prove nothing
+func (e *Evidence) verifyProducer(producers producerVerifier) error {
+	ok, err := producers.VerifySnapshotProducer(e.Blocks[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errEvidenceNotProducer
+	}
+
+	return nil
+}
+
+type evidenceStore interface {
+	StoreEvidence(key, data []byte)
+	DeleteEvidence(key []byte)
+	ReadEvidences() [][]byte
+}
+
+type evidenceChain interface {
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+}
+
+// evidenceCollector detect producers signing two snapshot blocks for the same slot from broadcast and sync,
+// keep the evidences of recent snapshot blocks, and gossip them to peers.
+type evidenceCollector struct {
+	peers     *peerSet
+	verifier  Verifier
+	producers producerVerifier
+	store     evidenceStore
+	chain     evidenceChain
+
+	mu        sync.Mutex
+	slots     map[slotKey]*ledger.SnapshotBlock // the first block seen of every slot
+	evidences map[slotKey]*Evidence
+	lastPrune int64
+
+	subs  map[int]EvidenceCallback
+	subId int
+
+	log log15.Logger
+}
+
+func newEvidenceCollector(peers *peerSet, verifier Verifier, producers producerVerifier, store evidenceStore, chain evidenceChain) *evidenceCollector {
+	c := &evidenceCollector{
+		peers:     peers,
+		verifier:  verifier,
+		producers: producers,
+		store:     store,
+		chain:     chain,
+		slots:     make(map[slotKey]*ledger.SnapshotBlock),
+		evidences: make(map[slotKey]*Evidence),
+		subs:      make(map[int]EvidenceCallback),
+		log:       netLog.New("module", "evidence"),
+	}
+
+	if store != nil {
+		for _, data := range store.ReadEvidences() {
+			e := new(Evidence)
+			if err := e.Deserialize(data); err != nil {
+				continue
+			}
+			c.evidences[e.key()] = e
+		}
+	}
+
+	return c
+}
+
+func (c *evidenceCollector) name() string {
+	return "evidence"
+}
+
+func (c *evidenceCollector) codes() []Code {
+	return []Code{CodeEvidence}
+}
+
+func (c *evidenceCollector) handle(msg Msg) error {
+	e := new(Evidence)
+	err := e.Deserialize(msg.Payload)
+	msg.Recycle()
+	if err != nil {
+		return err
+	}
+
+	if c.has(e.key()) {
+		return nil
+	}
+
+	if err = e.verify(c.verifier, c.producers); err != nil {
+		c.log.Warn(fmt.Sprintf("receive invalid evidence from %s: %v", msg.Sender, err))
+		return err
+	}
+
+	if c.add(e) {
+		c.log.Warn(fmt.Sprintf("receive evidence from %s: %s", msg.Sender, e))
+		c.gossip(e, msg.Sender)
+	}
+
+	return nil
+}
+
+func (c *evidenceCollector) has(key slotKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.evidences[key]
+	return ok
+}
+
+// observe a valid snapshot block, return the evidence if another block of the same slot has been observed
+func (c *evidenceCollector) observe(block *ledger.SnapshotBlock) *Evidence {
+	if c == nil || block.Timestamp == nil || len(block.PublicKey) == 0 {
+		return nil
+	}
+
+	key := slotKey{block.Producer(), block.Timestamp.Unix()}
+	now := time.Now().Unix()
+
+	var pruned []slotKey
+	c.mu.Lock()
+	if now-c.lastPrune > evidencePruneSeconds {
+		c.lastPrune = now
+		for k := range c.slots {
+			if k.timestamp+evidenceWindow < now {
+				delete(c.slots, k)
+			}
+		}
+		pruned = c.prune(false)
+	}
+
+	if key.timestamp+evidenceWindow < now {
+		c.mu.Unlock()
+		c.deleteStored(pruned)
+		return nil
+	}
+
+	first, ok := c.slots[key]
+	if !ok {
+		c.slots[key] = block
+		c.mu.Unlock()
+		c.deleteStored(pruned)
+		return nil
+	}
+	c.mu.Unlock()
+	c.deleteStored(pruned)
+
+	if first.Hash == block.Hash {
+		return nil
+	}
+
+	e := newEvidence(first, block)
+	if err := e.verifyProducer(c.producers); err != nil {
+		c.log.Warn(fmt.Sprintf("competing blocks of slot %d from %s: %v", key.timestamp, key.producer, err))
+		return nil
+	}
+
+	if c.add(e) {
+		c.log.Warn(fmt.Sprintf("found evidence: %s", e))
+		c.gossip(e, nil)
+		return e
+	}
+
+	return nil
+}
+
+// retainHeight is the lowest height of evidences kept
+func (c *evidenceCollector) retainHeight() uint64 {
+	if c.chain == nil {
+		return 0
+	}
+	latest := c.chain.GetLatestSnapshotBlock()
+	if latest == nil || latest.Height <= evidenceRetainHeight {
+		return 0
+	}
+	return latest.Height - evidenceRetainHeight
+}
+
+// prune the evidences lower than retainHeight, and the lowest one if full, c.mu must be held.
+// The keys pruned should be deleted from store by deleteStored.
+func (c *evidenceCollector) prune(full bool) (pruned []slotKey) {
+	retain := c.retainHeight()
+	var lowest *Evidence
+	for k, e := range c.evidences {
+		if e.height() < retain {
+			delete(c.evidences, k)
+			pruned = append(pruned, k)
+		} else if lowest == nil || e.height() < lowest.height() {
+			lowest = e
+		}
+	}
+
+	if full && len(c.evidences) >= maxEvidences && lowest != nil {
+		delete(c.evidences, lowest.key())
+		pruned = append(pruned, lowest.key())
+	}
+	return
+}
+
+func (c *evidenceCollector) deleteStored(pruned []slotKey) {
+	if c.store == nil {
+		return
+	}
+	for _, k := range pruned {
+		c.store.DeleteEvidence(k.bytes())
+	}
+}
+
+// add return true if the evidence is new, the lowest evidence is pruned if full
+func (c *evidenceCollector) add(e *Evidence) bool {
+	c.mu.Lock()
+	if _, ok := c.evidences[e.key()]; ok || e.height() < c.retainHeight() {
+		c.mu.Unlock()
+		return false
+	}
+	var pruned []slotKey
+	if len(c.evidences) >= maxEvidences {
+		pruned = c.prune(true)
+	}
+	c.evidences[e.key()] = e
+	subs := make([]EvidenceCallback, 0, len(c.subs))
+	for _, fn := range c.subs {
+		subs = append(subs, fn)
+	}
+	c.mu.Unlock()
+
+	c.deleteStored(pruned)
+	if c.store != nil {
+		if data, err := e.Serialize(); err == nil {
+			c.store.StoreEvidence(e.key().bytes(), data)
+		}
+	}
+
+	for _, fn := range subs {
+		fn(e)
+	}
+
+	return true
+}
+
+// gossip the evidence to all peers except the sender
+func (c *evidenceCollector) gossip(e *Evidence, sender *Peer) {
+	if c.peers == nil {
+		return
+	}
+
+	data, err := e.Serialize()
+	if err != nil {
+		return
+	}
+
+	for _, p := range c.peers.peers() {
+		if p == sender {
+			continue
+		}
+		if err = p.WriteMsg(Msg{Code: CodeEvidence, Payload: data}); err != nil {
+			p.catch(err)
+		}
+	}
+}
+
+// list all evidences in order of slot
+func (c *evidenceCollector) list() []*Evidence {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := make([]*Evidence, 0, len(c.evidences))
+	for _, e := range c.evidences {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Timestamp < list[j].Timestamp
+	})
+
+	return list
+}
+
+func (c *evidenceCollector) SubscribeEvidence(fn EvidenceCallback) (subId int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.subId++
+	c.subs[c.subId] = fn
+	return c.subId
+}
+
+func (c *evidenceCollector) UnsubscribeEvidence(subId int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.subs, subId)
+}
@@ -0,0 +1,243 @@
+package net
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type signatureVerifier struct{}
+
+func (signatureVerifier) VerifyNetSnapshotBlock(block *ledger.SnapshotBlock) error {
+	if block.ComputeHash() != block.Hash {
+		return errors.New("wrong hash")
+	}
+	if !ed25519.Verify(block.PublicKey, block.Hash.Bytes(), block.Signature) {
+		return errors.New("wrong signature")
+	}
+	return nil
+}
+
+func (signatureVerifier) VerifyNetAccountBlock(block *ledger.AccountBlock) error {
+	return nil
+}
+
+// VerifySnapshotProducer every producer is scheduled
+func (signatureVerifier) VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error) {
+	return true, nil
+}
+
+// unscheduled is the SBP schedule without any producer
+type unscheduled struct{}
+
+func (unscheduled) VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error) {
+	return false, nil
+}
+
+type memEvidenceStore map[string][]byte
+
+func (s memEvidenceStore) StoreEvidence(key, data []byte) {
+	s[string(key)] = data
+}
+
+func (s memEvidenceStore) DeleteEvidence(key []byte) {
+	delete(s, string(key))
+}
+
+func (s memEvidenceStore) ReadEvidences() (list [][]byte) {
+	for _, data := range s {
+		list = append(list, data)
+	}
+	return
+}
+
+func setForkPoints() {
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:          &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:           &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:        &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:          &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:          &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:         &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork:     &config.ForkPoint{Height: 600, Version: 7},
+		ExpiryFork:        &config.ForkPoint{Height: 700, Version: 8},
+		MultisigFork:      &config.ForkPoint{Height: 800, Version: 9},
+		DelegateGroupFork: &config.ForkPoint{Height: 900, Version: 10}})
+}
+
+func signedSnapshotBlock(key ed25519.PrivateKey, height uint64, timestamp time.Time) *ledger.SnapshotBlock {
+	block := &ledger.SnapshotBlock{
+		Height:    height,
+		Timestamp: &timestamp,
+		PublicKey: key.PubByte(),
+	}
+	block.Hash = block.ComputeHash()
+	block.Signature = ed25519.Sign(key, block.Hash.Bytes())
+	return block
+}
+
+func TestEvidenceCollector_observe(t *testing.T) {
+	setForkPoints()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	store := make(memEvidenceStore)
+	c := newEvidenceCollector(nil, signatureVerifier{}, signatureVerifier{}, store, nil)
+
+	var notified int
+	c.SubscribeEvidence(func(e *Evidence) {
+		notified++
+	})
+
+	now := time.Now()
+	a := signedSnapshotBlock(key, 10, now)
+	if c.observe(a) != nil || c.observe(a) != nil {
+		t.Fatal("the same block is not evidence")
+	}
+	if c.observe(signedSnapshotBlock(key, 11, now.Add(time.Second))) != nil {
+		t.Fatal("blocks of different slots are not evidence")
+	}
+
+	e := c.observe(signedSnapshotBlock(key, 11, now))
+	if e == nil {
+		t.Fatal("should find evidence")
+	}
+	if err := e.verify(signatureVerifier{}, signatureVerifier{}); err != nil {
+		t.Errorf("evidence should be valid: %v", err)
+	}
+	if c.observe(signedSnapshotBlock(key, 12, now)) != nil {
+		t.Error("evidence of the same slot should be found only once")
+	}
+	if notified != 1 || len(c.list()) != 1 {
+		t.Errorf("notified %d times, %d evidences", notified, len(c.list()))
+	}
+
+	// evidences are loaded from store
+	c = newEvidenceCollector(nil, signatureVerifier{}, signatureVerifier{}, store, nil)
+	if list := c.list(); len(list) != 1 || list[0].Blocks[0].Hash != e.Blocks[0].Hash {
+		t.Errorf("failed to load evidences")
+	}
+
+	// competing blocks of a producer not scheduled for the slot are not evidence
+	c = newEvidenceCollector(nil, signatureVerifier{}, unscheduled{}, nil, nil)
+	c.observe(signedSnapshotBlock(key, 10, now))
+	if c.observe(signedSnapshotBlock(key, 11, now)) != nil || len(c.list()) != 0 {
+		t.Error("evidence of unscheduled producer should be ignored")
+	}
+}
+
+type latestChain struct {
+	height uint64
+}
+
+func (c *latestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return &ledger.SnapshotBlock{Height: c.height}
+}
+
+func TestEvidenceCollector_prune(t *testing.T) {
+	setForkPoints()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	store := make(memEvidenceStore)
+	chain := &latestChain{height: evidenceRetainHeight + 100}
+	c := newEvidenceCollector(nil, signatureVerifier{}, signatureVerifier{}, store, chain)
+
+	now := time.Now()
+	evidence := func(height uint64, slot int) *Evidence {
+		timestamp := now.Add(time.Duration(slot) * time.Second)
+		return newEvidence(signedSnapshotBlock(key, height, timestamp), signedSnapshotBlock(key, height-1, timestamp))
+	}
+
+	if c.add(evidence(99, 0)) {
+		t.Error("evidence lower than retain height should be ignored")
+	}
+
+	for i := 0; i < maxEvidences; i++ {
+		if !c.add(evidence(uint64(100+i), i)) {
+			t.Fatalf("failed to add evidence %d", i)
+		}
+	}
+
+	// the lowest evidence is pruned for the new one when full
+	if !c.add(evidence(2000, maxEvidences)) {
+		t.Fatal("new evidence should be added when full")
+	}
+	list := c.list()
+	if len(list) != maxEvidences || len(store) != maxEvidences || list[0].height() != 101 || list[len(list)-1].height() != 2000 {
+		t.Fatalf("%d evidences, %d stored", len(list), len(store))
+	}
+
+	// evidences lower than retain height are pruned from store too
+	chain.height += 500
+	c.mu.Lock()
+	pruned := c.prune(false)
+	c.mu.Unlock()
+	c.deleteStored(pruned)
+	if list = c.list(); len(list) != maxEvidences-500+1 || len(store) != len(list) || list[0].height() != 600 {
+		t.Errorf("%d evidences, %d stored after prune", len(list), len(store))
+	}
+}
+
+func TestEvidence_Serialize(t *testing.T) {
+	setForkPoints()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	now := time.Now()
+	e := newEvidence(signedSnapshotBlock(key, 11, now), signedSnapshotBlock(key, 10, now))
+
+	data, err := e.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e2 := new(Evidence)
+	if err = e2.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if e2.Producer != e.Producer || e2.Timestamp != e.Timestamp {
+		t.Errorf("different evidence: %s %s", e, e2)
+	}
+	for i := range e.Blocks {
+		if e2.Blocks[i].Hash != e.Blocks[i].Hash {
+			t.Errorf("different block %d", i)
+		}
+	}
+
+	if err = e2.Deserialize(data[:3]); err == nil {
+		t.Error("should fail to deserialize short data")
+	}
+}
+
+func TestEvidence_verify(t *testing.T) {
+	setForkPoints()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	now := time.Now()
+
+	a := signedSnapshotBlock(key, 10, now)
+	cases := map[string]*Evidence{
+		"same block":     newEvidence(a, a),
+		"other producer": newEvidence(a, signedSnapshotBlock(other, 11, now)),
+		"other slot":     newEvidence(a, signedSnapshotBlock(key, 11, now.Add(time.Second))),
+	}
+
+	forged := signedSnapshotBlock(key, 11, now)
+	forged.Signature = ed25519.Sign(other, forged.Hash.Bytes())
+	cases["wrong signature"] = newEvidence(a, forged)
+
+	for name, e := range cases {
+		if err := e.verify(signatureVerifier{}, signatureVerifier{}); err == nil {
+			t.Errorf("%s should be invalid", name)
+		}
+	}
+
+	e := newEvidence(a, signedSnapshotBlock(key, 11, now))
+	if err := e.verify(signatureVerifier{}, signatureVerifier{}); err != nil {
+		t.Errorf("evidence should be valid: %v", err)
+	}
+	if err := e.verify(signatureVerifier{}, unscheduled{}); err != errEvidenceNotProducer {
+		t.Errorf("producer is not scheduled: %v", err)
+	}
+}
@@ -18,7 +18,7 @@ func TestFetch_failed(t *testing.T) {
 		Id:         vnode.RandomNodeID(),
 		reliable:   1,
 		writable:   1,
-		writeQueue: make(chan Msg, 10),
+		writeQueue: newWriteQueue(10),
 	}
 	_ = set.add(peer)
 
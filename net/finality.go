@@ -0,0 +1,162 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/bloom"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+// FinalityVoteCallback will be invoked when receive a finality vote with valid signature,
+// whether the voter is a producer should be checked by subscribers, return error to stop relaying the vote
+type FinalityVoteCallback = func(vote *ledger.FinalityVote) error
+
+var errNoFinalitySubscriber = errors.New("no subscriber to check finality votes")
+
+// finalityVotes relay finality votes between peers, every vote is forwarded once
+type finalityVotes struct {
+	peers  *peerSet
+	filter *bloom.Filter
+
+	mu    sync.Mutex
+	subs  map[int]FinalityVoteCallback
+	subId int
+
+	log log15.Logger
+}
+
+func newFinalityVotes(peers *peerSet) *finalityVotes {
+	return &finalityVotes{
+		peers:  peers,
+		filter: bloom.New(filterCap, rt),
+		subs:   make(map[int]FinalityVoteCallback),
+		log:    netLog.New("module", "finality"),
+	}
+}
+
+func (f *finalityVotes) name() string {
+	return "finality"
+}
+
+func (f *finalityVotes) codes() []Code {
+	return []Code{CodeFinalityVote}
+}
+
+func (f *finalityVotes) handle(msg Msg) error {
+	vote := new(ledger.FinalityVote)
+	err := vote.Deserialize(msg.Payload)
+	msg.Recycle()
+	if err != nil {
+		return err
+	}
+
+	if f.seen(vote) {
+		return nil
+	}
+
+	if !vote.VerifySignature() {
+		return fmt.Errorf("invalid signature of finality vote from %s", msg.Sender)
+	}
+
+	// the voter may be a producer of another period, so the sender is not punished
+	if err = f.notify(vote); err != nil {
+		f.log.Debug(fmt.Sprintf("drop finality vote %d/%s from %s: %v", vote.Height, vote.Hash, msg.Sender, err))
+		return nil
+	}
+
+	f.forward(vote, msg.Sender)
+
+	return nil
+}
+
+// seen return true if the vote has been received or broadcast
+func (f *finalityVotes) seen(vote *ledger.FinalityVote) bool {
+	key := vote.DataHash()
+	voter := vote.Voter()
+	return f.filter.TestAndAdd(append(key[:], voter[:]...))
+}
+
+// notify return error if the vote is rejected by any subscriber, or there is no subscriber to check it
+func (f *finalityVotes) notify(vote *ledger.FinalityVote) (err error) {
+	f.mu.Lock()
+	subs := make([]FinalityVoteCallback, 0, len(f.subs))
+	for _, fn := range f.subs {
+		subs = append(subs, fn)
+	}
+	f.mu.Unlock()
+
+	if len(subs) == 0 {
+		return errNoFinalitySubscriber
+	}
+
+	for _, fn := range subs {
+		if e := fn(vote); e != nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// forward the vote to all peers except the sender
+func (f *finalityVotes) forward(vote *ledger.FinalityVote, sender *Peer) {
+	data, err := vote.Serialize()
+	if err != nil {
+		return
+	}
+
+	for _, p := range f.peers.peers() {
+		if p == sender {
+			continue
+		}
+		if err = p.WriteMsg(Msg{Code: CodeFinalityVote, Payload: data}); err != nil {
+			p.catch(err)
+		}
+	}
+}
+
+// BroadcastFinalityVote send the vote signed by self to all peers
+func (f *finalityVotes) BroadcastFinalityVote(vote *ledger.FinalityVote) {
+	if f.seen(vote) {
+		return
+	}
+
+	f.forward(vote, nil)
+}
+
+func (f *finalityVotes) SubscribeFinalityVote(fn FinalityVoteCallback) (subId int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.subId++
+	f.subs[f.subId] = fn
+	return f.subId
+}
+
+func (f *finalityVotes) UnsubscribeFinalityVote(subId int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.subs, subId)
+}
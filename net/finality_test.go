@@ -0,0 +1,66 @@
+package net
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+func TestFinalityVotes_handle(t *testing.T) {
+	ps := newPeerSet()
+	sender := &Peer{Id: vnode.RandomNodeID(), writable: 1, writeQueue: newWriteQueue(10)}
+	other := &Peer{Id: vnode.RandomNodeID(), writable: 1, writeQueue: newWriteQueue(10)}
+	for _, p := range []*Peer{sender, other} {
+		if err := ps.add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	newVote := func(height uint64) Msg {
+		vote := &ledger.FinalityVote{Height: height}
+		vote.Signature, vote.PublicKey = ed25519.Sign(key, vote.DataHash().Bytes()), key.PubByte()
+		data, err := vote.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Msg{Code: CodeFinalityVote, Payload: data, Sender: sender}
+	}
+
+	f := newFinalityVotes(ps)
+
+	// nobody can check the voter
+	if err := f.handle(newVote(1)); err != nil {
+		t.Fatal(err)
+	}
+	if other.writeQueue.len() != 0 {
+		t.Fatal("vote should not be forwarded without subscriber")
+	}
+
+	var member bool
+	f.SubscribeFinalityVote(func(vote *ledger.FinalityVote) error {
+		if !member {
+			return errors.New("not producer")
+		}
+		return nil
+	})
+
+	if err := f.handle(newVote(2)); err != nil {
+		t.Fatal(err)
+	}
+	if other.writeQueue.len() != 0 {
+		t.Fatal("vote of non-producer should not be forwarded")
+	}
+
+	member = true
+	if err := f.handle(newVote(3)); err != nil {
+		t.Fatal(err)
+	}
+	if other.writeQueue.len() != 1 || sender.writeQueue.len() != 0 {
+		t.Errorf("vote should be forwarded to other peers only: %d %d", other.writeQueue.len(), sender.writeQueue.len())
+	}
+}
@@ -20,6 +20,7 @@ package net
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/vitelabs/go-vite/net/database"
@@ -49,9 +50,12 @@ type finder struct {
 	subId       int // table sub
 	minPeers    int
 	staticNodes []*vnode.Node
+	anchors     *anchors
 	resolver    interface {
 		GetNodes(n int) []*vnode.Node
 	}
+	lookup  func(role vnode.Role, count int) []*vnode.Node
+	looking int32
 
 	peers     *peerSet
 	connect   Connector
@@ -93,9 +97,10 @@ func (f *finder) UnSub(sub discovery.Subscriber) {
 	sub.UnSub(f.subId)
 }
 
-func newFinder(self types.Address, peers *peerSet, minPeers int, staticNodes []string, db *database.DB, connect Connector, consensus Consensus) (f *finder, err error) {
+func newFinder(self types.Address, peers *peerSet, minPeers int, staticNodes []string, anchors *anchors, db *database.DB, connect Connector, consensus Consensus) (f *finder, err error) {
 	f = &finder{
 		self:      self,
+		anchors:   anchors,
 		targets:   make(map[types.Address]*vnode.Node),
 		peers:     peers,
 		minPeers:  minPeers,
@@ -269,6 +274,10 @@ func (f *finder) loop() {
 	defer checkTicker.Stop()
 
 	f.rw.Lock()
+	// anchors first, they have reserved slots
+	for _, node := range f.anchors.nodes() {
+		f.dial(node)
+	}
 	nodes := f.db.ReadMarkNodes(30) // more than sbp count
 	for _, node := range nodes {
 		f.dial(node)
@@ -282,6 +291,9 @@ func (f *finder) loop() {
 			for _, n := range f.staticNodes {
 				f.dial(n)
 			}
+			for _, n := range f.anchors.nodes() {
+				f.dial(n)
+			}
 
 			if f._selfIsSBP {
 				for _, t := range f.targets {
@@ -299,6 +311,10 @@ func (f *finder) loop() {
 						f.dial(node)
 					}
 					f.rw.Unlock()
+
+					if f.lookup != nil && atomic.CompareAndSwapInt32(&f.looking, 0, 1) {
+						go f.lookupFileServers(f.minPeers - total)
+					}
 				}
 			}
 		case <-f.term:
@@ -307,6 +323,19 @@ func (f *finder) loop() {
 	}
 }
 
+// lookupFileServers find nodes can serve ledger files, so we can sync from them directly
+func (f *finder) lookupFileServers(count int) {
+	defer atomic.StoreInt32(&f.looking, 0)
+
+	nodes := f.lookup(vnode.RoleFileServer, count)
+
+	f.rw.Lock()
+	for _, node := range nodes {
+		f.dial(node)
+	}
+	f.rw.Unlock()
+}
+
 func setNodeExt(mineKey ed25519.PrivateKey, node *vnode.Node) {
 	// minePUB + minePriv.Sign(node.ID)
 	node.Ext = make([]byte, extLen)
@@ -315,14 +344,9 @@ func setNodeExt(mineKey ed25519.PrivateKey, node *vnode.Node) {
 	copy(node.Ext[32:], sign)
 }
 
+// parseNodeExt only verify the producer proof at the head of Node.Ext, the signed record may follow it
 func parseNodeExt(node *vnode.Node) (addr types.Address, ok bool) {
-	if len(node.Ext) < extLen {
-		ok = false
-		return
-	}
-
-	pub := node.Ext[:32]
-	ok = ed25519.Verify(pub, node.ID.Bytes(), node.Ext[32:])
+	pub, ok := vnode.ProducerProof(node)
 	if ok {
 		addr = types.PubkeyToAddress(pub)
 	}
@@ -0,0 +1,55 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+func TestParseNodeExt_Record(t *testing.T) {
+	pub, peerKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := vnode.Bytes2NodeID(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, mineKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := &vnode.Node{ID: id}
+	setNodeExt(mineKey, node)
+	if err = vnode.SetRecord(node, peerKey, &vnode.Record{Roles: vnode.RoleFull | vnode.RoleSBP}); err != nil {
+		t.Fatal(err)
+	}
+	if len(node.Ext) <= extLen {
+		t.Fatalf("record is not appended: %d", len(node.Ext))
+	}
+
+	addr, ok := parseNodeExt(node)
+	if !ok {
+		t.Fatal("producer proof should be valid with the record following it")
+	}
+	if addr != types.PubkeyToAddress(mineKey.PubByte()) {
+		t.Errorf("wrong producer address %s", addr)
+	}
+
+	r, err := vnode.ParseRecord(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Roles.Is(vnode.RoleSBP) {
+		t.Errorf("wrong roles: %s", r.Roles)
+	}
+
+	// the old format without record
+	setNodeExt(mineKey, node)
+	if _, ok = parseNodeExt(node); !ok {
+		t.Error("producer proof should be valid without record")
+	}
+}
@@ -0,0 +1,532 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+const (
+	seenCacheCap        = 100000
+	seenCacheTTL        = int64(10 * time.Minute)
+	bodyCacheCap        = 10000
+	bodyCacheTTL        = int64(time.Minute)
+	requestedTTL        = int64(5 * time.Second)
+	accountRateTTL      = int64(time.Minute)
+	limiterIdleTimeout  = int64(time.Minute)
+	gossipCleanInterval = int64(30 * time.Second)
+
+	maxAnnounceHashes = 1000
+	announceItemLen   = types.HashSize + types.AddressSize
+
+	// a snapshot block is produced every second, so the stake quota per snapshot block
+	// divided by the quota of a simple transfer is how many blocks an account can afford per second.
+	quotaPerAccountBlock = 21000
+)
+
+var errAnnounceTooLong = errors.New("too many hashes in announcement")
+var errAnnounceMalformed = errors.New("malformed announcement")
+
+// AnnouncedBlock is one item of AccountBlockHashes, the address is used for rate limit before the body is fetched
+type AnnouncedBlock struct {
+	Hash    types.Hash
+	Address types.Address
+}
+
+// AccountBlockHashes announce new account blocks by hash, the receiver request bodies it has not seen
+type AccountBlockHashes struct {
+	TTL    int32
+	Blocks []AnnouncedBlock
+}
+
+func (a *AccountBlockHashes) Serialize() ([]byte, error) {
+	if len(a.Blocks) > maxAnnounceHashes {
+		return nil, errAnnounceTooLong
+	}
+
+	buf := make([]byte, 4+len(a.Blocks)*announceItemLen)
+	binary.BigEndian.PutUint32(buf, uint32(a.TTL))
+
+	n := 4
+	for _, ab := range a.Blocks {
+		n += copy(buf[n:], ab.Hash[:])
+		n += copy(buf[n:], ab.Address[:])
+	}
+
+	return buf, nil
+}
+
+func (a *AccountBlockHashes) Deserialize(buf []byte) (err error) {
+	if len(buf) < 4 || (len(buf)-4)%announceItemLen != 0 {
+		return errAnnounceMalformed
+	}
+
+	count := (len(buf) - 4) / announceItemLen
+	if count > maxAnnounceHashes {
+		return errAnnounceTooLong
+	}
+
+	a.TTL = int32(binary.BigEndian.Uint32(buf))
+	a.Blocks = make([]AnnouncedBlock, count)
+
+	n := 4
+	for i := range a.Blocks {
+		n += copy(a.Blocks[i].Hash[:], buf[n:n+types.HashSize])
+		n += copy(a.Blocks[i].Address[:], buf[n:n+types.AddressSize])
+	}
+
+	return nil
+}
+
+// GetAccountBlockBodies request bodies of the announced account blocks,
+// the responder send every body back as a NewAccountBlock message with the TTL
+type GetAccountBlockBodies struct {
+	TTL    int32
+	Hashes []types.Hash
+}
+
+func (g *GetAccountBlockBodies) Serialize() ([]byte, error) {
+	if len(g.Hashes) > maxAnnounceHashes {
+		return nil, errAnnounceTooLong
+	}
+
+	buf := make([]byte, 4+len(g.Hashes)*types.HashSize)
+	binary.BigEndian.PutUint32(buf, uint32(g.TTL))
+
+	n := 4
+	for _, hash := range g.Hashes {
+		n += copy(buf[n:], hash[:])
+	}
+
+	return buf, nil
+}
+
+func (g *GetAccountBlockBodies) Deserialize(buf []byte) error {
+	if len(buf) < 4 || (len(buf)-4)%types.HashSize != 0 {
+		return errAnnounceMalformed
+	}
+
+	count := (len(buf) - 4) / types.HashSize
+	if count > maxAnnounceHashes {
+		return errAnnounceTooLong
+	}
+
+	g.TTL = int32(binary.BigEndian.Uint32(buf))
+	g.Hashes = make([]types.Hash, count)
+
+	n := 4
+	for i := range g.Hashes {
+		n += copy(g.Hashes[i][:], buf[n:n+types.HashSize])
+	}
+
+	return nil
+}
+
+// seenCache record the hashes of account blocks received recently, the least recently used is evicted when full
+type seenCache struct {
+	mu  sync.Mutex
+	lru *simplelru.LRU // hash -> expire
+	ttl int64
+}
+
+func newSeenCache(cap int, ttl int64) *seenCache {
+	// the size is always positive, so no error
+	l, _ := simplelru.NewLRU(cap, nil)
+	return &seenCache{
+		lru: l,
+		ttl: ttl,
+	}
+}
+
+func (s *seenCache) has(hash types.Hash) bool {
+	now := time.Now().UnixNano()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expire, ok := s.lru.Peek(hash)
+	return ok && expire.(int64) > now
+}
+
+// add return true if the hash has been seen
+func (s *seenCache) add(hash types.Hash) (exist bool) {
+	now := time.Now().UnixNano()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expire, ok := s.lru.Get(hash); ok && expire.(int64) > now {
+		return true
+	}
+
+	s.lru.Add(hash, now+s.ttl)
+	return false
+}
+
+func (s *seenCache) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Len()
+}
+
+type bodyItem struct {
+	expire int64
+	block  *ledger.AccountBlock
+}
+
+// bodyCache keep the bodies of account blocks announced recently,
+// so that we can answer GetAccountBlockBodies for blocks not yet in chain.
+type bodyCache struct {
+	mu  sync.Mutex
+	lru *simplelru.LRU // hash -> *bodyItem
+	ttl int64
+}
+
+func newBodyCache(cap int, ttl int64) *bodyCache {
+	l, _ := simplelru.NewLRU(cap, nil)
+	return &bodyCache{
+		lru: l,
+		ttl: ttl,
+	}
+}
+
+func (c *bodyCache) add(block *ledger.AccountBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Add(block.Hash, &bodyItem{
+		expire: time.Now().UnixNano() + c.ttl,
+		block:  block,
+	})
+}
+
+func (c *bodyCache) get(hash types.Hash) *ledger.AccountBlock {
+	now := time.Now().UnixNano()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.lru.Peek(hash); ok && v.(*bodyItem).expire > now {
+		return v.(*bodyItem).block
+	}
+
+	return nil
+}
+
+type tokenBucket struct {
+	tokens float64
+	rate   float64
+	burst  float64
+	last   int64
+}
+
+func (t *tokenBucket) take(now int64) bool {
+	if elapse := now - t.last; elapse > 0 {
+		t.tokens += float64(elapse) / float64(time.Second) * t.rate
+		if t.tokens > t.burst {
+			t.tokens = t.burst
+		}
+	}
+	t.last = now
+
+	if t.tokens < 1 {
+		return false
+	}
+
+	t.tokens--
+	return true
+}
+
+// rateLimiter is a set of token buckets, every key has its own bucket
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[interface{}]*tokenBucket
+	lastClean int64
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets: make(map[interface{}]*tokenBucket),
+	}
+}
+
+// allow take one token from the bucket of key, rate and burst will be updated if changed
+func (r *rateLimiter) allow(key interface{}, rate, burst float64, now int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now-r.lastClean > gossipCleanInterval {
+		for k, b := range r.buckets {
+			if now-b.last > limiterIdleTimeout {
+				delete(r.buckets, k)
+			}
+		}
+		r.lastClean = now
+	}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &tokenBucket{
+			tokens: burst,
+			last:   now,
+		}
+		r.buckets[key] = b
+	}
+	b.rate, b.burst = rate, burst
+
+	return b.take(now)
+}
+
+type quotaReader interface {
+	GetStakeQuota(addr types.Address) (*big.Int, *types.Quota, error)
+}
+
+type accountRate struct {
+	rate   float64
+	expire int64
+}
+
+// GossipStatus is for api
+type GossipStatus struct {
+	Announce        bool   `json:"announce"`
+	Seen            int    `json:"seen"`
+	Announced       uint64 `json:"announced"`
+	Requested       uint64 `json:"requested"`
+	PeerLimited     uint64 `json:"peerLimited"`
+	AccountLimited  uint64 `json:"accountLimited"`
+	DuplicateBlocks uint64 `json:"duplicateBlocks"`
+}
+
+// accountGossip decide whether an account block from a peer should be accepted, requested or dropped.
+// Blocks produced by SBPs or received from superior peers skip all limits.
+type accountGossip struct {
+	announce bool
+
+	peerRate, peerBurst       float64
+	accountRate, accountBurst float64
+
+	seen      *seenCache
+	bodies    *bodyCache // nil if not announce
+	peers     *rateLimiter
+	accounts  *rateLimiter
+	requested *seenCache
+
+	quota  quotaReader
+	rw     sync.RWMutex
+	rates  map[types.Address]accountRate
+	_isSBP func(addr types.Address) bool
+
+	announced       uint64
+	requestedCount  uint64
+	peerLimited     uint64
+	accountLimited  uint64
+	duplicateBlocks uint64
+}
+
+func newAccountGossip(cfg *config.Net, quota quotaReader) *accountGossip {
+	g := &accountGossip{
+		announce:    cfg.AnnounceAccountBlock,
+		peerRate:    float64(cfg.AccountBlockPeerRate),
+		accountRate: float64(cfg.AccountBlockAccountRate),
+		seen:        newSeenCache(seenCacheCap, seenCacheTTL),
+		peers:       newRateLimiter(),
+		accounts:    newRateLimiter(),
+		requested:   newSeenCache(seenCacheCap, requestedTTL),
+		quota:       quota,
+		rates:       make(map[types.Address]accountRate),
+	}
+
+	// bodies are requested only by peers received our announcements
+	if g.announce {
+		g.bodies = newBodyCache(bodyCacheCap, bodyCacheTTL)
+	}
+
+	if g.peerRate <= 0 {
+		g.peerRate = config.DefaultAccountBlockPeerRate
+	}
+	if g.accountRate <= 0 {
+		g.accountRate = config.DefaultAccountBlockAccountRate
+	}
+	g.peerBurst = g.peerRate * 4
+	g.accountBurst = g.accountRate * 5
+
+	return g
+}
+
+func (g *accountGossip) setSBPChecker(fn func(addr types.Address) bool) {
+	g._isSBP = fn
+}
+
+func (g *accountGossip) isSBP(addr types.Address) bool {
+	if g._isSBP == nil {
+		return false
+	}
+	return g._isSBP(addr)
+}
+
+// privileged blocks will not be limited
+func (g *accountGossip) privileged(sender *Peer, addr types.Address) bool {
+	if sender != nil && sender.Superior {
+		return true
+	}
+	return g.isSBP(addr)
+}
+
+// rateOf return how many blocks per second the account can send, depends on stake quota
+func (g *accountGossip) rateOf(addr types.Address, now int64) float64 {
+	if g.quota == nil {
+		return g.accountRate
+	}
+
+	g.rw.RLock()
+	r, ok := g.rates[addr]
+	g.rw.RUnlock()
+	if ok && r.expire > now {
+		return r.rate
+	}
+
+	rate := g.accountRate
+	if _, q, err := g.quota.GetStakeQuota(addr); err == nil && q != nil && !q.Blocked() {
+		rate += float64(q.StakeQuotaPerSnapshotBlock()) / quotaPerAccountBlock
+	}
+
+	g.rw.Lock()
+	if len(g.rates) >= seenCacheCap {
+		for k, v := range g.rates {
+			if v.expire <= now {
+				delete(g.rates, k)
+			}
+		}
+	}
+	g.rates[addr] = accountRate{rate, now + accountRateTTL}
+	g.rw.Unlock()
+
+	return rate
+}
+
+// allow check the peer limit and the account limit
+func (g *accountGossip) allow(sender *Peer, addr types.Address) bool {
+	if g.privileged(sender, addr) {
+		return true
+	}
+
+	now := time.Now().UnixNano()
+
+	if sender != nil && !g.peers.allow(sender.Id, g.peerRate, g.peerBurst, now) {
+		atomic.AddUint64(&g.peerLimited, 1)
+		return false
+	}
+
+	rate := g.rateOf(addr, now)
+	burst := rate * 5
+	if burst < g.accountBurst {
+		burst = g.accountBurst
+	}
+	if !g.accounts.allow(addr, rate, burst, now) {
+		atomic.AddUint64(&g.accountLimited, 1)
+		return false
+	}
+
+	return true
+}
+
+// receive is invoked when got a block body, return false if the block should be dropped.
+// The block is seen only if accepted, so it is not dropped as a duplicate when relayed by other peers after limited.
+func (g *accountGossip) receive(block *ledger.AccountBlock, hash types.Hash, sender *Peer) bool {
+	if g.seen.has(hash) {
+		atomic.AddUint64(&g.duplicateBlocks, 1)
+		return false
+	}
+
+	// body requested by us has been limited when announced
+	if !g.requested.has(hash) && !g.allow(sender, block.AccountAddress) {
+		return false
+	}
+
+	if g.seen.add(hash) {
+		atomic.AddUint64(&g.duplicateBlocks, 1)
+		return false
+	}
+	return true
+}
+
+// setBody keep the verified block for peers requesting it by hash
+func (g *accountGossip) setBody(block *ledger.AccountBlock) {
+	if g.bodies != nil {
+		g.bodies.add(block)
+	}
+}
+
+// body return nil if the block is unknown or expired
+func (g *accountGossip) body(hash types.Hash) *ledger.AccountBlock {
+	if g.bodies == nil {
+		return nil
+	}
+	return g.bodies.get(hash)
+}
+
+// wanted filter the announced blocks, return hashes should be requested, SBP blocks are in front
+func (g *accountGossip) wanted(msg *AccountBlockHashes, sender *Peer) (hashes []types.Hash) {
+	atomic.AddUint64(&g.announced, uint64(len(msg.Blocks)))
+
+	var common []types.Hash
+	for _, ab := range msg.Blocks {
+		if g.seen.has(ab.Hash) || g.requested.has(ab.Hash) {
+			continue
+		}
+
+		if g.privileged(sender, ab.Address) {
+			hashes = append(hashes, ab.Hash)
+		} else if g.allow(sender, ab.Address) {
+			common = append(common, ab.Hash)
+		} else {
+			continue
+		}
+
+		g.requested.add(ab.Hash)
+	}
+
+	hashes = append(hashes, common...)
+	atomic.AddUint64(&g.requestedCount, uint64(len(hashes)))
+
+	return
+}
+
+func (g *accountGossip) status() GossipStatus {
+	return GossipStatus{
+		Announce:        g.announce,
+		Seen:            g.seen.size(),
+		Announced:       atomic.LoadUint64(&g.announced),
+		Requested:       atomic.LoadUint64(&g.requestedCount),
+		PeerLimited:     atomic.LoadUint64(&g.peerLimited),
+		AccountLimited:  atomic.LoadUint64(&g.accountLimited),
+		DuplicateBlocks: atomic.LoadUint64(&g.duplicateBlocks),
+	}
+}
@@ -0,0 +1,267 @@
+package net
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func randHash() (h types.Hash) {
+	_, _ = rand.Read(h[:])
+	return
+}
+
+func randAddress() (addr types.Address) {
+	_, _ = rand.Read(addr[:types.AddressCoreSize])
+	return
+}
+
+func TestAccountBlockHashes_Serialize(t *testing.T) {
+	var ann = &AccountBlockHashes{
+		TTL: 10,
+	}
+	for i := 0; i < 10; i++ {
+		ann.Blocks = append(ann.Blocks, AnnouncedBlock{randHash(), randAddress()})
+	}
+
+	data, err := ann.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ann2 = &AccountBlockHashes{}
+	if err = ann2.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if ann2.TTL != ann.TTL || len(ann2.Blocks) != len(ann.Blocks) {
+		t.Fatalf("different announcement: %d/%d %d/%d", ann.TTL, ann2.TTL, len(ann.Blocks), len(ann2.Blocks))
+	}
+	for i, ab := range ann.Blocks {
+		if ann2.Blocks[i] != ab {
+			t.Errorf("different block %d", i)
+		}
+	}
+
+	if err = ann2.Deserialize(data[:len(data)-1]); err != errAnnounceMalformed {
+		t.Errorf("should be malformed: %v", err)
+	}
+}
+
+func TestGetAccountBlockBodies_Serialize(t *testing.T) {
+	var req = &GetAccountBlockBodies{
+		TTL:    3,
+		Hashes: []types.Hash{randHash(), randHash()},
+	}
+
+	data, err := req.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req2 = &GetAccountBlockBodies{}
+	if err = req2.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if req2.TTL != req.TTL || len(req2.Hashes) != 2 || req2.Hashes[0] != req.Hashes[0] || req2.Hashes[1] != req.Hashes[1] {
+		t.Errorf("different request")
+	}
+}
+
+func TestSeenCache(t *testing.T) {
+	s := newSeenCache(2, int64(50*time.Millisecond))
+
+	h1, h2, h3 := randHash(), randHash(), randHash()
+	if s.add(h1) {
+		t.Error("h1 should not exist")
+	}
+	if !s.add(h1) {
+		t.Error("h1 should exist")
+	}
+
+	s.add(h2)
+	// full, h1 is used more recently than h2
+	s.add(h1)
+	s.add(h3)
+	if !s.has(h3) || !s.has(h1) {
+		t.Error("h1 and h3 should be recorded")
+	}
+	if s.has(h2) {
+		t.Error("h2 should be evicted")
+	}
+	if s.size() != 2 {
+		t.Errorf("wrong size: %d", s.size())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if s.has(h1) {
+		t.Error("h1 should be expired")
+	}
+	if s.add(h1) {
+		t.Error("h1 should not exist")
+	}
+}
+
+func TestAccountGossip_body(t *testing.T) {
+	block := &ledger.AccountBlock{Hash: randHash()}
+
+	g := newAccountGossip(&config.Net{}, nil)
+	g.setBody(block)
+	if g.body(block.Hash) != nil {
+		t.Error("bodies should not be kept if not announce")
+	}
+
+	g = newAccountGossip(&config.Net{AnnounceAccountBlock: true}, nil)
+	g.setBody(block)
+	if b := g.body(block.Hash); b == nil || b.Hash != block.Hash {
+		t.Error("missing body")
+	}
+	if g.body(randHash()) != nil {
+		t.Error("unknown body")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	r := newRateLimiter()
+	now := time.Now().UnixNano()
+
+	for i := 0; i < 3; i++ {
+		if !r.allow("a", 1, 3, now) {
+			t.Fatalf("should allow %d", i)
+		}
+	}
+	if r.allow("a", 1, 3, now) {
+		t.Fatal("should not allow")
+	}
+	if !r.allow("b", 1, 3, now) {
+		t.Fatal("bucket of b should be independent")
+	}
+
+	now += int64(time.Second)
+	if !r.allow("a", 1, 3, now) {
+		t.Fatal("should allow after refill")
+	}
+	if r.allow("a", 1, 3, now) {
+		t.Fatal("should not allow")
+	}
+}
+
+type mockQuotaReader map[types.Address]uint64
+
+func (m mockQuotaReader) GetStakeQuota(addr types.Address) (*big.Int, *types.Quota, error) {
+	q := types.NewQuota(m[addr], 0, 0, 0, false, 0)
+	return big.NewInt(0), &q, nil
+}
+
+func TestAccountGossip_allow(t *testing.T) {
+	staked, poor, sbp := randAddress(), randAddress(), randAddress()
+
+	g := newAccountGossip(&config.Net{
+		AccountBlockPeerRate:    1000,
+		AccountBlockAccountRate: 1,
+	}, mockQuotaReader{
+		staked: 10 * quotaPerAccountBlock,
+	})
+	g.setSBPChecker(func(addr types.Address) bool {
+		return addr == sbp
+	})
+
+	p := &Peer{Id: peerId{1}}
+
+	count := func(addr types.Address) (n int) {
+		for i := 0; i < 100; i++ {
+			if g.allow(p, addr) {
+				n++
+			}
+		}
+		return
+	}
+
+	if n := count(poor); n != int(g.accountBurst) {
+		t.Errorf("account without quota should be limited to %v: %d", g.accountBurst, n)
+	}
+	if n := count(staked); n <= int(g.accountBurst) || n == 100 {
+		t.Errorf("account with quota should be allowed more: %d", n)
+	}
+	if n := count(sbp); n != 100 {
+		t.Errorf("sbp should not be limited: %d", n)
+	}
+	if g.status().AccountLimited == 0 {
+		t.Error("limited count should be recorded")
+	}
+}
+
+func TestAccountGossip_wanted(t *testing.T) {
+	sbp := randAddress()
+
+	g := newAccountGossip(&config.Net{}, nil)
+	g.setSBPChecker(func(addr types.Address) bool {
+		return addr == sbp
+	})
+
+	p := &Peer{Id: peerId{1}}
+
+	seen, common, fromSBP := randHash(), randHash(), randHash()
+	g.seen.add(seen)
+
+	ann := &AccountBlockHashes{
+		Blocks: []AnnouncedBlock{
+			{seen, randAddress()},
+			{common, randAddress()},
+			{fromSBP, sbp},
+		},
+	}
+
+	hashes := g.wanted(ann, p)
+	if len(hashes) != 2 || hashes[0] != fromSBP || hashes[1] != common {
+		t.Fatalf("wrong wanted hashes: %v", hashes)
+	}
+
+	// requested hashes will not be requested again
+	if hashes = g.wanted(ann, p); len(hashes) != 0 {
+		t.Fatalf("should not request again: %v", hashes)
+	}
+
+	// body requested by us should not be limited again
+	if !g.receive(&ledger.AccountBlock{Hash: common}, common, p) {
+		t.Error("requested body should be received")
+	}
+	if g.receive(&ledger.AccountBlock{Hash: common}, common, p) {
+		t.Error("duplicate body should be dropped")
+	}
+}
+
+func TestAccountGossip_receiveLimited(t *testing.T) {
+	g := newAccountGossip(&config.Net{
+		AccountBlockPeerRate: 1,
+	}, nil)
+
+	spammer, honest := &Peer{Id: peerId{1}}, &Peer{Id: peerId{2}}
+	for g.allow(spammer, randAddress()) {
+	}
+
+	hash := randHash()
+	block := &ledger.AccountBlock{Hash: hash, AccountAddress: randAddress()}
+	if g.receive(block, hash, spammer) {
+		t.Fatal("block from limited peer should be dropped")
+	}
+	if g.seen.has(hash) {
+		t.Error("dropped block should not be seen")
+	}
+
+	// the block relayed by other peers is not a duplicate
+	if !g.receive(block, hash, honest) {
+		t.Error("block from other peer should be received")
+	}
+	if g.receive(block, hash, honest) {
+		t.Error("duplicate body should be dropped")
+	}
+	if status := g.status(); status.PeerLimited == 0 || status.DuplicateBlocks != 1 {
+		t.Errorf("wrong status: %+v", status)
+	}
+}
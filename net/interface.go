@@ -66,6 +66,7 @@ type Consensus interface {
 	SubscribeProducers(gid types.Gid, id string, fn func(event consensus.ProducersEvent))
 	UnSubscribe(gid types.Gid, id string)
 	API() consensus.APIReader
+	VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error)
 }
 
 type Verifier interface {
@@ -211,6 +212,22 @@ type SyncStateSubscriber interface {
 	SyncState() SyncState
 }
 
+type EvidenceSubscriber interface {
+	// SubscribeEvidence return the subId, always larger than 0, use to unsubscribe
+	SubscribeEvidence(fn EvidenceCallback) (subId int)
+	// UnsubscribeEvidence if subId is 0, then ignore
+	UnsubscribeEvidence(subId int)
+}
+
+// A FinalityVoteRelay implementation can send finality votes to peers, and receive votes from peers
+type FinalityVoteRelay interface {
+	BroadcastFinalityVote(vote *ledger.FinalityVote)
+	// SubscribeFinalityVote return the subId, always larger than 0, use to unsubscribe
+	SubscribeFinalityVote(fn FinalityVoteCallback) (subId int)
+	// UnsubscribeFinalityVote if subId is 0, then ignore
+	UnsubscribeFinalityVote(subId int)
+}
+
 type Subscriber interface {
 	BlockSubscriber
 	SyncStateSubscriber
@@ -250,10 +267,18 @@ type Net interface {
 	Fetcher
 	Broadcaster
 	BlockSubscriber
+	EvidenceSubscriber
+	FinalityVoteRelay
 	Start() error
 	Stop() error
 	Info() NodeInfo
+	// NetInfo return the traffic of every message code and every peer
+	NetInfo() NetInfo
 	Nodes() []*vnode.Node
+	// FindNodes return nodes advertised the role through discovery, will be blocked until enough nodes found or timeout
+	FindNodes(role vnode.Role, count int) []*vnode.Node
 	PeerCount() int
 	PeerKey() ed25519.PrivateKey
+	// Evidences return the evidences of producers signing two snapshot blocks for the same slot
+	Evidences() []*Evidence
 }
@@ -35,14 +35,18 @@ const (
 	CodeControlFlow Code = 3
 	CodeHeartBeat   Code = 4
 
-	CodeGetHashList       Code = 25
-	CodeHashList          Code = 26
-	CodeGetSnapshotBlocks Code = 27
-	CodeSnapshotBlocks    Code = 28
-	CodeGetAccountBlocks  Code = 29
-	CodeAccountBlocks     Code = 30
-	CodeNewSnapshotBlock  Code = 31
-	CodeNewAccountBlock   Code = 32
+	CodeGetHashList           Code = 25
+	CodeHashList              Code = 26
+	CodeGetSnapshotBlocks     Code = 27
+	CodeSnapshotBlocks        Code = 28
+	CodeGetAccountBlocks      Code = 29
+	CodeAccountBlocks         Code = 30
+	CodeNewSnapshotBlock      Code = 31
+	CodeNewAccountBlock       Code = 32
+	CodeAccountBlockHashes    Code = 33
+	CodeGetAccountBlockBodies Code = 34
+	CodeEvidence              Code = 35
+	CodeFinalityVote          Code = 36
 
 	CodeSyncHandshake   Code = 60
 	CodeSyncHandshakeOK Code = 61
@@ -85,6 +85,27 @@ func (n *mockNet) SubscribeSnapshotBlock(fn SnapshotBlockCallback) (subId int) {
 func (n *mockNet) UnsubscribeSnapshotBlock(subId int) {
 }
 
+func (n *mockNet) SubscribeEvidence(fn EvidenceCallback) (subId int) {
+	return 0
+}
+
+func (n *mockNet) UnsubscribeEvidence(subId int) {
+}
+
+func (n *mockNet) Evidences() []*Evidence {
+	return nil
+}
+
+func (n *mockNet) BroadcastFinalityVote(vote *ledger.FinalityVote) {
+}
+
+func (n *mockNet) SubscribeFinalityVote(fn FinalityVoteCallback) (subId int) {
+	return 0
+}
+
+func (n *mockNet) UnsubscribeFinalityVote(subId int) {
+}
+
 func (n *mockNet) Stop() error {
 	return nil
 }
@@ -97,10 +118,18 @@ func (n *mockNet) Info() NodeInfo {
 	return NodeInfo{}
 }
 
+func (n *mockNet) NetInfo() NetInfo {
+	return NetInfo{}
+}
+
 func (n *mockNet) Nodes() []*vnode.Node {
 	return nil
 }
 
+func (n *mockNet) FindNodes(role vnode.Role, count int) []*vnode.Node {
+	return nil
+}
+
 func (n *mockNet) PeerCount() int {
 	return 0
 }
@@ -47,7 +47,7 @@ type net struct {
 
 	db *database.DB
 
-	dialer       _net.Dialer
+	transport    Transport
 	listener     _net.Listener
 	hkr          *handshaker
 	receiveSlots chan struct{}
@@ -56,13 +56,19 @@ type net struct {
 
 	syncServer *syncServer
 
-	peers *peerSet
+	peers     *peerSet
+	diversity diversity
+	anchors   *anchors
+	traffic   *traffic
+	limiter   *bandwidthLimiter
 
 	chain Chain
 
 	*syncer  // use pointer but not interface, because syncer can be start/stop, but interface has no start/stop method
 	*fetcher // use pointer but not interface, because fetcher can be start/stop, but interface has no start/stop method
 	*broadcaster
+	*finalityVotes
+	evidence   *evidenceCollector
 	reader     *cacheReader
 	downloader syncDownloader
 	BlockSubscriber
@@ -129,7 +135,7 @@ func (n *net) ConnectNode(node *vnode.Node) (err error) {
 		return fmt.Errorf("node %s has been banned", node.ID)
 	}
 
-	conn, err := _net.Dial("tcp", node.Address())
+	conn, err := n.transport.Dial(node.Address(), 0)
 	if err != nil {
 		n.blackList.Ban(node.ID.Bytes(), 10)
 		return
@@ -169,6 +175,7 @@ func (n *net) onConnection(conn _net.Conn, id peerId, inbound bool) {
 	}
 
 	peer := newPeer(c, their, publicAddress, fileAddress, superior, flag, n.peers, n.handlers)
+	peer.limiter = n.limiter
 
 	// run peer
 	_ = n.onPeerAdded(peer)
@@ -220,8 +227,17 @@ func (n *net) authorize(c Codec, flag PeerFlag, msg *HandshakeMsg) (superior boo
 		return
 	}
 
-	// no space
-	if n.peers.countWithoutSBP() >= n.config.MaxPeers {
+	ps := n.peers.peers()
+	if err = n.diversity.check(peerIP(c.Address()), ps); err != nil {
+		return
+	}
+
+	// no space, anchors can use the reserved slots
+	var reserved int
+	if !flag.is(PeerFlagOutbound) || !n.anchors.has(msg.ID) {
+		reserved = n.anchors.reserved(ps)
+	}
+	if n.peers.countWithoutSBP()+reserved >= n.config.MaxPeers {
 		err = PeerTooManyPeers
 		return
 	}
@@ -341,6 +357,12 @@ func retrieveAddressBytesFromConfig(address string, port int) (data []byte, err
 }
 
 func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, irreader IrreversibleReader) (Net, error) {
+	return NewWithTransport(cfg, chain, verifier, consensus, irreader, tcpTransport{})
+}
+
+// NewWithTransport is the same as New, but peers and file sync will use the given transport, not TCP.
+// It is used by simulator to run many nodes in one process.
+func NewWithTransport(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, irreader IrreversibleReader, tr Transport) (Net, error) {
 	// for test
 	if cfg.Single {
 		return mock(chain), nil
@@ -385,12 +407,27 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		return nil, err
 	}
 
+	if cfg.MaxSubnetPeers <= 0 {
+		cfg.MaxSubnetPeers = config.DefaultMaxSubnetPeers
+	}
+	if cfg.MaxBucketPeers <= 0 {
+		cfg.MaxBucketPeers = config.DefaultMaxBucketPeers
+	}
+	if cfg.AnchorPeers <= 0 {
+		cfg.AnchorPeers = config.DefaultAnchorPeers
+	}
+
 	peers := newPeerSet()
 
 	feed := newBlockFeeder(blackHashList)
 
 	forward := createForardStrategy(cfg.ForwardStrategy, peers)
-	broadcaster := newBroadcaster(peers, verifier, feed, newMemBlockStore(1000), forward, chain)
+	var quota quotaReader
+	if qr, ok := chain.(quotaReader); ok {
+		quota = qr
+	}
+	gossip := newAccountGossip(cfg, quota)
+	broadcaster := newBroadcaster(peers, verifier, feed, newMemBlockStore(1000), forward, chain, gossip)
 
 	receiver := &safeBlockNotifier{
 		blockFeeder: feed,
@@ -406,7 +443,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		peerKey: peerKey,
 		mineKey: cfg.MineKey,
 	}
-	downloader := newExecutor(50, 10, peers, syncConnFac)
+	downloader := newExecutor(50, 10, peers, syncConnFac, tr)
 
 	reader := newCacheReader(chain, verifier, downloader, irreader, blackHashList)
 
@@ -429,12 +466,18 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		peerKey:         peerKey,
 		BlockSubscriber: feed,
 		peers:           peers,
+		diversity:       diversity{maxSubnet: cfg.MaxSubnetPeers, maxBucket: cfg.MaxBucketPeers},
+		anchors:         newAnchors(cfg.AnchorPeers),
+		traffic:         newTraffic(nil),
+		limiter:         newBandwidthLimiter(cfg.OutboundBandwidth),
 		syncer:          syncer,
 		reader:          reader,
 		fetcher:         fetcher,
 		broadcaster:     broadcaster,
+		finalityVotes:   newFinalityVotes(peers),
 		downloader:      downloader,
-		syncServer:      newSyncServer(cfg.ListenInterface+":"+strconv.Itoa(cfg.FilePort), chain, syncConnFac),
+		syncServer:      newSyncServer(cfg.ListenInterface+":"+strconv.Itoa(cfg.FilePort), chain, syncConnFac, tr),
+		transport:       tr,
 		handlers:        newHandlers("vite"),
 		hb:              newHeartBeater(peers, chain),
 		blackList: netool.NewBlackList(func(t int64, count int) bool {
@@ -450,6 +493,8 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		confirmedHashHeightList: confirmedHashList,
 	}
 
+	n.syncServer.limiter = n.limiter
+
 	fileAddress, err := retrieveAddressBytesFromConfig(cfg.FilePublicAddress, cfg.FilePort)
 	if err != nil {
 		return nil, err
@@ -473,6 +518,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 			minCompressLength: 100,
 			readTimeout:       readMsgTimeout,
 			writeTimeout:      writeMsgTimeout,
+			traffic:           n.traffic,
 		},
 		chain:        chain,
 		blackList:    n.blackList,
@@ -484,6 +530,12 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		return nil, err
 	}
 
+	n.anchors.set(n.db.ReadAnchors())
+
+	n.evidence = newEvidenceCollector(peers, verifier, consensus, n.db, chain)
+	broadcaster.evidence = n.evidence
+	reader.evidence = n.evidence
+
 	if cfg.Discover {
 		n.discover = discovery.New(peerKey, n.node, cfg.BootNodes, cfg.BootSeeds, cfg.ListenInterface+":"+strconv.Itoa(cfg.Port), n.db)
 	}
@@ -493,7 +545,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		addr = types.PubkeyToAddress(n.config.MineKey.PubByte())
 	}
 
-	n.finder, err = newFinder(addr, n.peers, cfg.MinPeers, cfg.StaticNodes, n.db, n, consensus)
+	n.finder, err = newFinder(addr, n.peers, cfg.MinPeers, cfg.StaticNodes, n.anchors, n.db, n, consensus)
 	if err != nil {
 		return nil, err
 	}
@@ -503,13 +555,34 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		n.syncer.sbp = true
 	}
 
+	gossip.setSBPChecker(n.finder.isSBP)
+
 	if n.discover != nil {
 		n.discover.SetFinder(n.finder)
+		n.finder.lookup = n.discover.Lookup
 		if len(n.config.MineKey) != 0 {
 			setNodeExt(n.config.MineKey, n.node)
 		}
 	}
 
+	roles, err := vnode.ParseRoles(cfg.Roles)
+	if err != nil {
+		return nil, err
+	}
+	// every node can serve ledger files
+	roles |= vnode.RoleFull | vnode.RoleFileServer
+	if len(n.config.MineKey) != 0 {
+		roles |= vnode.RoleSBP
+	}
+	err = vnode.SetRecord(n.node, peerKey, &vnode.Record{
+		Seq:       uint64(time.Now().Unix()),
+		Roles:     roles,
+		Protocols: []uint32{version},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	err = n.handlers.register(&stateHandler{
 		maxNeighbors: 100,
 		peers:        peers,
@@ -533,6 +606,16 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		panic(fmt.Errorf("cannot register handler: broadcaster: %v", err))
 	}
 
+	// CodeEvidence
+	if err = n.handlers.register(n.evidence); err != nil {
+		panic(fmt.Errorf("cannot register handler: evidence: %v", err))
+	}
+
+	// CodeFinalityVote
+	if err = n.handlers.register(n.finalityVotes); err != nil {
+		panic(fmt.Errorf("cannot register handler: finality: %v", err))
+	}
+
 	// CodeSnapshotBlocks, CodeAccountBlocks
 	if err = n.handlers.register(fetcher); err != nil {
 		panic(fmt.Errorf("cannot register handler: fetcher: %v", err))
@@ -561,13 +644,17 @@ func (n *net) beatLoop() {
 				return
 			}
 
-			for _, pe := range n.peers.peers() {
+			ps := n.peers.peers()
+			for _, pe := range ps {
 				_ = pe.WriteMsg(Msg{
 					Code:    CodeHeartBeat,
 					Payload: n.hb.state(),
 				})
 			}
 
+			updateDiversityMetrics(diversityStatus(ps, n.anchors))
+			updateTrafficMetrics(n.traffic.status())
+
 		case <-storeTicker.C:
 			if n.running == 0 {
 				return
@@ -593,8 +680,49 @@ func (n *net) beatLoop() {
 
 				n.db.StoreMark(pe.Id, weight)
 			}
+
+			n.storeAnchors()
+		}
+	}
+}
+
+// storeAnchors elect anchors from peers and store them, keep the anchors of last time if no one elected
+func (n *net) storeAnchors() {
+	elected := n.anchors.elect(n.peers.peers(), time.Now().Unix())
+	if len(elected) == 0 {
+		return
+	}
+
+	nodes := make([]*vnode.Node, 0, len(elected))
+	for _, pe := range elected {
+		ep, err := vnode.ParseEndPoint(pe.publicAddress)
+		if err != nil {
+			continue
 		}
+		nodes = append(nodes, &vnode.Node{
+			ID:       pe.Id,
+			EndPoint: ep,
+			Net:      n.node.Net,
+		})
 	}
+
+	n.anchors.set(nodes)
+	n.db.StoreAnchors(nodes)
+}
+
+// Evidences return the stored evidences of producers signing two snapshot blocks for the same slot
+func (n *net) Evidences() []*Evidence {
+	return n.evidence.list()
+}
+
+// SubscribeEvidence register fn to be called with the new evidences, verified and stored
+func (n *net) SubscribeEvidence(fn EvidenceCallback) (subId int) {
+	return n.evidence.SubscribeEvidence(fn)
+}
+
+// UnsubscribeEvidence remove the callback registered by SubscribeEvidence
+func (n *net) UnsubscribeEvidence(subId int) {
+	n.evidence.UnsubscribeEvidence(subId)
 }
 
 type heartBeater struct {
@@ -662,7 +790,7 @@ func (h *heartBeater) state() []byte {
 
 func (n *net) Start() (err error) {
 	if atomic.CompareAndSwapInt32(&n.running, 0, 1) {
-		n.listener, err = _net.Listen("tcp", n.config.ListenInterface+":"+strconv.Itoa(n.config.Port))
+		n.listener, err = n.transport.Listen(n.config.ListenInterface + ":" + strconv.Itoa(n.config.Port))
 		if err != nil {
 			return
 		}
@@ -737,6 +865,14 @@ func (n *net) Nodes() []*vnode.Node {
 	return n.discover.Nodes()
 }
 
+func (n *net) FindNodes(role vnode.Role, count int) []*vnode.Node {
+	if n.discover == nil {
+		return nil
+	}
+
+	return n.discover.Lookup(role, count)
+}
+
 func (n *net) PeerKey() ed25519.PrivateKey {
 	return n.peerKey
 }
@@ -757,8 +893,11 @@ func (n *net) Info() NodeInfo {
 		Peers:     ps,
 		Height:    n.chain.GetLatestSnapshotBlock().Height,
 		//Nodes:     n.discover.NodesCount(),
+		Roles:                 n.roles(),
 		Latency:               n.broadcaster.Statistic(),
 		BroadCheckFailedRatio: n.broadcaster.rings.failedRatio(),
+		Gossip:                n.broadcaster.gossip.status(),
+		Diversity:             diversityStatus(n.peers.peers(), n.anchors),
 		Server:                FileServerStatus{},
 	}
 
@@ -769,17 +908,48 @@ func (n *net) Info() NodeInfo {
 	return info
 }
 
+func (n *net) NetInfo() NetInfo {
+	ps := n.peers.peers()
+	info := NetInfo{
+		OutboundBandwidth: n.config.OutboundBandwidth,
+		Traffic:           n.traffic.status(),
+		Peers:             make([]PeerTraffic, len(ps)),
+	}
+
+	for i, p := range ps {
+		info.Peers[i] = PeerTraffic{
+			Id:      p.Id.String(),
+			Address: p.codec.Address().String(),
+			Traffic: codecTraffic(p.codec).status(),
+		}
+	}
+
+	return info
+}
+
+func (n *net) roles() []string {
+	r, err := vnode.ParseRecord(n.node)
+	if err != nil {
+		return nil
+	}
+
+	return r.Roles.Names()
+}
+
 type NodeInfo struct {
 	ID                    vnode.NodeID     `json:"id"`
 	Name                  string           `json:"name"`
 	NetID                 int              `json:"netId"`
 	Version               int              `json:"version"`
 	Address               string           `json:"address"`
+	Roles                 []string         `json:"roles"`
 	PeerCount             int              `json:"peerCount"`
 	Peers                 []PeerInfo       `json:"peers"`
 	Height                uint64           `json:"height"`
 	Nodes                 int              `json:"nodes"`
 	Latency               []int64          `json:"latency"` // [0,1,12,24]
 	BroadCheckFailedRatio float32          `json:"broadCheckFailedRatio"`
+	Gossip                GossipStatus     `json:"gossip"`
+	Diversity             DiversityStatus  `json:"diversity"`
 	Server                FileServerStatus `json:"server"`
 }
@@ -25,6 +25,7 @@ var errPeerNotExist = errors.New("peer not exist")
 var errPeerAlreadyRunning = errors.New("peer is already running")
 var errPeerNotRunning = errors.New("peer is not running")
 var errPeerCannotWrite = errors.New("peer is not writable")
+var errPeerBusy = errors.New("peer is busy, message dropped")
 
 func extractAddress(sender *_net.TCPAddr, fileAddressBytes []byte, defaultPort int) (address string) {
 	var fromIP = sender.IP
@@ -80,6 +81,7 @@ type PeerInfo struct {
 	CreateAt   string   `json:"createAt"`
 	ReadQueue  int      `json:"readQueue"`
 	WriteQueue int      `json:"writeQueue"`
+	Dropped    uint64   `json:"dropped"`
 	Peers      []string `json:"peers"`
 }
 
@@ -112,19 +114,24 @@ type Peer struct {
 
 	CreateAt int64
 
+	ip        _net.IP // remote IP, nil if the connection is not TCP
+	connectAt int64   // local time when connected, CreateAt is given by the remote peer
+
 	Flag     PeerFlag
 	Superior bool
 
 	reliable int32 // whether the same chain
 
-	busy  int32
-	busyT int64
+	busy    int32
+	busyT   int64
+	dropped uint64 // messages dropped because the write queue is full or the peer is busy
 
 	running    int32
 	writable   int32 // set to 0 when write error in writeLoop, or close actively
 	writing    int32
-	readQueue  chan Msg // will be closed when read error in readLoop
-	writeQueue chan Msg // will be closed in method Close
+	readQueue  chan Msg   // will be closed when read error in readLoop
+	writeQueue writeQueue // will be closed in method Close
+	limiter    *bandwidthLimiter
 
 	errChan chan error
 	wg      sync.WaitGroup
@@ -159,29 +166,34 @@ func (p *Peer) setReliable(bool2 bool) {
 	}
 }
 
-// WriteMsg will put msg into queue, then write asynchronously
+// WriteMsg will put msg into queue, then write asynchronously.
+// Any full queue marks the peer busy for 5s, only high priority messages are queued meanwhile.
+// Return errPeerBusy if the message is dropped, it is not a reason to disconnect.
 func (p *Peer) WriteMsg(msg Msg) (err error) {
 	if !p.canWritable() {
 		return errPeerCannotWrite
 	}
 
+	high := priorityOf(msg.Code) == priorityHigh
+
 	// 5s
-	if atomic.LoadInt32(&p.busy) == 1 && time.Now().Unix()-p.busyT < 5 {
-		return nil
+	if !high && atomic.LoadInt32(&p.busy) == 1 && time.Now().Unix()-atomic.LoadInt64(&p.busyT) < 5 {
+		atomic.AddUint64(&p.dropped, 1)
+		return errPeerBusy
 	}
 
 	p.write()
 	defer p.writeDone()
 
-	select {
-	case p.writeQueue <- msg:
-		atomic.StoreInt32(&p.busy, 0)
-	default:
-		atomic.StoreInt32(&p.busy, 1)
-		p.busyT = time.Now().Unix()
+	if p.writeQueue.push(msg) {
+		return nil
 	}
 
-	return nil
+	atomic.AddUint64(&p.dropped, 1)
+	atomic.StoreInt64(&p.busyT, time.Now().Unix())
+	atomic.StoreInt32(&p.busy, 1)
+
+	return errPeerBusy
 }
 
 func (p *Peer) write() {
@@ -220,7 +232,8 @@ func (p *Peer) Info() PeerInfo {
 		Reliable:   atomic.LoadInt32(&p.reliable) == 1,
 		CreateAt:   time.Unix(p.CreateAt, 0).Format("2006-01-02 15:04:05"),
 		ReadQueue:  len(p.readQueue),
-		WriteQueue: len(p.writeQueue),
+		WriteQueue: p.writeQueue.len(),
+		Dropped:    atomic.LoadUint64(&p.dropped),
 		Peers:      ps,
 	}
 }
@@ -239,6 +252,8 @@ func newPeer(c Codec, their *HandshakeMsg, publicAddress, fileAddress string, su
 		publicAddress: publicAddress,
 		fileAddress:   fileAddress,
 		CreateAt:      their.Timestamp,
+		ip:            peerIP(c.Address()),
+		connectAt:     time.Now().Unix(),
 		Flag:          flag,
 		Superior:      superior,
 		reliable:      0,
@@ -246,7 +261,7 @@ func newPeer(c Codec, their *HandshakeMsg, publicAddress, fileAddress string, su
 		writable:      1,
 		writing:       0,
 		readQueue:     make(chan Msg, 10),
-		writeQueue:    make(chan Msg, 1000),
+		writeQueue:    newWriteQueue(1000),
 		errChan:       make(chan error, 4), // read write handle catch
 		wg:            sync.WaitGroup{},
 		manager:       manager,
@@ -317,7 +332,14 @@ func (p *Peer) readLoop() (err error) {
 
 func (p *Peer) writeLoop() (err error) {
 	var msg Msg
-	for msg = range p.writeQueue {
+	var ok bool
+	for {
+		if msg, ok = p.writeQueue.pop(); !ok {
+			break
+		}
+
+		p.limiter.wait(len(msg.Payload), priorityOf(msg.Code))
+
 		if err = p.codec.WriteMsg(msg); err != nil {
 			p.stopWrite(fmt.Errorf("failed to write msg %d %d bytes: %v", msg.Code, len(msg.Payload), err))
 			return
@@ -372,7 +394,7 @@ func (p *Peer) Close(err error) (err2 error) {
 			time.Sleep(100 * time.Millisecond)
 		}
 
-		close(p.writeQueue)
+		p.writeQueue.close()
 
 		if err3 := p.codec.Close(); err3 != nil {
 			err2 = err3
@@ -407,7 +429,12 @@ func (p *Peer) SetSuperior(superior bool) error {
 	return nil
 }
 
+// catch the error and disconnect, errPeerBusy is ignored because the message is dropped only
 func (p *Peer) catch(err error) {
+	if err == errPeerBusy {
+		return
+	}
+
 	p.once.Do(func() {
 		p.errChan <- err
 	})
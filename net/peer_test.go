@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sort"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -242,3 +243,29 @@ func TestPeer_peers2(t *testing.T) {
 		t.Errorf("should %d peers, but %d peers", should, len(m2))
 	}
 }
+
+func TestPeer_WriteMsg_busy(t *testing.T) {
+	p := &Peer{Id: vnode.RandomNodeID(), writable: 1, writeQueue: newWriteQueue(1)}
+
+	if err := p.WriteMsg(Msg{Code: CodeGetSnapshotBlocks}); err != nil {
+		t.Fatal(err)
+	}
+	// the normal priority queue is full
+	if err := p.WriteMsg(Msg{Code: CodeGetSnapshotBlocks}); err != errPeerBusy {
+		t.Fatalf("should be busy: %v", err)
+	}
+	// only high priority messages are queued when busy
+	if err := p.WriteMsg(Msg{Code: CodeGetAccountBlocks}); err != errPeerBusy {
+		t.Fatalf("should be busy: %v", err)
+	}
+	if err := p.WriteMsg(Msg{Code: CodeNewSnapshotBlock}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.WriteMsg(Msg{Code: CodeNewSnapshotBlock}); err != errPeerBusy {
+		t.Fatalf("should be busy: %v", err)
+	}
+
+	if n := atomic.LoadUint64(&p.dropped); n != 3 {
+		t.Errorf("should drop 3 messages, but %d", n)
+	}
+}
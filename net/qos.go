@@ -0,0 +1,173 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+type msgPriority byte
+
+const (
+	priorityHigh   msgPriority = iota // control messages and block broadcasts
+	priorityNormal                    // requests
+	priorityLow                       // bulk responses of sync and fetch
+	priorityCount
+)
+
+// maxLimiterWait is the max duration to sleep once, then check again, because high priority messages may be sent meanwhile
+const maxLimiterWait = 100 * time.Millisecond
+
+func priorityOf(code Code) msgPriority {
+	switch code {
+	case CodeGetHashList, CodeGetSnapshotBlocks, CodeGetAccountBlocks, CodeGetAccountBlockBodies:
+		return priorityNormal
+	case CodeHashList, CodeSnapshotBlocks, CodeAccountBlocks:
+		return priorityLow
+	default:
+		return priorityHigh
+	}
+}
+
+// writeQueue is message queues of every priority
+type writeQueue [priorityCount]chan Msg
+
+func newWriteQueue(size int) (q writeQueue) {
+	for i := range q {
+		q[i] = make(chan Msg, size)
+	}
+
+	return
+}
+
+// push msg into the queue of its priority, return false if the queue is full
+func (q writeQueue) push(msg Msg) bool {
+	select {
+	case q[priorityOf(msg.Code)] <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// pop the message of the highest priority, block until any message is available,
+// return false if queues are closed and no more messages
+func (q writeQueue) pop() (msg Msg, ok bool) {
+	for i := range q {
+		select {
+		case msg, ok = <-q[i]:
+			if ok {
+				return
+			}
+		default:
+		}
+	}
+
+	select {
+	case msg, ok = <-q[priorityHigh]:
+	case msg, ok = <-q[priorityNormal]:
+	case msg, ok = <-q[priorityLow]:
+	}
+
+	return
+}
+
+func (q writeQueue) len() (n int) {
+	for i := range q {
+		n += len(q[i])
+	}
+
+	return
+}
+
+func (q writeQueue) close() {
+	for i := range q {
+		close(q[i])
+	}
+}
+
+// bandwidthLimiter is a token bucket of outbound bytes shared by all peers and file server.
+// High priority messages are never blocked, but still consume tokens, so other messages will wait longer.
+type bandwidthLimiter struct {
+	rate  float64 // bytes per second
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newBandwidthLimiter return nil if rate is not positive, nil limiter never block
+func newBandwidthLimiter(rate int) *bandwidthLimiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	return &bandwidthLimiter{
+		rate:   float64(rate),
+		burst:  float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// wait until n bytes can be sent.
+// tokens can be negative after a large message sent, then the next message will wait until tokens are refilled.
+func (l *bandwidthLimiter) wait(n int, p msgPriority) {
+	if l == nil {
+		return
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+
+		if p == priorityHigh || l.tokens >= 0 {
+			l.tokens -= float64(n)
+			l.mu.Unlock()
+			return
+		}
+
+		d := time.Duration(-l.tokens / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if d > maxLimiterWait {
+			d = maxLimiterWait
+		}
+		time.Sleep(d)
+	}
+}
+
+// limitWriter write bytes with the lowest priority
+type limitWriter struct {
+	io.Writer
+	limiter *bandwidthLimiter
+}
+
+func (w limitWriter) Write(p []byte) (n int, err error) {
+	w.limiter.wait(len(p), priorityLow)
+	return w.Writer.Write(p)
+}
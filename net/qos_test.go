@@ -0,0 +1,123 @@
+package net
+
+import (
+	_net "net"
+	"testing"
+	"time"
+)
+
+func TestWriteQueue_pop(t *testing.T) {
+	q := newWriteQueue(10)
+
+	codes := []Code{CodeSnapshotBlocks, CodeGetSnapshotBlocks, CodeNewAccountBlock, CodeHashList, CodeNewSnapshotBlock}
+	for _, code := range codes {
+		if !q.push(Msg{Code: code}) {
+			t.Fatalf("failed to push %d", code)
+		}
+	}
+
+	if n := q.len(); n != len(codes) {
+		t.Errorf("wrong length: %d", n)
+	}
+
+	// high priority first, then in order of pushed
+	expect := []Code{CodeNewAccountBlock, CodeNewSnapshotBlock, CodeGetSnapshotBlocks, CodeSnapshotBlocks, CodeHashList}
+	for _, code := range expect {
+		msg, ok := q.pop()
+		if !ok {
+			t.Fatal("queue should not be closed")
+		}
+		if msg.Code != code {
+			t.Errorf("should pop %d, not %d", code, msg.Code)
+		}
+	}
+
+	q.push(Msg{Code: CodeAccountBlocks})
+	q.close()
+	if msg, ok := q.pop(); !ok || msg.Code != CodeAccountBlocks {
+		t.Error("should pop the message left after closed")
+	}
+	if _, ok := q.pop(); ok {
+		t.Error("queue should be closed")
+	}
+}
+
+func TestWriteQueue_full(t *testing.T) {
+	q := newWriteQueue(1)
+
+	if !q.push(Msg{Code: CodeSnapshotBlocks}) {
+		t.Fatal("failed to push")
+	}
+	if q.push(Msg{Code: CodeAccountBlocks}) {
+		t.Error("low priority queue should be full")
+	}
+	if !q.push(Msg{Code: CodeNewSnapshotBlock}) {
+		t.Error("high priority queue should not be full")
+	}
+}
+
+func TestBandwidthLimiter_wait(t *testing.T) {
+	if newBandwidthLimiter(0) != nil {
+		t.Error("limiter should be nil if no limit")
+	}
+
+	const rate = 10000
+	l := newBandwidthLimiter(rate)
+
+	// use out the burst and more
+	start := time.Now()
+	l.wait(2*rate, priorityHigh)
+	if d := time.Now().Sub(start); d > 10*time.Millisecond {
+		t.Errorf("high priority should not wait: %s", d)
+	}
+
+	// tokens is -rate, should wait about 1 second
+	start = time.Now()
+	l.wait(100, priorityLow)
+	if d := time.Now().Sub(start); d < 900*time.Millisecond || d > 1500*time.Millisecond {
+		t.Errorf("low priority should wait about 1s: %s", d)
+	}
+}
+
+func TestTransport_traffic(t *testing.T) {
+	c1, c2 := _net.Pipe()
+
+	root := newTraffic(nil)
+	f := &transportFactory{
+		minCompressLength: 100,
+		readTimeout:       time.Second,
+		writeTimeout:      time.Second,
+		traffic:           root,
+	}
+	t1, t2 := f.CreateCodec(c1), f.CreateCodec(c2)
+
+	done := make(chan struct{})
+	go func() {
+		_ = t1.WriteMsg(Msg{Code: CodeNewSnapshotBlock, Id: 1, Payload: []byte("hello")})
+		_ = t1.WriteMsg(Msg{Code: CodeHeartBeat})
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		if _, err := t2.ReadMsg(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+
+	// meta + code + id + length + payload
+	st := codecTraffic(t1).status()
+	if st.OutMsgs != 2 || st.OutBytes != 2+1+1+5+2 || st.InMsgs != 0 {
+		t.Errorf("wrong traffic of sender: %+v", st)
+	}
+	st = codecTraffic(t2).status()
+	if st.InMsgs != 2 || st.InBytes != 2+1+1+5+2 || st.OutMsgs != 0 {
+		t.Errorf("wrong traffic of receiver: %+v", st)
+	}
+
+	// both sender and receiver are counted by root
+	st = root.status()
+	if st.InBytes != st.OutBytes || len(st.Codes) != 2 || st.Codes[0].Code != "heartBeat" {
+		t.Errorf("wrong traffic of root: %+v", st)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package simulator
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// maxOrphans is the max count of blocks whose previous block is missing
+const maxOrphans = 1000
+
+var errNotSupported = errors.New("not supported in simulator")
+var errMissingBlock = errors.New("missing block")
+
+// chain is an in-memory snapshot chain, only snapshot blocks are simulated.
+// All received blocks are kept as a tree, the longest branch is the canonical chain,
+// if two branches have the same height, the first one seen wins.
+type chain struct {
+	mu       sync.RWMutex
+	genesis  *ledger.SnapshotBlock
+	head     *ledger.SnapshotBlock
+	blocks   map[types.Hash]*ledger.SnapshotBlock
+	heights  []*ledger.SnapshotBlock                // canonical chain, index is height
+	orphans  map[types.Hash][]*ledger.SnapshotBlock // key is the PrevHash
+	norphans int
+	cache    syncCache
+}
+
+// initForkPoints activate all forks from genesis, the block hash depends on fork points
+func initForkPoints() {
+	if fork.IsInitForkPoint() {
+		return
+	}
+
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:          &config.ForkPoint{Height: 1, Version: 1},
+		DexFork:           &config.ForkPoint{Height: 1, Version: 2},
+		DexFeeFork:        &config.ForkPoint{Height: 1, Version: 3},
+		StemFork:          &config.ForkPoint{Height: 1, Version: 4},
+		LeafFork:          &config.ForkPoint{Height: 1, Version: 5},
+		EarthFork:         &config.ForkPoint{Height: 1, Version: 6},
+		DexMiningFork:     &config.ForkPoint{Height: 1, Version: 7},
+		ExpiryFork:        &config.ForkPoint{Height: 1, Version: 8},
+		MultisigFork:      &config.ForkPoint{Height: 1, Version: 9},
+		DelegateGroupFork: &config.ForkPoint{Height: 1, Version: 10},
+	})
+}
+
+func newGenesis() *ledger.SnapshotBlock {
+	initForkPoints()
+
+	t := time.Unix(1558411200, 0)
+	genesis := &ledger.SnapshotBlock{
+		Height:    1,
+		Timestamp: &t,
+	}
+	genesis.Hash = genesis.ComputeHash()
+
+	return genesis
+}
+
+func newChain(genesis *ledger.SnapshotBlock) *chain {
+	return &chain{
+		genesis: genesis,
+		head:    genesis,
+		blocks: map[types.Hash]*ledger.SnapshotBlock{
+			genesis.Hash: genesis,
+		},
+		heights: []*ledger.SnapshotBlock{nil, genesis},
+		orphans: make(map[types.Hash][]*ledger.SnapshotBlock),
+	}
+}
+
+// produce create a block on the head, signed by key
+func (c *chain) produce(key ed25519.PrivateKey) *ledger.SnapshotBlock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	block := &ledger.SnapshotBlock{
+		PrevHash:  c.head.Hash,
+		Height:    c.head.Height + 1,
+		PublicKey: key.PubByte(),
+		Timestamp: &now,
+		// public key is not part of the hash, use seed to tell blocks produced at the same second apart
+		Seed: uint64(now.UnixNano()),
+	}
+	block.Hash = block.ComputeHash()
+	block.Signature = ed25519.Sign(key, block.Hash.Bytes())
+
+	c.add(block)
+
+	return block
+}
+
+// insert a block, return false if the previous block is missing,
+// the block will be kept and connected after the previous block inserted.
+func (c *chain) insert(block *ledger.SnapshotBlock) (connected bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.blocks[block.Hash]; ok {
+		return true
+	}
+
+	if _, ok := c.blocks[block.PrevHash]; !ok {
+		if c.norphans < maxOrphans {
+			for _, o := range c.orphans[block.PrevHash] {
+				if o.Hash == block.Hash {
+					return false
+				}
+			}
+			c.orphans[block.PrevHash] = append(c.orphans[block.PrevHash], block)
+			c.norphans++
+		}
+		return false
+	}
+
+	var pending = []*ledger.SnapshotBlock{block}
+	for len(pending) > 0 {
+		block = pending[0]
+		pending = pending[1:]
+
+		c.add(block)
+
+		children := c.orphans[block.Hash]
+		delete(c.orphans, block.Hash)
+		c.norphans -= len(children)
+		pending = append(pending, children...)
+	}
+
+	return true
+}
+
+// add block whose previous block exists, c.mu should be locked
+func (c *chain) add(block *ledger.SnapshotBlock) {
+	c.blocks[block.Hash] = block
+
+	if block.Height <= c.head.Height {
+		return
+	}
+
+	// reorganize, walk back to the fork point
+	var reorg []*ledger.SnapshotBlock
+	for b := block; b != nil; b = c.blocks[b.PrevHash] {
+		if b.Height < uint64(len(c.heights)) && c.heights[b.Height] == b {
+			break
+		}
+		reorg = append(reorg, b)
+	}
+
+	if n := int(block.Height) + 1; len(c.heights) < n {
+		c.heights = append(c.heights, make([]*ledger.SnapshotBlock, n-len(c.heights))...)
+	}
+	for _, b := range reorg {
+		c.heights[b.Height] = b
+	}
+	c.heights = c.heights[:block.Height+1]
+	c.head = block
+}
+
+func (c *chain) canonical(height uint64) *ledger.SnapshotBlock {
+	if height == 0 || height >= uint64(len(c.heights)) {
+		return nil
+	}
+	return c.heights[height]
+}
+
+func (c *chain) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.canonical(height), nil
+}
+
+func (c *chain) GetSnapshotBlockByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.blocks[hash], nil
+}
+
+func (c *chain) GetSnapshotBlocks(blockHash types.Hash, higher bool, count uint64) ([]*ledger.SnapshotBlock, error) {
+	c.mu.RLock()
+	block, ok := c.blocks[blockHash]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, errMissingBlock
+	}
+
+	return c.GetSnapshotBlocksByHeight(block.Height, higher, count)
+}
+
+// GetSnapshotBlocksByHeight return blocks from low to high
+func (c *chain) GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) (blocks []*ledger.SnapshotBlock, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var from, to uint64
+	if higher {
+		from, to = height, height+count-1
+	} else {
+		to = height
+		if to >= count {
+			from = to - count + 1
+		}
+	}
+
+	for h := from; h <= to; h++ {
+		if b := c.canonical(h); b != nil {
+			blocks = append(blocks, b)
+		}
+	}
+
+	return
+}
+
+func (c *chain) GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *chain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *chain) GetAccountBlocks(blockHash types.Hash, count uint64) ([]*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *chain) GetAccountBlocksByHeight(addr types.Address, height uint64, count uint64) ([]*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *chain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	return 0, nil
+}
+
+func (c *chain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.head
+}
+
+func (c *chain) GetGenesisSnapshotBlock() *ledger.SnapshotBlock {
+	return c.genesis
+}
+
+// GetLedgerReaderByHeight file sync is not simulated, nodes should not fall behind more than 100 blocks
+func (c *chain) GetLedgerReaderByHeight(startHeight uint64, endHeight uint64) (cr interfaces.LedgerReader, err error) {
+	return nil, errNotSupported
+}
+
+func (c *chain) GetSyncCache() interfaces.SyncCache {
+	return c.cache
+}
+
+// GetIrreversibleBlock use genesis as the irreversible block
+func (c *chain) GetIrreversibleBlock() *ledger.SnapshotBlock {
+	return c.genesis
+}
+
+// syncCache is always empty
+type syncCache struct{}
+
+func (syncCache) NewWriter(segment interfaces.Segment, size int64) (io.WriteCloser, error) {
+	return nil, errNotSupported
+}
+
+func (syncCache) Chunks() interfaces.SegmentList {
+	return nil
+}
+
+func (syncCache) NewReader(segment interfaces.Segment) (interfaces.ChunkReader, error) {
+	return nil, errNotSupported
+}
+
+func (syncCache) Delete(seg interfaces.Segment) error {
+	return nil
+}
+
+func (syncCache) Close() error {
+	return nil
+}
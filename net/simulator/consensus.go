@@ -0,0 +1,117 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package simulator
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/consensus/cdb"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var errInvalidHash = errors.New("invalid block hash")
+var errInvalidSignature = errors.New("invalid block signature")
+var errNotProducer = errors.New("block producer is not a SBP")
+
+// producers is the fixed SBP list of the simulation, it works as consensus and verifier of every node
+type producers struct {
+	genesis types.Hash
+	addrs   []types.Address
+	index   map[types.Address]struct{}
+}
+
+func newProducers(genesis types.Hash, keys []ed25519.PrivateKey) *producers {
+	p := &producers{
+		genesis: genesis,
+		index:   make(map[types.Address]struct{}, len(keys)),
+	}
+
+	for _, key := range keys {
+		addr := types.PubkeyToAddress(key.PubByte())
+		p.addrs = append(p.addrs, addr)
+		p.index[addr] = struct{}{}
+	}
+
+	return p
+}
+
+func (p *producers) isProducer(addr types.Address) bool {
+	_, ok := p.index[addr]
+	return ok
+}
+
+func (p *producers) VerifyNetSnapshotBlock(block *ledger.SnapshotBlock) error {
+	if block.ComputeHash() != block.Hash {
+		return errInvalidHash
+	}
+
+	// genesis has no producer
+	if block.Hash == p.genesis {
+		return nil
+	}
+
+	if len(block.PublicKey) != ed25519.PublicKeySize || !ed25519.Verify(block.PublicKey, block.Hash.Bytes(), block.Signature) {
+		return errInvalidSignature
+	}
+
+	if !p.isProducer(block.Producer()) {
+		return errNotProducer
+	}
+
+	return nil
+}
+
+func (p *producers) VerifyNetAccountBlock(block *ledger.AccountBlock) error {
+	return nil
+}
+
+// SubscribeProducers the SBP list never change in simulation, so the producers event is never fired
+func (p *producers) SubscribeProducers(gid types.Gid, id string, fn func(event consensus.ProducersEvent)) {
+}
+
+func (p *producers) UnSubscribe(gid types.Gid, id string) {
+}
+
+// VerifySnapshotProducer every SBP can produce at any slot in simulation
+func (p *producers) VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error) {
+	return p.isProducer(block.Producer()), nil
+}
+
+func (p *producers) API() consensus.APIReader {
+	return p
+}
+
+func (p *producers) ReadVoteMap(t time.Time) ([]*consensus.VoteDetails, *ledger.HashHeight, error) {
+	details := make([]*consensus.VoteDetails, len(p.addrs))
+	for i, addr := range p.addrs {
+		details[i] = &consensus.VoteDetails{
+			CurrentAddr: addr,
+		}
+	}
+
+	return details, nil, nil
+}
+
+func (p *producers) ReadSuccessRate(start, end uint64) ([]map[types.Address]*cdb.Content, error) {
+	return nil, nil
+}
@@ -0,0 +1,287 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vitelabs/go-vite/net"
+)
+
+// pollInterval is the interval to check the expectation
+const pollInterval = 100 * time.Millisecond
+
+// Step is an action or an expectation of a scenario
+type Step struct {
+	Name string
+	Do   func(s *Simulator) error
+}
+
+// Scenario is a list of steps run in order, it fails at the first failed step
+type Scenario struct {
+	Name  string
+	Steps []Step
+}
+
+// Run the scenario, the simulator should be started
+func (s *Simulator) Run(sc Scenario) error {
+	for i, step := range sc.Steps {
+		s.log.Info(fmt.Sprintf("scenario %s step %d: %s", sc.Name, i, step.Name))
+
+		if err := step.Do(s); err != nil {
+			return fmt.Errorf("scenario %s step %d %s: %v", sc.Name, i, step.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Wait d
+func Wait(d time.Duration) Step {
+	return Step{
+		Name: "wait " + d.String(),
+		Do: func(s *Simulator) error {
+			select {
+			case <-time.After(d):
+			case <-s.term:
+			}
+			return nil
+		},
+	}
+}
+
+// Offline isolate the node from the network
+func Offline(name string) Step {
+	return Step{
+		Name: name + " offline",
+		Do: func(s *Simulator) error {
+			return s.Offline(name)
+		},
+	}
+}
+
+// Online let the offline node rejoin the network
+func Online(name string) Step {
+	return Step{
+		Name: name + " online",
+		Do: func(s *Simulator) error {
+			return s.Online(name)
+		},
+	}
+}
+
+// Split the network into groups
+func Split(groups ...[]string) Step {
+	return Step{
+		Name: fmt.Sprintf("split %v", groups),
+		Do: func(s *Simulator) error {
+			return s.Split(groups...)
+		},
+	}
+}
+
+// Heal all partitions
+func Heal() Step {
+	return Step{
+		Name: "heal",
+		Do: func(s *Simulator) error {
+			s.Heal()
+			return nil
+		},
+	}
+}
+
+// Eclipse add count attackers to victim
+func Eclipse(victim string, count int) Step {
+	return Step{
+		Name: fmt.Sprintf("eclipse %s by %d attackers", victim, count),
+		Do: func(s *Simulator) (err error) {
+			_, err = s.AddAttackers(victim, count)
+			return
+		},
+	}
+}
+
+// expect poll check until it return nil, or return the last error when timeout
+func (s *Simulator) expect(timeout time.Duration, check func() error) (err error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err = check(); err == nil {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			return
+		}
+
+		select {
+		case <-time.After(pollInterval):
+		case <-s.term:
+			return
+		}
+	}
+}
+
+// ExpectConverged expect the nodes on the same chain within timeout, names empty means all online honest nodes
+func ExpectConverged(timeout time.Duration, names ...string) Step {
+	return Step{
+		Name: fmt.Sprintf("expect %v converged", names),
+		Do: func(s *Simulator) error {
+			return s.expect(timeout, func() error {
+				return s.Converged(1, names...)
+			})
+		},
+	}
+}
+
+// ExpectForked expect the nodes on different branches within timeout, names empty means all online honest nodes
+func ExpectForked(timeout time.Duration, names ...string) Step {
+	return Step{
+		Name: fmt.Sprintf("expect %v forked", names),
+		Do: func(s *Simulator) error {
+			return s.expect(timeout, func() error {
+				forks, err := s.Forks(names...)
+				if err != nil {
+					return err
+				}
+				if forks < 2 {
+					return fmt.Errorf("not forked")
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// ExpectPeers expect every node has at least min peers within timeout, names empty means all online honest nodes
+func ExpectPeers(min int, timeout time.Duration, names ...string) Step {
+	return Step{
+		Name: fmt.Sprintf("expect %v have %d peers", names, min),
+		Do: func(s *Simulator) error {
+			nodes, err := s.lookup(names)
+			if err != nil {
+				return err
+			}
+
+			return s.expect(timeout, func() error {
+				for _, n := range nodes {
+					if count := n.net.PeerCount(); count < min {
+						return fmt.Errorf("%s has %d peers", n, count)
+					}
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// ExpectGrowing expect the height of node increase at least n blocks within timeout
+func ExpectGrowing(name string, n uint64, timeout time.Duration) Step {
+	return Step{
+		Name: fmt.Sprintf("expect %s grow %d blocks", name, n),
+		Do: func(s *Simulator) error {
+			node := s.Node(name)
+			if node == nil {
+				return errUnknownNode
+			}
+
+			start := node.Head().Height
+			return s.expect(timeout, func() error {
+				if current := node.Head().Height; current < start+n {
+					return fmt.Errorf("height from %d to %d", start, current)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// ExpectSyncState expect the sync state of node within timeout
+func ExpectSyncState(name string, state net.SyncState, timeout time.Duration) Step {
+	return Step{
+		Name: fmt.Sprintf("expect %s %s", name, state),
+		Do: func(s *Simulator) error {
+			node := s.Node(name)
+			if node == nil {
+				return errUnknownNode
+			}
+
+			return s.expect(timeout, func() error {
+				if st := node.SyncState(); st != state {
+					return fmt.Errorf("sync state is %s", st)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// SBPOffline scenario: a SBP goes offline, the others keep producing, then the SBP comes back and catches up.
+// The SBP may be banned by other nodes for a while after it is back, so wait longer for converged.
+func SBPOffline(sbp string, duration time.Duration) Scenario {
+	return Scenario{
+		Name: "sbp offline",
+		Steps: []Step{
+			ExpectPeers(1, 4*duration),
+			ExpectConverged(duration),
+			Offline(sbp),
+			Wait(duration),
+			ExpectConverged(duration),
+			Online(sbp),
+			ExpectConverged(4 * duration),
+			ExpectGrowing(sbp, 2, duration),
+		},
+	}
+}
+
+// SplitAndHeal scenario: the network is split into two groups, both groups produce their own branch,
+// after healed, all nodes switch to the longest branch.
+func SplitAndHeal(group1, group2 []string, duration time.Duration) Scenario {
+	return Scenario{
+		Name: "split and heal",
+		Steps: []Step{
+			ExpectPeers(1, 4*duration),
+			ExpectConverged(duration),
+			Split(group1, group2),
+			ExpectForked(duration),
+			ExpectConverged(duration, group1...),
+			ExpectConverged(duration, group2...),
+			Heal(),
+			ExpectConverged(4 * duration),
+		},
+	}
+}
+
+// EclipseAttempt scenario: attackers try to occupy all peer slots of victim and feed it a forged chain,
+// victim should keep following the honest chain.
+func EclipseAttempt(victim string, attackers int, duration time.Duration) Scenario {
+	return Scenario{
+		Name: "eclipse attempt",
+		Steps: []Step{
+			ExpectPeers(1, 4*duration),
+			ExpectConverged(duration),
+			Eclipse(victim, attackers),
+			Wait(duration),
+			ExpectConverged(duration),
+			ExpectGrowing(victim, 2, duration),
+			ExpectSyncState(victim, net.SyncDone, duration),
+		},
+	}
+}
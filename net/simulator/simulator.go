@@ -0,0 +1,649 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package simulator run many net instances in one process, connected by an in-memory network.
+// The link quality between nodes can be configured, and nodes can be partitioned or go offline,
+// so protocol changes can be tested by scripted scenarios before deployed to testnet.
+// Only snapshot blocks are simulated, SBPs produce blocks in turn, every node keeps an in-memory chain,
+// and the longest branch wins.
+package simulator
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/net/vnode"
+	"github.com/vitelabs/go-vite/tools/mock_conn"
+)
+
+const simNetID = 9
+const port = 8483
+const filePort = 8484
+
+// maxFetchBlocks is the max count of blocks fetched when a block`s previous block is missing
+const maxFetchBlocks = 100
+
+var errUnknownNode = errors.New("unknown node")
+var errSimulatorIsRunning = errors.New("simulator is already running")
+
+// Config of a simulation
+type Config struct {
+	// Producers is the count of SBPs, they produce snapshot blocks in turn
+	Producers int
+	// Nodes is the count of full nodes
+	Nodes int
+	// Degree is the count of static neighbors of every node, 0 means every node connects to all others
+	Degree int
+	// Interval is the time between two snapshot blocks
+	Interval time.Duration
+	// Link is the link quality between nodes
+	Link mock_conn.Link
+	// MaxPeers of every node, default is config.DefaultMaxPeers
+	MaxPeers int
+	// DataDir is where nodes store the peer database, a temporary directory will be used if empty
+	DataDir string
+}
+
+// Node is a net instance in simulation
+type Node struct {
+	Name     string
+	IP       string
+	Producer bool
+	Attacker bool
+
+	net     net.Net
+	chain   *chain
+	mineKey ed25519.PrivateKey
+	vnode   *vnode.Node
+	offline int32
+
+	blocks chan *ledger.SnapshotBlock
+	term   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Net return the net instance of node
+func (n *Node) Net() net.Net {
+	return n.net
+}
+
+// Head return the latest snapshot block of node
+func (n *Node) Head() *ledger.SnapshotBlock {
+	return n.chain.GetLatestSnapshotBlock()
+}
+
+// Online return false if the node has been isolated by Simulator.Offline
+func (n *Node) Online() bool {
+	return atomic.LoadInt32(&n.offline) == 0
+}
+
+// SyncState return the sync state of the net instance
+func (n *Node) SyncState() net.SyncState {
+	return n.net.Status().State
+}
+
+func (n *Node) String() string {
+	return n.Name + "@" + n.IP
+}
+
+// receiveLoop insert blocks from network into chain, fetch the missing blocks if the previous block is unknown
+func (n *Node) receiveLoop() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case <-n.term:
+			return
+		case block := <-n.blocks:
+			if n.chain.insert(block) {
+				continue
+			}
+
+			count := uint64(maxFetchBlocks)
+			if block.Height <= count {
+				count = block.Height - 1
+			}
+			n.net.FetchSnapshotBlocks(block.PrevHash, count)
+		}
+	}
+}
+
+func (n *Node) start() (err error) {
+	if err = n.net.Start(); err != nil {
+		return
+	}
+
+	// attacker don`t care about the honest chain
+	if !n.Attacker {
+		n.net.SubscribeSnapshotBlock(func(block *ledger.SnapshotBlock, source types.BlockSource) {
+			select {
+			case n.blocks <- block:
+			default:
+			}
+		})
+
+		n.wg.Add(1)
+		go n.receiveLoop()
+	}
+
+	return nil
+}
+
+func (n *Node) stop() {
+	close(n.term)
+	n.wg.Wait()
+	_ = n.net.Stop()
+}
+
+// Simulator run many net instances in one process
+type Simulator struct {
+	cfg     Config
+	network *mock_conn.Network
+	genesis *ledger.SnapshotBlock
+	sbps    *producers
+	dataDir string
+	tempDir bool
+
+	mu        sync.Mutex
+	nodes     []*Node
+	byName    map[string]*Node
+	producers []*Node
+	attackers []*Node
+
+	slot    uint64
+	running int32
+	term    chan struct{}
+	wg      sync.WaitGroup
+
+	log log15.Logger
+}
+
+// New create all nodes of the simulation, but not start them
+func New(cfg Config) (s *Simulator, err error) {
+	if cfg.Producers < 1 {
+		return nil, errors.New("at least one producer")
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.MaxPeers == 0 {
+		cfg.MaxPeers = config.DefaultMaxPeers
+	}
+
+	s = &Simulator{
+		cfg:     cfg,
+		network: mock_conn.NewNetwork(cfg.Link),
+		genesis: newGenesis(),
+		dataDir: cfg.DataDir,
+		byName:  make(map[string]*Node),
+		term:    make(chan struct{}),
+		log:     log15.New("module", "simulator"),
+	}
+
+	if s.dataDir == "" {
+		if s.dataDir, err = ioutil.TempDir("", "net_simulator"); err != nil {
+			return nil, err
+		}
+		s.tempDir = true
+	}
+
+	var mineKeys = make([]ed25519.PrivateKey, cfg.Producers)
+	for i := range mineKeys {
+		if _, mineKeys[i], err = ed25519.GenerateKey(nil); err != nil {
+			return nil, err
+		}
+	}
+	s.sbps = newProducers(s.genesis.Hash, mineKeys)
+
+	type seed struct {
+		name    string
+		ip      string
+		mineKey ed25519.PrivateKey
+		peerKey ed25519.PrivateKey
+		vnode   *vnode.Node
+	}
+
+	// every honest node has its own subnet, attackers are run by one operator in the same subnet
+	var seeds []*seed
+	for i := 0; i < cfg.Producers+cfg.Nodes; i++ {
+		sd := &seed{}
+		if i < cfg.Producers {
+			sd.name = "sbp" + strconv.Itoa(i)
+			sd.ip = fmt.Sprintf("10.0.%d.1", i+1)
+			sd.mineKey = mineKeys[i]
+		} else {
+			sd.name = "node" + strconv.Itoa(i-cfg.Producers)
+			sd.ip = fmt.Sprintf("10.1.%d.1", i-cfg.Producers+1)
+		}
+
+		if sd.vnode, sd.peerKey, err = newVNode(sd.ip); err != nil {
+			return nil, err
+		}
+
+		seeds = append(seeds, sd)
+	}
+
+	total := len(seeds)
+	for i, sd := range seeds {
+		var static []string
+		if cfg.Degree == 0 || cfg.Degree >= total-1 {
+			for j, sd2 := range seeds {
+				if j != i {
+					static = append(static, sd2.vnode.String())
+				}
+			}
+		} else {
+			// ring lattice
+			for j := 1; j <= cfg.Degree; j++ {
+				static = append(static, seeds[(i+j)%total].vnode.String())
+			}
+		}
+
+		if _, err = s.newNode(sd.name, sd.ip, sd.peerKey, sd.mineKey, sd.vnode, static, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func newVNode(ip string) (n *vnode.Node, peerKey ed25519.PrivateKey, err error) {
+	pub, peerKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return
+	}
+
+	id, err := vnode.Bytes2NodeID(pub)
+	if err != nil {
+		return
+	}
+
+	ep, err := vnode.ParseEndPoint(ip + ":" + strconv.Itoa(port))
+	if err != nil {
+		return
+	}
+
+	n = &vnode.Node{
+		ID:       id,
+		EndPoint: ep,
+		Net:      simNetID,
+	}
+
+	return
+}
+
+func (s *Simulator) newNode(name, ip string, peerKey, mineKey ed25519.PrivateKey, vn *vnode.Node, static []string, attacker bool) (n *Node, err error) {
+	n = &Node{
+		Name:     name,
+		IP:       ip,
+		Producer: len(mineKey) != 0 && !attacker,
+		Attacker: attacker,
+		chain:    newChain(s.genesis),
+		mineKey:  mineKey,
+		vnode:    vn,
+		blocks:   make(chan *ledger.SnapshotBlock, 1000),
+		term:     make(chan struct{}),
+	}
+
+	cfg := &config.Net{
+		Name:            name,
+		NetID:           simNetID,
+		ListenInterface: ip,
+		Port:            port,
+		FilePort:        filePort,
+		DataDir:         filepath.Join(s.dataDir, name),
+		PeerKey:         hex.EncodeToString(peerKey),
+		StaticNodes:     static,
+		MaxPeers:        s.cfg.MaxPeers,
+		MaxInboundRatio: config.DefaultMaxInboundRatio,
+		MinPeers:        config.DefaultMinPeers,
+		MaxPendingPeers: config.DefaultMaxPendingPeers,
+		ForwardStrategy: config.DefaultForwardStrategy,
+		AccessControl:   config.DefaultAccessControl,
+	}
+	// the key of attackers is not a SBP key, only used to sign the forged blocks
+	if n.Producer {
+		cfg.MineKey = mineKey
+	}
+
+	if n.net, err = net.NewWithTransport(cfg, n.chain, s.sbps, s.sbps, n.chain, s.network.Host(ip)); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byName[name]; ok {
+		return nil, fmt.Errorf("node %s already exists", name)
+	}
+
+	s.byName[name] = n
+	s.nodes = append(s.nodes, n)
+	if n.Producer {
+		s.producers = append(s.producers, n)
+	}
+	if attacker {
+		s.attackers = append(s.attackers, n)
+	}
+
+	return n, nil
+}
+
+// Start all nodes and block production
+func (s *Simulator) Start() error {
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return errSimulatorIsRunning
+	}
+
+	for _, n := range s.Nodes() {
+		if err := n.start(); err != nil {
+			return fmt.Errorf("failed to start %s: %v", n, err)
+		}
+	}
+
+	s.wg.Add(1)
+	go s.produceLoop()
+
+	return nil
+}
+
+// Stop block production and all nodes, the temporary data directory will be removed
+func (s *Simulator) Stop() {
+	if !atomic.CompareAndSwapInt32(&s.running, 1, 0) {
+		return
+	}
+
+	close(s.term)
+	s.wg.Wait()
+
+	var wg sync.WaitGroup
+	for _, n := range s.Nodes() {
+		wg.Add(1)
+		go func(n *Node) {
+			defer wg.Done()
+			n.stop()
+		}(n)
+	}
+	wg.Wait()
+
+	if s.tempDir {
+		_ = os.RemoveAll(s.dataDir)
+	}
+}
+
+func (s *Simulator) produceLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.term:
+			return
+		case <-ticker.C:
+			s.produce()
+		}
+	}
+}
+
+// produce one block by the SBP of current slot, skip the slot if the SBP is offline.
+// attackers produce forged blocks on their own chains every slot.
+func (s *Simulator) produce() {
+	s.mu.Lock()
+	p := s.producers[s.slot%uint64(len(s.producers))]
+	attackers := s.attackers
+	s.slot++
+	s.mu.Unlock()
+
+	if p.Online() {
+		block := p.chain.produce(p.mineKey)
+		p.net.BroadcastSnapshotBlock(block)
+	}
+
+	for _, a := range attackers {
+		block := a.chain.produce(a.mineKey)
+		a.net.BroadcastSnapshotBlock(block)
+	}
+}
+
+// Node return the node by name
+func (s *Simulator) Node(name string) *Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.byName[name]
+}
+
+// Nodes return all nodes, include attackers
+func (s *Simulator) Nodes() []*Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := make([]*Node, len(s.nodes))
+	copy(nodes, s.nodes)
+
+	return nodes
+}
+
+// Honest return the online nodes which are not attackers
+func (s *Simulator) Honest() (nodes []*Node) {
+	for _, n := range s.Nodes() {
+		if !n.Attacker && n.Online() {
+			nodes = append(nodes, n)
+		}
+	}
+	return
+}
+
+func (s *Simulator) lookup(names []string) (nodes []*Node, err error) {
+	if len(names) == 0 {
+		return s.Honest(), nil
+	}
+
+	for _, name := range names {
+		n := s.Node(name)
+		if n == nil {
+			return nil, fmt.Errorf("%v: %s", errUnknownNode, name)
+		}
+		nodes = append(nodes, n)
+	}
+
+	return
+}
+
+// Offline disconnect the node from all other nodes, SBP will not produce blocks when offline
+func (s *Simulator) Offline(name string) error {
+	n := s.Node(name)
+	if n == nil {
+		return errUnknownNode
+	}
+
+	atomic.StoreInt32(&n.offline, 1)
+	s.network.Isolate(n.IP)
+
+	return nil
+}
+
+// Online make the offline node reachable again, it will reconnect the static nodes
+func (s *Simulator) Online(name string) error {
+	n := s.Node(name)
+	if n == nil {
+		return errUnknownNode
+	}
+
+	s.network.Rejoin(n.IP)
+	atomic.StoreInt32(&n.offline, 0)
+
+	return nil
+}
+
+// Split nodes into groups, nodes can only reach nodes in the same group, nodes not in any groups are in a same group
+func (s *Simulator) Split(groups ...[]string) error {
+	ips := make([][]string, len(groups))
+	for i, group := range groups {
+		nodes, err := s.lookup(group)
+		if err != nil {
+			return err
+		}
+		for _, n := range nodes {
+			ips[i] = append(ips[i], n.IP)
+		}
+	}
+
+	s.network.Partition(ips...)
+
+	return nil
+}
+
+// Heal remove all partitions made by Split
+func (s *Simulator) Heal() {
+	s.network.Heal()
+}
+
+// SetLink change the link quality between two nodes, existing connections are not affected
+func (s *Simulator) SetLink(a, b string, l mock_conn.Link) error {
+	n1, n2 := s.Node(a), s.Node(b)
+	if n1 == nil || n2 == nil {
+		return errUnknownNode
+	}
+
+	s.network.SetLink(n1.IP, n2.IP, l)
+
+	return nil
+}
+
+// AddAttackers create count attacker nodes connected to victim, they try to occupy all peer slots of victim,
+// and keep broadcasting a forged chain, which are produced by the attackers but not SBPs.
+func (s *Simulator) AddAttackers(victim string, count int) (attackers []*Node, err error) {
+	v := s.Node(victim)
+	if v == nil {
+		return nil, errUnknownNode
+	}
+
+	s.mu.Lock()
+	offset := len(s.attackers)
+	s.mu.Unlock()
+
+	for i := 0; i < count; i++ {
+		ip := fmt.Sprintf("10.2.0.%d", offset+i+1)
+
+		var vn *vnode.Node
+		var peerKey, mineKey ed25519.PrivateKey
+		if vn, peerKey, err = newVNode(ip); err != nil {
+			return
+		}
+		if _, mineKey, err = ed25519.GenerateKey(nil); err != nil {
+			return
+		}
+
+		var n *Node
+		n, err = s.newNode("attacker"+strconv.Itoa(offset+i), ip, peerKey, mineKey, vn, []string{v.vnode.String()}, true)
+		if err != nil {
+			return
+		}
+
+		if atomic.LoadInt32(&s.running) == 1 {
+			if err = n.start(); err != nil {
+				return
+			}
+		}
+
+		attackers = append(attackers, n)
+	}
+
+	return
+}
+
+// Forks return the count of different branches the nodes are on, nodes are the online honest nodes if names is empty.
+// Branches are compared at the lowest head of the nodes, so the nodes just fall behind one or two blocks are not forked.
+func (s *Simulator) Forks(names ...string) (int, error) {
+	nodes, err := s.lookup(names)
+	if err != nil {
+		return 0, err
+	}
+	if len(nodes) == 0 {
+		return 0, nil
+	}
+
+	var height = nodes[0].Head().Height
+	for _, n := range nodes[1:] {
+		if h := n.Head().Height; h < height {
+			height = h
+		}
+	}
+
+	branches := make(map[string]struct{})
+	for _, n := range nodes {
+		block, _ := n.chain.GetSnapshotBlockByHeight(height)
+		if block != nil {
+			branches[block.Hash.String()] = struct{}{}
+		}
+	}
+
+	return len(branches), nil
+}
+
+// Converged return nil if the nodes are on the same chain, and heights differ at most maxLag
+func (s *Simulator) Converged(maxLag uint64, names ...string) error {
+	nodes, err := s.lookup(names)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	low, high := nodes[0], nodes[0]
+	for _, n := range nodes[1:] {
+		if n.Head().Height < low.Head().Height {
+			low = n
+		}
+		if n.Head().Height > high.Head().Height {
+			high = n
+		}
+	}
+
+	if lag := high.Head().Height - low.Head().Height; lag > maxLag {
+		return fmt.Errorf("%s at %d falls behind %s at %d", low, low.Head().Height, high, high.Head().Height)
+	}
+
+	forks, err := s.Forks(names...)
+	if err != nil {
+		return err
+	}
+	if forks > 1 {
+		return fmt.Errorf("%d forks at height %d", forks, low.Head().Height)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/tools/mock_conn"
+)
+
+func TestChain_insert(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesis := newGenesis()
+	c1, c2 := newChain(genesis), newChain(genesis)
+
+	// c1: genesis - a2 - a3
+	a2 := c1.produce(key)
+	a3 := c1.produce(key)
+
+	// c2: genesis - a2 - b3 - b4
+	if !c2.insert(a2) {
+		t.Fatal("a2 should be connected")
+	}
+	b3 := c2.produce(key)
+	b4 := c2.produce(key)
+
+	// b4 is orphan before b3 received
+	if c1.insert(b4) {
+		t.Fatal("b4 should be orphan")
+	}
+	if c1.GetLatestSnapshotBlock() != a3 {
+		t.Fatal("head should be a3")
+	}
+
+	// longer branch wins
+	if !c1.insert(b3) {
+		t.Fatal("b3 should be connected")
+	}
+	if c1.GetLatestSnapshotBlock() != b4 {
+		t.Fatalf("head should be b4: %d", c1.GetLatestSnapshotBlock().Height)
+	}
+	if b, _ := c1.GetSnapshotBlockByHeight(3); b != b3 {
+		t.Error("canonical block at 3 should be b3")
+	}
+
+	blocks, err := c1.GetSnapshotBlocks(b4.Hash, false, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 3 || blocks[0] != a2 || blocks[2] != b4 {
+		t.Errorf("wrong blocks: %d", len(blocks))
+	}
+}
+
+func TestSimulator(t *testing.T) {
+	if testing.Short() {
+		t.Skip("simulation takes a long time")
+	}
+
+	log15.Root().SetHandler(log15.DiscardHandler())
+
+	s, err := New(Config{
+		Producers: 4,
+		Nodes:     4,
+		Degree:    4,
+		Interval:  300 * time.Millisecond,
+		Link: mock_conn.Link{
+			Latency:   20 * time.Millisecond,
+			Bandwidth: 1 << 20,
+			Loss:      0.01,
+		},
+		MaxPeers: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	const duration = 10 * time.Second
+
+	scenarios := []Scenario{
+		SBPOffline("sbp1", duration/2),
+		SplitAndHeal([]string{"sbp0", "sbp1", "sbp2", "node0", "node1"}, []string{"sbp3", "node2", "node3"}, duration),
+		EclipseAttempt("node3", 6, duration/2),
+	}
+
+	for _, sc := range scenarios {
+		if err = s.Run(sc); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
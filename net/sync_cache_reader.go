@@ -69,6 +69,8 @@ type cacheReader struct {
 	verifier   Verifier
 	downloader syncDownloader
 	irreader   IrreversibleReader
+	// evidence observes the snapshot blocks of sync, competing blocks may be delivered by sync only
+	evidence *evidenceCollector
 
 	running bool
 	mu      sync.Mutex
@@ -681,6 +683,7 @@ func (s *cacheReader) read(c interfaces.Segment) (chunk *Chunk, fatal bool, err
 			if err = chunk.addSnapshotBlock(sb); err != nil {
 				break
 			}
+			s.evidence.observe(sb)
 		}
 	}
 
@@ -20,7 +20,6 @@ package net
 
 import (
 	"fmt"
-	net2 "net"
 	"sort"
 	"sync"
 	"time"
@@ -132,7 +131,7 @@ type executor struct {
 	pool    *downloadConnPool
 	factory syncConnInitiator
 	dialing map[string]struct{}
-	dialer  *net2.Dialer
+	tr      Transport
 
 	listeners []taskListener
 	running   bool
@@ -141,7 +140,7 @@ type executor struct {
 	log log15.Logger
 }
 
-func newExecutor(max, batch int, peers *peerSet, factory syncConnInitiator) *executor {
+func newExecutor(max, batch int, peers *peerSet, factory syncConnInitiator, tr Transport) *executor {
 	e := &executor{
 		max:     max,
 		batch:   batch,
@@ -149,11 +148,8 @@ func newExecutor(max, batch int, peers *peerSet, factory syncConnInitiator) *exe
 		pool:    newDownloadConnPool(peers),
 		factory: factory,
 		dialing: make(map[string]struct{}),
-		dialer: &net2.Dialer{
-			Timeout:   5 * time.Second,
-			KeepAlive: 5 * time.Second,
-		},
-		log: netLog.New("module", "downloader"),
+		tr:      tr,
+		log:     netLog.New("module", "downloader"),
 	}
 
 	e.cond = sync.NewCond(&e.mu)
@@ -451,7 +447,7 @@ func (e *executor) createConn(p *Peer) (c *syncConn, err error) {
 	e.dialing[addr] = struct{}{}
 	e.mu.Unlock()
 
-	tcp, err := e.dialer.Dial("tcp", addr)
+	tcp, err := e.tr.Dial(addr, 5*time.Second)
 
 	e.mu.Lock()
 	delete(e.dialing, addr)
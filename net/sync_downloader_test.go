@@ -138,7 +138,7 @@ import (
 //}
 
 func TestExecutor_cancel(t *testing.T) {
-	exec := newExecutor(100, 3, nil, nil)
+	exec := newExecutor(100, 3, nil, nil, tcpTransport{})
 	exec.start()
 
 	exec.download(&syncTask{
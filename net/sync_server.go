@@ -47,17 +47,20 @@ type syncServer struct {
 	sconnMap map[peerId]*syncConn // key is addr
 	chain    ledgerReader
 	factory  syncConnReceiver
+	tr       Transport
+	limiter  *bandwidthLimiter
 	running  int32
 	wg       sync.WaitGroup
 	log      log15.Logger
 }
 
-func newSyncServer(addr string, chain ledgerReader, factory syncConnReceiver) *syncServer {
+func newSyncServer(addr string, chain ledgerReader, factory syncConnReceiver, tr Transport) *syncServer {
 	return &syncServer{
 		addr:     addr,
 		sconnMap: make(map[peerId]*syncConn),
 		chain:    chain,
 		factory:  factory,
+		tr:       tr,
 		log:      log15.New("module", "server"),
 	}
 }
@@ -81,7 +84,7 @@ func (s *syncServer) status() FileServerStatus {
 
 func (s *syncServer) start() error {
 	if atomic.CompareAndSwapInt32(&s.running, 0, 1) {
-		if ln, err := net2.Listen("tcp", s.addr); err != nil {
+		if ln, err := s.tr.Listen(s.addr); err != nil {
 			return err
 		} else {
 			s.ln = ln
@@ -243,8 +246,12 @@ func (s *syncServer) handleConn(conn net2.Conn) {
 		}
 
 		var wn int64
+		var w io.Writer = conn
+		if s.limiter != nil {
+			w = limitWriter{conn, s.limiter}
+		}
 		_ = conn.SetWriteDeadline(time.Now().Add(fileTimeout))
-		wn, err = io.Copy(conn, reader)
+		wn, err = io.Copy(w, reader)
 		_ = reader.Close()
 
 		if wn != int64(reader.Size()) {
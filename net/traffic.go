@@ -0,0 +1,179 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+var trafficRegistry = metrics.NewPrefixedChildRegistry(metrics.DefaultRegistry, "/net/traffic")
+
+var codeNames = map[Code]string{
+	CodeDisconnect:            "disconnect",
+	CodeHandshake:             "handshake",
+	CodeControlFlow:           "controlFlow",
+	CodeHeartBeat:             "heartBeat",
+	CodeGetHashList:           "getHashList",
+	CodeHashList:              "hashList",
+	CodeGetSnapshotBlocks:     "getSnapshotBlocks",
+	CodeSnapshotBlocks:        "snapshotBlocks",
+	CodeGetAccountBlocks:      "getAccountBlocks",
+	CodeAccountBlocks:         "accountBlocks",
+	CodeNewSnapshotBlock:      "newSnapshotBlock",
+	CodeNewAccountBlock:       "newAccountBlock",
+	CodeAccountBlockHashes:    "accountBlockHashes",
+	CodeGetAccountBlockBodies: "getAccountBlockBodies",
+	CodeEvidence:              "evidence",
+	CodeFinalityVote:          "finalityVote",
+	CodeSyncHandshake:         "syncHandshake",
+	CodeSyncHandshakeOK:       "syncHandshakeOK",
+	CodeSyncRequest:           "syncRequest",
+	CodeSyncReady:             "syncReady",
+	CodeException:             "exception",
+	CodeTrace:                 "trace",
+}
+
+func codeName(code Code) string {
+	if name, ok := codeNames[code]; ok {
+		return name
+	}
+
+	return strconv.Itoa(int(code))
+}
+
+type codeCounter struct {
+	inMsgs, inBytes   uint64
+	outMsgs, outBytes uint64
+}
+
+// traffic count messages and bytes on the wire of every message code,
+// the counts are also added to parent, so the traffic of a peer is part of the whole node.
+type traffic struct {
+	codes  [256]codeCounter
+	parent *traffic
+}
+
+func newTraffic(parent *traffic) *traffic {
+	return &traffic{
+		parent: parent,
+	}
+}
+
+func (t *traffic) in(code Code, n int) {
+	for ; t != nil; t = t.parent {
+		c := &t.codes[code]
+		atomic.AddUint64(&c.inMsgs, 1)
+		atomic.AddUint64(&c.inBytes, uint64(n))
+	}
+}
+
+func (t *traffic) out(code Code, n int) {
+	for ; t != nil; t = t.parent {
+		c := &t.codes[code]
+		atomic.AddUint64(&c.outMsgs, 1)
+		atomic.AddUint64(&c.outBytes, uint64(n))
+	}
+}
+
+// CodeTraffic is the traffic of one message code
+type CodeTraffic struct {
+	Code     string `json:"code"`
+	InMsgs   uint64 `json:"inMsgs"`
+	InBytes  uint64 `json:"inBytes"`
+	OutMsgs  uint64 `json:"outMsgs"`
+	OutBytes uint64 `json:"outBytes"`
+}
+
+// TrafficStatus is the total traffic, and traffic of codes which have messages sent or received
+type TrafficStatus struct {
+	InMsgs   uint64        `json:"inMsgs"`
+	InBytes  uint64        `json:"inBytes"`
+	OutMsgs  uint64        `json:"outMsgs"`
+	OutBytes uint64        `json:"outBytes"`
+	Codes    []CodeTraffic `json:"codes,omitempty"`
+}
+
+func (t *traffic) status() (st TrafficStatus) {
+	if t == nil {
+		return
+	}
+
+	for i := range t.codes {
+		c := &t.codes[i]
+		ct := CodeTraffic{
+			Code:     codeName(Code(i)),
+			InMsgs:   atomic.LoadUint64(&c.inMsgs),
+			InBytes:  atomic.LoadUint64(&c.inBytes),
+			OutMsgs:  atomic.LoadUint64(&c.outMsgs),
+			OutBytes: atomic.LoadUint64(&c.outBytes),
+		}
+
+		if ct.InMsgs == 0 && ct.OutMsgs == 0 {
+			continue
+		}
+
+		st.InMsgs += ct.InMsgs
+		st.InBytes += ct.InBytes
+		st.OutMsgs += ct.OutMsgs
+		st.OutBytes += ct.OutBytes
+		st.Codes = append(st.Codes, ct)
+	}
+
+	return
+}
+
+func updateTrafficMetrics(st TrafficStatus) {
+	if !metrics.MetricsEnabled {
+		return
+	}
+
+	for _, ct := range st.Codes {
+		metrics.GetOrRegisterGauge("/"+ct.Code+"/inmsgs", trafficRegistry).Update(int64(ct.InMsgs))
+		metrics.GetOrRegisterGauge("/"+ct.Code+"/inbytes", trafficRegistry).Update(int64(ct.InBytes))
+		metrics.GetOrRegisterGauge("/"+ct.Code+"/outmsgs", trafficRegistry).Update(int64(ct.OutMsgs))
+		metrics.GetOrRegisterGauge("/"+ct.Code+"/outbytes", trafficRegistry).Update(int64(ct.OutBytes))
+	}
+}
+
+// codecTraffic return the traffic of codec, nil if codec is not a transport
+func codecTraffic(c Codec) *traffic {
+	if t, ok := c.(*transport); ok {
+		return t.traffic
+	}
+
+	return nil
+}
+
+// PeerTraffic is the traffic of one peer
+type PeerTraffic struct {
+	Id      string        `json:"id"`
+	Address string        `json:"address"`
+	Traffic TrafficStatus `json:"traffic"`
+}
+
+// NetInfo is the traffic of our node and every peer
+type NetInfo struct {
+	// OutboundBandwidth is the limit of bytes sent per second, 0 means no limit
+	OutboundBandwidth int           `json:"outboundBandwidth"`
+	Traffic           TrafficStatus `json:"traffic"`
+	Peers             []PeerTraffic `json:"peers"`
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	_net "net"
+	"time"
+)
+
+// Transport create the stream listeners and connections used by peers and file sync.
+// The default implementation is TCP, simulator can replace it by an in-memory network,
+// RemoteAddr of the connections should be *net.TCPAddr, so the peer address can be extracted.
+type Transport interface {
+	Listen(address string) (_net.Listener, error)
+	// Dial connect to address, timeout 0 means no timeout
+	Dial(address string, timeout time.Duration) (_net.Conn, error)
+}
+
+const tcpKeepAlive = 5 * time.Second
+
+type tcpTransport struct{}
+
+func (tcpTransport) Listen(address string) (_net.Listener, error) {
+	return _net.Listen("tcp", address)
+}
+
+func (tcpTransport) Dial(address string, timeout time.Duration) (_net.Conn, error) {
+	d := _net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: tcpKeepAlive,
+	}
+
+	return d.Dial("tcp", address)
+}
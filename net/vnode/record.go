@@ -0,0 +1,252 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package vnode
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+)
+
+// Role is what services a node can provide, a node can have multiple roles
+type Role byte
+
+const (
+	RoleFull Role = 1 << iota
+	RoleArchive
+	RoleLightServer
+	RoleFileServer
+	RoleSBP
+)
+
+var roleNames = []struct {
+	role Role
+	name string
+}{
+	{RoleFull, "full"},
+	{RoleArchive, "archive"},
+	{RoleLightServer, "light-server"},
+	{RoleFileServer, "file-server"},
+	{RoleSBP, "sbp"},
+}
+
+// Is return true if r has all roles of r2
+func (r Role) Is(r2 Role) bool {
+	return r&r2 == r2
+}
+
+// Names return the name of every role r has
+func (r Role) Names() (names []string) {
+	for _, rn := range roleNames {
+		if r.Is(rn.role) {
+			names = append(names, rn.name)
+		}
+	}
+	return
+}
+
+func (r Role) String() string {
+	return strings.Join(r.Names(), ",")
+}
+
+var errUnknownRole = errors.New("unknown role")
+
+// ParseRole parse a role name, eg. `light-server`
+func ParseRole(name string) (Role, error) {
+	for _, rn := range roleNames {
+		if rn.name == name {
+			return rn.role, nil
+		}
+	}
+
+	return 0, errUnknownRole
+}
+
+// ParseRoles parse role names and combine them
+func ParseRoles(names []string) (r Role, err error) {
+	var r2 Role
+	for _, name := range names {
+		r2, err = ParseRole(name)
+		if err != nil {
+			return
+		}
+		r |= r2
+	}
+	return
+}
+
+// ProducerProofLength is the length of the producer proof at the head of Node.Ext,
+// the proof is producer public key and the signature of NodeID signed by the producer.
+const ProducerProofLength = 32 + 64
+
+const recordMagic byte = 0xE7
+const recordVersion byte = 0
+const recordHeadLength = 1 + 1 + 8 + 1 + 1
+const maxRecordProtocols = 16
+
+var errInvalidRecord = errors.New("invalid node record")
+var errRecordSignature = errors.New("invalid node record signature")
+var errTooManyProtocols = errors.New("too many protocol versions")
+
+// Record is a signed description of a node, carried in Node.Ext following the optional producer proof,
+// it is signed by the peer key, so it can be forwarded by other nodes but cannot be forged.
+// Seq should increase every time the record changes, the record with larger Seq is newer.
+type Record struct {
+	Seq       uint64
+	Roles     Role
+	Protocols []uint32
+}
+
+// Support return true if the node speaks the protocol version
+func (r *Record) Support(version uint32) bool {
+	for _, v := range r.Protocols {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Record) content() (data []byte, err error) {
+	if len(r.Protocols) > maxRecordProtocols {
+		return nil, errTooManyProtocols
+	}
+
+	data = make([]byte, recordHeadLength+4*len(r.Protocols))
+	data[0] = recordMagic
+	data[1] = recordVersion
+	binary.BigEndian.PutUint64(data[2:10], r.Seq)
+	data[10] = byte(r.Roles)
+	data[11] = byte(len(r.Protocols))
+
+	n := recordHeadLength
+	for _, v := range r.Protocols {
+		binary.BigEndian.PutUint32(data[n:], v)
+		n += 4
+	}
+
+	return
+}
+
+// Sign encode the record and sign it with the peer key, the node ID is the public key of peer key
+func (r *Record) Sign(key ed25519.PrivateKey) (data []byte, err error) {
+	var id NodeID
+	if id, err = Bytes2NodeID(key.PubByte()); err != nil {
+		return
+	}
+
+	content, err := r.content()
+	if err != nil {
+		return
+	}
+
+	sign := ed25519.Sign(key, append(id.Bytes(), content...))
+
+	return append(content, sign...), nil
+}
+
+// decodeRecord verify the signature and decode the record, data should be the whole signed record
+func decodeRecord(id NodeID, data []byte) (r *Record, err error) {
+	if len(data) < recordHeadLength+64 || data[0] != recordMagic || data[1] != recordVersion {
+		return nil, errInvalidRecord
+	}
+
+	count := int(data[11])
+	if count > maxRecordProtocols || len(data) != recordHeadLength+4*count+64 {
+		return nil, errInvalidRecord
+	}
+
+	content := data[:len(data)-64]
+	sign := data[len(data)-64:]
+	if false == ed25519.Verify(id.Bytes(), append(id.Bytes(), content...), sign) {
+		return nil, errRecordSignature
+	}
+
+	r = &Record{
+		Seq:       binary.BigEndian.Uint64(data[2:10]),
+		Roles:     Role(data[10]),
+		Protocols: make([]uint32, count),
+	}
+
+	n := recordHeadLength
+	for i := range r.Protocols {
+		r.Protocols[i] = binary.BigEndian.Uint32(data[n:])
+		n += 4
+	}
+
+	return r, nil
+}
+
+// ProducerProof return the producer public key if Node.Ext has a valid producer proof
+func ProducerProof(n *Node) (pub []byte, ok bool) {
+	if len(n.Ext) < ProducerProofLength {
+		return
+	}
+
+	pub = n.Ext[:32]
+	if ed25519.Verify(pub, n.ID.Bytes(), n.Ext[32:ProducerProofLength]) {
+		return pub, true
+	}
+
+	return nil, false
+}
+
+// ParseRecord retrieve the record from Node.Ext. RoleSBP will be removed if there is no valid producer proof.
+func ParseRecord(n *Node) (r *Record, err error) {
+	_, producer := ProducerProof(n)
+
+	var data []byte
+	if producer {
+		data = n.Ext[ProducerProofLength:]
+	} else {
+		data = n.Ext
+	}
+
+	r, err = decodeRecord(n.ID, data)
+	if err != nil {
+		return
+	}
+
+	if !producer {
+		r.Roles &^= RoleSBP
+	}
+
+	return
+}
+
+// SetRecord sign the record and put into Node.Ext, the producer proof will be kept.
+// key must be the private key of the node.
+func SetRecord(n *Node, key ed25519.PrivateKey, r *Record) (err error) {
+	data, err := r.Sign(key)
+	if err != nil {
+		return
+	}
+
+	var ext []byte
+	if _, ok := ProducerProof(n); ok {
+		ext = make([]byte, ProducerProofLength, ProducerProofLength+len(data))
+		copy(ext, n.Ext[:ProducerProofLength])
+	}
+
+	n.Ext = append(ext, data...)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package vnode
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+)
+
+func mockKeyNode(t *testing.T) (ed25519.PrivateKey, *Node) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := Bytes2NodeID(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return priv, &Node{
+		ID: id,
+	}
+}
+
+func TestParseRoles(t *testing.T) {
+	r, err := ParseRoles([]string{"archive", "light-server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Is(RoleArchive) || !r.Is(RoleLightServer) || r.Is(RoleSBP) {
+		t.Errorf("wrong roles: %s", r)
+	}
+	if r.String() != "archive,light-server" {
+		t.Errorf("wrong string: %s", r)
+	}
+
+	if _, err = ParseRoles([]string{"miner"}); err != errUnknownRole {
+		t.Errorf("should be unknown role: %v", err)
+	}
+}
+
+func TestSetRecord(t *testing.T) {
+	key, n := mockKeyNode(t)
+
+	var r = &Record{
+		Seq:       10,
+		Roles:     RoleFull | RoleLightServer | RoleSBP,
+		Protocols: []uint32{0, 1},
+	}
+
+	if err := SetRecord(n, key, r); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, err := ParseRecord(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2.Seq != r.Seq || !r2.Support(1) || r2.Support(2) {
+		t.Errorf("wrong record: %+v", r2)
+	}
+	// no producer proof
+	if r2.Roles.Is(RoleSBP) || !r2.Roles.Is(RoleFull|RoleLightServer) {
+		t.Errorf("wrong roles: %s", r2.Roles)
+	}
+
+	// record cannot be used by other nodes
+	_, n2 := mockKeyNode(t)
+	n2.Ext = n.Ext
+	if _, err = ParseRecord(n2); err != errRecordSignature {
+		t.Errorf("should be invalid signature: %v", err)
+	}
+}
+
+func TestSetRecord_ProducerProof(t *testing.T) {
+	key, n := mockKeyNode(t)
+	_, mineKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.Ext = make([]byte, ProducerProofLength)
+	copy(n.Ext, mineKey.PubByte())
+	copy(n.Ext[32:], ed25519.Sign(mineKey, n.ID.Bytes()))
+
+	if err = SetRecord(n, key, &Record{Seq: 1, Roles: RoleSBP}); err != nil {
+		t.Fatal(err)
+	}
+	// update record, proof should be kept
+	if err = SetRecord(n, key, &Record{Seq: 2, Roles: RoleSBP | RoleFull}); err != nil {
+		t.Fatal(err)
+	}
+
+	pub, ok := ProducerProof(n)
+	if !ok || string(pub) != string(mineKey.PubByte()) {
+		t.Fatal("producer proof should be kept")
+	}
+
+	r, err := ParseRecord(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Seq != 2 || !r.Roles.Is(RoleSBP|RoleFull) {
+		t.Errorf("wrong record: %+v", r)
+	}
+}
@@ -65,6 +65,17 @@ type Config struct {
 	BlackBlockHashList []string // from high to low, like: "xxxxxx-11111"
 	WhiteBlockList     []string // from high to low, like: "xxxxxx-10001"
 	ForwardStrategy    string
+	NodeRoles          []string
+
+	AnnounceAccountBlock    bool
+	AccountBlockPeerRate    int
+	AccountBlockAccountRate int
+
+	MaxSubnetPeers int
+	MaxBucketPeers int
+	AnchorPeers    int
+
+	OutboundBandwidth int
 
 	//producer
 	EntropyStorePath     string `json:"EntropyStorePath"`
@@ -72,6 +83,18 @@ type Config struct {
 	CoinBase             string `json:"CoinBase"`
 	MinerEnabled         bool   `json:"Miner"`
 	MinerInterval        int    `json:"MinerInterval"`
+	RemoteSigner         string `json:"RemoteSigner"`
+	RemoteSignerSecret   string `json:"RemoteSignerSecret"`
+	FailoverLease        string `json:"FailoverLease"`
+	FailoverNodeId       string `json:"FailoverNodeId"`
+	FailoverStandby      bool   `json:"FailoverStandby"`
+	FailoverMissedSlots  int    `json:"FailoverMissedSlots"`
+	FailoverLeaseTTL     int    `json:"FailoverLeaseTTL"`
+
+	// external signers holding keys outside the node, e.g. "unix:///var/run/signer.sock",
+	// they are not used by producers since they may wait for confirmation of user
+	ExternalSigners        []string `json:"ExternalSigners"`
+	ExternalSignerAccounts uint32   `json:"ExternalSignerAccounts"`
 
 	//rpc
 	RPCEnabled  bool  `json:"RPCEnabled"`
@@ -94,7 +117,11 @@ type Config struct {
 	TestTokenHexPrivKey string   `json:"TestTokenHexPrivKey"`
 	TestTokenTti        string   `json:"TestTokenTti"`
 
-	PowServerUrl string `json:"PowServerUrl"`
+	// PoW is computed in-process by PowWorkers goroutines if PowServerUrl is empty, all CPUs are used if PowWorkers is 0.
+	// PoW requested by rpc is computed in-process only if PowLocalSolve is true.
+	PowServerUrl  string `json:"PowServerUrl"`
+	PowWorkers    int    `json:"PowWorkers"`
+	PowLocalSolve bool   `json:"PowLocalSolve"`
 
 	//Log level
 	LogLevel    string `json:"LogLevel"`
@@ -115,6 +142,17 @@ type Config struct {
 	// reward
 	RewardAddr string `json:"RewardAddr"`
 
+	// analytics of SBP performance and rewards
+	AnalyticsEnabled  bool   `json:"AnalyticsEnabled"`
+	AnalyticsBackfill uint64 `json:"AnalyticsBackfill"`
+
+	// scheduling of contract onroad processing
+	OnRoadSchedulePolicy   string                   `json:"OnRoadSchedulePolicy"`
+	OnRoadContractPriority map[types.Address]int    `json:"OnRoadContractPriority"`
+	OnRoadContractWeight   map[types.Address]uint64 `json:"OnRoadContractWeight"`
+	OnRoadCallerCap        uint64                   `json:"OnRoadCallerCap"`
+	OnRoadMaxLatency       uint64                   `json:"OnRoadMaxLatency"`
+
 	//metrics
 	MetricsEnable    *bool   `json:"MetricsEnable"`
 	InfluxDBEnable   *bool   `json:"InfluxDBEnable"`
@@ -139,6 +177,8 @@ func (c *Config) makeViteConfig() *config.Config {
 		Subscribe: c.makeSubscribeConfig(),
 		Reward:    c.makeRewardConfig(),
 		Genesis:   config_gen.MakeGenesisConfig(c.GenesisFile),
+		Analytics: c.makeAnalyticsConfig(),
+		OnRoad:    c.makeOnRoadConfig(),
 		LogLevel:  c.LogLevel,
 	}
 }
@@ -147,31 +187,39 @@ func (c *Config) makeNetConfig() *config.Net {
 	datadir := filepath.Join(c.DataDir, config.DefaultNetDirName)
 
 	return &config.Net{
-		Single:             c.Single,
-		Name:               c.Identity,
-		NetID:              c.NetID,
-		ListenInterface:    c.ListenInterface,
-		Port:               c.Port,
-		FilePort:           c.FilePort,
-		PublicAddress:      c.PublicAddress,
-		FilePublicAddress:  c.FilePublicAddress,
-		DataDir:            datadir,
-		PeerKey:            c.PeerKey,
-		Discover:           c.Discover,
-		BootNodes:          c.BootNodes,
-		BootSeeds:          c.BootSeeds,
-		StaticNodes:        c.StaticNodes,
-		MaxPeers:           c.MaxPeers,
-		MaxInboundRatio:    c.MaxInboundRatio,
-		MinPeers:           c.MinPeers,
-		MaxPendingPeers:    c.MaxPendingPeers,
-		ForwardStrategy:    c.ForwardStrategy,
-		AccessControl:      c.AccessControl,
-		AccessAllowKeys:    c.AccessAllowKeys,
-		AccessDenyKeys:     c.AccessDenyKeys,
-		BlackBlockHashList: c.BlackBlockHashList,
-		WhiteBlockList:     c.WhiteBlockList,
-		MineKey:            nil,
+		Single:                  c.Single,
+		Name:                    c.Identity,
+		NetID:                   c.NetID,
+		ListenInterface:         c.ListenInterface,
+		Port:                    c.Port,
+		FilePort:                c.FilePort,
+		PublicAddress:           c.PublicAddress,
+		FilePublicAddress:       c.FilePublicAddress,
+		DataDir:                 datadir,
+		PeerKey:                 c.PeerKey,
+		Discover:                c.Discover,
+		BootNodes:               c.BootNodes,
+		BootSeeds:               c.BootSeeds,
+		StaticNodes:             c.StaticNodes,
+		MaxPeers:                c.MaxPeers,
+		MaxInboundRatio:         c.MaxInboundRatio,
+		MinPeers:                c.MinPeers,
+		MaxPendingPeers:         c.MaxPendingPeers,
+		MaxSubnetPeers:          c.MaxSubnetPeers,
+		MaxBucketPeers:          c.MaxBucketPeers,
+		AnchorPeers:             c.AnchorPeers,
+		OutboundBandwidth:       c.OutboundBandwidth,
+		ForwardStrategy:         c.ForwardStrategy,
+		Roles:                   c.NodeRoles,
+		AnnounceAccountBlock:    c.AnnounceAccountBlock,
+		AccountBlockPeerRate:    c.AccountBlockPeerRate,
+		AccountBlockAccountRate: c.AccountBlockAccountRate,
+		AccessControl:           c.AccessControl,
+		AccessAllowKeys:         c.AccessAllowKeys,
+		AccessDenyKeys:          c.AccessDenyKeys,
+		BlackBlockHashList:      c.BlackBlockHashList,
+		WhiteBlockList:          c.WhiteBlockList,
+		MineKey:                 nil,
 	}
 }
 
@@ -197,6 +245,23 @@ func (c *Config) makeSubscribeConfig() *config.Subscribe {
 	}
 }
 
+func (c *Config) makeAnalyticsConfig() *config.Analytics {
+	return &config.Analytics{
+		Enabled:  c.AnalyticsEnabled,
+		Backfill: c.AnalyticsBackfill,
+	}
+}
+
+func (c *Config) makeOnRoadConfig() *config.OnRoad {
+	return &config.OnRoad{
+		SchedulePolicy:   c.OnRoadSchedulePolicy,
+		ContractPriority: c.OnRoadContractPriority,
+		ContractWeight:   c.OnRoadContractWeight,
+		CallerCap:        c.OnRoadCallerCap,
+		MaxLatency:       c.OnRoadMaxLatency,
+	}
+}
+
 func (c *Config) makeMetricsConfig() *metrics.Config {
 	mc := &metrics.Config{
 		IsEnable:         false,
@@ -227,6 +292,15 @@ func (c *Config) makeMinerConfig() *config.Producer {
 		Producer:         c.MinerEnabled,
 		Coinbase:         c.CoinBase,
 		EntropyStorePath: c.EntropyStorePath,
+
+		RemoteSigner:       c.RemoteSigner,
+		RemoteSignerSecret: c.RemoteSignerSecret,
+
+		FailoverLease:       c.FailoverLease,
+		FailoverNodeId:      c.FailoverNodeId,
+		FailoverStandby:     c.FailoverStandby,
+		FailoverMissedSlots: c.FailoverMissedSlots,
+		FailoverLeaseTTL:    c.FailoverLeaseTTL,
 	}
 }
 
@@ -35,8 +35,14 @@ var DefaultNodeConfig = Config{
 	MaxInboundRatio: config.DefaultMaxInboundRatio,
 	MinPeers:        config.DefaultMinPeers,
 	MaxPendingPeers: config.DefaultMaxPendingPeers,
+	MaxSubnetPeers:  config.DefaultMaxSubnetPeers,
+	MaxBucketPeers:  config.DefaultMaxBucketPeers,
+	AnchorPeers:     config.DefaultAnchorPeers,
 	ForwardStrategy: config.DefaultForwardStrategy,
 	AccessControl:   config.DefaultAccessControl,
+
+	AccountBlockPeerRate:    config.DefaultAccountBlockPeerRate,
+	AccountBlockAccountRate: config.DefaultAccountBlockAccountRate,
 }
 
 // DefaultDataDir is the default data directory to use for the databases and other persistence requirements.
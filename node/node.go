@@ -27,6 +27,7 @@ import (
 	"github.com/vitelabs/go-vite/vite"
 	"github.com/vitelabs/go-vite/wallet"
 	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/external"
 )
 
 var (
@@ -130,6 +131,7 @@ func (node *Node) Prepare() (err error) {
 	//init rpc_PowServerUrl
 	remote.InitRawUrl(node.Config().PowServerUrl)
 	pow.Init(node.Config().VMTestParamEnabled)
+	pow.InitSolver(node.Config().PowWorkers)
 
 	// Start vite
 	if err = node.viteServer.Init(); err != nil {
@@ -260,6 +262,18 @@ func (node *Node) startWallet() (err error) {
 
 	}
 
+	for _, url := range node.config.ExternalSigners {
+		var b *external.Backend
+		if b, err = external.Dial(url, node.config.ExternalSignerAccounts); err != nil {
+			log.Error(fmt.Sprintf("dial external signer %v error: %v", url, err))
+			return err
+		}
+		if err = node.walletManager.AddBackend(b); err != nil {
+			b.Close()
+			return err
+		}
+	}
+
 	return nil
 }
 func (node *Node) startMetrics() {
@@ -310,7 +324,7 @@ func (node *Node) startVite() error {
 func (node *Node) startRPC() error {
 
 	// Init rpc log
-	rpcapi.Init(node.config.DataDir, node.config.LogLevel, node.config.TestTokenHexPrivKey, node.config.TestTokenTti, uint(node.config.NetID), node.config.TxDexEnable)
+	rpcapi.Init(node.config.DataDir, node.config.LogLevel, node.config.TestTokenHexPrivKey, node.config.TestTokenTti, uint(node.config.NetID), node.config.TxDexEnable, node.config.PowLocalSolve)
 
 	// Start the various API endpoints, terminating all in case of errors
 	if err := node.startInProcess(node.GetInProcessApis()); err != nil {
@@ -0,0 +1,282 @@
+package onroad
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/quota"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	// autoReceivePageSize is the number of onroad blocks loaded at a time
+	autoReceivePageSize = 50
+	// autoReceiveInterval is the interval to check the onroad blocks without signal
+	autoReceiveInterval = 5 * time.Second
+)
+
+var (
+	errAutoReceiveLocked   = errors.New("address is locked")
+	errAutoReceiveContract = errors.New("contract address can not be received automatically")
+)
+
+// AutoReceiveFilter decides which onroad blocks are received by the AutoReceiveWorker.
+// An empty TokenWhitelist accepts all tokens, a nil MinAmount accepts all amounts.
+type AutoReceiveFilter struct {
+	TokenWhitelist []types.TokenTypeId
+	MinAmount      *big.Int
+}
+
+// Match returns true if the send block should be received.
+func (f *AutoReceiveFilter) Match(sendBlock *ledger.AccountBlock) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.TokenWhitelist) > 0 {
+		found := false
+		for _, tokenId := range f.TokenWhitelist {
+			if tokenId == sendBlock.TokenId {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.MinAmount != nil {
+		if sendBlock.Amount == nil || sendBlock.Amount.Cmp(f.MinAmount) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AutoReceiveWorker receives the onroad blocks of an unlocked normal account,
+// it computes the PoW of a receive block when the quota of the account is insufficient.
+type AutoReceiveWorker struct {
+	manager *Manager
+	address types.Address
+	filter  *AutoReceiveFilter
+
+	status      int
+	statusMutex sync.Mutex
+
+	newSignal chan struct{}
+	term      chan struct{}
+	wg        sync.WaitGroup
+
+	log log15.Logger
+}
+
+// NewAutoReceiveWorker creates an AutoReceiveWorker of the address.
+func NewAutoReceiveWorker(manager *Manager, address types.Address, filter *AutoReceiveFilter) *AutoReceiveWorker {
+	return &AutoReceiveWorker{
+		manager:   manager,
+		address:   address,
+		filter:    filter,
+		status:    create,
+		newSignal: make(chan struct{}, 1),
+		log:       slog.New("worker", "a", "addr", address),
+	}
+}
+
+// Status returns the status of the worker.
+func (w *AutoReceiveWorker) Status() int {
+	w.statusMutex.Lock()
+	defer w.statusMutex.Unlock()
+	return w.status
+}
+
+// Start starts the loop of receiving.
+func (w *AutoReceiveWorker) Start() {
+	w.statusMutex.Lock()
+	defer w.statusMutex.Unlock()
+	if w.status == start {
+		return
+	}
+	w.log.Info("worker start")
+
+	w.term = make(chan struct{})
+	w.wg.Add(1)
+	go w.loop()
+	w.status = start
+}
+
+// Stop stops the loop and waits for the block in process.
+func (w *AutoReceiveWorker) Stop() {
+	w.statusMutex.Lock()
+	defer w.statusMutex.Unlock()
+	if w.status != start {
+		return
+	}
+	close(w.term)
+	w.wg.Wait()
+	w.status = stop
+	w.log.Info("worker stop")
+}
+
+// Close stops the worker.
+func (w *AutoReceiveWorker) Close() error {
+	w.Stop()
+	return nil
+}
+
+// Filter returns the filter of the worker.
+func (w *AutoReceiveWorker) Filter() *AutoReceiveFilter {
+	return w.filter
+}
+
+// NewOnRoadSignal wakes the worker up to receive the new onroad blocks.
+func (w *AutoReceiveWorker) NewOnRoadSignal() {
+	select {
+	case w.newSignal <- struct{}{}:
+	default:
+	}
+}
+
+func (w *AutoReceiveWorker) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(autoReceiveInterval)
+	defer ticker.Stop()
+
+	for {
+		w.receiveAll()
+
+		select {
+		case <-w.term:
+			return
+		case <-w.newSignal:
+		case <-ticker.C:
+		}
+	}
+}
+
+// receiveAll receives the onroad blocks matched by the filter one by one,
+// the page is reloaded after every receive block since it is deleted from onroad.
+func (w *AutoReceiveWorker) receiveAll() {
+	if w.manager.Net().SyncState() != net.SyncDone {
+		return
+	}
+
+	skipped := make(map[types.Hash]bool)
+	for pageNum := 0; ; {
+		select {
+		case <-w.term:
+			return
+		default:
+		}
+
+		blocks, err := w.manager.Chain().GetOnRoadBlocksByAddr(w.address, pageNum, autoReceivePageSize)
+		if err != nil {
+			w.log.Error(fmt.Sprintf("GetOnRoadBlocksByAddr failed, err:%v", err))
+			return
+		}
+		if len(blocks) == 0 {
+			return
+		}
+
+		var sBlock *ledger.AccountBlock
+		for _, v := range blocks {
+			if skipped[v.Hash] {
+				continue
+			}
+			if !w.filter.Match(v) {
+				skipped[v.Hash] = true
+				continue
+			}
+			sBlock = v
+			break
+		}
+		if sBlock == nil {
+			if len(blocks) < autoReceivePageSize {
+				return
+			}
+			pageNum++
+			continue
+		}
+
+		if err := w.receive(sBlock); err != nil {
+			w.log.Error(fmt.Sprintf("receive failed, err:%v", err), "s", sBlock.Hash)
+			skipped[sBlock.Hash] = true
+		}
+	}
+}
+
+func (w *AutoReceiveWorker) receive(sBlock *ledger.AccountBlock) error {
+	addrState, err := generator.GetAddressStateForGenerator(w.manager.Chain(), &w.address)
+	if err != nil || addrState == nil {
+		return fmt.Errorf("failed to get account state for generator, err:%v", err)
+	}
+
+	difficulty, err := w.powDifficulty(sBlock, addrState)
+	if err != nil {
+		return err
+	}
+
+	gen, err := generator.NewGenerator(w.manager.Chain(), w.manager.Consensus(), w.address, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
+	if err != nil {
+		return fmt.Errorf("NewGenerator failed, err:%v", err)
+	}
+	genResult, err := gen.GenerateWithOnRoad(sBlock, &w.address, w.manager.signer.SignAccountBlock, difficulty)
+	if err != nil {
+		return fmt.Errorf("GenerateWithOnRoad failed, err:%v", err)
+	}
+	if genResult.Err != nil {
+		return fmt.Errorf("vm.Run failed, err:%v", genResult.Err)
+	}
+	if genResult.VMBlock == nil {
+		return errors.New("no receive block is generated")
+	}
+
+	w.log.Info(fmt.Sprintf("insertBlockToPool %v, s[%v]", genResult.VMBlock.AccountBlock.Hash, sBlock.Hash), "pow", difficulty != nil)
+	return w.manager.insertBlockToPool(genResult.VMBlock)
+}
+
+// powDifficulty returns nil if the stake quota of the account is enough for the receive block,
+// otherwise the difficulty of PoW to get the quota required.
+func (w *AutoReceiveWorker) powDifficulty(sBlock *ledger.AccountBlock, addrState *generator.EnvPrepareForGenerator) (*big.Int, error) {
+	chain := w.manager.Chain()
+	db, err := vm_db.NewVmDb(chain, &w.address, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
+	if err != nil {
+		return nil, err
+	}
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: w.address,
+		PrevHash:       *addrState.LatestAccountHash,
+		FromBlockHash:  sBlock.Hash,
+	}
+	quotaRequired, err := vm.GasRequiredForBlock(db, block, util.QuotaTableByHeight(addrState.LatestSnapshotHeight), addrState.LatestSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	stakeAmount, err := chain.GetStakeBeneficialAmount(w.address)
+	if err != nil {
+		return nil, err
+	}
+	q, err := quota.GetQuota(db, w.address, stakeAmount, addrState.LatestSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	if q.Current() >= quotaRequired {
+		return nil, nil
+	}
+
+	if !quota.CanPoW(db, w.address) {
+		return nil, util.ErrCalcPoWTwice
+	}
+	return quota.CalcPoWDifficulty(db, quotaRequired, q, addrState.LatestSnapshotHeight)
+}
@@ -0,0 +1,53 @@
+package onroad
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestAutoReceiveFilter_Match(t *testing.T) {
+	otherTokenId, _ := types.HexToTokenTypeId("tti_251a3e67a41b5ea2373936c8")
+	send := func(tokenId types.TokenTypeId, amount int64) *ledger.AccountBlock {
+		return &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, TokenId: tokenId, Amount: big.NewInt(amount)}
+	}
+
+	cases := []struct {
+		filter *AutoReceiveFilter
+		block  *ledger.AccountBlock
+		match  bool
+	}{
+		{nil, send(otherTokenId, 0), true},
+		{&AutoReceiveFilter{}, send(otherTokenId, 1), true},
+		{&AutoReceiveFilter{TokenWhitelist: []types.TokenTypeId{ledger.ViteTokenId}}, send(ledger.ViteTokenId, 1), true},
+		{&AutoReceiveFilter{TokenWhitelist: []types.TokenTypeId{ledger.ViteTokenId}}, send(otherTokenId, 1), false},
+		{&AutoReceiveFilter{MinAmount: big.NewInt(10)}, send(otherTokenId, 10), true},
+		{&AutoReceiveFilter{MinAmount: big.NewInt(10)}, send(otherTokenId, 9), false},
+		{&AutoReceiveFilter{MinAmount: big.NewInt(10)}, &ledger.AccountBlock{TokenId: otherTokenId}, false},
+		{&AutoReceiveFilter{TokenWhitelist: []types.TokenTypeId{otherTokenId}, MinAmount: big.NewInt(10)}, send(ledger.ViteTokenId, 100), false},
+	}
+	for i, c := range cases {
+		if c.filter.Match(c.block) != c.match {
+			t.Errorf("case %d: match should be %v", i, c.match)
+		}
+	}
+}
+
+func TestAutoReceiveWorker_signal(t *testing.T) {
+	w := NewAutoReceiveWorker(&Manager{}, types.Address{}, nil)
+	if w.Status() != create {
+		t.Fatalf("status %d", w.Status())
+	}
+	// signals are merged before the worker takes them
+	w.NewOnRoadSignal()
+	w.NewOnRoadSignal()
+	if len(w.newSignal) != 1 {
+		t.Errorf("%d signals are pending", len(w.newSignal))
+	}
+	w.Stop()
+	if w.Status() != create {
+		t.Error("a worker not started should not be stopped")
+	}
+}
@@ -37,6 +37,12 @@ func (manager *Manager) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) erro
 	for addr, list := range cutMap {
 		// handle contract onroad
 		if !types.IsContractAddr(addr) {
+			for _, v := range list {
+				if v.IsSendBlock() {
+					manager.newOnRoadSignalToAutoReceiveWorker(addr)
+					break
+				}
+			}
 			continue
 		}
 		var gid types.Gid
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common"
@@ -14,6 +15,7 @@ import (
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/metrics"
 	"github.com/vitelabs/go-vite/producer/producerevent"
 	"go.uber.org/atomic"
 )
@@ -39,8 +41,9 @@ type ContractWorker struct {
 
 	contractTaskProcessors []*ContractTaskProcessor
 
-	blackList      map[types.Address]inferiorState
-	blackListMutex sync.RWMutex
+	blackList       map[types.Address]inferiorState
+	blackListReason map[types.Address]string
+	blackListMutex  sync.RWMutex
 
 	workingAddrList      map[types.Address]bool
 	workingAddrListMutex sync.RWMutex
@@ -48,6 +51,14 @@ type ContractWorker struct {
 	contractTaskPQueue contractTaskPQueue
 	ctpMutex           sync.RWMutex
 
+	policy schedulePolicy
+	// callerCount is the receive blocks of callers to contracts in the current snapshot block, for ScheduleConfig.CallerCap
+	callerCount      map[types.Address]map[types.Address]uint64
+	callerCountMutex sync.Mutex
+
+	stats      map[types.Address]*contractStat
+	statsMutex sync.Mutex
+
 	selectivePendingCache *sync.Map //map[types.Address]*callerPendingMap
 
 	log log15.Logger
@@ -63,9 +74,14 @@ func NewContractWorker(manager *Manager) *ContractWorker {
 		newBlockCond: common.NewTimeoutCond(),
 
 		blackList:             make(map[types.Address]inferiorState),
+		blackListReason:       make(map[types.Address]string),
 		workingAddrList:       make(map[types.Address]bool),
 		selectivePendingCache: &sync.Map{},
 
+		policy:      newSchedulePolicy(manager.scheduleCfg),
+		callerCount: make(map[types.Address]map[types.Address]uint64),
+		stats:       make(map[types.Address]*contractStat),
+
 		log: slog.New("worker", "contract"),
 	}
 	processors := make([]*ContractTaskProcessor, ContractTaskProcessorSize)
@@ -104,6 +120,10 @@ func (w *ContractWorker) Start(accEvent producerevent.AccountStartEvent) {
 		w.contractAddressList = addressList
 		log.Info(fmt.Sprintf("get addresslist len %v", len(addressList)))
 
+		w.policy = newSchedulePolicy(w.manager.scheduleCfg)
+		w.clearStats()
+		log.Info("schedule policy " + w.policy.name())
+
 		// 2. get getAndSortAllAddrQuota it is a heavy operation so we call it only once in start
 		w.getAndSortAllAddrQuota()
 		log.Info(fmt.Sprintf("getAndSortAllAddrQuota len %v", len(w.contractTaskPQueue)))
@@ -129,6 +149,9 @@ func (w *ContractWorker) Start(accEvent producerevent.AccountStartEvent) {
 
 		log.Info("addSnapshotEventLis", "gid", w.gid, "event", "snapshotEvent")
 		w.manager.addSnapshotEventLis(w.gid, func(latestHeight uint64) {
+			w.clearCallerCount()
+			w.updateMetrics()
+
 			pendingTask := make([]*contractTask, len(w.contractAddressList))
 			count := 0
 			for _, addr := range w.contractAddressList {
@@ -219,12 +242,15 @@ func (w *ContractWorker) getAndSortAllAddrQuota() {
 
 	w.contractTaskPQueue = make([]*contractTask, len(quotas))
 	i := 0
+	now := time.Now()
 	for addr, quota := range quotas {
 		task := &contractTask{
-			Addr:  addr,
-			Index: i,
-			Quota: quota,
+			Addr:    addr,
+			Index:   i,
+			Quota:   quota,
+			Enqueue: now,
 		}
+		w.policy.prepare(task)
 		w.contractTaskPQueue[i] = task
 		i++
 	}
@@ -247,20 +273,159 @@ func (w *ContractWorker) pushContractTask(t *contractTask) {
 	for _, v := range w.contractTaskPQueue {
 		if v.Addr == t.Addr {
 			v.Quota = t.Quota
+			w.policy.prepare(v)
 			heap.Fix(&w.contractTaskPQueue, v.Index)
 			return
 		}
 	}
+	t.Enqueue = time.Now()
+	w.policy.prepare(t)
 	heap.Push(&w.contractTaskPQueue, t)
 }
 
+// popContractTask pops the contract which has waited longer than ScheduleConfig.MaxLatency first,
+// then the top of queue.
 func (w *ContractWorker) popContractTask() *contractTask {
 	w.ctpMutex.Lock()
 	defer w.ctpMutex.Unlock()
-	if w.contractTaskPQueue.Len() > 0 {
-		return heap.Pop(&w.contractTaskPQueue).(*contractTask)
+	if w.contractTaskPQueue.Len() <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var task *contractTask
+	if maxLatency := w.manager.scheduleCfg.MaxLatency; maxLatency > 0 {
+		for _, v := range w.contractTaskPQueue {
+			if now.Sub(v.Enqueue) > maxLatency && (task == nil || v.Enqueue.Before(task.Enqueue)) {
+				task = v
+			}
+		}
+	}
+	if task != nil {
+		heap.Remove(&w.contractTaskPQueue, task.Index)
+	} else {
+		task = heap.Pop(&w.contractTaskPQueue).(*contractTask)
+	}
+
+	wait := now.Sub(task.Enqueue)
+	w.statsMutex.Lock()
+	stat := w.stat(task.Addr)
+	stat.lastWait = wait
+	if wait > stat.maxWait {
+		stat.maxWait = wait
+	}
+	w.statsMutex.Unlock()
+	if metrics.MetricsEnabled {
+		metrics.GetOrRegisterTimer("/"+task.Addr.String()+"/wait", contractRegistry).Update(wait)
+	}
+	return task
+}
+
+// stat must be called with statsMutex held
+func (w *ContractWorker) stat(addr types.Address) *contractStat {
+	stat, ok := w.stats[addr]
+	if !ok {
+		stat = &contractStat{}
+		w.stats[addr] = stat
+	}
+	return stat
+}
+
+func (w *ContractWorker) clearStats() {
+	w.statsMutex.Lock()
+	defer w.statsMutex.Unlock()
+	w.stats = make(map[types.Address]*contractStat)
+}
+
+// processed counts the receive block of a contract, returns true if the caller reaches ScheduleConfig.CallerCap.
+func (w *ContractWorker) processed(contract, caller types.Address) bool {
+	w.policy.processed(contract)
+
+	w.statsMutex.Lock()
+	w.stat(contract).processed++
+	w.statsMutex.Unlock()
+
+	callerCap := w.manager.scheduleCfg.CallerCap
+	if callerCap == 0 {
+		return false
+	}
+	w.callerCountMutex.Lock()
+	defer w.callerCountMutex.Unlock()
+	counts, ok := w.callerCount[contract]
+	if !ok {
+		counts = make(map[types.Address]uint64)
+		w.callerCount[contract] = counts
+	}
+	counts[caller]++
+	return counts[caller] >= callerCap
+}
+
+func (w *ContractWorker) clearCallerCount() {
+	w.callerCountMutex.Lock()
+	defer w.callerCountMutex.Unlock()
+	w.callerCount = make(map[types.Address]map[types.Address]uint64)
+}
+
+// Stats returns the scheduling state of all contracts of the group.
+func (w *ContractWorker) Stats() []*ContractScheduleStat {
+	now := time.Now()
+	queued := make(map[types.Address]time.Time)
+	w.ctpMutex.RLock()
+	for _, v := range w.contractTaskPQueue {
+		queued[v.Addr] = v.Enqueue
+	}
+	w.ctpMutex.RUnlock()
+
+	cfg := w.manager.scheduleCfg
+	list := make([]*ContractScheduleStat, 0, len(w.contractAddressList))
+	for _, addr := range w.contractAddressList {
+		stat := &ContractScheduleStat{
+			Addr:     addr,
+			Priority: cfg.Priority[addr],
+			Weight:   cfg.weight(addr),
+		}
+		if backlog, err := w.manager.GetOnRoadTotalNumByAddr(w.gid, addr); err == nil {
+			stat.Backlog = backlog
+		}
+		if enqueue, ok := queued[addr]; ok {
+			stat.Queued = true
+			stat.WaitTime = now.Sub(enqueue)
+		}
+
+		w.statsMutex.Lock()
+		if s, ok := w.stats[addr]; ok {
+			stat.LastWait = s.lastWait
+			stat.MaxWait = s.maxWait
+			stat.Processed = s.processed
+		}
+		w.statsMutex.Unlock()
+
+		w.blackListMutex.RLock()
+		if state, ok := w.blackList[addr]; ok {
+			stat.BlackList = state.String()
+			stat.BlackListReason = w.blackListReason[addr]
+		}
+		w.blackListMutex.RUnlock()
+
+		list = append(list, stat)
+	}
+	return list
+}
+
+// updateMetrics updates the gauges of backlog and blacklist of contracts, it is called at every snapshot block.
+func (w *ContractWorker) updateMetrics() {
+	if !metrics.MetricsEnabled {
+		return
+	}
+	for _, stat := range w.Stats() {
+		prefix := "/" + stat.Addr.String()
+		metrics.GetOrRegisterGauge(prefix+"/backlog", contractRegistry).Update(int64(stat.Backlog))
+		var blackList int64
+		if stat.BlackList != "" {
+			blackList = 1
+		}
+		metrics.GetOrRegisterGauge(prefix+"/blacklist", contractRegistry).Update(blackList)
 	}
-	return nil
 }
 
 func (w *ContractWorker) clearWorkingAddrList() {
@@ -295,12 +460,14 @@ func (w *ContractWorker) clearContractBlackList() {
 	w.blackListMutex.Lock()
 	defer w.blackListMutex.Unlock()
 	w.blackList = make(map[types.Address]inferiorState)
+	w.blackListReason = make(map[types.Address]string)
 }
 
 // Don't deal with it for this around of blocks-generating period if is OUT state
-func (w *ContractWorker) restrictContract(addr types.Address, state inferiorState) {
+func (w *ContractWorker) restrictContract(addr types.Address, state inferiorState, reason string) {
 	w.blackListMutex.Lock()
 	w.blackList[addr] = state
+	w.blackListReason[addr] = reason
 	w.blackListMutex.Unlock()
 	if state == OUT {
 		w.selectivePendingCache.Delete(addr)
@@ -327,6 +494,7 @@ func (w *ContractWorker) releaseContract(addr types.Address) (pushContractTask b
 		}
 		if s == RETRY {
 			delete(w.blackList, addr)
+			delete(w.blackListReason, addr)
 		}
 	}
 	count := w.releaseContractCallers(addr, RETRY)
@@ -9,18 +9,22 @@ import (
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/generator"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/net"
 	"github.com/vitelabs/go-vite/onroad/pool"
 	"github.com/vitelabs/go-vite/producer/producerevent"
-	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 var (
 	slog           = log15.New("module", "onroad")
 	errNotSyncDone = errors.New("network synchronization is not complete")
 
+	errContractWorkerNotWorking = errors.New("contract worker of the gid is not working")
+
 	defaultContractGidList = []types.Gid{types.DELEGATE_GID}
 )
 
@@ -28,19 +32,21 @@ var (
 type Manager struct {
 	net      netReader
 	producer producer
-	wallet   *wallet.Manager
+	signer   signer.Signer
 
 	pool      pool
 	chain     chain.Chain
 	consensus generator.Consensus
 
 	contractWorkers     map[types.Gid]*ContractWorker
+	scheduleCfg         ScheduleConfig
+	autoReceiveWorkers  sync.Map //map[types.Address]*AutoReceiveWorker
+	autoReceiveMutex    sync.Mutex
 	newContractListener sync.Map //map[types.Gid]contractReactFunc
 	newSnapshotListener sync.Map //map[types.Gid]snapshotEventReactFunc
 
 	onRoadPools sync.Map //map[types.Gid]contract_pool.OnRoadPool
 
-	unlockLid   int
 	netStateLid int
 
 	lastProducerAccEvent *producerevent.AccountStartEvent
@@ -49,14 +55,15 @@ type Manager struct {
 }
 
 // NewManager creates a onroad Manager.
-func NewManager(net netReader, pool pool, producer producer, consensus generator.Consensus, wallet *wallet.Manager) *Manager {
+func NewManager(net netReader, pool pool, producer producer, consensus generator.Consensus, s signer.Signer) *Manager {
 	m := &Manager{
 		net:             net,
 		producer:        producer,
-		wallet:          wallet,
+		signer:          s,
 		pool:            pool,
 		consensus:       consensus,
 		contractWorkers: make(map[types.Gid]*ContractWorker),
+		scheduleCfg:     ScheduleConfig{Policy: config.OnRoadPolicyQuota},
 		log:             slog.New("w", "manager"),
 	}
 	return m
@@ -72,6 +79,11 @@ func (manager *Manager) Init(chain chain.Chain) {
 	}
 }
 
+// SetScheduleConfig sets the policy of ContractWorker, it must be called before Start.
+func (manager *Manager) SetScheduleConfig(cfg ScheduleConfig) {
+	manager.scheduleCfg = cfg
+}
+
 // Start method subscribes the info of net, pool and chain module.
 func (manager *Manager) Start() {
 	manager.netStateLid = manager.Net().SubscribeSyncStatus(manager.netStateChangedFunc)
@@ -85,12 +97,12 @@ func (manager *Manager) Start() {
 func (manager *Manager) Stop() {
 	manager.log.Info("Close")
 	manager.Net().UnsubscribeSyncStatus(manager.netStateLid)
-	manager.wallet.RemoveUnlockChangeChannel(manager.unlockLid)
 	if manager.producer != nil {
 		manager.Producer().SetAccountEventFunc(nil)
 	}
 	manager.Chain().UnRegister(manager)
 	manager.stopAllWorks()
+	manager.stopAllAutoReceiveWorkers()
 	manager.log.Info("Close end")
 }
 
@@ -133,7 +145,7 @@ func (manager *Manager) producerStartEventFunc(accevent producerevent.AccountEve
 		return
 	}
 
-	if !manager.wallet.GlobalCheckAddrUnlock(event.Address) {
+	if !signer.Contains(manager.signer, event.Address) {
 		manager.log.Error("receive chain right event but address locked", "event", event)
 		return
 	}
@@ -171,6 +183,89 @@ func (manager *Manager) stopAllWorks() {
 	manager.log.Info("stopAllWorks end")
 }
 
+// StartAutoReceiveWorker starts to receive the onroad blocks of an unlocked normal account,
+// the filter of the running worker is replaced.
+func (manager *Manager) StartAutoReceiveWorker(addr types.Address, filter *AutoReceiveFilter) error {
+	if types.IsContractAddr(addr) {
+		return errAutoReceiveContract
+	}
+	if !signer.Contains(manager.signer, addr) {
+		return errAutoReceiveLocked
+	}
+
+	manager.autoReceiveMutex.Lock()
+	defer manager.autoReceiveMutex.Unlock()
+
+	if w, ok := manager.autoReceiveWorkers.Load(addr); ok {
+		w.(*AutoReceiveWorker).Stop()
+	}
+	w := NewAutoReceiveWorker(manager, addr, filter)
+	manager.autoReceiveWorkers.Store(addr, w)
+	w.Start()
+	return nil
+}
+
+// StopAutoReceiveWorker stops the AutoReceiveWorker of the address.
+func (manager *Manager) StopAutoReceiveWorker(addr types.Address) {
+	manager.autoReceiveMutex.Lock()
+	defer manager.autoReceiveMutex.Unlock()
+
+	if w, ok := manager.autoReceiveWorkers.Load(addr); ok {
+		manager.autoReceiveWorkers.Delete(addr)
+		w.(*AutoReceiveWorker).Stop()
+	}
+}
+
+// ListWorkingAutoReceiveWorker returns the addresses whose AutoReceiveWorker is running.
+func (manager *Manager) ListWorkingAutoReceiveWorker() []types.Address {
+	addrs := make([]types.Address, 0)
+	manager.autoReceiveWorkers.Range(func(k, v interface{}) bool {
+		if v.(*AutoReceiveWorker).Status() == start {
+			addrs = append(addrs, k.(types.Address))
+		}
+		return true
+	})
+	return addrs
+}
+
+// UnlockEventFunc listens to the lock events of wallet,
+// stops the AutoReceiveWorker whose address can not be signed any more.
+func (manager *Manager) UnlockEventFunc(event entropystore.UnlockEvent) {
+	if event.Unlocked() {
+		return
+	}
+	manager.log.Info("receive lock event", "event", event)
+	common.Go(func() {
+		manager.autoReceiveMutex.Lock()
+		defer manager.autoReceiveMutex.Unlock()
+
+		manager.autoReceiveWorkers.Range(func(k, v interface{}) bool {
+			if !signer.Contains(manager.signer, k.(types.Address)) {
+				manager.autoReceiveWorkers.Delete(k)
+				v.(*AutoReceiveWorker).Stop()
+			}
+			return true
+		})
+	})
+}
+
+func (manager *Manager) newOnRoadSignalToAutoReceiveWorker(addr types.Address) {
+	if w, ok := manager.autoReceiveWorkers.Load(addr); ok {
+		w.(*AutoReceiveWorker).NewOnRoadSignal()
+	}
+}
+
+func (manager *Manager) stopAllAutoReceiveWorkers() {
+	manager.autoReceiveMutex.Lock()
+	defer manager.autoReceiveMutex.Unlock()
+
+	manager.autoReceiveWorkers.Range(func(k, v interface{}) bool {
+		manager.autoReceiveWorkers.Delete(k)
+		v.(*AutoReceiveWorker).Stop()
+		return true
+	})
+}
+
 func (manager *Manager) resumeContractWorks() {
 	manager.log.Info("resumeContractWorks")
 	if manager.lastProducerAccEvent != nil {
@@ -209,6 +304,15 @@ func (manager Manager) Consensus() generator.Consensus {
 	return manager.consensus
 }
 
+// GetContractScheduleStats returns the schedule policy and the state of contracts of the working ContractWorker of gid.
+func (manager *Manager) GetContractScheduleStats(gid types.Gid) (string, []*ContractScheduleStat, error) {
+	w, ok := manager.contractWorkers[gid]
+	if !ok || w.Status() != start {
+		return "", nil, errContractWorkerNotWorking
+	}
+	return w.policy.name(), w.Stats(), nil
+}
+
 // Info returns the info of all contract.
 func (manager Manager) Info() map[string]interface{} {
 	result := make(map[string]interface{})
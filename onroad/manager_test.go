@@ -9,6 +9,7 @@ import (
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 	"os"
 	"path"
 	"testing"
@@ -64,7 +65,7 @@ func TestManager_ContractWorker(t *testing.T) {
 	addr := generateUnlockAddress()
 	v.Producer().(*mockProducer).Addr = addr
 
-	manager := NewManager(v.Net(), v.Pool(), v.Producer(), nil, tWallet)
+	manager := NewManager(v.Net(), v.Pool(), v.Producer(), nil, signer.NewLocal(tWallet))
 	manager.Init(v.chain)
 	manager.Start()
 
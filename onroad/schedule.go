@@ -0,0 +1,158 @@
+package onroad
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/math"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+// fairScale is the virtual time of a receive block of a contract with weight 1
+const fairScale = uint64(1000000)
+
+var contractRegistry = metrics.NewPrefixedChildRegistry(metrics.DefaultRegistry, "/onroad/contract")
+
+// ScheduleConfig decides the order in which the ContractWorker processes the contracts of its group.
+type ScheduleConfig struct {
+	Policy string
+	// Priority goes before the policy, a contract with higher priority is processed first.
+	Priority map[types.Address]int
+	// Weight is the share of a contract in the fair policy, 1 by default.
+	Weight map[types.Address]uint64
+	// CallerCap limits the receive blocks of a caller to a contract in a snapshot block, 0 means no limit.
+	CallerCap uint64
+	// MaxLatency is the max time a contract waits in the queue, 0 means no limit.
+	MaxLatency time.Duration
+}
+
+// NewScheduleConfig checks the onroad config of node and converts it.
+func NewScheduleConfig(cfg *config.OnRoad) (ScheduleConfig, error) {
+	sc := ScheduleConfig{Policy: config.OnRoadPolicyQuota}
+	if cfg == nil {
+		return sc, nil
+	}
+	switch cfg.SchedulePolicy {
+	case "":
+	case config.OnRoadPolicyQuota, config.OnRoadPolicyFair:
+		sc.Policy = cfg.SchedulePolicy
+	default:
+		return sc, fmt.Errorf("unknown onroad schedule policy %s", cfg.SchedulePolicy)
+	}
+	for addr, w := range cfg.ContractWeight {
+		if w == 0 {
+			return sc, fmt.Errorf("weight of contract %v is 0", addr)
+		}
+	}
+	sc.Priority = cfg.ContractPriority
+	sc.Weight = cfg.ContractWeight
+	sc.CallerCap = cfg.CallerCap
+	sc.MaxLatency = time.Duration(cfg.MaxLatency) * time.Second
+	return sc, nil
+}
+
+func (sc ScheduleConfig) weight(addr types.Address) uint64 {
+	if w, ok := sc.Weight[addr]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// schedulePolicy sets the keys by which contractTaskPQueue sorts the tasks.
+type schedulePolicy interface {
+	name() string
+	// prepare is called before the task is pushed into or fixed in the queue.
+	prepare(task *contractTask)
+	// processed is called after a receive block of the contract is inserted into pool.
+	processed(addr types.Address)
+}
+
+func newSchedulePolicy(cfg ScheduleConfig) schedulePolicy {
+	if cfg.Policy == config.OnRoadPolicyFair {
+		return &fairPolicy{cfg: cfg, finish: make(map[types.Address]uint64)}
+	}
+	return &quotaPolicy{cfg: cfg}
+}
+
+// quotaPolicy processes the contract with more stake quota first.
+type quotaPolicy struct {
+	cfg ScheduleConfig
+}
+
+func (p *quotaPolicy) name() string {
+	return config.OnRoadPolicyQuota
+}
+
+func (p *quotaPolicy) prepare(task *contractTask) {
+	task.Priority = p.cfg.Priority[task.Addr]
+	task.Score = 0
+}
+
+func (p *quotaPolicy) processed(addr types.Address) {}
+
+// fairPolicy is the start-time fair queuing of contracts, each receive block of a contract
+// costs fairScale/weight of virtual time, the contract which starts earliest in virtual time goes first.
+type fairPolicy struct {
+	cfg ScheduleConfig
+
+	mu     sync.Mutex
+	vtime  uint64
+	finish map[types.Address]uint64
+}
+
+func (p *fairPolicy) name() string {
+	return config.OnRoadPolicyFair
+}
+
+func (p *fairPolicy) start(addr types.Address) uint64 {
+	if f := p.finish[addr]; f > p.vtime {
+		return f
+	}
+	return p.vtime
+}
+
+func (p *fairPolicy) prepare(task *contractTask) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	task.Priority = p.cfg.Priority[task.Addr]
+	task.Score = math.MaxUint64 - p.start(task.Addr)
+}
+
+func (p *fairPolicy) processed(addr types.Address) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	start := p.start(addr)
+	p.vtime = start
+	p.finish[addr] = start + fairScale/p.cfg.weight(addr)
+}
+
+// ContractScheduleStat is the state of a contract in the ContractWorker of its group.
+type ContractScheduleStat struct {
+	Addr     types.Address
+	Priority int
+	Weight   uint64
+
+	// Backlog is the count of onroad blocks of the contract
+	Backlog uint64
+	// Queued is true if the contract is waiting in the queue, for WaitTime
+	Queued   bool
+	WaitTime time.Duration
+	// LastWait and MaxWait are the waiting time of the contract popped from the queue
+	LastWait time.Duration
+	MaxWait  time.Duration
+	// Processed is the count of receive blocks generated since the worker started
+	Processed uint64
+
+	BlackList       string
+	BlackListReason string
+}
+
+// contractStat is the waiting time and the work done of a contract since the worker started.
+type contractStat struct {
+	lastWait  time.Duration
+	maxWait   time.Duration
+	processed uint64
+}
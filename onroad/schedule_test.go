@@ -0,0 +1,116 @@
+package onroad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+)
+
+func newScheduleWorker(cfg ScheduleConfig, quotas map[types.Address]uint64) *ContractWorker {
+	w := NewContractWorker(&Manager{scheduleCfg: cfg})
+	for addr, q := range quotas {
+		w.pushContractTask(&contractTask{Addr: addr, Quota: q})
+	}
+	return w
+}
+
+func TestSchedulePolicy_quota(t *testing.T) {
+	a, b, c := common.MockAddress(0), common.MockAddress(1), common.MockAddress(2)
+	cfg := ScheduleConfig{Policy: config.OnRoadPolicyQuota, Priority: map[types.Address]int{c: 1}}
+	w := newScheduleWorker(cfg, map[types.Address]uint64{a: 10, b: 20, c: 1})
+
+	for i, addr := range []types.Address{c, b, a} {
+		task := w.popContractTask()
+		if task == nil || task.Addr != addr {
+			t.Fatalf("task %d should be %v: %+v", i, addr, task)
+		}
+	}
+	if w.popContractTask() != nil {
+		t.Error("queue should be empty")
+	}
+}
+
+func TestSchedulePolicy_fair(t *testing.T) {
+	a, b := common.MockAddress(0), common.MockAddress(1)
+	cfg := ScheduleConfig{Policy: config.OnRoadPolicyFair, Weight: map[types.Address]uint64{a: 3}}
+	w := newScheduleWorker(cfg, map[types.Address]uint64{a: 1, b: 1000})
+
+	// every contract always has onroad blocks, the receive blocks are shared by weight
+	counts := make(map[types.Address]int)
+	for i := 0; i < 40; i++ {
+		task := w.popContractTask()
+		counts[task.Addr]++
+		w.processed(task.Addr, common.MockAddress(10))
+		w.pushContractTask(task)
+	}
+	if counts[a] != 30 || counts[b] != 10 {
+		t.Errorf("receive blocks are not shared by weight: %v", counts)
+	}
+}
+
+func TestSchedulePolicy_maxLatency(t *testing.T) {
+	a, b := common.MockAddress(0), common.MockAddress(1)
+	cfg := ScheduleConfig{Policy: config.OnRoadPolicyQuota, MaxLatency: time.Second}
+	w := newScheduleWorker(cfg, map[types.Address]uint64{a: 1, b: 1000})
+
+	// a waits too long, it goes before b with more quota
+	for _, v := range w.contractTaskPQueue {
+		if v.Addr == a {
+			v.Enqueue = time.Now().Add(-2 * time.Second)
+		}
+	}
+	if task := w.popContractTask(); task.Addr != a {
+		t.Fatalf("contract waiting longer than max latency should go first: %v", task.Addr)
+	}
+	if w.stats[a].lastWait < 2*time.Second {
+		t.Errorf("wait of contract is %v", w.stats[a].lastWait)
+	}
+	if task := w.popContractTask(); task.Addr != b {
+		t.Fatalf("contract in time should be popped by policy: %v", task.Addr)
+	}
+}
+
+func TestContractWorker_callerCap(t *testing.T) {
+	contract, caller := common.MockAddress(0), common.MockAddress(1)
+	w := NewContractWorker(&Manager{scheduleCfg: ScheduleConfig{CallerCap: 2}})
+
+	if w.processed(contract, caller) {
+		t.Error("caller should not reach the cap")
+	}
+	if !w.processed(contract, caller) {
+		t.Error("caller should reach the cap")
+	}
+	if w.processed(contract, common.MockAddress(2)) {
+		t.Error("cap is counted per caller")
+	}
+	w.clearCallerCount()
+	if w.processed(contract, caller) {
+		t.Error("cap is counted per snapshot block")
+	}
+	if w.stats[contract].processed != 4 {
+		t.Errorf("%d blocks are processed", w.stats[contract].processed)
+	}
+}
+
+func TestNewScheduleConfig(t *testing.T) {
+	cases := []*config.OnRoad{
+		{SchedulePolicy: "unknown"},
+		{SchedulePolicy: config.OnRoadPolicyFair, ContractWeight: map[types.Address]uint64{common.MockAddress(0): 0}},
+	}
+	for i, c := range cases {
+		if _, err := NewScheduleConfig(c); err == nil {
+			t.Errorf("case %d: config should be refused", i)
+		}
+	}
+
+	cfg, err := NewScheduleConfig(&config.OnRoad{MaxLatency: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Policy != config.OnRoadPolicyQuota || cfg.MaxLatency != 3*time.Second || cfg.weight(common.MockAddress(0)) != 1 {
+		t.Errorf("config %+v", cfg)
+	}
+}
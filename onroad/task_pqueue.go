@@ -1,6 +1,8 @@
 package onroad
 
 import (
+	"time"
+
 	"github.com/vitelabs/go-vite/common/types"
 )
 
@@ -8,6 +10,12 @@ type contractTask struct {
 	Addr  types.Address
 	Index int
 	Quota uint64
+
+	// Priority and Score are set by the schedulePolicy, they go before Quota in order
+	Priority int
+	Score    uint64
+	// Enqueue is the time the contract starts waiting in the queue
+	Enqueue time.Time
 }
 
 type contractTaskPQueue []*contractTask
@@ -30,7 +38,14 @@ func (q *contractTaskPQueue) Pop() interface{} {
 func (q *contractTaskPQueue) Len() int { return len(*q) }
 
 func (q *contractTaskPQueue) Less(i, j int) bool {
-	if (*q)[i].Quota < (*q)[j].Quota {
+	a, b := (*q)[i], (*q)[j]
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Quota < b.Quota {
 		return false
 	}
 	return true
@@ -105,21 +105,14 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 		blog.Error(fmt.Sprintf("NewGenerator failed, err:%v", err))
 		return true
 	}
-	genResult, err := gen.GenerateWithOnRoad(sBlock, &tp.worker.address,
-		func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
-			_, key, _, err := tp.worker.manager.wallet.GlobalFindAddr(addr)
-			if err != nil {
-				return nil, nil, err
-			}
-			return key.SignData(data)
-		}, nil)
+	genResult, err := gen.GenerateWithOnRoad(sBlock, &tp.worker.address, tp.worker.manager.signer.SignAccountBlock, nil)
 
 
 	// judge generator result
 	if err != nil || genResult == nil {
 		blog.Error(fmt.Sprintf("GenerateWithOnRoad failed, err:%v", err))
 		if err != nil && strings.EqualFold(err.Error(), generator.ErrVmRunPanic.Error()) {
-			tp.restrictContract(task.Addr, RETRY)
+			tp.restrictContract(task.Addr, RETRY, "vm panic")
 			return false
 		}
 		return true
@@ -138,10 +131,14 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 			tp.worker.restrictContractCaller(task.Addr, sBlock.AccountAddress, OUT)
 			return true
 		}
+		if tp.worker.processed(task.Addr, sBlock.AccountAddress) {
+			blog.Info("caller reaches the cap in this snapshot block")
+			tp.worker.restrictContractCaller(task.Addr, sBlock.AccountAddress, RETRY)
+		}
 
 		if genResult.IsRetry {
 			blog.Info("impossible situation: vmBlock and vmRetry")
-			tp.restrictContract(task.Addr, OUT)
+			tp.restrictContract(task.Addr, OUT, "vm block generated with retry")
 			return false
 		}
 	} else {
@@ -157,29 +154,29 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 					blog.Info("Check quota is gone to be insufficient", "snapshotHeightWaited", snapshotHeightWaited,
 						"quota", fmt.Sprintf("(u:%v c:%v sc:%v a:%v sb:%v)", q.StakeQuotaPerSnapshotBlock(), q.Current(), q.SnapshotCurrent(), q.Avg(), addrState.LatestSnapshotHash))
 					if snapshotHeightWaited >= 3 {
-						tp.restrictContract(task.Addr, OUT)
+						tp.restrictContract(task.Addr, OUT, "insufficient quota")
 					} else {
-						tp.restrictContract(task.Addr, RETRY)
+						tp.restrictContract(task.Addr, RETRY, "insufficient quota")
 					}
 					return false
 				}
 			}
 			if genResult.Err != nil {
-				tp.restrictContract(task.Addr, RETRY)
+				tp.restrictContract(task.Addr, RETRY, "vm retry: "+genResult.Err.Error())
 				return false
 			}
 		} else {
 			// no vmBlock no vmRetry in condition that fail to create contract
 			blog.Info(fmt.Sprintf("manager.DeleteDirect, contract %v hash %v", task.Addr, sBlock.Hash))
 			tp.worker.manager.deleteDirect(sBlock)
-			tp.restrictContract(task.Addr, OUT)
+			tp.restrictContract(task.Addr, OUT, "contract creation failed")
 			return false
 		}
 	}
 	return true
 }
 
-func (tp *ContractTaskProcessor) restrictContract(addr types.Address, state inferiorState) {
-	tp.worker.restrictContract(addr, state)
-	tp.log.Info("restrict contract", "addr", addr, "state", state)
+func (tp *ContractTaskProcessor) restrictContract(addr types.Address, state inferiorState, reason string) {
+	tp.worker.restrictContract(addr, state, reason)
+	tp.log.Info("restrict contract", "addr", addr, "state", state, "reason", reason)
 }
@@ -55,6 +55,9 @@ type accountPoolBlock struct {
 	failStat *recoverStat
 	delStat  *recoverStat
 	fail     bool
+
+	// pendingTask is the result of the last verification if it is pending
+	pendingTask *verifier.AccBlockPendingTask
 }
 
 func (accB *accountPoolBlock) ReferHashes() (keys []types.Hash, accounts []types.Hash, snapshot *types.Hash) {
@@ -391,6 +394,7 @@ func (accP *accountPool) tryInsertItems(p batch.Batch, items []batch.Item, lates
 			}
 
 			stat := accP.v.verifyAccount(block, latestSb)
+			block.pendingTask = stat.taskList
 			if !block.checkForkVersion() {
 				block.resetForkVersion()
 				return errors.New("new fork version")
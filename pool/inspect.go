@@ -0,0 +1,271 @@
+package pool
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/verifier"
+)
+
+// PendingState explains why an account block is still waiting in the pool
+type PendingState string
+
+const (
+	// PendingReady means the block is in the current chain and will be inserted in the next batch
+	PendingReady PendingState = "ready"
+	// PendingWaitSnapshot means the block receives a send block which is not confirmed enough for the contract
+	PendingWaitSnapshot PendingState = "waitSnapshot"
+	// PendingWaitDependency means the block is not linked to the chain, its previous blocks are missing
+	PendingWaitDependency PendingState = "waitDependency"
+	// PendingVerify means the verifier is waiting for the blocks in PendingFor
+	PendingVerify PendingState = "verifyPending"
+	// PendingForked means the block is in a branch other than the current chain
+	PendingForked PendingState = "forked"
+	// PendingBlacklisted means the block is in the blacklist and will not be inserted until it times out
+	PendingBlacklisted PendingState = "blacklisted"
+)
+
+var errBranchNotFound = errors.New("branch not found")
+
+// PendingAccountBlock is an account block waiting in the pool
+type PendingAccountBlock struct {
+	Hash          types.Hash
+	Height        uint64
+	PrevHash      types.Hash
+	BlockType     byte
+	FromBlockHash types.Hash
+
+	// Branch is the id of the branch or snippet chain the block is in, empty for a free block
+	Branch string
+	State  PendingState
+	// PendingFor is the hashes of the blocks the verifier is waiting for
+	PendingFor []types.Hash
+	WaitTime   time.Duration
+	// Size is the serialized size of the block
+	Size int
+}
+
+// PendingAccount is the summary of the pending blocks of an account
+type PendingAccount struct {
+	Address types.Address
+	Blocks  int
+	States  map[PendingState]int
+	Size    uint64
+}
+
+// PendingStat is the summary of the pending blocks in the pool
+type PendingStat struct {
+	Accounts       int
+	AccountBlocks  int
+	SnapshotBlocks uint64
+	States         map[PendingState]int
+	// Size is the serialized size of the pending account blocks, it is an estimate of the memory usage
+	Size uint64
+}
+
+// Inspector provides typed views of the pending account blocks and lets operators evict them
+type Inspector interface {
+	PendingStat() *PendingStat
+	PendingAccounts() []*PendingAccount
+	PendingAccountBlocks(addr types.Address) []*PendingAccountBlock
+	EvictAccount(addr types.Address) int
+	BlacklistAccountBranch(addr types.Address, branchID string, duration time.Duration) (int, error)
+	UnblacklistAccount(addr types.Address) int
+}
+
+func (pl *pool) PendingStat() *PendingStat {
+	stat := &PendingStat{States: make(map[PendingState]int), SnapshotBlocks: pl.SnapshotPendingNum()}
+	for _, acc := range pl.PendingAccounts() {
+		stat.Accounts++
+		stat.AccountBlocks += acc.Blocks
+		stat.Size += acc.Size
+		for k, v := range acc.States {
+			stat.States[k] += v
+		}
+	}
+	return stat
+}
+
+func (pl *pool) PendingAccounts() []*PendingAccount {
+	var pendings []*accountPool
+	pl.pendingAc.Range(func(_, v interface{}) bool {
+		pendings = append(pendings, v.(*accountPool))
+		return true
+	})
+
+	var result []*PendingAccount
+	for _, p := range pendings {
+		blocks := p.pendingBlocks()
+		if len(blocks) == 0 {
+			continue
+		}
+		acc := &PendingAccount{Address: p.address, Blocks: len(blocks), States: make(map[PendingState]int)}
+		for _, b := range blocks {
+			acc.States[b.State]++
+			acc.Size += uint64(b.Size)
+		}
+		result = append(result, acc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Blocks > result[j].Blocks
+	})
+	return result
+}
+
+func (pl *pool) PendingAccountBlocks(addr types.Address) []*PendingAccountBlock {
+	p, ok := pl.pendingAc.Load(addr)
+	if !ok {
+		return nil
+	}
+	return p.(*accountPool).pendingBlocks()
+}
+
+// EvictAccount drops all pending blocks of the account, returns the count of blocks dropped.
+func (pl *pool) EvictAccount(addr types.Address) int {
+	pl.LockInsert()
+	defer pl.UnLockInsert()
+
+	p, ok := pl.pendingAc.Load(addr)
+	if !ok {
+		return 0
+	}
+	num := len(p.(*accountPool).pendingBlocks())
+	pl.destroyPendingAc(addr)
+	pl.log.Warn("evict account pool", "addr", addr, "blocks", num)
+	return num
+}
+
+// BlacklistAccountBranch adds the blocks of a branch of the account to the blacklist, the current chain
+// is chosen if branchID is empty. The blocks are not inserted until duration passes, or forever if it is 0.
+func (pl *pool) BlacklistAccountBranch(addr types.Address, branchID string, duration time.Duration) (int, error) {
+	p, ok := pl.pendingAc.Load(addr)
+	if !ok {
+		return 0, errBranchNotFound
+	}
+	accP := p.(*accountPool)
+	if branchID == "" {
+		branchID = accP.CurrentChain().ID()
+	}
+	num := 0
+	for _, b := range accP.pendingBlocks() {
+		if b.Branch == branchID {
+			pl.hashBlacklist.AddAddTimeout(b.Hash, duration)
+			num++
+		}
+	}
+	if num == 0 {
+		return 0, errBranchNotFound
+	}
+	pl.log.Warn("blacklist account branch", "addr", addr, "branch", branchID, "blocks", num, "duration", duration)
+	return num, nil
+}
+
+// UnblacklistAccount removes the pending blocks of the account from the blacklist, returns the count of blocks removed.
+func (pl *pool) UnblacklistAccount(addr types.Address) int {
+	p, ok := pl.pendingAc.Load(addr)
+	if !ok {
+		return 0
+	}
+	num := 0
+	for _, b := range p.(*accountPool).pendingBlocks() {
+		if b.State == PendingBlacklisted {
+			pl.hashBlacklist.Remove(b.Hash)
+			num++
+		}
+	}
+	return num
+}
+
+// pendingBlocks lists the blocks in the free pool, the snippet chains and the branches of the tree.
+// The locks are taken in the order of Compact and makePackage, chains first and the free pool last.
+func (accP *accountPool) pendingBlocks() []*PendingAccountBlock {
+	accP.chainHeadMu.Lock()
+	defer accP.chainHeadMu.Unlock()
+
+	accP.chainTailMu.Lock()
+	defer accP.chainTailMu.Unlock()
+
+	accP.blockpool.pendingMu.Lock()
+	defer accP.blockpool.pendingMu.Unlock()
+
+	var result []*PendingAccountBlock
+	cp := accP.chainpool
+	mainID := cp.tree.Main().ID()
+	for _, branch := range cp.allChain() {
+		id := branch.ID()
+		tailHeight, _ := branch.TailHH()
+		headHeight, _ := branch.HeadHH()
+		for h := tailHeight + 1; h <= headHeight; h++ {
+			k := branch.GetKnot(h, false)
+			if k == nil {
+				continue
+			}
+			block := k.(*accountPoolBlock)
+			state := PendingForked
+			if id == mainID {
+				state = accP.pendingState(block)
+			}
+			result = append(result, accP.newPendingBlock(block, id, state))
+		}
+	}
+	for id, snippet := range cp.snippetChains {
+		for _, w := range snippet.heightBlocks {
+			result = append(result, accP.newPendingBlock(w.(*accountPoolBlock), id, PendingWaitDependency))
+		}
+	}
+	for _, w := range accP.blockpool.freeBlocks {
+		result = append(result, accP.newPendingBlock(w.(*accountPoolBlock), "", PendingWaitDependency))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Height < result[j].Height
+	})
+	return result
+}
+
+// pendingState is the state of a block in the current chain.
+func (accP *accountPool) pendingState(block *accountPoolBlock) PendingState {
+	if block.pendingTask != nil {
+		return PendingVerify
+	}
+	if err := accP.checkSnapshotSuccess(block); err != nil {
+		return PendingWaitSnapshot
+	}
+	return PendingReady
+}
+
+func (accP *accountPool) newPendingBlock(block *accountPoolBlock, branch string, state PendingState) *PendingAccountBlock {
+	if accP.hashBlacklist.Exists(block.Hash()) {
+		state = PendingBlacklisted
+	}
+	pb := &PendingAccountBlock{
+		Hash:          block.block.Hash,
+		Height:        block.block.Height,
+		PrevHash:      block.block.PrevHash,
+		BlockType:     block.block.BlockType,
+		FromBlockHash: block.block.FromBlockHash,
+		Branch:        branch,
+		State:         state,
+		WaitTime:      time.Now().Sub(block.nTime),
+	}
+	if state == PendingVerify {
+		pb.PendingFor = pendingHashes(block.pendingTask, block.Hash())
+	}
+	if data, err := block.block.Serialize(); err == nil {
+		pb.Size = len(data)
+	}
+	return pb
+}
+
+// pendingHashes returns the blocks in the pending task except the block itself.
+func pendingHashes(task *verifier.AccBlockPendingTask, self types.Hash) []types.Hash {
+	var hashes []types.Hash
+	for _, v := range task.AccountTask {
+		if v.Hash != nil && *v.Hash != self {
+			hashes = append(hashes, *v.Hash)
+		}
+	}
+	return hashes
+}
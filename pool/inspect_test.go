@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool/tree"
+	"github.com/vitelabs/go-vite/verifier"
+)
+
+func newInspectAccountPool(t *testing.T, addr types.Address, bl Blacklist) *accountPool {
+	accP := newAccountPool("unittest", nil, &common.Version{}, bl, log15.New("module", "unittest"))
+	accP.address = addr
+	accP.blockpool = &blockPool{freeBlocks: make(map[types.Hash]commonBlock)}
+	accP.chainpool = &chainPool{poolID: "unittest", tree: tree.NewTree(), log: accP.log}
+	accP.chainpool.snippetChains = make(map[string]*snippetChain)
+	assert.NoError(t, accP.chainpool.tree.Init("unittest", tree.NewMockBranchRoot()))
+	return accP
+}
+
+func newInspectBlock(addr types.Address, height uint64, prev types.Hash) *accountPoolBlock {
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		Height:         height,
+		PrevHash:       prev,
+		AccountAddress: addr,
+		Amount:         big.NewInt(0),
+		Fee:            big.NewInt(0),
+	}
+	block.Hash = block.ComputeHash()
+	return newAccountPoolBlock(block, nil, &common.Version{}, types.Local)
+}
+
+func TestPool_PendingAccountBlocks(t *testing.T) {
+	addr := types.Address{1}
+	bl, err := NewBlacklist()
+	assert.NoError(t, err)
+	accP := newInspectAccountPool(t, addr, bl)
+	pl := &pool{hashBlacklist: bl, log: log15.New("module", "unittest")}
+	pl.pendingAc.Store(addr, accP)
+
+	// 3 blocks in current chain, 1 free block
+	tr := accP.chainpool.tree
+	var main []*accountPoolBlock
+	prev := types.Hash{}
+	for i := uint64(1); i <= 3; i++ {
+		b := newInspectBlock(addr, i, prev)
+		assert.NoError(t, tr.AddHead(tr.Main(), b))
+		main = append(main, b)
+		prev = b.Hash()
+	}
+	free := newInspectBlock(addr, 10, types.Hash{9})
+	accP.blockpool.freeBlocks[free.Hash()] = free
+
+	main[1].pendingTask = &verifier.AccBlockPendingTask{AccountTask: []*verifier.AccountPendingTask{
+		{Hash: &types.Hash{8}},
+		{Addr: &addr, Hash: &main[1].block.Hash},
+	}}
+	bl.Add(main[2].Hash())
+
+	blocks := pl.PendingAccountBlocks(addr)
+	assert.Equal(t, 4, len(blocks))
+	states := []PendingState{PendingReady, PendingVerify, PendingBlacklisted, PendingWaitDependency}
+	for i, b := range blocks {
+		assert.Equal(t, states[i], b.State, "block %d", b.Height)
+		assert.True(t, b.Size > 0)
+	}
+	assert.Equal(t, []types.Hash{{8}}, blocks[1].PendingFor)
+	assert.Equal(t, tr.Main().ID(), blocks[0].Branch)
+	assert.Equal(t, "", blocks[3].Branch)
+
+	accounts := pl.PendingAccounts()
+	assert.Equal(t, 1, len(accounts))
+	assert.Equal(t, 4, accounts[0].Blocks)
+	assert.Equal(t, 1, accounts[0].States[PendingWaitDependency])
+
+	// blacklist the current chain, then remove the blacklist
+	num, err := pl.BlacklistAccountBranch(addr, "", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, num)
+	_, err = pl.BlacklistAccountBranch(addr, "unknown", time.Minute)
+	assert.Equal(t, errBranchNotFound, err)
+	assert.Equal(t, 3, pl.UnblacklistAccount(addr))
+	assert.False(t, bl.Exists(main[0].Hash()))
+
+	assert.Equal(t, 4, pl.EvictAccount(addr))
+	assert.Nil(t, pl.PendingAccountBlocks(addr))
+	assert.Equal(t, 0, len(pl.PendingAccounts()))
+}
+
+func TestPool_PendingAccountBlocksWithCompact(t *testing.T) {
+	addr := types.Address{1}
+	bl, err := NewBlacklist()
+	assert.NoError(t, err)
+	accP := newInspectAccountPool(t, addr, bl)
+	// skip fetching for snippets, the pool has no syncer
+	accP.loopFetchTime = time.Now().Add(time.Hour)
+	pl := &pool{hashBlacklist: bl, log: log15.New("module", "unittest")}
+	pl.pendingAc.Store(addr, accP)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := uint64(0); i < 1000; i++ {
+			free := newInspectBlock(addr, 10+i, types.Hash{byte(i), byte(i >> 8)})
+			accP.blockpool.pendingMu.Lock()
+			accP.blockpool.freeBlocks[free.Hash()] = free
+			accP.blockpool.pendingMu.Unlock()
+			accP.Compact()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			pl.PendingAccountBlocks(addr)
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("inspect and compact are deadlocked")
+	}
+}
@@ -46,6 +46,11 @@ type Reader interface {
 	GetIrreversibleBlock() *ledger.SnapshotBlock
 }
 
+// FinalityReader reads the snapshot block finalized by votes, the pool never rolls it back
+type FinalityReader interface {
+	FinalizedSnapshotBlock() *ledger.SnapshotBlock
+}
+
 // Debug provide more detail info for BlockPool
 type Debug interface {
 	Info() map[string]interface{}
@@ -65,9 +70,11 @@ type BlockPool interface {
 	Reader
 	SnapshotProducerWriter
 	Debug
+	Inspector
 
 	Start()
 	Stop()
+	SetFinalityReader(reader FinalityReader)
 	Init(s syncer,
 		wt *wallet.Manager,
 		snapshotV *verifier.SnapshotVerifier,
@@ -162,6 +169,7 @@ type pool struct {
 
 	hashBlacklist Blacklist
 	cs            consensus.Consensus
+	final         FinalityReader
 }
 
 func (pl *pool) Snapshot() map[string]interface{} {
@@ -211,6 +219,18 @@ func NewPool(bc chainDb) (BlockPool, error) {
 	return self, nil
 }
 
+// SetFinalityReader sets the reader of the final snapshot block, forks below it are refused
+func (pl *pool) SetFinalityReader(reader FinalityReader) {
+	pl.final = reader
+}
+
+func (pl *pool) finalizedSnapshotBlock() *ledger.SnapshotBlock {
+	if pl.final == nil {
+		return nil
+	}
+	return pl.final.FinalizedSnapshotBlock()
+}
+
 func (pl *pool) Init(s syncer,
 	wt *wallet.Manager,
 	snapshotV *verifier.SnapshotVerifier,
@@ -218,7 +238,7 @@ func (pl *pool) Init(s syncer,
 	cs consensus.Consensus) {
 	pl.sync = s
 	pl.wt = wt
-	rw := &snapshotCh{version: pl.version, bc: pl.bc, log: pl.log}
+	rw := &snapshotCh{version: pl.version, bc: pl.bc, log: pl.log, final: pl.finalizedSnapshotBlock}
 	fe := &snapshotSyncer{fetcher: s, log: pl.log.New("t", "snapshot")}
 	v := &snapshotVerifier{v: snapshotV}
 	pl.accountVerifier = accountV
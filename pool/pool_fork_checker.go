@@ -163,7 +163,22 @@ func (irreversible irreversibleInfo) String() string {
 	return result
 }
 
+// checkFinalPrinciple refuses to delete snapshot blocks from height if the final block is deleted too
+func checkFinalPrinciple(final func() *ledger.SnapshotBlock, height uint64) error {
+	if final == nil {
+		return nil
+	}
+	block := final()
+	if block == nil || block.Height < height {
+		return nil
+	}
+	return errors.Errorf("check Final Principle Fail, final[%d-%s], height:%d", block.Height, block.Hash, height)
+}
+
 func (pl *pool) checkIrreversiblePrinciple(keyPoint *snapshotPoolBlock) error {
+	if err := checkFinalPrinciple(pl.finalizedSnapshotBlock, keyPoint.Height()); err != nil {
+		return err
+	}
 	info := pl.pendingSc.irreversible
 	if info == nil {
 		return nil
@@ -238,14 +253,13 @@ func (pl *pool) getLatestIrreversibleBlock(lastProofPoint *ledger.SnapshotBlock)
 			lastIdx = ti.Time2Index(*block.Timestamp)
 		}
 	}
-	return pl.getLatestIrreversible(lastIdx, nodeCnt, ti)
+	return pl.getLatestIrreversible(lastIdx, nodeCnt, pl.cs.Engine().IrreversibleCount(), ti)
 }
 
-func (pl *pool) getLatestIrreversible(lastIdx uint64, nodeCnt int, ti core.TimeIndex) (*irreversibleInfo, error) {
+func (pl *pool) getLatestIrreversible(lastIdx uint64, nodeCnt int, irreversibleCnt uint64, ti core.TimeIndex) (*irreversibleInfo, error) {
 	if nodeCnt < 3 {
 		return nil, nil
 	}
-	irreversibleCnt := uint64(nodeCnt/3*2 + 1)
 
 	head := pl.bc.GetLatestSnapshotBlock()
 	latest := head
@@ -1 +1,56 @@
 package pool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+type mockFinalityReader struct {
+	final *ledger.SnapshotBlock
+}
+
+func (r *mockFinalityReader) FinalizedSnapshotBlock() *ledger.SnapshotBlock {
+	return r.final
+}
+
+// mockDeleteChain records the heights deleted, the real chain exits if a delete listener fails
+type mockDeleteChain struct {
+	chainDb
+	deleted []uint64
+}
+
+func (c *mockDeleteChain) DeleteSnapshotBlocksToHeight(toHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	c.deleted = append(c.deleted, toHeight)
+	return nil, nil
+}
+
+func TestPool_ForkBelowFinal(t *testing.T) {
+	bc := &mockDeleteChain{}
+	pl := &pool{bc: bc, version: &common.Version{}, pendingSc: &snapshotPool{}, log: log15.New("module", "unittest")}
+	rw := &snapshotCh{bc: bc, version: pl.version, log: pl.log, final: pl.finalizedSnapshotBlock}
+
+	keyPoint := func(height uint64) *snapshotPoolBlock {
+		return newSnapshotPoolBlock(&ledger.SnapshotBlock{Height: height, Hash: types.Hash{byte(height)}}, pl.version, types.RemoteBroadcast)
+	}
+
+	// nothing is final without a reader
+	assert.NoError(t, pl.checkIrreversiblePrinciple(keyPoint(5)))
+
+	pl.SetFinalityReader(&mockFinalityReader{final: &ledger.SnapshotBlock{Height: 10, Hash: types.Hash{10}}})
+	assert.Error(t, pl.checkIrreversiblePrinciple(keyPoint(5)))
+	assert.Error(t, pl.checkIrreversiblePrinciple(keyPoint(10)))
+	assert.NoError(t, pl.checkIrreversiblePrinciple(keyPoint(11)))
+
+	// the final block is never deleted from the chain
+	_, _, err := rw.delToHeight(10)
+	assert.Error(t, err)
+	assert.Empty(t, bc.deleted)
+	_, _, err = rw.delToHeight(11)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{11}, bc.deleted)
+}
@@ -209,6 +209,7 @@ type snapshotCh struct {
 	bc      chainDb
 	version *common.Version
 	log     log15.Logger
+	final   func() *ledger.SnapshotBlock
 }
 
 func (sCh *snapshotCh) getBlock(height uint64) commonBlock {
@@ -262,6 +263,9 @@ func (sCh *snapshotCh) getSnapshotBlockByHash(hash types.Hash) *ledger.SnapshotB
 }
 
 func (sCh *snapshotCh) delToHeight(height uint64) ([]commonBlock, map[types.Address][]commonBlock, error) {
+	if err := checkFinalPrinciple(sCh.final, height); err != nil {
+		return nil, nil, err
+	}
 	schunk, e := sCh.bc.DeleteSnapshotBlocksToHeight(height)
 
 	if e != nil {
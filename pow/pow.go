@@ -1,13 +1,12 @@
 package pow
 
 import (
+	"context"
 	"github.com/vitelabs/go-vite/common/helper"
 	"math/big"
 
 	"encoding/binary"
-	"errors"
 	"github.com/vitelabs/go-vite/common/types"
-	"github.com/vitelabs/go-vite/crypto"
 	"golang.org/x/crypto/blake2b"
 )
 
@@ -20,35 +19,31 @@ const (
 var defaultTarget = new(big.Int).SetUint64(FullThreshold)
 var VMTestParamEnabled = false
 
+var defaultSolver = NewSolver(0)
+
 func Init(vMTestParamEnabled bool) {
 	VMTestParamEnabled = vMTestParamEnabled
 }
 
+// InitSolver sets the count of workers of the default solver, runtime.NumCPU() is used if workers is 0.
+func InitSolver(workers int) {
+	defaultSolver = NewSolver(workers)
+}
+
+// DefaultSolver returns the solver used by GetPowNonce.
+func DefaultSolver() *Solver {
+	return defaultSolver
+}
+
 // data = Hash(address + prehash); data + nonce < target.
+// The nonce is computed by the default solver with all workers.
 func GetPowNonce(difficulty *big.Int, dataHash types.Hash) ([]byte, error) {
-	var target *big.Int = nil
-	if VMTestParamEnabled {
-		target = defaultTarget
-	} else {
-		if difficulty == nil {
-			return nil, errors.New("difficulty can't be nil")
-		}
-		target = DifficultyToTarget(difficulty)
-		if target == nil || target.BitLen() > 256 {
-			return nil, errors.New("target too long")
-		}
-	}
+	return defaultSolver.Solve(context.Background(), difficulty, dataHash)
+}
 
-	data := dataHash.Bytes()
-	target256 := helper.LeftPadBytes(target.Bytes(), 32)
-	for {
-		nonce := crypto.GetEntropyCSPRNG(8)
-		out := powHash256(nonce, data)
-		if QuickGreater(out, target256) {
-			return nonce, nil
-		}
-	}
-	return nil, errors.New("get pow nonce error")
+// GetPowNonceWithContext is the same as GetPowNonce, except that it can be canceled by ctx.
+func GetPowNonceWithContext(ctx context.Context, difficulty *big.Int, dataHash types.Hash) ([]byte, error) {
+	return defaultSolver.Solve(ctx, difficulty, dataHash)
 }
 
 func powHash256(nonce []byte, data []byte) []byte {
@@ -73,6 +68,15 @@ func CheckPowNonce(difficulty *big.Int, nonce []byte, data []byte) bool {
 	return QuickGreater(out, helper.LeftPadBytes(target.Bytes(), 32))
 }
 
+// CheckPowNonceByTarget is the same as CheckPowNonce, except that the target is given instead of the difficulty.
+func CheckPowNonceByTarget(target *big.Int, nonce []byte, data []byte) bool {
+	if target == nil || target.BitLen() > 256 {
+		return false
+	}
+	out := powHash256(nonce, data)
+	return QuickGreater(out, helper.LeftPadBytes(target.Bytes(), 32))
+}
+
 func QuickInc(x []byte) []byte {
 	for i := 1; i <= len(x); i++ {
 		x[len(x)-i] = x[len(x)-i] + 1
@@ -3,6 +3,8 @@ package remote
 type workGenerate struct {
 	DataHash  string `json:"hash"`
 	Threshold string `json:"threshold"`
+	// Token is the secret of the client, only the client knowing it can cancel the work
+	Token string `json:"token"`
 }
 
 type workValidate struct {
@@ -13,4 +15,5 @@ type workValidate struct {
 
 type workCancel struct {
 	DataHash string `json:"hash"`
+	Token    string `json:"token"`
 }
@@ -0,0 +1,485 @@
+package remote
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/pow"
+)
+
+// ApiActionStatus returns the ServerStatus of the pow server
+const ApiActionStatus = "/api/status"
+
+const (
+	maxRequestSize = 1024
+	// clientIdleTimeout is the time after which the rate limiter of an idle client is dropped
+	clientIdleTimeout = 10 * time.Minute
+)
+
+const (
+	codeError = 1 + iota
+	codeRateLimited
+	codeQueueFull
+	codeCanceled
+)
+
+var (
+	errRateLimited = errors.New("too many requests")
+	errQueueFull   = errors.New("work queue is full")
+	errNotFound    = errors.New("work not found")
+	errNoToken     = errors.New("token is required")
+)
+
+var serverRegistry = metrics.NewPrefixedChildRegistry(metrics.DefaultRegistry, "/pow/server")
+
+// ServerConfig is the config of the pow server
+type ServerConfig struct {
+	// Solvers is the count of works solved at the same time
+	Solvers int
+	// Workers is the count of goroutines of every solver, all CPUs are used if 0
+	Workers int
+	// QueueSize is the max count of works waiting for a solver
+	QueueSize int
+	// Timeout is the max time to solve a work, no limit if 0
+	Timeout time.Duration
+	// RateLimit is the count of requests per second allowed for a client, no limit if 0
+	RateLimit float64
+	// RateBurst is the count of requests a client can send at once
+	RateBurst int
+}
+
+// ServerStatus is the metrics of the pow server
+type ServerStatus struct {
+	Waiting      int    `json:"waiting"`
+	Solving      int    `json:"solving"`
+	Clients      int    `json:"clients"`
+	Generated    uint64 `json:"generated"`
+	Deduplicated uint64 `json:"deduplicated"`
+	Canceled     uint64 `json:"canceled"`
+	Rejected     uint64 `json:"rejected"`
+	RateLimited  uint64 `json:"rateLimited"`
+	Hashes       uint64 `json:"hashes"`
+}
+
+type workKey struct {
+	dataHash types.Hash
+	target   string
+}
+
+// waiter is a request waiting for a work, it is canceled by the client of the token only
+type waiter struct {
+	token    string
+	canceled chan struct{}
+}
+
+// work is shared by the requests of the same data hash and threshold
+type work struct {
+	key     workKey
+	target  *big.Int
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters map[*waiter]struct{}
+	done    chan struct{}
+	nonce   []byte
+	err     error
+}
+
+// limiter is a token bucket of a client
+type limiter struct {
+	tokens float64
+	last   time.Time
+}
+
+// Server serves the pow remote protocol by a pool of local solvers. The works of the same data hash
+// and threshold are deduplicated, a cancel request stops waiting for the works of the data hash submitted
+// with the same token, a work is stopped if nobody else waits for it.
+type Server struct {
+	cfg     ServerConfig
+	solvers chan *pow.Solver
+	all     []*pow.Solver
+
+	mu       sync.Mutex
+	works    map[workKey]*work
+	waiting  int
+	solving  int
+	limiters map[string]*limiter
+
+	generated    uint64
+	deduplicated uint64
+	canceled     uint64
+	rejected     uint64
+	rateLimited  uint64
+
+	log log15.Logger
+}
+
+func NewServer(cfg ServerConfig) *Server {
+	if cfg.Solvers <= 0 {
+		cfg.Solvers = 1
+	}
+	if cfg.RateBurst <= 0 {
+		cfg.RateBurst = 1
+	}
+	s := &Server{
+		cfg:      cfg,
+		solvers:  make(chan *pow.Solver, cfg.Solvers),
+		works:    make(map[workKey]*work),
+		limiters: make(map[string]*limiter),
+		log:      log15.New("module", "pow/server"),
+	}
+	for i := 0; i < cfg.Solvers; i++ {
+		solver := pow.NewSolver(cfg.Workers)
+		s.all = append(s.all, solver)
+		s.solvers <- solver
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.URL.Path == ApiActionStatus {
+		writeResponse(w, 0, s.Status(), nil)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeResponse(w, codeError, nil, errors.Errorf("unknown request %s %s", r.Method, r.URL.Path))
+		return
+	}
+	if !s.allow(clientHost(r)) {
+		atomic.AddUint64(&s.rateLimited, 1)
+		metrics.GetOrRegisterCounter("/ratelimited", serverRegistry).Inc(1)
+		writeResponse(w, codeRateLimited, nil, errRateLimited)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		writeResponse(w, codeError, nil, err)
+		return
+	}
+
+	switch r.URL.Path {
+	case ApiActionGenerate:
+		req := &workGenerate{}
+		if err = json.Unmarshal(body, req); err != nil {
+			writeResponse(w, codeError, nil, err)
+			return
+		}
+		s.generate(r.Context(), w, req)
+	case ApiActionValidate:
+		req := &workValidate{}
+		if err = json.Unmarshal(body, req); err != nil {
+			writeResponse(w, codeError, nil, err)
+			return
+		}
+		valid, err := validate(req)
+		if err != nil {
+			writeResponse(w, codeError, nil, err)
+			return
+		}
+		result := &workValidateResult{Valid: "0"}
+		if valid {
+			result.Valid = "1"
+		}
+		writeResponse(w, 0, result, nil)
+	case ApiActionCancel:
+		req := &workCancel{}
+		if err = json.Unmarshal(body, req); err != nil {
+			writeResponse(w, codeError, nil, err)
+			return
+		}
+		dataHash, err := parseDataHash(req.DataHash)
+		if err != nil {
+			writeResponse(w, codeError, nil, err)
+			return
+		}
+		if req.Token == "" {
+			writeResponse(w, codeError, nil, errNoToken)
+			return
+		}
+		if !s.Cancel(dataHash, req.Token) {
+			writeResponse(w, codeError, nil, errNotFound)
+			return
+		}
+		writeResponse(w, 0, &workCancelResult{}, nil)
+	default:
+		writeResponse(w, codeError, nil, errors.Errorf("unknown request %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) generate(ctx context.Context, w http.ResponseWriter, req *workGenerate) {
+	dataHash, err := parseDataHash(req.DataHash)
+	if err != nil {
+		writeResponse(w, codeError, nil, err)
+		return
+	}
+	target, ok := new(big.Int).SetString(req.Threshold, 16)
+	if !ok || target.Sign() <= 0 || target.BitLen() > 256 {
+		writeResponse(w, codeError, nil, errors.New("invalid threshold"))
+		return
+	}
+
+	wt := &waiter{token: req.Token, canceled: make(chan struct{})}
+	wk, err := s.join(workKey{dataHash: dataHash, target: target.Text(16)}, target, wt)
+	if err != nil {
+		atomic.AddUint64(&s.rejected, 1)
+		metrics.GetOrRegisterCounter("/rejected", serverRegistry).Inc(1)
+		writeResponse(w, codeQueueFull, nil, err)
+		return
+	}
+
+	select {
+	case <-wk.done:
+	case <-wt.canceled:
+		writeResponse(w, codeCanceled, nil, pow.ErrPowCanceled)
+		return
+	case <-ctx.Done():
+		// the client is gone, stop the work if nobody else waits for it
+		s.leave(wk, wt)
+		return
+	}
+	s.leave(wk, wt)
+
+	if wk.err != nil {
+		writeResponse(w, codeCanceled, nil, wk.err)
+		return
+	}
+	work := strconv.FormatUint(binary.LittleEndian.Uint64(wk.nonce), 16)
+	writeResponse(w, 0, &workGenerateResult{Work: work}, nil)
+}
+
+// join returns the work of the key, a new work is queued if it does not exist.
+func (s *Server) join(key workKey, target *big.Int, wt *waiter) (*work, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if wk, ok := s.works[key]; ok {
+		wk.waiters[wt] = struct{}{}
+		atomic.AddUint64(&s.deduplicated, 1)
+		return wk, nil
+	}
+	// the works more than the idle solvers wait in the queue
+	if s.waiting >= s.cfg.QueueSize+s.cfg.Solvers-s.solving {
+		return nil, errQueueFull
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if s.cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), s.cfg.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	wk := &work{
+		key:     key,
+		target:  target,
+		ctx:     ctx,
+		cancel:  cancel,
+		waiters: map[*waiter]struct{}{wt: {}},
+		done:    make(chan struct{}),
+	}
+	s.works[key] = wk
+	s.waiting++
+	go s.solve(wk)
+	return wk, nil
+}
+
+func (s *Server) leave(wk *work, wt *waiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeWaiter(wk, wt)
+}
+
+// removeWaiter stops the work if nobody else waits for it, s.mu must be held.
+func (s *Server) removeWaiter(wk *work, wt *waiter) {
+	delete(wk.waiters, wt)
+	if len(wk.waiters) == 0 {
+		wk.cancel()
+		s.removeWork(wk)
+	}
+}
+
+func (s *Server) solve(wk *work) {
+	defer wk.cancel()
+	defer close(wk.done)
+
+	var solver *pow.Solver
+	select {
+	case solver = <-s.solvers:
+	case <-wk.ctx.Done():
+	}
+
+	s.mu.Lock()
+	s.waiting--
+	if solver != nil {
+		s.solving++
+	}
+	s.mu.Unlock()
+
+	if solver == nil {
+		wk.err = pow.ErrPowCanceled
+	} else {
+		startTime := time.Now()
+		wk.nonce, wk.err = solver.SolveTarget(wk.ctx, wk.target, wk.key.dataHash)
+		s.solvers <- solver
+		metrics.GetOrRegisterResettingTimer("/solve", serverRegistry).UpdateSince(startTime)
+	}
+
+	s.mu.Lock()
+	if solver != nil {
+		s.solving--
+	}
+	s.removeWork(wk)
+	s.mu.Unlock()
+
+	if wk.err != nil {
+		atomic.AddUint64(&s.canceled, 1)
+		metrics.GetOrRegisterCounter("/canceled", serverRegistry).Inc(1)
+		s.log.Info("work canceled", "hash", wk.key.dataHash, "err", wk.err)
+		return
+	}
+	atomic.AddUint64(&s.generated, 1)
+	metrics.GetOrRegisterCounter("/generated", serverRegistry).Inc(1)
+}
+
+// removeWork deletes the work from the map unless it is replaced by a new one, s.mu must be held.
+func (s *Server) removeWork(wk *work) {
+	if s.works[wk.key] == wk {
+		delete(s.works, wk.key)
+	}
+}
+
+// Cancel stops waiting for the works of the data hash submitted with the token, returns false if there is none.
+func (s *Server) Cancel(dataHash types.Hash, token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	for key, wk := range s.works {
+		if key.dataHash != dataHash {
+			continue
+		}
+		for wt := range wk.waiters {
+			if wt.token == token {
+				close(wt.canceled)
+				s.removeWaiter(wk, wt)
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// Stop cancels all works.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, wk := range s.works {
+		wk.cancel()
+	}
+}
+
+func (s *Server) Status() *ServerStatus {
+	status := &ServerStatus{
+		Generated:    atomic.LoadUint64(&s.generated),
+		Deduplicated: atomic.LoadUint64(&s.deduplicated),
+		Canceled:     atomic.LoadUint64(&s.canceled),
+		Rejected:     atomic.LoadUint64(&s.rejected),
+		RateLimited:  atomic.LoadUint64(&s.rateLimited),
+	}
+	for _, solver := range s.all {
+		status.Hashes += solver.Status().Hashes
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status.Waiting = s.waiting
+	status.Solving = s.solving
+	status.Clients = len(s.limiters)
+	return status
+}
+
+// allow takes a token from the bucket of the client, the idle clients are dropped by the way.
+func (s *Server) allow(client string) bool {
+	if s.cfg.RateLimit <= 0 {
+		return true
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, l := range s.limiters {
+		if now.Sub(l.last) > clientIdleTimeout {
+			delete(s.limiters, k)
+		}
+	}
+
+	l, ok := s.limiters[client]
+	if !ok {
+		l = &limiter{tokens: float64(s.cfg.RateBurst), last: now}
+		s.limiters[client] = l
+	}
+	l.tokens += now.Sub(l.last).Seconds() * s.cfg.RateLimit
+	if l.tokens > float64(s.cfg.RateBurst) {
+		l.tokens = float64(s.cfg.RateBurst)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+func validate(req *workValidate) (bool, error) {
+	data, err := hex.DecodeString(req.DataHash)
+	if err != nil {
+		return false, err
+	}
+	nonce, err := hex.DecodeString(req.Work)
+	if err != nil {
+		return false, err
+	}
+	target, ok := new(big.Int).SetString(req.Threshold, 16)
+	if !ok {
+		return false, errors.New("invalid threshold")
+	}
+	return pow.CheckPowNonceByTarget(target, nonce, data), nil
+}
+
+func parseDataHash(str string) (types.Hash, error) {
+	data, err := hex.DecodeString(str)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return types.BytesToHash(data)
+}
+
+func clientHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeResponse(w http.ResponseWriter, code int, data interface{}, err error) {
+	res := &ResponseJson{Code: code, Data: data, Msg: "ok"}
+	if err != nil {
+		res.Error = err.Error()
+		res.Msg = "error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
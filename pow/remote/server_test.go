@@ -0,0 +1,130 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/pow"
+)
+
+func TestServer_GenerateWork(t *testing.T) {
+	server := NewServer(ServerConfig{Solvers: 2, Workers: 2, QueueSize: 4})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	InitRawUrl(ts.URL)
+	defer InitRawUrl("")
+
+	difficulty := big.NewInt(1000000)
+	data := types.DataHash([]byte{1}).Bytes()
+
+	// the same work is solved once for the concurrent requests
+	var wg sync.WaitGroup
+	works := make([]string, 3)
+	for i := range works {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			work, err := GenerateWork(data, difficulty)
+			if assert.NoError(t, err) {
+				works[i] = *work
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, work := range works {
+		nonceBig, ok := new(big.Int).SetString(work, 16)
+		assert.True(t, ok)
+		nonce := make([]byte, 8)
+		binary.LittleEndian.PutUint64(nonce, nonceBig.Uint64())
+		assert.True(t, pow.CheckPowNonce(difficulty, nonce, data))
+
+		valid, err := VaildateWork(data, pow.DifficultyToTarget(difficulty), nonce)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	status := server.Status()
+	assert.Equal(t, uint64(3), status.Generated+status.Deduplicated)
+	assert.True(t, status.Generated >= 1)
+	assert.Equal(t, 0, status.Solving+status.Waiting)
+}
+
+func TestServer_CancelWork(t *testing.T) {
+	server := NewServer(ServerConfig{Solvers: 1, Workers: 1})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	InitRawUrl(ts.URL)
+	defer InitRawUrl("")
+
+	difficulty, _ := new(big.Int).SetString("1000000000000000000", 10)
+	data := types.DataHash([]byte{1}).Bytes()
+
+	errCh := make(chan error)
+	go func() {
+		_, err := GenerateWork(data, difficulty)
+		errCh <- err
+	}()
+
+	// the queue is full while the only solver is busy
+	time.Sleep(100 * time.Millisecond)
+	_, err := GenerateWork(types.DataHash([]byte{2}).Bytes(), difficulty)
+	assert.EqualError(t, err, errQueueFull.Error())
+
+	// other clients can not cancel the work
+	cancel := func(token string) int {
+		body, _ := json.Marshal(&workCancel{DataHash: hex.EncodeToString(data), Token: token})
+		resp, err := http.Post(ts.URL+ApiActionCancel, "application/json", bytes.NewReader(body))
+		if !assert.NoError(t, err) {
+			return -1
+		}
+		defer resp.Body.Close()
+		res := &ResponseJson{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+		return res.Code
+	}
+	assert.Equal(t, codeError, cancel(""))
+	assert.Equal(t, codeError, cancel(newClientToken()))
+	assert.Equal(t, 1, server.Status().Solving)
+
+	assert.NoError(t, CancelWork(data))
+	assert.EqualError(t, <-errCh, pow.ErrPowCanceled.Error())
+	assert.Error(t, CancelWork(data))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, uint64(1), server.Status().Canceled)
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	server := NewServer(ServerConfig{RateLimit: 0.001, RateBurst: 2})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	body, _ := json.Marshal(&workValidate{
+		DataHash:  hex.EncodeToString(types.DataHash([]byte{1}).Bytes()),
+		Threshold: "ff",
+		Work:      "0000000000000000",
+	})
+	codes := make([]int, 3)
+	for i := range codes {
+		resp, err := http.Post(ts.URL+ApiActionValidate, "application/json", bytes.NewReader(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		res := &ResponseJson{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+		resp.Body.Close()
+		codes[i] = res.Code
+	}
+	assert.Equal(t, []int{0, 0, codeRateLimited}, codes)
+	assert.Equal(t, uint64(1), server.Status().RateLimited)
+}
@@ -2,6 +2,7 @@ package remote
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"github.com/pkg/errors"
@@ -15,6 +16,8 @@ import (
 var (
 	powClientLog = log15.New("module", "pow_request")
 	requestUrl   string
+	// clientToken ties the works to this process, other clients of the server can not cancel them
+	clientToken = newClientToken()
 )
 
 const (
@@ -24,15 +27,29 @@ const (
 	// DefaultThreshold  = "FFFFFFC000000000000000000000000000000000000000000000000000000000"
 )
 
+func newClientToken() string {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(token)
+}
+
 func InitRawUrl(rawurl string) {
 	requestUrl = rawurl
 }
 
+// Enabled returns true if the url of the pow server is set, otherwise pow is computed in-process.
+func Enabled() bool {
+	return requestUrl != ""
+}
+
 func GenerateWork(dataHash []byte, difficulty *big.Int) (*string, error) {
 	threshold := pow.DifficultyToTarget(difficulty)
 	wg := &workGenerate{
 		Threshold: threshold.Text(16),
 		DataHash:  hex.EncodeToString(dataHash),
+		Token:     clientToken,
 	}
 	bytesData, err := json.Marshal(wg)
 	if err != nil {
@@ -49,6 +66,7 @@ func GenerateWork(dataHash []byte, difficulty *big.Int) (*string, error) {
 func CancelWork(dataHash []byte) error {
 	wg := &workCancel{
 		DataHash: hex.EncodeToString(dataHash),
+		Token:    clientToken,
 	}
 	bytesData, err := json.Marshal(wg)
 	if err != nil {
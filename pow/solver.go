@@ -0,0 +1,239 @@
+package pow
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+	"golang.org/x/crypto/blake2b"
+)
+
+// progressBatch is the count of hashes a worker computes between two checks of cancellation and progress
+const progressBatch = 1 << 14
+
+var ErrPowCanceled = errors.New("pow canceled")
+
+// ProgressFunc is called during a solve with the count of hashes computed for dataHash so far.
+type ProgressFunc func(dataHash types.Hash, hashes uint64)
+
+// SolverStatus is the status of a Solver.
+type SolverStatus struct {
+	Workers int
+	Solving []types.Hash
+	// Hashes is the count of hashes computed since the solver is created
+	Hashes   uint64
+	Solved   uint64
+	Canceled uint64
+	Elapsed  time.Duration
+}
+
+// Solver computes PoW nonces in-process, the nonce space is split among the workers,
+// each of which walks its own range from a random start.
+type Solver struct {
+	workers  int
+	progress ProgressFunc
+
+	hashes   uint64
+	solved   uint64
+	canceled uint64
+	elapsed  int64
+
+	mu      sync.Mutex
+	seq     uint64
+	solving map[types.Hash]map[uint64]context.CancelFunc
+}
+
+// NewSolver creates a solver with workers goroutines per solve, runtime.NumCPU() is used if workers is 0.
+func NewSolver(workers int) *Solver {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Solver{
+		workers: workers,
+		solving: make(map[types.Hash]map[uint64]context.CancelFunc),
+	}
+}
+
+// SetProgress sets the callback of progress, it is called by the workers concurrently.
+func (s *Solver) SetProgress(f ProgressFunc) {
+	s.progress = f
+}
+
+func (s *Solver) Workers() int {
+	return s.workers
+}
+
+// Solve finds a nonce that Hash(nonce + dataHash) is not less than the target of difficulty.
+// It returns ErrPowCanceled if ctx is done or Cancel is called for dataHash before a nonce is found.
+func (s *Solver) Solve(ctx context.Context, difficulty *big.Int, dataHash types.Hash) ([]byte, error) {
+	target, err := powTarget256(difficulty)
+	if err != nil {
+		return nil, err
+	}
+	return s.solve(ctx, target, dataHash)
+}
+
+// SolveTarget is the same as Solve, except that the target is given instead of the difficulty.
+func (s *Solver) SolveTarget(ctx context.Context, target *big.Int, dataHash types.Hash) ([]byte, error) {
+	if target == nil || target.BitLen() > 256 {
+		return nil, errors.New("target too long")
+	}
+	return s.solve(ctx, helper.LeftPadBytes(target.Bytes(), 32), dataHash)
+}
+
+func (s *Solver) solve(ctx context.Context, target []byte, dataHash types.Hash) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	id := s.register(dataHash, cancel)
+	defer s.unregister(dataHash, id)
+
+	startTime := time.Now()
+	defer func() {
+		atomic.AddInt64(&s.elapsed, int64(time.Since(startTime)))
+	}()
+
+	var (
+		wg     sync.WaitGroup
+		once   sync.Once
+		nonce  []byte
+		hashes uint64
+	)
+	start := binary.BigEndian.Uint64(crypto.GetEntropyCSPRNG(8))
+	step := ^uint64(0)/uint64(s.workers) + 1
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func(from uint64) {
+			defer wg.Done()
+			if n := s.search(ctx, dataHash, target, from, &hashes); n != nil {
+				once.Do(func() {
+					nonce = n
+					cancel()
+				})
+			}
+		}(start + uint64(i)*step)
+	}
+	wg.Wait()
+
+	if nonce == nil {
+		atomic.AddUint64(&s.canceled, 1)
+		return nil, ErrPowCanceled
+	}
+	atomic.AddUint64(&s.solved, 1)
+	return nonce, nil
+}
+
+// SolveBatch solves the data hashes one by one with all workers, it stops at the first error.
+// The nonces are in the same order as dataHashes.
+func (s *Solver) SolveBatch(ctx context.Context, difficulty *big.Int, dataHashes []types.Hash) ([][]byte, error) {
+	nonces := make([][]byte, len(dataHashes))
+	for i, dataHash := range dataHashes {
+		nonce, err := s.Solve(ctx, difficulty, dataHash)
+		if err != nil {
+			return nonces[:i], err
+		}
+		nonces[i] = nonce
+	}
+	return nonces, nil
+}
+
+// Cancel stops the solves of dataHash, returns false if it is not being solved.
+func (s *Solver) Cancel(dataHash types.Hash) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cancels, ok := s.solving[dataHash]
+	for _, cancel := range cancels {
+		cancel()
+	}
+	return ok
+}
+
+func (s *Solver) Status() *SolverStatus {
+	status := &SolverStatus{
+		Workers:  s.workers,
+		Hashes:   atomic.LoadUint64(&s.hashes),
+		Solved:   atomic.LoadUint64(&s.solved),
+		Canceled: atomic.LoadUint64(&s.canceled),
+		Elapsed:  time.Duration(atomic.LoadInt64(&s.elapsed)),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for dataHash := range s.solving {
+		status.Solving = append(status.Solving, dataHash)
+	}
+	return status
+}
+
+func (s *Solver) register(dataHash types.Hash, cancel context.CancelFunc) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	cancels, ok := s.solving[dataHash]
+	if !ok {
+		cancels = make(map[uint64]context.CancelFunc)
+		s.solving[dataHash] = cancels
+	}
+	cancels[s.seq] = cancel
+	return s.seq
+}
+
+func (s *Solver) unregister(dataHash types.Hash, id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.solving[dataHash], id)
+	if len(s.solving[dataHash]) == 0 {
+		delete(s.solving, dataHash)
+	}
+}
+
+// search walks the nonces from the start, the hash input is reused to avoid allocation.
+func (s *Solver) search(ctx context.Context, dataHash types.Hash, target []byte, from uint64, hashes *uint64) []byte {
+	var input [8 + types.HashSize]byte
+	copy(input[8:], dataHash.Bytes())
+	nonce := from
+	for {
+		for i := 0; i < progressBatch; i++ {
+			binary.BigEndian.PutUint64(input[:8], nonce)
+			out := blake2b.Sum256(input[:])
+			if QuickGreater(out[:], target) {
+				atomic.AddUint64(&s.hashes, uint64(i+1))
+				return append([]byte(nil), input[:8]...)
+			}
+			nonce++
+		}
+		atomic.AddUint64(&s.hashes, progressBatch)
+		total := atomic.AddUint64(hashes, progressBatch)
+		if s.progress != nil {
+			s.progress(dataHash, total)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+}
+
+// powTarget256 returns the target of difficulty in 32 bytes.
+func powTarget256(difficulty *big.Int) ([]byte, error) {
+	var target *big.Int
+	if VMTestParamEnabled {
+		target = defaultTarget
+	} else {
+		if difficulty == nil {
+			return nil, errors.New("difficulty can't be nil")
+		}
+		target = DifficultyToTarget(difficulty)
+		if target == nil || target.BitLen() > 256 {
+			return nil, errors.New("target too long")
+		}
+	}
+	return helper.LeftPadBytes(target.Bytes(), 32), nil
+}
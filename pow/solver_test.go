@@ -0,0 +1,58 @@
+package pow_test
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/pow"
+)
+
+func TestSolver_Solve(t *testing.T) {
+	difficulty := big.NewInt(1000000)
+	solver := pow.NewSolver(4)
+	var progress uint64
+	solver.SetProgress(func(dataHash types.Hash, hashes uint64) {
+		atomic.StoreUint64(&progress, hashes)
+	})
+
+	dataHashes := []types.Hash{types.DataHash([]byte{1}), types.DataHash([]byte{2}), types.DataHash([]byte{3})}
+	nonces, err := solver.SolveBatch(context.Background(), difficulty, dataHashes)
+	assert.NoError(t, err)
+	assert.Equal(t, len(dataHashes), len(nonces))
+	for i, nonce := range nonces {
+		assert.True(t, pow.CheckPowNonce(difficulty, nonce, dataHashes[i].Bytes()))
+	}
+
+	status := solver.Status()
+	assert.Equal(t, 4, status.Workers)
+	assert.Equal(t, uint64(3), status.Solved)
+	assert.True(t, status.Hashes > 0)
+	assert.Empty(t, status.Solving)
+	t.Log("hashes", status.Hashes, "progress", atomic.LoadUint64(&progress), "elapsed", status.Elapsed)
+}
+
+func TestSolver_Cancel(t *testing.T) {
+	difficulty, _ := new(big.Int).SetString("1000000000000000000", 10)
+	solver := pow.NewSolver(2)
+	dataHash := types.DataHash([]byte{1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := solver.Solve(ctx, difficulty, dataHash)
+	assert.Equal(t, pow.ErrPowCanceled, err)
+
+	assert.False(t, solver.Cancel(dataHash))
+	go func() {
+		for !solver.Cancel(dataHash) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	_, err = solver.Solve(context.Background(), difficulty, dataHash)
+	assert.Equal(t, pow.ErrPowCanceled, err)
+	assert.Equal(t, uint64(2), solver.Status().Canceled)
+}
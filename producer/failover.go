@@ -0,0 +1,264 @@
+package producer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+const (
+	defaultFailoverMissedSlots = 3
+	defaultFailoverLeaseTTL    = 15 * time.Second
+	// a slot is missed if its block is not in chain after the slot ends for a while
+	failoverSlotGrace = 2 * time.Second
+)
+
+// FailoverConfig is the config of a node in an active/standby pair sharing one coinbase
+type FailoverConfig struct {
+	// NodeId identify the node in the lease, it must be different in the pair
+	NodeId string
+	// Standby node takes over only if the active node missed MissedSlots slots in a row,
+	// the active node takes the lease whenever it is expired
+	Standby     bool
+	MissedSlots int
+	LeaseTTL    time.Duration
+	// Heartbeat is the interval to renew the lease, also the tolerance of clock drift in the pair.
+	// It is a fifth of LeaseTTL if 0.
+	Heartbeat time.Duration
+}
+
+// FailoverChain is the part of chain used by Failover to watch the blocks of coinbase
+type FailoverChain interface {
+	GetSnapshotHeaderBeforeTime(timestamp *time.Time) (*ledger.SnapshotBlock, error)
+}
+
+// FailoverStatus is the state of a node in the pair
+type FailoverStatus struct {
+	NodeId string
+	Active bool
+	Missed int
+	Lease  *LeaseRecord
+}
+
+// Failover decide which node of an active/standby pair produces the blocks of the shared coinbase.
+//
+// A node produces a slot only if it holds the lease until the end of the slot, with a heartbeat of margin for clock drift.
+// The lease is taken only after it expires, so the pair never produces two blocks in the same slot,
+// as long as their clocks drift less than a heartbeat. A node which can not renew the lease stops producing
+// before it expires.
+type Failover struct {
+	chain    FailoverChain
+	coinbase types.Address
+	lease    LeaseBackend
+	cfg      FailoverConfig
+
+	mu      sync.Mutex
+	record  *LeaseRecord // the last lease known by the node
+	missed  int          // slots of coinbase missed in a row
+	pending []*consensus.Event
+
+	term chan struct{}
+	wg   sync.WaitGroup
+
+	log log15.Logger
+}
+
+// NewFailover create the watchdog of the node, use default values for zero fields of cfg
+func NewFailover(chain FailoverChain, coinbase types.Address, lease LeaseBackend, cfg FailoverConfig) *Failover {
+	if cfg.MissedSlots <= 0 {
+		cfg.MissedSlots = defaultFailoverMissedSlots
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = defaultFailoverLeaseTTL
+	}
+	if cfg.Heartbeat <= 0 || cfg.Heartbeat*2 >= cfg.LeaseTTL {
+		cfg.Heartbeat = cfg.LeaseTTL / 5
+	}
+
+	return &Failover{
+		chain:    chain,
+		coinbase: coinbase,
+		lease:    lease,
+		cfg:      cfg,
+		log:      log15.New("module", "producer/failover", "node", cfg.NodeId),
+	}
+}
+
+func (f *Failover) Start() {
+	f.term = make(chan struct{})
+	f.tick(time.Now())
+
+	f.wg.Add(1)
+	go f.loop()
+}
+
+// Stop give up the lease, the other node takes over after missing slots
+func (f *Failover) Stop() {
+	close(f.term)
+	f.wg.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.holdUntil(time.Now()) {
+		if err := f.lease.Release(f.cfg.NodeId); err != nil {
+			f.log.Error("failed to release lease", "err", err)
+		}
+	}
+	f.record = nil
+}
+
+func (f *Failover) loop() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.cfg.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.term:
+			return
+		case now := <-ticker.C:
+			f.tick(now)
+		}
+	}
+}
+
+// Allow is called at every snapshot slot of coinbase, return true if the node should produce it
+func (f *Failover) Allow(e consensus.Event) bool {
+	f.mu.Lock()
+	f.pending = append(f.pending, &e)
+	allowed := f.holdUntil(e.Etime)
+	f.mu.Unlock()
+
+	if !allowed {
+		return false
+	}
+	if !f.leaseUnchanged() {
+		return false
+	}
+	if f.produced(&e) {
+		f.log.Warn("slot has been produced", "timestamp", e.Timestamp)
+		return false
+	}
+	return true
+}
+
+// leaseUnchanged read the lease again before producing, return false if it is taken by another node
+// since the last heartbeat, or it can not be read
+func (f *Failover) leaseUnchanged() bool {
+	record, err := f.lease.Get()
+	if err != nil {
+		f.log.Error("failed to read lease", "err", err)
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.record == nil || record == nil || record.Holder != f.record.Holder || record.Epoch != f.record.Epoch {
+		f.log.Warn("lease is changed since the last heartbeat", "lease", record)
+		f.record = record
+		return false
+	}
+	return true
+}
+
+// Active return true if the node holds the lease now
+func (f *Failover) Active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.holdUntil(time.Now())
+}
+
+func (f *Failover) Status() FailoverStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	status := FailoverStatus{NodeId: f.cfg.NodeId, Active: f.holdUntil(time.Now()), Missed: f.missed}
+	if f.record != nil {
+		record := *f.record
+		status.Lease = &record
+	}
+	return status
+}
+
+// holdUntil return true if the lease is held by self until t, a heartbeat earlier than it expires
+func (f *Failover) holdUntil(t time.Time) bool {
+	if f.record == nil || f.record.Holder != f.cfg.NodeId {
+		return false
+	}
+	return t.Before(f.record.Expire.Add(-f.cfg.Heartbeat))
+}
+
+// produced return true if the block of the slot is in chain
+func (f *Failover) produced(e *consensus.Event) bool {
+	block, err := f.chain.GetSnapshotHeaderBeforeTime(&e.Etime)
+	if err != nil || block == nil {
+		return false
+	}
+	return block.Timestamp.Equal(e.Timestamp) && block.Producer() == f.coinbase
+}
+
+// tick count the missed slots, then renew or take the lease
+func (f *Failover) tick(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.checkSlots(now)
+
+	if f.record != nil && f.record.Holder == f.cfg.NodeId {
+		record, err := f.lease.Acquire(f.cfg.NodeId, now, f.cfg.LeaseTTL)
+		if err == nil {
+			f.record = record
+			return
+		}
+		f.log.Error("failed to renew lease", "err", err)
+		if err != errLeaseHeld {
+			// keep the known lease, production stops before it expires
+			return
+		}
+	}
+
+	record, err := f.lease.Get()
+	if err != nil {
+		f.log.Error("failed to read lease", "err", err)
+		return
+	}
+	f.record = record
+	if !record.Expired(now) {
+		return
+	}
+	if f.cfg.Standby && f.missed < f.cfg.MissedSlots {
+		return
+	}
+
+	record, err = f.lease.Acquire(f.cfg.NodeId, now, f.cfg.LeaseTTL)
+	if err != nil {
+		f.log.Error("failed to take lease", "err", err)
+		return
+	}
+	f.log.Warn("take over the production", "missed", f.missed, "epoch", record.Epoch)
+	f.record = record
+	f.missed = 0
+}
+
+// checkSlots count the slots ended for a while
+func (f *Failover) checkSlots(now time.Time) {
+	var rest []*consensus.Event
+	for _, e := range f.pending {
+		if now.Before(e.Etime.Add(failoverSlotGrace)) {
+			rest = append(rest, e)
+			continue
+		}
+		if f.produced(e) {
+			f.missed = 0
+		} else {
+			f.missed++
+			f.log.Warn("slot of coinbase is missed", "timestamp", e.Timestamp, "missed", f.missed)
+		}
+	}
+	f.pending = rest
+}
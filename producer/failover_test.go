@@ -0,0 +1,219 @@
+package producer
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type slotChain struct {
+	blocks []*ledger.SnapshotBlock
+}
+
+func (c *slotChain) GetSnapshotHeaderBeforeTime(timestamp *time.Time) (*ledger.SnapshotBlock, error) {
+	var result *ledger.SnapshotBlock
+	for _, b := range c.blocks {
+		if b.Timestamp.Before(*timestamp) {
+			result = b
+		}
+	}
+	return result, nil
+}
+
+func slotEvent(t time.Time) consensus.Event {
+	return consensus.Event{Timestamp: t, Stime: t, Etime: t.Add(time.Second)}
+}
+
+func TestFailover(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	coinbase := types.PubkeyToAddress(key.PubByte())
+
+	c := &slotChain{}
+	lease := NewMemLease()
+	cfg := FailoverConfig{MissedSlots: 3, LeaseTTL: 10 * time.Second, Heartbeat: 2 * time.Second}
+	cfg.NodeId = "a"
+	active := NewFailover(c, coinbase, lease, cfg)
+	cfg.NodeId, cfg.Standby = "b", true
+	standby := NewFailover(c, coinbase, lease, cfg)
+
+	now := time.Unix(1541640427, 0)
+	standby.tick(now)
+	active.tick(now)
+	standby.tick(now)
+	if record := active.Status().Lease; record == nil || record.Holder != "a" {
+		t.Fatalf("lease should be taken by the active node: %+v", active.Status().Lease)
+	}
+
+	// the active node produces the first slot
+	e := slotEvent(now.Add(time.Second))
+	if !active.Allow(e) || standby.Allow(e) {
+		t.Fatal("only the active node should produce")
+	}
+	produced := e.Timestamp
+	c.blocks = append(c.blocks, &ledger.SnapshotBlock{Height: 1, Timestamp: &produced, PublicKey: key.PubByte()})
+
+	// then it is down, the standby waits for missed slots and the expiry of lease
+	slot := now.Add(2 * time.Second)
+	for i := 0; i < 3; i++ {
+		e = slotEvent(slot)
+		if standby.Allow(e) {
+			t.Fatalf("standby should not produce slot %d", i)
+		}
+		slot = slot.Add(time.Second)
+	}
+	standby.tick(slot.Add(failoverSlotGrace))
+	if standby.Status().Missed != 3 || standby.Status().Active {
+		t.Fatalf("standby should wait for the lease: %+v", standby.Status())
+	}
+
+	// the lease of active expires, no slot is allowed to both nodes around the expiry
+	for slot = slot.Add(time.Second); slot.Before(now.Add(20 * time.Second)); slot = slot.Add(time.Second) {
+		standby.tick(slot)
+		e = slotEvent(slot)
+		if active.Allow(e) && standby.Allow(e) {
+			t.Fatalf("slot %s is allowed to both nodes", slot)
+		}
+	}
+	if status := standby.Status(); status.Lease == nil || status.Lease.Holder != "b" || status.Lease.Epoch != 1 || status.Missed != 0 {
+		t.Fatalf("standby should take over: %+v", status.Lease)
+	}
+
+	// the active node comes back and stays passive
+	active.tick(slot)
+	if active.Allow(slotEvent(slot)) || !standby.Allow(slotEvent(slot)) {
+		t.Error("the node holding the lease should produce")
+	}
+}
+
+func TestFileLease(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lease, err := NewFileLease(filepath.Join(dir, "shared", "lease"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record, err := lease.Get(); err != nil || record != nil {
+		t.Fatalf("lease should be empty: %v %v", record, err)
+	}
+
+	now := time.Now()
+	if _, err = lease.Acquire("a", now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lease.Acquire("b", now.Add(time.Second), time.Minute); err != errLeaseHeld {
+		t.Errorf("lease should be held by a: %v", err)
+	}
+	if _, err = lease.Acquire("a", now.Add(time.Second), time.Minute); err != nil {
+		t.Errorf("a should renew the lease: %v", err)
+	}
+
+	record, err := lease.Acquire("b", now.Add(2*time.Minute), time.Minute)
+	if err != nil || record.Holder != "b" || record.Epoch != 1 {
+		t.Fatalf("b should take the expired lease: %v %v", record, err)
+	}
+
+	if err = lease.Release("a"); err != nil {
+		t.Fatal(err)
+	}
+	if record, _ = lease.Get(); record.Expired(now.Add(2 * time.Minute)) {
+		t.Error("release of another holder should be ignored")
+	}
+	if err = lease.Release("b"); err != nil {
+		t.Fatal(err)
+	}
+	if record, _ = lease.Get(); !record.Expired(now.Add(2 * time.Minute)) {
+		t.Error("lease should be released")
+	}
+}
+
+func TestFailover_Allow_leaseChanged(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	coinbase := types.PubkeyToAddress(key.PubByte())
+
+	lease := NewMemLease()
+	f := NewFailover(&slotChain{}, coinbase, lease, FailoverConfig{NodeId: "a", LeaseTTL: 10 * time.Second})
+
+	now := time.Unix(1541640427, 0)
+	f.tick(now)
+	if !f.Allow(slotEvent(now.Add(time.Second))) {
+		t.Fatal("the node holding the lease should produce")
+	}
+
+	// another node takes the lease between heartbeats
+	if err := lease.Release("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lease.Acquire("b", now, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if f.Allow(slotEvent(now.Add(2 * time.Second))) {
+		t.Error("should not produce after the lease is taken")
+	}
+	if f.Active() {
+		t.Error("should know the new holder")
+	}
+}
+
+func TestFileLease_lock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := &fileLease{path: filepath.Join(dir, "lease")}
+	lockPath := l.path + ".lock"
+
+	// a stale lock of a crashed node is broken
+	if err = ioutil.WriteFile(lockPath, []byte("crashed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * leaseLockStale)
+	if err = os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	lk, err := l.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lk.held() {
+		t.Fatal("lock should be held")
+	}
+
+	// a fresh lock of another node is never removed
+	if _, err = l.lock(); err == nil {
+		t.Fatal("lock should be exclusive")
+	}
+
+	// the lock is broken and taken by another node while it is held
+	if err = os.Remove(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(lockPath, []byte("other"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if lk.held() {
+		t.Error("lock should be lost")
+	}
+	lk.unlock()
+	if owner, err := ioutil.ReadFile(lockPath); err != nil || string(owner) != "other" {
+		t.Errorf("lock of another node should be kept: %q %v", owner, err)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("only the lock of another node should be left, got %d files", len(files))
+	}
+}
@@ -0,0 +1,330 @@
+package producer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// wait for the lock of the lease file at most
+	leaseLockTimeout = 2 * time.Second
+	// the lock of the lease file is left by a crashed node if it is older than this
+	leaseLockStale = 10 * time.Second
+)
+
+var (
+	errLeaseHeld     = errors.New("lease is held by another node")
+	errLeaseLockLost = errors.New("lock of lease is taken by another node")
+)
+
+// LeaseRecord is the production lease of a coinbase shared by an active/standby pair.
+// Only the holder of an unexpired lease produces blocks, it renews the lease by heartbeats.
+type LeaseRecord struct {
+	Holder    string    `json:"holder"`
+	Expire    time.Time `json:"expire"`
+	Heartbeat time.Time `json:"heartbeat"`
+	// Epoch is increased every time the lease is taken by another holder
+	Epoch uint64 `json:"epoch"`
+}
+
+// Expired return true if nobody holds the lease at now
+func (r *LeaseRecord) Expired(now time.Time) bool {
+	return r == nil || r.Holder == "" || !now.Before(r.Expire)
+}
+
+// LeaseBackend is the compare-and-set store of the lease shared by the nodes of a pair.
+// It is the same as a lock key bound to an etcd lease, so it can be implemented by an etcd client.
+type LeaseBackend interface {
+	// Get return the current lease, nil if it has never been acquired
+	Get() (*LeaseRecord, error)
+	// Acquire take or renew the lease for holder until now+ttl,
+	// return errLeaseHeld if another holder has an unexpired lease
+	Acquire(holder string, now time.Time, ttl time.Duration) (*LeaseRecord, error)
+	// Release give up the lease if it is held by holder
+	Release(holder string) error
+}
+
+// acquireLease is the compare-and-set of Acquire on the current record
+func acquireLease(current *LeaseRecord, holder string, now time.Time, ttl time.Duration) (*LeaseRecord, error) {
+	if current != nil && current.Holder != holder && !current.Expired(now) {
+		return nil, errLeaseHeld
+	}
+
+	next := &LeaseRecord{Holder: holder, Expire: now.Add(ttl), Heartbeat: now}
+	if current != nil {
+		next.Epoch = current.Epoch
+		if current.Holder != holder {
+			next.Epoch++
+		}
+	}
+	return next, nil
+}
+
+// memLease keep the lease in memory, it is the local stand-in of a shared backend
+type memLease struct {
+	mu     sync.Mutex
+	record *LeaseRecord
+}
+
+// NewMemLease return a lease backend in memory, shared by the producers in the same process
+func NewMemLease() LeaseBackend {
+	return &memLease{}
+}
+
+func (l *memLease) Get() (*LeaseRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.record == nil {
+		return nil, nil
+	}
+	record := *l.record
+	return &record, nil
+}
+
+func (l *memLease) Acquire(holder string, now time.Time, ttl time.Duration) (*LeaseRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	next, err := acquireLease(l.record, holder, now, ttl)
+	if err != nil {
+		return nil, err
+	}
+	l.record = next
+	record := *next
+	return &record, nil
+}
+
+func (l *memLease) Release(holder string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.record != nil && l.record.Holder == holder {
+		l.record.Expire = time.Time{}
+	}
+	return nil
+}
+
+// fileLease keep the lease in a json file on storage shared by the pair, such as NFS.
+// Every change is made under a lock file holding a random token of its owner, and the file is replaced by rename,
+// so it can be read without the lock.
+type fileLease struct {
+	path string
+}
+
+// NewFileLease return a lease backend kept in the file of path
+func NewFileLease(path string) (LeaseBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrap(err, "create directory of lease")
+	}
+	return &fileLease{path: path}, nil
+}
+
+// fileLock is the lock file of lease, the content is the token of its owner
+type fileLock struct {
+	path  string
+	token string
+}
+
+// lock link a complete lock file of a new token to the lock path, which fails if the path exists.
+// It is exclusive on NFS, unlike flock.
+func (l *fileLease) lock() (*fileLock, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	lk := &fileLock{path: l.path + ".lock", token: hex.EncodeToString(token)}
+
+	own := lk.path + "." + lk.token
+	if err := ioutil.WriteFile(own, []byte(lk.token), 0600); err != nil {
+		return nil, errors.Wrap(err, "lock lease")
+	}
+	defer os.Remove(own)
+
+	deadline := time.Now().Add(leaseLockTimeout)
+	for {
+		err := os.Link(own, lk.path)
+		if err == nil {
+			return lk, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrap(err, "lock lease")
+		}
+
+		if lk.breakStale() {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("lock lease timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// take move the lock file aside and return its owner, so it is removed only by the one moving it
+func (lk *fileLock) take(suffix string) (moved string, owner []byte, err error) {
+	moved = lk.path + "." + lk.token + suffix
+	if err = os.Rename(lk.path, moved); err != nil {
+		return "", nil, err
+	}
+	owner, err = ioutil.ReadFile(moved)
+	return moved, owner, err
+}
+
+// restore put back the lock of another node taken by mistake, it fails if the lock is taken again
+func (lk *fileLock) restore(moved string) {
+	os.Link(moved, lk.path)
+	os.Remove(moved)
+}
+
+// breakStale remove the lock left by a crashed node, return true if it is removed
+func (lk *fileLock) breakStale() bool {
+	info, err := os.Stat(lk.path)
+	if err != nil || time.Since(info.ModTime()) <= leaseLockStale {
+		return false
+	}
+	stale, err := ioutil.ReadFile(lk.path)
+	if err != nil {
+		return false
+	}
+
+	// the stale lock may be broken and replaced by another node after it was read
+	moved, owner, err := lk.take(".stale")
+	if moved == "" {
+		return false
+	}
+	if err != nil || string(owner) != string(stale) {
+		lk.restore(moved)
+		return false
+	}
+	os.Remove(moved)
+	return true
+}
+
+// held return true if the lock has not been broken as stale
+func (lk *fileLock) held() bool {
+	owner, err := ioutil.ReadFile(lk.path)
+	return err == nil && string(owner) == lk.token
+}
+
+// unlock remove the lock only if it is still owned
+func (lk *fileLock) unlock() {
+	moved, owner, err := lk.take(".unlock")
+	if moved == "" {
+		return
+	}
+	if err != nil || string(owner) != lk.token {
+		lk.restore(moved)
+		return
+	}
+	os.Remove(moved)
+}
+
+func (l *fileLease) read() (*LeaseRecord, error) {
+	data, err := ioutil.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read lease")
+	}
+
+	record := new(LeaseRecord)
+	if err = json.Unmarshal(data, record); err != nil {
+		return nil, errors.Wrap(err, "parse lease")
+	}
+	return record, nil
+}
+
+func (l *fileLease) write(record *LeaseRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "write lease")
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "write lease")
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Get read the lease without the lock, the file is always complete since it is replaced by rename
+func (l *fileLease) Get() (*LeaseRecord, error) {
+	return l.read()
+}
+
+func (l *fileLease) Acquire(holder string, now time.Time, ttl time.Duration) (*LeaseRecord, error) {
+	lk, err := l.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lk.unlock()
+
+	current, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	next, err := acquireLease(current, holder, now, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !lk.held() {
+		return nil, errLeaseLockLost
+	}
+	if err = l.write(next); err != nil {
+		return nil, err
+	}
+
+	// compare the written lease in case another node wrote it meanwhile after breaking the lock
+	written, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if !sameLease(written, next) {
+		return nil, errLeaseHeld
+	}
+	return next, nil
+}
+
+func (l *fileLease) Release(holder string) error {
+	lk, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer lk.unlock()
+
+	current, err := l.read()
+	if err != nil || current == nil || current.Holder != holder {
+		return err
+	}
+	if !lk.held() {
+		return errLeaseLockLost
+	}
+	current.Expire = time.Time{}
+	return l.write(current)
+}
+
+// sameLease return true if a and b are the same lease of the same holder
+func sameLease(a, b *LeaseRecord) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Holder == b.Holder && a.Epoch == b.Epoch && a.Expire.Equal(b.Expire)
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/producer/producerevent"
 	"github.com/vitelabs/go-vite/verifier"
-	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 // Package producer implements vite block creation
@@ -80,6 +80,7 @@ type producer struct {
 	accountFn            func(producerevent.AccountEvent)
 	syncState            net.SyncState
 	netSyncId            int
+	failover             *Failover
 }
 
 // todo syncDone
@@ -88,13 +89,14 @@ func NewProducer(rw chain.Chain,
 	coinbase *AddressContext,
 	cs consensus.Subscriber,
 	verifier *verifier.SnapshotVerifier,
-	wt *wallet.Manager,
-	p pool.SnapshotProducerWriter) *producer {
-	chain := newChainRw(rw, verifier, wt, p)
+	s signer.Signer,
+	p pool.SnapshotProducerWriter,
+	dataDir string) *producer {
+	chain := newChainRw(rw, verifier, s, p)
 	miner := &producer{tools: chain, coinbase: coinbase}
 
 	miner.cs = cs
-	miner.worker = newWorker(chain, coinbase)
+	miner.worker = newWorker(chain, coinbase, dataDir)
 	miner.subscriber = subscriber
 	miner.downloaderRegisterCh = make(chan int)
 	miner.dwlFinished = false
@@ -135,15 +137,21 @@ func (self *producer) Start() error {
 	snapshotId := self.coinbase.Address.String() + "_snapshot"
 	contractId := self.coinbase.Address.String() + "_contract"
 
+	if self.failover != nil {
+		self.failover.Start()
+	}
+
 	self.cs.Subscribe(types.SNAPSHOT_GID, snapshotId, &self.coinbase.Address, func(e consensus.Event) {
 		mLog.Info("snapshot producer trigger.", "addr", self.coinbase.Address, "syncState", self.syncState, "e", e)
-		if self.syncState == net.SyncDone {
+		// the slot is watched by failover even if the node is syncing
+		allowed := self.failover == nil || self.failover.Allow(e)
+		if self.syncState == net.SyncDone && allowed {
 			self.worker.produceSnapshot(e)
 		}
 	})
 	self.cs.Subscribe(types.DELEGATE_GID, contractId, &self.coinbase.Address, func(e consensus.Event) {
 		mLog.Info("contract producer trigger.", "addr", self.coinbase.Address, "syncState", self.syncState, "e", e)
-		if self.syncState == net.SyncDone {
+		if self.syncState == net.SyncDone && (self.failover == nil || self.failover.Active()) {
 			self.producerContract(e)
 		}
 	})
@@ -172,6 +180,10 @@ func (self *producer) Stop() error {
 	self.subscriber.UnsubscribeSyncStatus(self.netSyncId)
 	self.netSyncId = 0
 
+	if self.failover != nil {
+		self.failover.Stop()
+	}
+
 	err := self.worker.Stop()
 	if err != nil {
 		return err
@@ -215,6 +227,12 @@ func (self *producer) SetAccountEventFunc(accountFn func(producerevent.AccountEv
 	self.accountFn = accountFn
 }
 
+// SetFailover make the producer one node of an active/standby pair sharing the coinbase,
+// it produces only when it holds the lease of failover. It must be called before Start.
+func (self *producer) SetFailover(failover *Failover) {
+	self.failover = failover
+}
+
 func (self *producer) GetCoinBase() types.Address {
 	return self.coinbase.Address
 }
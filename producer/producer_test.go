@@ -18,6 +18,7 @@ import (
 	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 type testConsensus struct {
@@ -109,7 +110,7 @@ func TestSnapshot(t *testing.T) {
 	w := wallet.New(nil)
 	av := verifier.NewAccountVerifier(c, cs)
 	p1, _ := pool.NewPool(c)
-	p := NewProducer(c, &testSubscriber{}, coinbase, cs, sv, w, p1)
+	p := NewProducer(c, &testSubscriber{}, coinbase, cs, sv, signer.NewLocal(w), p1, "")
 
 	p1.Init(&pool.MockSyncer{}, w, sv, av)
 	p.Init()
@@ -148,7 +149,7 @@ func TestProducer_Init(t *testing.T) {
 	w := wallet.New(nil)
 	av := verifier.NewAccountVerifier(c, cs)
 	p1, _ := pool.NewPool(c)
-	p := NewProducer(c, &testSubscriber{}, coinbase, cs, sv, w, p1)
+	p := NewProducer(c, &testSubscriber{}, coinbase, cs, sv, signer.NewLocal(w), p1, "")
 
 	c.Init()
 	c.Start()
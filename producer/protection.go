@@ -0,0 +1,281 @@
+package producer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+const (
+	// ProtectionDirName is the directory of slashing protection in the data directory of producer
+	ProtectionDirName = "protection"
+	// ProtectionFormatVersion is the version of the interchange format of slashing protection
+	ProtectionFormatVersion = "1"
+)
+
+var (
+	signedPrefix = []byte("signed:")
+	genesisKey   = []byte("genesis")
+)
+
+var (
+	errDoubleProduction = errors.New("another snapshot block has been signed in the same slot")
+	errSlotTooOld       = errors.New("slot is earlier than the last signed slot")
+	errGenesisMismatch  = errors.New("slashing protection belongs to another chain")
+)
+
+// SignedSnapshotBlock is a snapshot block signed by a producer.
+// Slot is the unix time of the block timestamp, every slot of a producer has an unique timestamp.
+type SignedSnapshotBlock struct {
+	Slot   uint64     `json:"slot,string"`
+	Height uint64     `json:"height,string"`
+	Hash   types.Hash `json:"hash"`
+}
+
+// ProtectionRecords is all the snapshot blocks signed by a producer, in order of slot
+type ProtectionRecords struct {
+	Address      types.Address         `json:"address"`
+	SignedBlocks []SignedSnapshotBlock `json:"signedSnapshotBlocks"`
+}
+
+// ProtectionMetadata describe the interchange file
+type ProtectionMetadata struct {
+	Version     string      `json:"interchangeFormatVersion"`
+	GenesisHash *types.Hash `json:"genesisSnapshotHash,omitempty"`
+}
+
+// ProtectionInterchange is the JSON format to export and import slashing protection,
+// so a producer can be migrated to another machine without double production.
+type ProtectionInterchange struct {
+	Metadata ProtectionMetadata  `json:"metadata"`
+	Data     []ProtectionRecords `json:"data"`
+}
+
+// SlashingProtection records every snapshot block before it is signed,
+// and refuse to sign a block conflicting with the records,
+// so the same coinbase running on two machines with the same records can not produce two blocks in one slot.
+type SlashingProtection struct {
+	mu sync.Mutex
+	db *leveldb.DB
+}
+
+// OpenSlashingProtection open the records in dir, use memory if dir is empty
+func OpenSlashingProtection(dir string) (*SlashingProtection, error) {
+	var db *leveldb.DB
+	var err error
+	if dir == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(dir, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "open slashing protection")
+	}
+
+	return &SlashingProtection{db: db}, nil
+}
+
+func (p *SlashingProtection) Close() error {
+	return p.db.Close()
+}
+
+func signedKey(addr types.Address, slot uint64) []byte {
+	key := make([]byte, len(signedPrefix)+types.AddressSize+8)
+	n := copy(key, signedPrefix)
+	n += copy(key[n:], addr.Bytes())
+	binary.BigEndian.PutUint64(key[n:], slot)
+	return key
+}
+
+func signedValue(height uint64, hash types.Hash) []byte {
+	value := make([]byte, 8+types.HashSize)
+	binary.BigEndian.PutUint64(value, height)
+	copy(value[8:], hash.Bytes())
+	return value
+}
+
+func parseSigned(key, value []byte) (block SignedSnapshotBlock, err error) {
+	if len(key) != len(signedPrefix)+types.AddressSize+8 || len(value) != 8+types.HashSize {
+		return block, errors.New("malformed record")
+	}
+
+	block.Slot = binary.BigEndian.Uint64(key[len(key)-8:])
+	block.Height = binary.BigEndian.Uint64(value)
+	block.Hash, err = types.BytesToHash(value[8:])
+	return
+}
+
+// Genesis return the genesis hash of the chain the records belong to, nil if not set yet
+func (p *SlashingProtection) Genesis() *types.Hash {
+	value, err := p.db.Get(genesisKey, nil)
+	if err != nil {
+		return nil
+	}
+
+	hash, err := types.BytesToHash(value)
+	if err != nil {
+		return nil
+	}
+	return &hash
+}
+
+// setGenesis bind the records to the chain, return error if they belong to another chain
+func (p *SlashingProtection) setGenesis(hash types.Hash) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if old := p.Genesis(); old != nil {
+		if *old != hash {
+			return errGenesisMismatch
+		}
+		return nil
+	}
+
+	return p.db.Put(genesisKey, hash.Bytes(), &opt.WriteOptions{Sync: true})
+}
+
+// lastSlot return the latest slot signed by addr
+func (p *SlashingProtection) lastSlot(addr types.Address) (slot uint64, ok bool) {
+	itr := p.db.NewIterator(util.BytesPrefix(signedKey(addr, 0)[:len(signedPrefix)+types.AddressSize]), nil)
+	defer itr.Release()
+
+	if !itr.Last() {
+		return 0, false
+	}
+
+	key := itr.Key()
+	return binary.BigEndian.Uint64(key[len(key)-8:]), true
+}
+
+// record check the block will be signed by addr, and sync it to disk before signing.
+// Signing the same block again is allowed.
+func (p *SlashingProtection) record(addr types.Address, slot, height uint64, hash types.Hash) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := signedKey(addr, slot)
+	value, err := p.db.Get(key, nil)
+	if err == nil {
+		if bytes.Equal(value, signedValue(height, hash)) {
+			return nil
+		}
+		return errDoubleProduction
+	} else if err != leveldb.ErrNotFound {
+		return err
+	}
+
+	if last, ok := p.lastSlot(addr); ok && slot < last {
+		return errSlotTooOld
+	}
+
+	return p.db.Put(key, signedValue(height, hash), &opt.WriteOptions{Sync: true})
+}
+
+// Export write all records in JSON
+func (p *SlashingProtection) Export(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ex := ProtectionInterchange{
+		Metadata: ProtectionMetadata{
+			Version:     ProtectionFormatVersion,
+			GenesisHash: p.Genesis(),
+		},
+		Data: []ProtectionRecords{},
+	}
+
+	itr := p.db.NewIterator(util.BytesPrefix(signedPrefix), nil)
+	defer itr.Release()
+
+	var records *ProtectionRecords
+	for itr.Next() {
+		key := itr.Key()
+		block, err := parseSigned(key, itr.Value())
+		if err != nil {
+			return err
+		}
+
+		addr, err := types.BytesToAddress(key[len(signedPrefix) : len(signedPrefix)+types.AddressSize])
+		if err != nil {
+			return err
+		}
+
+		if records == nil || records.Address != addr {
+			ex.Data = append(ex.Data, ProtectionRecords{Address: addr})
+			records = &ex.Data[len(ex.Data)-1]
+		}
+		records.SignedBlocks = append(records.SignedBlocks, block)
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ex)
+}
+
+// Import merge records in JSON, nothing is imported if any record conflicts with local records
+func (p *SlashingProtection) Import(r io.Reader) error {
+	var ex ProtectionInterchange
+	if err := json.NewDecoder(r).Decode(&ex); err != nil {
+		return errors.Wrap(err, "decode slashing protection")
+	}
+	if ex.Metadata.Version != ProtectionFormatVersion {
+		return fmt.Errorf("unsupported interchange format version %q", ex.Metadata.Version)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	genesis := p.Genesis()
+	if genesis != nil && ex.Metadata.GenesisHash != nil && *genesis != *ex.Metadata.GenesisHash {
+		return errGenesisMismatch
+	}
+
+	batch := new(leveldb.Batch)
+	if genesis == nil && ex.Metadata.GenesisHash != nil {
+		batch.Put(genesisKey, ex.Metadata.GenesisHash.Bytes())
+	}
+
+	imported := make(map[string][]byte)
+	for _, records := range ex.Data {
+		for _, block := range records.SignedBlocks {
+			key := signedKey(records.Address, block.Slot)
+			value := signedValue(block.Height, block.Hash)
+
+			old, ok := imported[string(key)]
+			if ok {
+				if !bytes.Equal(old, value) {
+					return fmt.Errorf("%s at slot %d: %v", records.Address, block.Slot, errDoubleProduction)
+				}
+				continue
+			}
+			imported[string(key)] = value
+
+			old, err := p.db.Get(key, nil)
+			if err == nil {
+				if !bytes.Equal(old, value) {
+					return fmt.Errorf("%s at slot %d: %v", records.Address, block.Slot, errDoubleProduction)
+				}
+				continue
+			} else if err != leveldb.ErrNotFound {
+				return err
+			}
+
+			batch.Put(key, value)
+		}
+	}
+
+	return p.db.Write(batch, &opt.WriteOptions{Sync: true})
+}
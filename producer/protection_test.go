@@ -0,0 +1,84 @@
+package producer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func TestSlashingProtection_record(t *testing.T) {
+	p, err := OpenSlashingProtection("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	addr := types.Address{1}
+	hash1 := types.HexToHashPanic(calculateHash("1"))
+	hash2 := types.HexToHashPanic(calculateHash("2"))
+
+	assert.NoError(t, p.record(addr, 100, 10, hash1))
+	// sign the same block again
+	assert.NoError(t, p.record(addr, 100, 10, hash1))
+	// another block in the same slot
+	assert.Equal(t, errDoubleProduction, p.record(addr, 100, 11, hash2))
+
+	assert.NoError(t, p.record(addr, 101, 11, hash2))
+	assert.Equal(t, errSlotTooOld, p.record(addr, 99, 9, hash2))
+
+	// other producers are not affected
+	assert.NoError(t, p.record(types.Address{2}, 99, 9, hash2))
+
+	assert.NoError(t, p.setGenesis(hash1))
+	assert.NoError(t, p.setGenesis(hash1))
+	assert.Equal(t, errGenesisMismatch, p.setGenesis(hash2))
+}
+
+func TestSlashingProtection_interchange(t *testing.T) {
+	p, err := OpenSlashingProtection("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	addr := types.Address{1}
+	genesis := types.HexToHashPanic(calculateHash("genesis"))
+	hash1 := types.HexToHashPanic(calculateHash("1"))
+	hash2 := types.HexToHashPanic(calculateHash("2"))
+
+	assert.NoError(t, p.setGenesis(genesis))
+	assert.NoError(t, p.record(addr, 100, 10, hash1))
+	assert.NoError(t, p.record(addr, 101, 11, hash2))
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, p.Export(buf))
+	exported := buf.Bytes()
+
+	// migrate to another machine
+	p2, err := OpenSlashingProtection("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+
+	assert.NoError(t, p2.Import(bytes.NewReader(exported)))
+	assert.Equal(t, genesis, *p2.Genesis())
+	assert.Equal(t, errDoubleProduction, p2.record(addr, 101, 11, hash1))
+	assert.Equal(t, errSlotTooOld, p2.record(addr, 50, 5, hash1))
+
+	buf.Reset()
+	assert.NoError(t, p2.Export(buf))
+	assert.Equal(t, string(exported), buf.String())
+
+	// import again is fine, conflicting records are refused
+	assert.NoError(t, p2.Import(bytes.NewReader(exported)))
+	p3, _ := OpenSlashingProtection("")
+	defer p3.Close()
+	assert.NoError(t, p3.record(addr, 100, 10, hash2))
+	assert.Error(t, p3.Import(bytes.NewReader(exported)))
+	last, ok := p3.lastSlot(addr)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), last)
+}
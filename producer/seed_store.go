@@ -0,0 +1,120 @@
+package producer
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+const (
+	// keep revealed seeds for a while, in case the revealing block is rolled back and produced again
+	seedRevealedTTL = 10 * time.Minute
+	// GetLastUnpublishedSeedSnapshotHeader only look back 600 blocks, older seeds can not be revealed anymore
+	seedUnrevealedTTL = 24 * time.Hour
+
+	seedValueLength = 24 // seed + createdAt + revealedAt
+
+	seedDirName = "seeds"
+)
+
+var seedPrefix = []byte("seed:")
+
+// seedStore keep the seeds committed by SeedHash of snapshot blocks until they are revealed.
+// The seed must be written to disk before the block is inserted, otherwise it is lost after a crash,
+// and the SBP can never reveal it.
+type seedStore struct {
+	db *leveldb.DB
+}
+
+// newSeedStore open the store in dir, use memory if dir is empty
+func newSeedStore(dir string) (*seedStore, error) {
+	var db *leveldb.DB
+	var err error
+	if dir == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(dir, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "open seed store")
+	}
+
+	return &seedStore{db: db}, nil
+}
+
+func seedKey(hash types.Hash) []byte {
+	return append(append([]byte{}, seedPrefix...), hash.Bytes()...)
+}
+
+// put the seed and sync to disk
+func (s *seedStore) put(hash types.Hash, seed uint64, now time.Time) error {
+	value := make([]byte, seedValueLength)
+	binary.BigEndian.PutUint64(value, seed)
+	binary.BigEndian.PutUint64(value[8:], uint64(now.Unix()))
+
+	return s.db.Put(seedKey(hash), value, &opt.WriteOptions{Sync: true})
+}
+
+func (s *seedStore) get(hash types.Hash) (seed uint64, ok bool) {
+	value, err := s.db.Get(seedKey(hash), nil)
+	if err != nil || len(value) != seedValueLength {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint64(value), true
+}
+
+// reveal mark the seed revealed, it will be pruned after seedRevealedTTL
+func (s *seedStore) reveal(hash types.Hash, now time.Time) error {
+	key := seedKey(hash)
+	value, err := s.db.Get(key, nil)
+	if err != nil || len(value) != seedValueLength {
+		return err
+	}
+
+	binary.BigEndian.PutUint64(value[16:], uint64(now.Unix()))
+
+	return s.db.Put(key, value, nil)
+}
+
+// prune revealed seeds and seeds too old to be revealed, return count of pruned seeds
+func (s *seedStore) prune(now time.Time) (n int) {
+	itr := s.db.NewIterator(util.BytesPrefix(seedPrefix), nil)
+	defer itr.Release()
+
+	batch := new(leveldb.Batch)
+	for itr.Next() {
+		value := itr.Value()
+		if len(value) != seedValueLength {
+			batch.Delete(append([]byte{}, itr.Key()...))
+			continue
+		}
+
+		createdAt := time.Unix(int64(binary.BigEndian.Uint64(value[8:])), 0)
+		revealedAt := int64(binary.BigEndian.Uint64(value[16:]))
+
+		if revealedAt > 0 && now.Sub(time.Unix(revealedAt, 0)) > seedRevealedTTL ||
+			now.Sub(createdAt) > seedUnrevealedTTL {
+			batch.Delete(append([]byte{}, itr.Key()...))
+		}
+	}
+
+	if batch.Len() == 0 {
+		return 0
+	}
+	if err := s.db.Write(batch, nil); err != nil {
+		return 0
+	}
+
+	return batch.Len()
+}
+
+func (s *seedStore) close() error {
+	return s.db.Close()
+}
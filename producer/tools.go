@@ -13,18 +13,18 @@ import (
 	"github.com/vitelabs/go-vite/monitor"
 	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/verifier"
-	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 type tools struct {
 	log       log15.Logger
-	wt        *wallet.Manager
+	signer    signer.Signer
 	pool      pool.SnapshotProducerWriter
 	chain     chain.Chain
 	sVerifier *verifier.SnapshotVerifier
 }
 
-func (self *tools) generateSnapshot(e *consensus.Event, coinbase *AddressContext, seed uint64, fn func(*types.Hash) uint64) (*ledger.SnapshotBlock, error) {
+func (self *tools) generateSnapshot(e *consensus.Event, coinbase *AddressContext, seed uint64, fn func(*types.Hash) uint64, protection *SlashingProtection) (*ledger.SnapshotBlock, error) {
 	head := self.chain.GetLatestSnapshotBlock()
 	accounts, err := self.generateAccounts(head)
 	if err != nil {
@@ -57,16 +57,11 @@ func (self *tools) generateSnapshot(e *consensus.Event, coinbase *AddressContext
 	}
 
 	block.Hash = block.ComputeHash()
-	manager, err := self.wt.GetEntropyStoreManager(coinbase.EntryPath)
-	if err != nil {
-		return nil, err
-	}
-	_, key, err := manager.DeriveForIndexPath(coinbase.Index)
-	if err != nil {
-		return nil, err
+	// record the block before signing, refuse to produce another block in the same slot
+	if err := protection.record(coinbase.Address, uint64(e.Timestamp.Unix()), block.Height, block.Hash); err != nil {
+		return nil, errors.Wrap(err, "slashing protection")
 	}
-	signedData, pubkey, err := key.SignData(block.Hash.Bytes())
-
+	signedData, pubkey, err := self.signer.SignSnapshotBlock(coinbase.Address, block)
 	if err != nil {
 		return nil, err
 	}
@@ -81,9 +76,9 @@ func (self *tools) insertSnapshot(block *ledger.SnapshotBlock) error {
 	return self.pool.AddDirectSnapshotBlock(block)
 }
 
-func newChainRw(ch chain.Chain, sVerifier *verifier.SnapshotVerifier, wt *wallet.Manager, p pool.SnapshotProducerWriter) *tools {
+func newChainRw(ch chain.Chain, sVerifier *verifier.SnapshotVerifier, s signer.Signer, p pool.SnapshotProducerWriter) *tools {
 	log := log15.New("module", "tools")
-	return &tools{chain: ch, log: log, sVerifier: sVerifier, wt: wt, pool: p}
+	return &tools{chain: ch, log: log, sVerifier: sVerifier, signer: s, pool: p}
 }
 
 func (self *tools) checkAddressLock(address types.Address, coinbase *AddressContext) error {
@@ -91,7 +86,10 @@ func (self *tools) checkAddressLock(address types.Address, coinbase *AddressCont
 		return errors.Errorf("addres not equals.%s-%s", address, coinbase.Address)
 	}
 
-	return self.wt.MatchAddress(coinbase.EntryPath, coinbase.Address, coinbase.Index)
+	if !signer.Contains(self.signer, address) {
+		return errors.Errorf("address %s can not be signed", address)
+	}
+	return nil
 }
 
 func (self *tools) generateAccounts(head *ledger.SnapshotBlock) (ledger.SnapshotContent, error) {
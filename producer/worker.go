@@ -1,14 +1,14 @@
 package producer
 
 import (
+	crand "crypto/rand"
+	"encoding/binary"
 	"fmt"
-	"math/rand"
+	"path/filepath"
 	"sync"
 
 	"github.com/vitelabs/go-vite/common/types"
 
-	"github.com/hashicorp/golang-lru"
-
 	"time"
 
 	"github.com/pkg/errors"
@@ -23,20 +23,26 @@ var wLog = log15.New("module", "miner/worker")
 // worker
 type worker struct {
 	producerLifecycle
-	tools     *tools
-	coinbase  *AddressContext
-	mu        sync.Mutex
-	wg        sync.WaitGroup
-	seedCache *lru.Cache
-	log       log15.Logger
+	tools      *tools
+	coinbase   *AddressContext
+	mu         sync.Mutex
+	wg         sync.WaitGroup
+	dataDir    string // empty means data are kept in memory
+	seeds      *seedStore
+	protection *SlashingProtection
+	log        log15.Logger
 }
 
-func newWorker(chain *tools, coinbase *AddressContext) *worker {
-	cache, err := lru.New(1000)
-	if err != nil {
-		panic(err)
+func newWorker(chain *tools, coinbase *AddressContext, dataDir string) *worker {
+	return &worker{tools: chain, coinbase: coinbase, dataDir: dataDir, log: log15.New("module", "producer/worker")}
+}
+
+// dataPath return the path of name in dataDir, or empty if data are kept in memory
+func (self *worker) dataPath(name string) string {
+	if self.dataDir == "" {
+		return ""
 	}
-	return &worker{tools: chain, coinbase: coinbase, seedCache: cache, log: log15.New("module", "producer/worker")}
+	return filepath.Join(self.dataDir, name)
 }
 
 func (self *worker) Init() error {
@@ -52,6 +58,28 @@ func (self *worker) Start() error {
 		return errors.New("pre start fail.")
 	}
 	defer self.PostStart()
+
+	protection, err := OpenSlashingProtection(self.dataPath(ProtectionDirName))
+	if err != nil {
+		return err
+	}
+	if self.tools != nil {
+		if err = protection.setGenesis(self.tools.chain.GetGenesisSnapshotBlock().Hash); err != nil {
+			protection.Close()
+			return err
+		}
+	}
+
+	seeds, err := newSeedStore(self.dataPath(seedDirName))
+	if err != nil {
+		protection.Close()
+		return err
+	}
+	if n := seeds.prune(time.Now()); n > 0 {
+		self.log.Info(fmt.Sprintf("prune %d seeds", n))
+	}
+	self.seeds = seeds
+	self.protection = protection
 	return nil
 }
 
@@ -61,7 +89,8 @@ func (self *worker) Stop() error {
 	}
 	defer self.PostStop()
 	self.wg.Wait()
-	return nil
+	self.protection.Close()
+	return self.seeds.close()
 }
 
 func (self *worker) produceSnapshot(e consensus.Event) {
@@ -89,15 +118,30 @@ func (self *worker) genAndInsert(e *consensus.Event) {
 	// unlock pool
 	defer self.tools.pool.UnLockInsert()
 
-	seed := self.randomSeed()
+	seed, err := randomSeed()
+	if err != nil {
+		wLog.Error("produce snapshot block fail[seed].", "err", err)
+		return
+	}
 
-	// generate snapshot block
-	b, err := self.tools.generateSnapshot(e, self.coinbase, seed, self.getSeedByHash)
+	// generate snapshot block, remember which seed is revealed
+	var revealed *types.Hash
+	b, err := self.tools.generateSnapshot(e, self.coinbase, seed, func(hash *types.Hash) uint64 {
+		revealed = hash
+		return self.getSeedByHash(hash)
+	}, self.protection)
 	if err != nil {
 		wLog.Error("produce snapshot block fail[generate].", "err", err)
 		return
 	}
 
+	// the seed must be on disk before the block is inserted and broadcast,
+	// otherwise it can not be revealed after crash
+	if err = self.storeSeedHash(seed, b.SeedHash); err != nil {
+		wLog.Error("produce snapshot block fail[store seed].", "err", err)
+		return
+	}
+
 	// insert snapshot block
 	err = self.tools.insertSnapshot(b)
 	if err != nil {
@@ -105,13 +149,25 @@ func (self *worker) genAndInsert(e *consensus.Event) {
 		return
 	}
 
-	// todo
-	self.storeSeedHash(seed, b.SeedHash)
+	if revealed != nil && b.Seed != 0 {
+		if err = self.seeds.reveal(*revealed, time.Now()); err != nil {
+			self.log.Error("mark seed revealed fail.", "hash", revealed, "err", err)
+		}
+		self.seeds.prune(time.Now())
+	}
 }
 
-func (self *worker) randomSeed() uint64 {
-	r := rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
-	return r.Uint64()
+// randomSeed generate a non-zero seed by CSPRNG, zero means no seed
+func randomSeed() (uint64, error) {
+	var buf [8]byte
+	for {
+		if _, err := crand.Read(buf[:]); err != nil {
+			return 0, err
+		}
+		if seed := binary.BigEndian.Uint64(buf[:]); seed != 0 {
+			return seed, nil
+		}
+	}
 }
 
 func (self *worker) getSeedByHash(hash *types.Hash) uint64 {
@@ -119,23 +175,24 @@ func (self *worker) getSeedByHash(hash *types.Hash) uint64 {
 		// default-> zero
 		return 0
 	}
-	fmt.Printf("query seed, hash:%s\n", hash)
-	value, ok := self.seedCache.Get(*hash)
+	seed, ok := self.seeds.get(*hash)
 	if ok {
-		self.log.Info(fmt.Sprintf("query seed, hash:%s, seed:%d\n", hash, value.(uint64)))
-		return value.(uint64)
+		self.log.Info(fmt.Sprintf("query seed, hash:%s, seed:%d\n", hash, seed))
+		return seed
 	} else {
+		self.log.Warn(fmt.Sprintf("query seed, hash:%s, seed not found\n", hash))
 		// default-> zero
 		return 0
 	}
 }
-func (self *worker) storeSeedHash(seed uint64, hash *types.Hash) {
+
+func (self *worker) storeSeedHash(seed uint64, hash *types.Hash) error {
 	if seed == 0 {
-		return
+		return nil
 	}
 	if hash == nil {
-		return
+		return nil
 	}
-	self.log.Info(fmt.Sprintf("store seed, hash:%s, seed:%d\n", hash, seed))
-	self.seedCache.Add(*hash, seed)
+	self.log.Info(fmt.Sprintf("store seed, hash:%s\n", hash))
+	return self.seeds.put(*hash, seed, time.Now())
 }
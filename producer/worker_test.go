@@ -3,14 +3,20 @@ package producer
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vitelabs/go-vite/common/types"
 )
 
 func TestStoreSeed(t *testing.T) {
-	w := newWorker(nil, nil)
+	w := newWorker(nil, nil, "")
+	assert.NoError(t, w.Init())
+	assert.NoError(t, w.Start())
+	defer w.Stop()
 
 	hash := types.HexToHashPanic(calculateHash("100"))
 	w.storeSeedHash(100, &hash)
@@ -18,6 +24,60 @@ func TestStoreSeed(t *testing.T) {
 	assert.Equal(t, uint64(100), result)
 }
 
+func TestSeedStore_reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seeds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newSeedStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	revealed := types.HexToHashPanic(calculateHash("1"))
+	unrevealed := types.HexToHashPanic(calculateHash("2"))
+	expired := types.HexToHashPanic(calculateHash("3"))
+	assert.NoError(t, s.put(revealed, 1, now))
+	assert.NoError(t, s.put(unrevealed, 2, now))
+	assert.NoError(t, s.put(expired, 3, now.Add(-seedUnrevealedTTL-time.Minute)))
+	assert.NoError(t, s.reveal(revealed, now))
+	assert.NoError(t, s.close())
+
+	// seeds survive restart
+	s, err = newSeedStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+
+	seed, ok := s.get(revealed)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), seed)
+
+	assert.Equal(t, 1, s.prune(now))
+	_, ok = s.get(expired)
+	assert.False(t, ok)
+
+	assert.Equal(t, 1, s.prune(now.Add(seedRevealedTTL+time.Minute)))
+	_, ok = s.get(revealed)
+	assert.False(t, ok)
+	seed, ok = s.get(unrevealed)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), seed)
+}
+
+func TestRandomSeed(t *testing.T) {
+	a, err := randomSeed()
+	assert.NoError(t, err)
+	b, err := randomSeed()
+	assert.NoError(t, err)
+	assert.NotEqual(t, uint64(0), a)
+	assert.NotEqual(t, a, b)
+}
+
 func calculateHash(s string) string {
 	h := sha256.New()
 	h.Write([]byte(s))
@@ -1,22 +1,32 @@
 package api
 
 import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vite"
 	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/util"
 )
 
 type ConsensusGroupApi struct {
 	chain chain.Chain
+	cs    consensus.Consensus
 	log   log15.Logger
 }
 
 func NewConsensusGroupApi(vite *vite.Vite) *ConsensusGroupApi {
 	return &ConsensusGroupApi{
 		chain: vite.Chain(),
+		cs:    vite.Consensus(),
 		log:   log15.New("module", "rpc_api/consensus_group_api"),
 	}
 }
@@ -42,6 +52,7 @@ type ConsensusGroup struct {
 	Owner                  types.Address           `json:"owner"`
 	PledgeAmount           string                  `json:"pledgeAmount"`
 	WithdrawHeight         string                  `json:"withdrawHeight"`
+	Cancelled              bool                    `json:"cancelled"`
 }
 type RegisterConditionParam struct {
 	PledgeAmount string            `json:"pledgeAmount"`
@@ -70,6 +81,7 @@ func newConsensusGroup(source *types.ConsensusGroupInfo, sbHeight uint64) *Conse
 		VoteConditionId:     source.VoteConditionId,
 		Owner:               source.Owner,
 		WithdrawHeight:      Uint64ToString(source.ExpirationHeight),
+		Cancelled:           abi.IsCancelledConsensusGroup(source),
 	}
 	if source.StakeAmount != nil {
 		target.PledgeAmount = *bigIntToString(source.StakeAmount)
@@ -118,3 +130,366 @@ func (c *ConsensusGroupApi) GetConsensusGroupList() ([]*ConsensusGroup, error) {
 	}
 	return resultList, nil
 }
+
+const (
+	GroupTxRegister       = "register"
+	GroupTxUpdateProducer = "updateProducer"
+	GroupTxRevoke         = "revoke"
+	GroupTxWithdrawReward = "withdrawReward"
+	GroupTxVote           = "vote"
+	GroupTxCancelVote     = "cancelVote"
+
+	// contract blocks are scanned back at most, to count the blocks produced in a period
+	maxGroupScanBlocks = 1000
+)
+
+// GroupTxParam is the transaction to manage the members of a contract consensus group.
+// Groups are created in genesis or by GetCreateGroupTx, contracts are assigned to a group by the gid of contract creation.
+type GroupTxParam struct {
+	Action          string         `json:"action"`
+	Gid             types.Gid      `json:"gid"`
+	Name            string         `json:"name"`
+	ProducerAddress *types.Address `json:"producerAddress"`
+	ReceiveAddress  *types.Address `json:"receiveAddress"`
+}
+
+// GroupTx is the unsigned request to the governance contract, send it by tx_sendTxWithPrivateKey or a wallet
+type GroupTx struct {
+	ToAddress types.Address     `json:"toAddress"`
+	TokenId   types.TokenTypeId `json:"tokenId"`
+	Amount    string            `json:"amount"`
+	Data      []byte            `json:"data"`
+}
+
+// GetGroupTx build the request of an action on a consensus group
+func (c *ConsensusGroupApi) GetGroupTx(param GroupTxParam) (*GroupTx, error) {
+	if util.IsDelegateGid(param.Gid) {
+		return nil, errors.New("the global delegate group has no member to manage")
+	}
+	db, err := getVmDb(c.chain, types.AddressGovernance)
+	if err != nil {
+		return nil, err
+	}
+	group, err := abi.GetConsensusGroup(db, param.Gid)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil || !group.IsActive() {
+		return nil, errors.Errorf("consensus group %s not exist", param.Gid)
+	}
+
+	tx := &GroupTx{ToAddress: types.AddressGovernance, TokenId: ledger.ViteTokenId, Amount: "0"}
+	switch param.Action {
+	case GroupTxRegister:
+		if param.ProducerAddress == nil {
+			return nil, errors.New("missing producer address")
+		}
+		if abi.IsCancelledConsensusGroup(group) {
+			return nil, errors.Errorf("consensus group %s is cancelled", param.Gid)
+		}
+		sb, err := db.LatestSnapshotBlock()
+		if err != nil {
+			return nil, err
+		}
+		amount := contracts.SbpStakeAmountPreMainnet
+		if fork.IsLeafFork(sb.Height) {
+			amount = contracts.SbpStakeAmountMainnet
+		}
+		tx.Amount = amount.String()
+		tx.Data, err = abi.ABIGovernance.PackMethod(abi.MethodNameRegister, param.Gid, param.Name, *param.ProducerAddress)
+	case GroupTxUpdateProducer:
+		if param.ProducerAddress == nil {
+			return nil, errors.New("missing producer address")
+		}
+		tx.Data, err = abi.ABIGovernance.PackMethod(abi.MethodNameUpdateBlockProducintAddressV2, param.Gid, param.Name, *param.ProducerAddress)
+	case GroupTxRevoke:
+		tx.Data, err = abi.ABIGovernance.PackMethod(abi.MethodNameRevokeV2, param.Gid, param.Name)
+	case GroupTxWithdrawReward:
+		if param.ReceiveAddress == nil {
+			return nil, errors.New("missing receive address")
+		}
+		tx.Data, err = abi.ABIGovernance.PackMethod(abi.MethodNameWithdrawRewardV2, param.Gid, param.Name, *param.ReceiveAddress)
+	case GroupTxVote:
+		tx.Data, err = abi.ABIGovernance.PackMethod(abi.MethodNameVote, param.Gid, param.Name)
+	case GroupTxCancelVote:
+		tx.Data, err = abi.ABIGovernance.PackMethod(abi.MethodNameCancelVote, param.Gid)
+	default:
+		return nil, errors.Errorf("unknown action %s", param.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// CreateGroupParam is the schedule of a new delegate consensus group, it can not be changed after creation.
+// Producers register with the stake amount of a snapshot block producer, locked for RegisterStakeHeight snapshot blocks.
+type CreateGroupParam struct {
+	NodeCount           uint8  `json:"nodeCount"`
+	Interval            int64  `json:"interval"`
+	PerCount            int64  `json:"perCount"`
+	RandCount           uint8  `json:"randCount"`
+	RandRank            uint8  `json:"randRank"`
+	Repeat              uint16 `json:"repeat"`
+	CheckLevel          uint8  `json:"checkLevel"`
+	RegisterStakeHeight string `json:"registerStakeHeight"`
+}
+
+// GetCreateGroupTx build the request to create a delegate consensus group, the gid is in the createDelegateGroup event
+// of the receive block. The stake of the group is locked and returned when the group is cancelled.
+func (c *ConsensusGroupApi) GetCreateGroupTx(param CreateGroupParam) (*GroupTx, error) {
+	if err := c.checkDelegateGroupFork(); err != nil {
+		return nil, err
+	}
+	stakeHeight, err := StringToUint64(param.RegisterStakeHeight)
+	if err != nil {
+		return nil, err
+	}
+	data, err := abi.ABIGovernance.PackMethod(abi.MethodNameCreateDelegateGroup,
+		param.NodeCount, param.Interval, param.PerCount, param.RandCount, param.RandRank, param.Repeat, param.CheckLevel, stakeHeight)
+	if err != nil {
+		return nil, err
+	}
+	return &GroupTx{ToAddress: types.AddressGovernance, TokenId: ledger.ViteTokenId, Amount: contracts.DelegateGroupStakeAmount.String(), Data: data}, nil
+}
+
+// GetUpdateGroupTx build the request of the group owner to change the register stake height of a delegate consensus group
+func (c *ConsensusGroupApi) GetUpdateGroupTx(gid types.Gid, registerStakeHeight string) (*GroupTx, error) {
+	if err := c.checkDelegateGroupFork(); err != nil {
+		return nil, err
+	}
+	stakeHeight, err := StringToUint64(registerStakeHeight)
+	if err != nil {
+		return nil, err
+	}
+	data, err := abi.ABIGovernance.PackMethod(abi.MethodNameUpdateDelegateGroup, gid, stakeHeight)
+	if err != nil {
+		return nil, err
+	}
+	return &GroupTx{ToAddress: types.AddressGovernance, TokenId: ledger.ViteTokenId, Amount: "0", Data: data}, nil
+}
+
+// GetCancelGroupTx build the request of the group owner to cancel a delegate consensus group and withdraw the stake.
+// Registered producers keep producing blocks for contracts of a cancelled group, new producers can not register.
+func (c *ConsensusGroupApi) GetCancelGroupTx(gid types.Gid) (*GroupTx, error) {
+	if err := c.checkDelegateGroupFork(); err != nil {
+		return nil, err
+	}
+	data, err := abi.ABIGovernance.PackMethod(abi.MethodNameCancelDelegateGroup, gid)
+	if err != nil {
+		return nil, err
+	}
+	return &GroupTx{ToAddress: types.AddressGovernance, TokenId: ledger.ViteTokenId, Amount: "0", Data: data}, nil
+}
+
+func (c *ConsensusGroupApi) checkDelegateGroupFork() error {
+	sb := c.chain.GetLatestSnapshotBlock()
+	if !fork.IsDelegateGroupFork(sb.Height) {
+		return errors.New("delegate consensus groups can not be managed before the delegate group fork")
+	}
+	return nil
+}
+
+type GroupMember struct {
+	Name            string        `json:"name"`
+	ProducerAddress types.Address `json:"producerAddress"`
+	StakeAddress    types.Address `json:"stakeAddress"`
+	Active          bool          `json:"active"`
+	Voters          int           `json:"voters"`
+}
+
+type GroupPlan struct {
+	ProducerAddress types.Address `json:"producerAddress"`
+	STime           int64         `json:"stime"`
+	ETime           int64         `json:"etime"`
+}
+
+type GroupProducerStats struct {
+	ProducerAddress types.Address `json:"producerAddress"`
+	Planned         int           `json:"planned"`
+	Produced        int           `json:"produced"`
+}
+
+type GroupPeriod struct {
+	Index     string                `json:"index"`
+	STime     int64                 `json:"stime"`
+	ETime     int64                 `json:"etime"`
+	Producers []*GroupProducerStats `json:"producers"`
+}
+
+type GroupContract struct {
+	Address    types.Address `json:"address"`
+	Unreceived string        `json:"unreceived"`
+}
+
+// GroupDashboard is the state of a consensus group. The schedule is of the current period,
+// produced blocks are counted by the time they are snapshotted, so they are approximate.
+type GroupDashboard struct {
+	Group     *ConsensusGroup  `json:"group"`
+	Members   []*GroupMember   `json:"members"`
+	Schedule  []*GroupPlan     `json:"schedule"`
+	Periods   []*GroupPeriod   `json:"periods"`
+	Contracts []*GroupContract `json:"contracts"`
+}
+
+// GetConsensusGroupDashboard return the members, schedule, produced blocks of the current and the last period,
+// and the unreceived blocks of every contract in the group
+func (c *ConsensusGroupApi) GetConsensusGroupDashboard(gid types.Gid) (*GroupDashboard, error) {
+	group, err := c.GetConsensusGroupById(gid)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, errors.Errorf("consensus group %s not exist", gid)
+	}
+	dashboard := &GroupDashboard{Group: group}
+
+	if dashboard.Members, err = c.groupMembers(gid); err != nil {
+		return nil, err
+	}
+
+	addrList, err := c.chain.GetContractList(gid)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrList {
+		contract := &GroupContract{Address: addr, Unreceived: "0"}
+		if info, err := c.chain.GetAccountOnRoadInfo(addr); err == nil && info != nil {
+			contract.Unreceived = Uint64ToString(info.TotalNumber)
+		}
+		dashboard.Contracts = append(dashboard.Contracts, contract)
+	}
+
+	current, err := c.cs.VoteTimeToIndex(gid, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var events [][]*consensus.Event
+	for index := current; ; index-- {
+		list, _, err := c.cs.ReadByIndex(gid, index)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, list)
+		if index == 0 || len(events) == 2 {
+			break
+		}
+	}
+
+	for _, e := range events[0] {
+		dashboard.Schedule = append(dashboard.Schedule, &GroupPlan{ProducerAddress: e.Address, STime: e.Stime.Unix(), ETime: e.Etime.Unix()})
+	}
+
+	produced, err := c.producedBlocks(addrList, events[len(events)-1])
+	if err != nil {
+		return nil, err
+	}
+	for i, list := range events {
+		index := current - uint64(i)
+		stime, etime, err := c.cs.VoteIndexToTime(gid, index)
+		if err != nil {
+			return nil, err
+		}
+		period := &GroupPeriod{Index: Uint64ToString(index), STime: stime.Unix(), ETime: etime.Unix()}
+
+		m := make(map[types.Address]*GroupProducerStats)
+		for _, e := range list {
+			stats, ok := m[e.Address]
+			if !ok {
+				stats = &GroupProducerStats{ProducerAddress: e.Address, Produced: produced[index][e.Address]}
+				m[e.Address] = stats
+				period.Producers = append(period.Producers, stats)
+			}
+			stats.Planned++
+		}
+		dashboard.Periods = append(dashboard.Periods, period)
+	}
+	return dashboard, nil
+}
+
+func (c *ConsensusGroupApi) groupMembers(gid types.Gid) ([]*GroupMember, error) {
+	db, err := getVmDb(c.chain, types.AddressGovernance)
+	if err != nil {
+		return nil, err
+	}
+	registrations, err := abi.GetAllRegistrationList(db, gid)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := abi.GetVoteList(db, gid)
+	if err != nil {
+		return nil, err
+	}
+	voters := make(map[string]int)
+	for _, v := range votes {
+		voters[v.SbpName]++
+	}
+
+	members := make([]*GroupMember, 0, len(registrations))
+	for _, r := range registrations {
+		members = append(members, &GroupMember{
+			Name:            r.Name,
+			ProducerAddress: r.BlockProducingAddress,
+			StakeAddress:    r.StakeAddress,
+			Active:          r.IsActive(),
+			Voters:          voters[r.Name],
+		})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return members, nil
+}
+
+// producedBlocks count the blocks of contracts by period and producer, from the period of the first event to now.
+// A block is in the period it is snapshotted, unconfirmed blocks are in the current period.
+func (c *ConsensusGroupApi) producedBlocks(addrList []types.Address, first []*consensus.Event) (map[uint64]map[types.Address]int, error) {
+	result := make(map[uint64]map[types.Address]int)
+	if len(first) == 0 {
+		return result, nil
+	}
+	gid := first[0].Gid
+	since := first[0].PeriodStime
+
+	count := func(t time.Time, producer types.Address) error {
+		index, err := c.cs.VoteTimeToIndex(gid, t)
+		if err != nil {
+			return err
+		}
+		m, ok := result[index]
+		if !ok {
+			m = make(map[types.Address]int)
+			result[index] = m
+		}
+		m[producer]++
+		return nil
+	}
+
+	for _, addr := range addrList {
+		block, err := c.chain.GetLatestAccountBlock(addr)
+		for i := 0; err == nil && block != nil && i < maxGroupScanBlocks; i++ {
+			t := time.Now()
+			var sb *ledger.SnapshotBlock
+			if sb, err = c.chain.GetConfirmSnapshotHeaderByAbHash(block.Hash); err != nil {
+				break
+			}
+			if sb != nil {
+				t = *sb.Timestamp
+			}
+			if t.Before(since) {
+				break
+			}
+			if block.IsReceiveBlock() {
+				if err = count(t, block.Producer()); err != nil {
+					break
+				}
+			}
+			if block.Height <= 1 {
+				break
+			}
+			block, err = c.chain.GetAccountBlockByHeight(addr, block.Height-1)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
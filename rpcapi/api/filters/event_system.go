@@ -25,6 +25,7 @@ const (
 	OnroadBlocksSubscriptionV2
 	SnapshotBlocksSubscription
 	SnapshotBlocksSubscriptionV2
+	WatchSetSubscription
 )
 
 type subscription struct {
@@ -40,6 +41,8 @@ type subscription struct {
 	accountBlockWithHeightCh chan []*AccountBlockWithHeight
 	logsCh                   chan []*Logs
 	onroadMsgCh              chan []*OnroadMsg
+	watched                  func(addr types.Address) bool
+	watchMsgCh               chan []*WatchMsg
 }
 
 type EventSystem struct {
@@ -92,7 +95,7 @@ func (es *EventSystem) Stop() {
 func (es *EventSystem) eventLoop() {
 	es.log.Info("start event loop")
 	index := make(map[FilterType]map[rpc.ID]*subscription)
-	for i := LogsSubscription; i <= SnapshotBlocksSubscriptionV2; i++ {
+	for i := LogsSubscription; i <= WatchSetSubscription; i++ {
 		index[i] = make(map[rpc.ID]*subscription)
 	}
 
@@ -223,6 +226,12 @@ func (es *EventSystem) handleAcEvent(filters map[FilterType]map[rpc.ID]*subscrip
 			f.onroadMsgCh <- onroadMsgs
 		}
 	}
+	// handle watch sets
+	for _, f := range filters[WatchSetSubscription] {
+		if watchMsgs := filterWatchMsgs(acEvent, f.watched, removed); len(watchMsgs) > 0 {
+			f.watchMsgCh <- watchMsgs
+		}
+	}
 	// handle logs
 	for _, f := range filters[LogsSubscription] {
 		var logs []*Logs
@@ -256,6 +265,32 @@ func appendOnroadMsg(onroadMsgs map[types.Address][]*OnroadMsg, toAddr types.Add
 	return onroadMsgs
 }
 
+// filterWatchMsgs returns the blocks sent or received by the watched addresses, and the blocks sent to them
+func filterWatchMsgs(acEvent []*AccountChainEvent, watched func(addr types.Address) bool, removed bool) []*WatchMsg {
+	var msgs []*WatchMsg
+	for _, e := range acEvent {
+		height := api.Uint64ToString(e.Height)
+		if ledger.IsSendBlock(e.BlockType) {
+			if watched(e.Addr) {
+				msgs = append(msgs, &WatchMsg{Address: e.Addr, AccountAddress: e.Addr, Hash: e.Hash, Height: height, Type: WatchMsgSend, Removed: removed})
+			}
+			if watched(e.ToAddr) {
+				msgs = append(msgs, &WatchMsg{Address: e.ToAddr, AccountAddress: e.Addr, Hash: e.Hash, Height: height, Type: WatchMsgIncoming, Removed: removed})
+			}
+			continue
+		}
+		if watched(e.Addr) {
+			msgs = append(msgs, &WatchMsg{Address: e.Addr, AccountAddress: e.Addr, Hash: e.Hash, Height: height, Type: WatchMsgReceive, Removed: removed})
+		}
+		for _, sendBlock := range e.SendBlockList {
+			if watched(sendBlock.ToAddr) {
+				msgs = append(msgs, &WatchMsg{Address: sendBlock.ToAddr, AccountAddress: e.Addr, Hash: sendBlock.Hash, Type: WatchMsgIncoming, Removed: removed})
+			}
+		}
+	}
+	return msgs
+}
+
 func filterLogs(e *AccountChainEvent, filter *api.FilterParam, removed bool) []*Logs {
 	if len(e.Logs) == 0 {
 		return nil
@@ -299,6 +334,7 @@ func (s *RpcSubscription) Unsubscribe() {
 			case <-s.sub.logsCh:
 			case <-s.sub.snapshotBlockCh:
 			case <-s.sub.onroadMsgCh:
+			case <-s.sub.watchMsgCh:
 			}
 		}
 		<-s.Err()
@@ -317,6 +353,7 @@ func (es *EventSystem) SubscribeAccountBlocks(ch chan []*AccountBlock) *RpcSubsc
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		watchMsgCh:               make(chan []*WatchMsg),
 	}
 	return es.subscribe(sub)
 }
@@ -334,6 +371,7 @@ func (es *EventSystem) SubscribeAccountBlocksByAddr(addr types.Address, ch chan
 		accountBlockWithHeightCh: ch,
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		watchMsgCh:               make(chan []*WatchMsg),
 	}
 	return es.subscribe(sub)
 }
@@ -351,6 +389,7 @@ func (es *EventSystem) SubscribeOnroadBlocksByAddr(addr types.Address, ch chan [
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              ch,
+		watchMsgCh:               make(chan []*WatchMsg),
 	}
 	return es.subscribe(sub)
 }
@@ -367,6 +406,7 @@ func (es *EventSystem) SubscribeSnapshotBlocks(ch chan []*SnapshotBlock, ft Filt
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		watchMsgCh:               make(chan []*WatchMsg),
 	}
 	return es.subscribe(sub)
 }
@@ -384,6 +424,26 @@ func (es *EventSystem) SubscribeLogs(p *api.FilterParam, ch chan []*Logs, ft Fil
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   ch,
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		watchMsgCh:               make(chan []*WatchMsg),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeWatchSet notifies the blocks of the addresses watched is true for, it is called for every new block
+func (es *EventSystem) SubscribeWatchSet(watched func(addr types.Address) bool, ch chan []*WatchMsg) *RpcSubscription {
+	sub := &subscription{
+		id:                       rpc.NewID(),
+		typ:                      WatchSetSubscription,
+		createTime:               time.Now(),
+		installed:                make(chan struct{}),
+		err:                      make(chan error),
+		snapshotBlockCh:          make(chan []*SnapshotBlock),
+		accountBlockCh:           make(chan []*AccountBlock),
+		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
+		logsCh:                   make(chan []*Logs),
+		onroadMsgCh:              make(chan []*OnroadMsg),
+		watched:                  watched,
+		watchMsgCh:               ch,
 	}
 	return es.subscribe(sub)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net"
 	"github.com/vitelabs/go-vite/rpc"
 	"github.com/vitelabs/go-vite/rpcapi/api"
 	"github.com/vitelabs/go-vite/vite"
@@ -117,6 +118,21 @@ type SnapshotBlockV2 struct {
 	Removed bool       `json:"removed"`
 }
 
+const (
+	WatchMsgSend     = "send"     // the watched address sent the block
+	WatchMsgReceive  = "receive"  // the watched address received a block
+	WatchMsgIncoming = "incoming" // the block is sent to the watched address and waiting to be received
+)
+
+type WatchMsg struct {
+	Address        types.Address `json:"address"`
+	AccountAddress types.Address `json:"accountAddress"`
+	Hash           types.Hash    `json:"hash"`
+	Height         string        `json:"height,omitempty"`
+	Type           string        `json:"type"`
+	Removed        bool          `json:"removed"`
+}
+
 type Logs struct {
 	Log              *ledger.VmLog  `json:"log"`
 	AccountBlockHash types.Hash     `json:"accountBlockHash"`
@@ -507,6 +523,131 @@ func (s *SubscribeApi) createSnapshotBlockSubscription(ctx context.Context, ft F
 	return rpcSub, nil
 }
 
+// CreateEvidenceSubscription notify evidences of producers signing two snapshot blocks for the same slot
+func (s *SubscribeApi) CreateEvidenceSubscription(ctx context.Context) (*rpc.Subscription, error) {
+	s.log.Info("createEvidenceSubscription")
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	evidenceCh := make(chan *api.Evidence, 128)
+	subId := s.vite.Net().SubscribeEvidence(func(e *net.Evidence) {
+		select {
+		case evidenceCh <- api.ToEvidence(e):
+		default:
+		}
+	})
+
+	go func() {
+		defer s.vite.Net().UnsubscribeEvidence(subId)
+		for {
+			select {
+			case e := <-evidenceCh:
+				notifier.Notify(rpcSub.ID, e)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// CreateFinalizedSnapshotBlockSubscription notify the snapshot block when it is voted final by more than 2/3 of producers
+func (s *SubscribeApi) CreateFinalizedSnapshotBlockSubscription(ctx context.Context) (*rpc.Subscription, error) {
+	s.log.Info("createFinalizedSnapshotBlockSubscription")
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	finalCh := make(chan *SnapshotBlockV2, 128)
+	subId := s.vite.Finality().SubscribeFinalized(func(block *ledger.SnapshotBlock) {
+		select {
+		case finalCh <- &SnapshotBlockV2{Hash: block.Hash, Height: api.Uint64ToString(block.Height)}:
+		default:
+		}
+	})
+
+	go func() {
+		defer s.vite.Finality().UnsubscribeFinalized(subId)
+		for {
+			select {
+			case m := <-finalCh:
+				notifier.Notify(rpcSub.ID, m)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// PrivateSubscribeApi is the subscriptions about the wallet, it is served by IPC only unless exposed explicitly
+type PrivateSubscribeApi struct {
+	vite        *vite.Vite
+	log         log15.Logger
+	eventSystem *EventSystem
+}
+
+func NewPrivateSubscribeApi(vite *vite.Vite) *PrivateSubscribeApi {
+	if Es == nil {
+		panic("Set \"SubscribeEnabled\" to \"true\" in node_config.json")
+	}
+	return &PrivateSubscribeApi{
+		vite:        vite,
+		log:         log15.New("module", "rpc_api/private_subscribe_api"),
+		eventSystem: Es,
+	}
+}
+
+// CreateWatchSetSubscription notify the blocks sent or received by the addresses in the watch set of wallet,
+// the addresses added to or removed from the set later are also applied
+func (s *PrivateSubscribeApi) CreateWatchSetSubscription(ctx context.Context, set string) (*rpc.Subscription, error) {
+	s.log.Info("createWatchSetSubscription")
+	wt := s.vite.WalletManager()
+	if wt == nil {
+		return nil, errors.New("wallet is not enabled")
+	}
+	if _, err := wt.GetWatchSet(set); err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		watchCh := make(chan []*WatchMsg, 128)
+		watchSub := s.eventSystem.SubscribeWatchSet(func(addr types.Address) bool {
+			return wt.IsWatched(set, addr)
+		}, watchCh)
+		for {
+			select {
+			case msgs := <-watchCh:
+				notifier.Notify(rpcSub.ID, msgs)
+			case <-rpcSub.Err():
+				watchSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				watchSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Deprecated: use subscribe_createAccountBlockSubscription instead
 func (s *SubscribeApi) NewAccountBlocks(ctx context.Context) (*rpc.Subscription, error) {
 	return s.createAccountBlockSubscription(ctx)
@@ -264,6 +264,21 @@ func (l *LedgerApi) GetLatestSnapshotBlock() (*SnapshotBlock, error) {
 	return l.ledgerSnapshotBlockToRpcBlock(block)
 }
 
+// GetFinalizedSnapshotBlock return the highest snapshot block voted final by more than 2/3 of producers, nil if none
+func (l *LedgerApi) GetFinalizedSnapshotBlock() (*SnapshotBlock, error) {
+	header := l.vite.Finality().FinalizedSnapshotBlock()
+	if header == nil {
+		return nil, nil
+	}
+
+	block, err := l.chain.GetSnapshotBlockByHash(header.Hash)
+	if err != nil {
+		l.log.Error("GetSnapshotBlockByHash failed, error is "+err.Error(), "method", "GetFinalizedSnapshotBlock")
+		return nil, err
+	}
+	return l.ledgerSnapshotBlockToRpcBlock(block)
+}
+
 // old api
 func (l *LedgerApi) GetLatestBlock(addr types.Address) (*AccountBlock, error) {
 	return l.GetLatestAccountBlock(addr)
@@ -72,6 +72,10 @@ type AccountBlock struct {
 	ReceiveBlockHeight *string     `json:"receiveBlockHeight"`
 	ReceiveBlockHash   *types.Hash `json:"receiveBlockHash"`
 
+	// expiry of an unreceived call to a user contract
+	ExpirationHeight *string `json:"expirationHeight,omitempty"`
+	Expired          *bool   `json:"expired,omitempty"`
+
 	Timestamp int64 `json:"timestamp"`
 }
 
@@ -270,6 +270,9 @@ func (l *LedgerApi) GetUnreceivedBlocksByAddress(address types.Address, index, c
 			if e != nil {
 				return nil, e
 			}
+			if e := l.setUnreceivedExpiry(v, accountBlock); e != nil {
+				return nil, e
+			}
 			a[sum] = accountBlock
 			sum++
 		}
@@ -277,6 +280,22 @@ func (l *LedgerApi) GetUnreceivedBlocksByAddress(address types.Address, index, c
 	return a[:sum], nil
 }
 
+// setUnreceivedExpiry fills the expiration of a confirmed call to a user contract
+func (l *LedgerApi) setUnreceivedExpiry(block *ledger.AccountBlock, rpcBlock *AccountBlock) error {
+	if block.BlockType != ledger.BlockTypeSendCall || !types.IsContractAddr(block.ToAddress) || types.IsBuiltinContractAddr(block.ToAddress) {
+		return nil
+	}
+	confirmSb, err := l.chain.GetConfirmSnapshotHeaderByAbHash(block.Hash)
+	if err != nil || confirmSb == nil {
+		return err
+	}
+	expirationHeight := Uint64ToString(util.UnreceivedExpirationHeight(confirmSb.Height))
+	expired := util.IsUnreceivedExpired(confirmSb.Height, l.chain.GetLatestSnapshotBlock().Height)
+	rpcBlock.ExpirationHeight = &expirationHeight
+	rpcBlock.Expired = &expired
+	return nil
+}
+
 // new api: ledger_getUnreceivedTransactionSummaryByAddress <- onroad_getOnroadInfoByAddress
 func (l *LedgerApi) GetUnreceivedTransactionSummaryByAddress(address types.Address) (*AccountInfo, error) {
 
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"sort"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+// MultisigApi builds the call data of multisig contract and queries the wallets,
+// the calls are sent to types.AddressMultisig after the multisig fork.
+type MultisigApi struct {
+	chain chain.Chain
+	log   log15.Logger
+}
+
+func NewMultisigApi(vite *vite.Vite) *MultisigApi {
+	return &MultisigApi{
+		chain: vite.Chain(),
+		log:   log15.New("module", "rpc_api/multisig_api"),
+	}
+}
+
+func (m MultisigApi) String() string {
+	return "MultisigApi"
+}
+
+// GetCreateWalletData builds data of creating a wallet, amount of the call is deposited into the wallet
+func (m *MultisigApi) GetCreateWalletData(owners []types.Address, threshold uint8) ([]byte, error) {
+	return abi.ABIMultisig.PackMethod(abi.MethodNameMultisigCreateWallet, owners, threshold)
+}
+
+func (m *MultisigApi) GetDepositData(walletId string) ([]byte, error) {
+	id, err := StringToUint64(walletId)
+	if err != nil {
+		return nil, err
+	}
+	return abi.ABIMultisig.PackMethod(abi.MethodNameMultisigDeposit, id)
+}
+
+type MultisigTransferParam struct {
+	WalletId  string            `json:"walletId"`
+	ToAddress types.Address     `json:"toAddress"`
+	TokenId   types.TokenTypeId `json:"tokenId"`
+	Amount    string            `json:"amount"`
+	Data      []byte            `json:"data"`
+}
+
+// GetProposeTransferData builds data of proposing a transfer or a contract call from the wallet
+func (m *MultisigApi) GetProposeTransferData(param MultisigTransferParam) ([]byte, error) {
+	id, err := StringToUint64(param.WalletId)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := stringToBigInt(&param.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if param.Data == nil {
+		param.Data = []byte{}
+	}
+	return abi.ABIMultisig.PackMethod(abi.MethodNameMultisigProposeTransfer, id, param.ToAddress, param.TokenId, amount, param.Data)
+}
+
+func (m *MultisigApi) GetProposeOwnerChangeData(walletId string, owners []types.Address, threshold uint8) ([]byte, error) {
+	id, err := StringToUint64(walletId)
+	if err != nil {
+		return nil, err
+	}
+	return abi.ABIMultisig.PackMethod(abi.MethodNameMultisigProposeOwnerChange, id, owners, threshold)
+}
+
+func (m *MultisigApi) GetApproveData(walletId string, proposalId string) ([]byte, error) {
+	id, err := StringToUint64(walletId)
+	if err != nil {
+		return nil, err
+	}
+	pId, err := StringToUint64(proposalId)
+	if err != nil {
+		return nil, err
+	}
+	return abi.ABIMultisig.PackMethod(abi.MethodNameMultisigApprove, id, pId)
+}
+
+type MultisigWalletInfo struct {
+	WalletId  string                       `json:"walletId"`
+	Owners    []types.Address              `json:"owners"`
+	Threshold uint8                        `json:"threshold"`
+	Balances  map[types.TokenTypeId]string `json:"balances"`
+}
+
+type MultisigProposalInfo struct {
+	ProposalId string          `json:"proposalId"`
+	Proposer   types.Address   `json:"proposer"`
+	Approvals  []types.Address `json:"approvals"`
+	// approvals of the current owners, the proposal is executed when it reaches the threshold
+	ApprovalCount int `json:"approvalCount"`
+
+	// transfer
+	ToAddress *types.Address     `json:"toAddress,omitempty"`
+	TokenId   *types.TokenTypeId `json:"tokenId,omitempty"`
+	Amount    *string            `json:"amount,omitempty"`
+	Data      []byte             `json:"data,omitempty"`
+
+	// owner change
+	Owners    []types.Address `json:"owners,omitempty"`
+	Threshold uint8           `json:"threshold,omitempty"`
+}
+
+// GetWallet returns the owners and balances of the wallet, nil if not exists
+func (m *MultisigApi) GetWallet(walletId string) (*MultisigWalletInfo, error) {
+	id, err := StringToUint64(walletId)
+	if err != nil {
+		return nil, err
+	}
+	db, err := getVmDb(m.chain, types.AddressMultisig)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := abi.GetMultisigWallet(db, id)
+	if err != nil || wallet == nil {
+		return nil, err
+	}
+	balances, err := abi.GetMultisigWalletBalances(db, id)
+	if err != nil {
+		return nil, err
+	}
+	info := &MultisigWalletInfo{
+		WalletId:  walletId,
+		Owners:    wallet.Owners,
+		Threshold: wallet.Threshold,
+		Balances:  make(map[types.TokenTypeId]string, len(balances)),
+	}
+	for tokenId, balance := range balances {
+		info.Balances[tokenId] = *bigIntToString(balance)
+	}
+	return info, nil
+}
+
+// GetWalletIdsByOwner returns ids of the wallets owned by addr
+func (m *MultisigApi) GetWalletIdsByOwner(owner types.Address) ([]string, error) {
+	db, err := getVmDb(m.chain, types.AddressMultisig)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := abi.GetMultisigWalletsByOwner(db, owner)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	list := make([]string, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, Uint64ToString(id))
+	}
+	return list, nil
+}
+
+// GetPendingProposals returns the proposals of the wallet waiting for approvals
+func (m *MultisigApi) GetPendingProposals(walletId string) ([]*MultisigProposalInfo, error) {
+	id, err := StringToUint64(walletId)
+	if err != nil {
+		return nil, err
+	}
+	db, err := getVmDb(m.chain, types.AddressMultisig)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := abi.GetMultisigWallet(db, id)
+	if err != nil || wallet == nil {
+		return nil, err
+	}
+	proposals, err := abi.GetMultisigProposalList(db, id)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*MultisigProposalInfo, 0, len(proposals))
+	for _, p := range proposals {
+		list = append(list, toMultisigProposalInfo(p, wallet))
+	}
+	return list, nil
+}
+
+func toMultisigProposalInfo(p *abi.MultisigProposal, wallet *abi.MultisigWallet) *MultisigProposalInfo {
+	info := &MultisigProposalInfo{
+		ProposalId:    Uint64ToString(p.Id),
+		Proposer:      p.Proposer,
+		Approvals:     p.Approvals,
+		ApprovalCount: p.ApprovalCount(wallet),
+	}
+	if p.IsOwnerChange() {
+		owners := new(abi.ParamMultisigOwners)
+		if err := abi.ABIMultisig.UnpackVariable(owners, abi.VariableNameMultisigOwners, p.Data); err == nil {
+			info.Owners, info.Threshold = owners.Owners, owners.Threshold
+		}
+		return info
+	}
+	info.ToAddress = &p.To
+	info.TokenId = &p.TokenId
+	info.Amount = bigIntToString(p.Amount)
+	info.Data = p.Data
+	return info
+}
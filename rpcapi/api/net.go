@@ -3,6 +3,7 @@ package api
 import (
 	"strconv"
 
+	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/net"
 	"github.com/vitelabs/go-vite/net/vnode"
@@ -58,6 +59,43 @@ func (n *NetApi) NodeInfo() net.NodeInfo {
 	return n.net.Info()
 }
 
+// NetInfo return the traffic of every message code and every peer
+func (n *NetApi) NetInfo() net.NetInfo {
+	return n.net.NetInfo()
+}
+
+// Evidence is two snapshot blocks signed by the same producer for the same slot
+type Evidence struct {
+	Producer  types.Address    `json:"producer"`
+	Timestamp int64            `json:"timestamp"`
+	Blocks    []*SnapshotBlock `json:"blocks"`
+}
+
+func ToEvidence(e *net.Evidence) *Evidence {
+	evidence := &Evidence{
+		Producer:  e.Producer,
+		Timestamp: e.Timestamp,
+	}
+	for _, block := range e.Blocks {
+		rpcBlock, _ := ledgerSnapshotBlockToRpcBlock(block)
+		evidence.Blocks = append(evidence.Blocks, rpcBlock)
+	}
+
+	return evidence
+}
+
+// Evidences return the evidences of producers signing two snapshot blocks for the same slot,
+// found locally or received from peers
+func (n *NetApi) Evidences() []*Evidence {
+	list := n.net.Evidences()
+	evidences := make([]*Evidence, len(list))
+	for i, e := range list {
+		evidences[i] = ToEvidence(e)
+	}
+
+	return evidences
+}
+
 type Nodes struct {
 	Count int
 	Nodes []*vnode.Node
@@ -70,3 +108,17 @@ func (n *NetApi) Nodes() Nodes {
 		Count: len(nodes),
 	}
 }
+
+// FindNodes find nodes advertised the role, role can be `full`, `archive`, `light-server`, `file-server` or `sbp`
+func (n *NetApi) FindNodes(role string, count int) (*Nodes, error) {
+	r, err := vnode.ParseRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := n.net.FindNodes(r, count)
+	return &Nodes{
+		Nodes: nodes,
+		Count: len(nodes),
+	}, nil
+}
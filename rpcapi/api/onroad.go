@@ -2,9 +2,13 @@ package api
 
 import (
 	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/go-errors/errors"
 	"github.com/vitelabs/go-vite/common/math"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/onroad"
 	"github.com/vitelabs/go-vite/vite"
 )
 
@@ -36,11 +40,13 @@ func (pub PublicOnroadApi) GetOnroadInfoByAddress(address types.Address) (*RpcAc
 
 type PrivateOnroadApi struct {
 	ledgerApi *LedgerApi
+	manager   *onroad.Manager
 }
 
 func NewPrivateOnroadApi(vite *vite.Vite) *PrivateOnroadApi {
 	return &PrivateOnroadApi{
 		ledgerApi: NewLedgerApi(vite),
+		manager:   vite.OnRoad(),
 	}
 }
 
@@ -105,3 +111,92 @@ func (pri PrivateOnroadApi) GetOnroadInfoInBatch(addrList []types.Address) ([]*R
 	}
 	return resultList, nil
 }
+
+type AutoReceiveFilterParam struct {
+	TokenIdList []types.TokenTypeId `json:"tokenIdList"`
+	MinAmount   *string             `json:"minAmount"`
+}
+
+func (p *AutoReceiveFilterParam) toFilter() (*onroad.AutoReceiveFilter, error) {
+	if p == nil {
+		return nil, nil
+	}
+	filter := &onroad.AutoReceiveFilter{TokenWhitelist: p.TokenIdList}
+	if p.MinAmount != nil {
+		amount, err := stringToBigInt(p.MinAmount)
+		if err != nil {
+			return nil, err
+		}
+		filter.MinAmount = amount
+	}
+	return filter, nil
+}
+
+func (pri PrivateOnroadApi) StartAutoReceive(addr types.Address, filter *AutoReceiveFilterParam) error {
+	log.Info("StartAutoReceive", "addr", addr)
+	f, err := filter.toFilter()
+	if err != nil {
+		return err
+	}
+	return pri.manager.StartAutoReceiveWorker(addr, f)
+}
+
+func (pri PrivateOnroadApi) StopAutoReceive(addr types.Address) error {
+	log.Info("StopAutoReceive", "addr", addr)
+	pri.manager.StopAutoReceiveWorker(addr)
+	return nil
+}
+
+func (pri PrivateOnroadApi) ListWorkingAutoReceiveWorker() []types.Address {
+	return pri.manager.ListWorkingAutoReceiveWorker()
+}
+
+type ContractScheduleStat struct {
+	Address  types.Address `json:"address"`
+	Priority int           `json:"priority"`
+	Weight   string        `json:"weight"`
+
+	Backlog   string `json:"backlog"`
+	Queued    bool   `json:"queued"`
+	WaitTime  string `json:"waitTime"` // in milliseconds
+	LastWait  string `json:"lastWait"` // in milliseconds
+	MaxWait   string `json:"maxWait"`  // in milliseconds
+	Processed string `json:"processed"`
+
+	BlackList       string `json:"blackList,omitempty"`
+	BlackListReason string `json:"blackListReason,omitempty"`
+}
+
+type ContractScheduleInfo struct {
+	Gid       types.Gid               `json:"gid"`
+	Policy    string                  `json:"policy"`
+	Contracts []*ContractScheduleStat `json:"contracts"`
+}
+
+func (pri PrivateOnroadApi) GetContractScheduleStats(gid types.Gid) (*ContractScheduleInfo, error) {
+	policy, stats, err := pri.manager.GetContractScheduleStats(gid)
+	if err != nil {
+		return nil, err
+	}
+	info := &ContractScheduleInfo{Gid: gid, Policy: policy, Contracts: make([]*ContractScheduleStat, 0, len(stats))}
+	for _, v := range stats {
+		info.Contracts = append(info.Contracts, &ContractScheduleStat{
+			Address:         v.Addr,
+			Priority:        v.Priority,
+			Weight:          Uint64ToString(v.Weight),
+			Backlog:         Uint64ToString(v.Backlog),
+			Queued:          v.Queued,
+			WaitTime:        durationToMillisString(v.WaitTime),
+			LastWait:        durationToMillisString(v.LastWait),
+			MaxWait:         durationToMillisString(v.MaxWait),
+			Processed:       Uint64ToString(v.Processed),
+			BlackList:       v.BlackList,
+			BlackListReason: v.BlackListReason,
+		})
+	}
+	return info, nil
+}
+
+func durationToMillisString(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Millisecond), 10)
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/pow"
 	"github.com/vitelabs/go-vite/pow/remote"
@@ -21,22 +23,147 @@ func NewPow(vite *vite.Vite) *Pow {
 	}
 }
 
-func (p Pow) GetPowNonce(difficulty string, data types.Hash) ([]byte, error) {
+type PowSolverStatus struct {
+	Remote   bool         `json:"remote"`
+	Workers  int          `json:"workers"`
+	Solving  []types.Hash `json:"solving"`
+	Hashes   string       `json:"hashes"`
+	Solved   string       `json:"solved"`
+	Canceled string       `json:"canceled"`
+	Elapsed  string       `json:"elapsed"` // in milliseconds
+}
+
+const (
+	// maxPowBatch is the max count of data hashes of GetPowNonces
+	maxPowBatch = 16
+	// maxConcurrentPow is the max count of in-process solves requested by rpc at the same time
+	maxConcurrentPow = 2
+)
+
+var (
+	errPowBusy          = errors.New("too many pow requests, try later")
+	errPowBatchTooLong  = fmt.Errorf("at most %d data hashes in a batch", maxPowBatch)
+	errPowTooDifficult  = errors.New("difficulty is too high")
+	errLocalPowDisabled = errors.New("pow server is not set and in-process pow is disabled")
+)
+
+// powSlots limit the in-process solves requested by rpc, the solves of the node itself are not limited
+var powSlots = make(chan struct{}, maxConcurrentPow)
+
+func acquirePowSlot() error {
+	select {
+	case powSlots <- struct{}{}:
+		return nil
+	default:
+		return errPowBusy
+	}
+}
+
+func releasePowSlot() {
+	<-powSlots
+}
+
+func (p Pow) GetPowNonce(ctx context.Context, difficulty string, data types.Hash) ([]byte, error) {
 	log.Info("GetPowNonce")
+	return getPowNonce(ctx, p.vite, difficulty, data)
+}
 
+// PrivatePow is the pow api for the node operator only, it solves in-process regardless of PowLocalSolve
+type PrivatePow struct {
+	vite *vite.Vite
+}
+
+func NewPrivatePow(vite *vite.Vite) *PrivatePow {
+	return &PrivatePow{
+		vite: vite,
+	}
+}
+
+// GetPowNonces computes the nonces of the data hashes with the same difficulty in-process
+func (p PrivatePow) GetPowNonces(ctx context.Context, difficulty string, data []types.Hash) ([][]byte, error) {
+	log.Info("GetPowNonces", "count", len(data))
+	if len(data) > maxPowBatch {
+		return nil, errPowBatchTooLong
+	}
+	realDifficulty, err := powDifficulty(p.vite, difficulty)
+	if err != nil {
+		return nil, err
+	}
+	if err = acquirePowSlot(); err != nil {
+		return nil, err
+	}
+	defer releasePowSlot()
+	return pow.DefaultSolver().SolveBatch(ctx, realDifficulty, data)
+}
+
+func (p PrivatePow) CancelPow(data types.Hash) error {
+	if !remote.Enabled() {
+		if !pow.DefaultSolver().Cancel(data) {
+			return errors.New("pow not found")
+		}
+		return nil
+	}
+	if err := remote.CancelWork(data.Bytes()); err != nil {
+		return errors.New("pow cancel failed")
+	}
+	return nil
+}
+
+func (p PrivatePow) GetSolverStatus() *PowSolverStatus {
+	status := pow.DefaultSolver().Status()
+	return &PowSolverStatus{
+		Remote:   remote.Enabled(),
+		Workers:  status.Workers,
+		Solving:  status.Solving,
+		Hashes:   Uint64ToString(status.Hashes),
+		Solved:   Uint64ToString(status.Solved),
+		Canceled: Uint64ToString(status.Canceled),
+		Elapsed:  durationToMillisString(status.Elapsed),
+	}
+}
+
+// powDifficulty parses the difficulty, nil is returned in vm test mode, in which the default target is used.
+// The difficulty is not more than that of the max quota of a block.
+func powDifficulty(vite *vite.Vite, difficulty string) (*big.Int, error) {
 	if pow.VMTestParamEnabled {
 		log.Info("use defaultTarget to calc")
-		return pow.GetPowNonce(nil, data)
+		return nil, nil
 	}
 
 	realDifficulty, ok := new(big.Int).SetString(difficulty, 10)
-	if !ok {
+	if !ok || realDifficulty.Sign() < 0 {
 		return nil, ErrStrToBigInt
 	}
+	if realDifficulty.Cmp(quota.MaxPoWDifficulty()) > 0 {
+		return nil, errPowTooDifficult
+	}
 
-	if _, _, isCongestion := quota.CalcQc(p.vite.Chain(), p.vite.Chain().GetLatestSnapshotBlock().Height); isCongestion {
+	if _, _, isCongestion := quota.CalcQc(vite.Chain(), vite.Chain().GetLatestSnapshotBlock().Height); isCongestion {
 		return nil, ErrPoWNotSupportedUnderCongestion
 	}
+	return realDifficulty, nil
+}
+
+// getPowNonce computes the nonce by the pow server if PowServerUrl is set,
+// otherwise in-process if PowLocalSolve is true or in vm test mode.
+func getPowNonce(ctx context.Context, vite *vite.Vite, difficulty string, data types.Hash) ([]byte, error) {
+	realDifficulty, err := powDifficulty(vite, difficulty)
+	if err != nil {
+		return nil, err
+	}
+	if realDifficulty == nil {
+		return pow.GetPowNonceWithContext(ctx, realDifficulty, data)
+	}
+	if !remote.Enabled() {
+		if !powLocalSolve {
+			return nil, errLocalPowDisabled
+		}
+		if err = acquirePowSlot(); err != nil {
+			return nil, err
+		}
+		defer releasePowSlot()
+		return pow.GetPowNonceWithContext(ctx, realDifficulty, data)
+	}
 
 	work, e := remote.GenerateWork(data.Bytes(), realDifficulty)
 	if e != nil {
@@ -52,21 +179,9 @@ func (p Pow) GetPowNonce(difficulty string, data types.Hash) ([]byte, error) {
 	nn := make([]byte, 8)
 	binary.LittleEndian.PutUint64(nn[:], nonceUint64)
 
-	bd, ok := new(big.Int).SetString(difficulty, 10)
-	if !ok {
-		return nil, errors.New("wrong nonce difficulty")
-	}
-
-	if !pow.CheckPowNonce(bd, nn, data.Bytes()) {
+	if !pow.CheckPowNonce(realDifficulty, nn, data.Bytes()) {
 		return nil, errors.New("check nonce failed")
 	}
 
 	return nn, nil
 }
-
-func (p Pow) CancelPow(data types.Hash) error {
-	if err := remote.CancelWork(data.Bytes()); err != nil {
-		return errors.New("pow cancel failed")
-	}
-	return nil
-}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func TestPrivatePow_GetPowNonces(t *testing.T) {
+	p := PrivatePow{}
+	if _, err := p.GetPowNonces(context.Background(), "1", make([]types.Hash, maxPowBatch+1)); err != errPowBatchTooLong {
+		t.Fatalf("batch should be too long: %v", err)
+	}
+}
+
+func TestPowSlots(t *testing.T) {
+	for i := 0; i < maxConcurrentPow; i++ {
+		if err := acquirePowSlot(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := acquirePowSlot(); err != errPowBusy {
+		t.Fatalf("should be busy: %v", err)
+	}
+	releasePowSlot()
+	if err := acquirePowSlot(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxConcurrentPow; i++ {
+		releasePowSlot()
+	}
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"time"
 
+	"github.com/vitelabs/go-vite/analytics"
+	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/consensus"
 	"github.com/vitelabs/go-vite/consensus/core"
 	"github.com/vitelabs/go-vite/log15"
@@ -11,14 +15,16 @@ import (
 )
 
 type StatsApi struct {
-	cs  consensus.Consensus
-	log log15.Logger
+	vite *vite.Vite
+	cs   consensus.Consensus
+	log  log15.Logger
 }
 
 func NewStatsApi(vite *vite.Vite) *StatsApi {
 	return &StatsApi{
-		cs:  vite.Consensus(),
-		log: log15.New("module", "rpc_api/stats_api"),
+		vite: vite,
+		cs:   vite.Consensus(),
+		log:  log15.New("module", "rpc_api/stats_api"),
 	}
 }
 
@@ -156,3 +162,147 @@ func (c StatsApi) reIndex(timeIndex core.TimeIndex) (uint64, uint64) {
 	}
 	return startIdx, endIdx
 }
+
+const maxAnalyticsCycles = 100
+
+var errAnalyticsDisabled = errors.New("analytics is disabled, set AnalyticsEnabled in config")
+
+type MissedSlot struct {
+	Timestamp int64  `json:"timestamp"`
+	Period    uint64 `json:"period"`
+}
+
+type SBPCycleStats struct {
+	Name       string        `json:"name"`
+	Address    types.Address `json:"blockProducingAddress"`
+	Planned    uint64        `json:"planned"`
+	Produced   uint64        `json:"produced"`
+	Missed     []*MissedSlot `json:"missed"`
+	AvgLatency int64         `json:"avgLatency"`
+	MaxLatency int64         `json:"maxLatency"`
+	Reward     string        `json:"reward"`
+}
+
+type CycleStats struct {
+	Cycle uint64           `json:"cycle"`
+	STime int64            `json:"stime"`
+	ETime int64            `json:"etime"`
+	Stats []*SBPCycleStats `json:"stats"`
+}
+
+type SBPPerformance struct {
+	Name        string        `json:"name"`
+	Address     types.Address `json:"blockProducingAddress"`
+	Cycles      int           `json:"cycles"`
+	Planned     uint64        `json:"planned"`
+	Produced    uint64        `json:"produced"`
+	Missed      uint64        `json:"missed"`
+	ProduceRate float64       `json:"produceRate"`
+	AvgLatency  int64         `json:"avgLatency"`
+	MaxLatency  int64         `json:"maxLatency"`
+	Reward      string        `json:"reward"`
+}
+
+func ToCycleStats(c *analytics.CycleStats) *CycleStats {
+	result := &CycleStats{Cycle: c.Cycle, STime: c.STime, ETime: c.ETime}
+	for _, p := range c.Producers {
+		stats := &SBPCycleStats{
+			Name:       p.Name,
+			Address:    p.Address,
+			Planned:    p.Planned,
+			Produced:   p.Produced,
+			Missed:     make([]*MissedSlot, len(p.Missed)),
+			AvgLatency: p.AvgLatency(),
+			MaxLatency: p.MaxLatency,
+			Reward:     *bigIntToString(p.Reward),
+		}
+		for i, m := range p.Missed {
+			stats.Missed[i] = &MissedSlot{Timestamp: m.Timestamp, Period: m.Period}
+		}
+		result.Stats = append(result.Stats, stats)
+	}
+	return result
+}
+
+func (c StatsApi) cycles(startCycle uint64, endCycle uint64) ([]*analytics.CycleStats, error) {
+	collector := c.vite.Analytics()
+	if collector == nil {
+		return nil, errAnalyticsDisabled
+	}
+	if startCycle > endCycle {
+		return nil, errors.New("startCycle is larger than endCycle")
+	}
+	if endCycle-startCycle >= maxAnalyticsCycles {
+		return nil, errors.New("max step is 100")
+	}
+	return collector.Cycles(startCycle, endCycle)
+}
+
+// GetSBPAnalytics return the planned and produced blocks, missed slots, latency and rewards of SBPs in the cycles,
+// cycles not analysed yet are omitted
+func (c StatsApi) GetSBPAnalytics(startCycle uint64, endCycle uint64) ([]*CycleStats, error) {
+	list, err := c.cycles(startCycle, endCycle)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*CycleStats, len(list))
+	for i, v := range list {
+		result[i] = ToCycleStats(v)
+	}
+	return result, nil
+}
+
+// GetSBPPerformance return the performance of SBPs added up in the cycles, the most reliable first
+func (c StatsApi) GetSBPPerformance(startCycle uint64, endCycle uint64) ([]*SBPPerformance, error) {
+	list, err := c.cycles(startCycle, endCycle)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*SBPPerformance
+	for _, s := range analytics.Summarize(list) {
+		result = append(result, &SBPPerformance{
+			Name:        s.Name,
+			Address:     s.Address,
+			Cycles:      s.Cycles,
+			Planned:     s.Planned,
+			Produced:    s.Produced,
+			Missed:      s.Missed,
+			ProduceRate: s.ProduceRate(),
+			AvgLatency:  s.AvgLatency(),
+			MaxLatency:  s.MaxLatency,
+			Reward:      *bigIntToString(s.Reward),
+		})
+	}
+	return result, nil
+}
+
+// ExportSBPAnalytics export the stats of SBPs in the cycles, format is "csv" or "json"
+func (c StatsApi) ExportSBPAnalytics(startCycle uint64, endCycle uint64, format string) (string, error) {
+	list, err := c.cycles(startCycle, endCycle)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case "csv":
+		var buf bytes.Buffer
+		if err = analytics.WriteCSV(&buf, list); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case "json", "":
+		result := make([]*CycleStats, len(list))
+		for i, v := range list {
+			result[i] = ToCycleStats(v)
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		return "", errors.New("unknown format " + format)
+	}
+}
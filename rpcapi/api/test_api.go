@@ -106,13 +106,13 @@ func (t TestApi) CreateTxWithPrivKey(params CreateTxWithPrivKeyParmsTest) error
 	if e != nil {
 		return e
 	}
-	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress, func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress, func(addr types.Address, block *ledger.AccountBlock) (signedData, pubkey []byte, err error) {
 		var privkey ed25519.PrivateKey
 		privkey, e := ed25519.HexToPrivateKey(params.PrivateKey)
 		if e != nil {
 			return nil, nil, e
 		}
-		signData := ed25519.Sign(privkey, data)
+		signData := ed25519.Sign(privkey, block.Hash.Bytes())
 		pubkey = privkey.PubByte()
 		return signData, pubkey, nil
 	})
@@ -179,8 +179,8 @@ func (t TestApi) ReceiveOnroadTx(params CreateReceiveTxParms) error {
 		return e
 	}
 	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress,
-		func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
-			return ed25519.Sign(privKey, data), pubKey, nil
+		func(addr types.Address, block *ledger.AccountBlock) (signedData, pubkey []byte, err error) {
+			return ed25519.Sign(privKey, block.Hash.Bytes()), pubKey, nil
 		})
 	if e != nil {
 		return e
@@ -139,13 +139,13 @@ func (t Tx) SendTxWithPrivateKey(param SendTxWithPrivateKeyParam) (*AccountBlock
 	if e != nil {
 		return nil, e
 	}
-	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress, func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress, func(addr types.Address, block *ledger.AccountBlock) (signedData, pubkey []byte, err error) {
 		var privkey ed25519.PrivateKey
 		privkey, e := ed25519.HexToPrivateKey(*param.PrivateKey)
 		if e != nil {
 			return nil, nil, e
 		}
-		signData := ed25519.Sign(privkey, data)
+		signData := ed25519.Sign(privkey, block.Hash.Bytes())
 		pubkey = privkey.PubByte()
 		return signData, pubkey, nil
 	})
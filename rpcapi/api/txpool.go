@@ -0,0 +1,125 @@
+package api
+
+import (
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+type TxPoolApi struct {
+	pool pool.BlockPool
+	log  log15.Logger
+}
+
+func NewTxPoolApi(vite *vite.Vite) *TxPoolApi {
+	return &TxPoolApi{
+		pool: vite.Pool(),
+		log:  log15.New("module", "rpc_api/txpool_api"),
+	}
+}
+
+func (t TxPoolApi) String() string {
+	return "TxPoolApi"
+}
+
+type TxPoolStatus struct {
+	Accounts       int                       `json:"accounts"`
+	AccountBlocks  int                       `json:"accountBlocks"`
+	SnapshotBlocks string                    `json:"snapshotBlocks"`
+	States         map[pool.PendingState]int `json:"states"`
+	Size           string                    `json:"size"` // serialized bytes of the account blocks
+}
+
+type TxPoolAccount struct {
+	Address types.Address             `json:"address"`
+	Blocks  int                       `json:"blocks"`
+	States  map[pool.PendingState]int `json:"states"`
+	Size    string                    `json:"size"`
+}
+
+type TxPoolBlock struct {
+	Hash          types.Hash  `json:"hash"`
+	Height        string      `json:"height"`
+	PrevHash      types.Hash  `json:"prevHash"`
+	BlockType     byte        `json:"blockType"`
+	FromBlockHash *types.Hash `json:"fromBlockHash,omitempty"`
+
+	Branch     string            `json:"branch"`
+	State      pool.PendingState `json:"state"`
+	PendingFor []types.Hash      `json:"pendingFor,omitempty"`
+	WaitTime   string            `json:"waitTime"` // in milliseconds
+	Size       int               `json:"size"`
+}
+
+// Status counts the pending blocks in the pool by state
+func (t TxPoolApi) Status() *TxPoolStatus {
+	stat := t.pool.PendingStat()
+	return &TxPoolStatus{
+		Accounts:       stat.Accounts,
+		AccountBlocks:  stat.AccountBlocks,
+		SnapshotBlocks: Uint64ToString(stat.SnapshotBlocks),
+		States:         stat.States,
+		Size:           Uint64ToString(stat.Size),
+	}
+}
+
+// Inspect lists the accounts with pending blocks, the account with more blocks goes first
+func (t TxPoolApi) Inspect() []*TxPoolAccount {
+	accounts := t.pool.PendingAccounts()
+	result := make([]*TxPoolAccount, 0, len(accounts))
+	for _, v := range accounts {
+		result = append(result, &TxPoolAccount{
+			Address: v.Address,
+			Blocks:  v.Blocks,
+			States:  v.States,
+			Size:    Uint64ToString(v.Size),
+		})
+	}
+	return result
+}
+
+// Content lists the pending blocks of an account
+func (t TxPoolApi) Content(addr types.Address) []*TxPoolBlock {
+	blocks := t.pool.PendingAccountBlocks(addr)
+	result := make([]*TxPoolBlock, 0, len(blocks))
+	for _, v := range blocks {
+		b := &TxPoolBlock{
+			Hash:       v.Hash,
+			Height:     Uint64ToString(v.Height),
+			PrevHash:   v.PrevHash,
+			BlockType:  v.BlockType,
+			Branch:     v.Branch,
+			State:      v.State,
+			PendingFor: v.PendingFor,
+			WaitTime:   durationToMillisString(v.WaitTime),
+			Size:       v.Size,
+		}
+		if !v.FromBlockHash.IsZero() {
+			fromHash := v.FromBlockHash
+			b.FromBlockHash = &fromHash
+		}
+		result = append(result, b)
+	}
+	return result
+}
+
+// EvictAccount drops all pending blocks of an account, they may be fetched again from the network
+func (t TxPoolApi) EvictAccount(addr types.Address) int {
+	t.log.Info("EvictAccount", "addr", addr)
+	return t.pool.EvictAccount(addr)
+}
+
+// BlacklistAccountBranch stops the blocks of a branch from being inserted for seconds, or until
+// UnblacklistAccount if seconds is 0. The current chain of the account is chosen if branchId is empty.
+func (t TxPoolApi) BlacklistAccountBranch(addr types.Address, branchId string, seconds uint64) (int, error) {
+	t.log.Info("BlacklistAccountBranch", "addr", addr, "branch", branchId, "seconds", seconds)
+	return t.pool.BlacklistAccountBranch(addr, branchId, time.Duration(seconds)*time.Second)
+}
+
+func (t TxPoolApi) UnblacklistAccount(addr types.Address) int {
+	t.log.Info("UnblacklistAccount", "addr", addr)
+	return t.pool.UnblacklistAccount(addr)
+}
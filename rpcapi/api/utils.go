@@ -29,6 +29,7 @@ var (
 	dataDir                          = ""
 	netId                            = uint(0)
 	dexTxAvailable                   = false
+	powLocalSolve                    = false
 )
 
 func InitConfig(id uint, dexAvailable *bool) {
@@ -38,6 +39,11 @@ func InitConfig(id uint, dexAvailable *bool) {
 	}
 }
 
+// InitPow allows rpc requests to compute PoW in-process when the pow server is not set
+func InitPow(localSolve bool) {
+	powLocalSolve = localSolve
+}
+
 func InitLog(dir, lvl string) {
 	dataDir = dir
 	logLevel, err := log15.LvlFromString(lvl)
@@ -194,6 +194,17 @@ func (m WalletApi) ExtractMnemonic(entropyStore string, passphrase string) (stri
 	return m.wallet.ExtractMnemonic(entropyStore, passphrase)
 }
 
+// ChangeEntropyStorePassphrase re-encrypts the entropy store file by newPassphrase, the format of the file is kept
+func (m WalletApi) ChangeEntropyStorePassphrase(entropyStore string, passphrase string, newPassphrase string) error {
+	return m.wallet.ChangePassphrase(entropyStore, passphrase, newPassphrase, nil)
+}
+
+// UpgradeEntropyStoreKDF re-encrypts the entropy store file in version 2 with the kdf params, the passphrase is not changed.
+// The file of version 2 can not be read by the old gvite any more.
+func (m WalletApi) UpgradeEntropyStoreKDF(entropyStore string, passphrase string, params entropystore.KDFParams) error {
+	return m.wallet.ChangePassphrase(entropyStore, passphrase, passphrase, &params)
+}
+
 func (m WalletApi) FindAddrWithPassphrase(entropyStore string, passphrase string, addr types.Address) (findResult *FindAddrResult, e error) {
 	manager, e := m.wallet.GetEntropyStoreManager(entropyStore)
 	if e != nil {
@@ -251,12 +262,7 @@ func (m WalletApi) SignData(addr types.Address, hexMsg string) (*HexSignedTuple,
 	if err != nil {
 		return nil, err
 	}
-	_, key, _, e := m.wallet.GlobalFindAddr(addr)
-	if e != nil {
-		return nil, e
-	}
-
-	signedData, pubkey, err := key.SignData(msgbytes)
+	signedData, pubkey, err := m.wallet.SignData(addr, msgbytes)
 	if err != nil {
 		return nil, err
 	}
@@ -271,6 +277,24 @@ func (m WalletApi) SignData(addr types.Address, hexMsg string) (*HexSignedTuple,
 }
 
 func (m WalletApi) CreateTxWithPassphrase(params CreateTransferTxParms) (*types.Hash, error) {
+	return m.createTx(params, func(addr types.Address, block *ledger.AccountBlock) (signedData, pubkey []byte, err error) {
+		if params.EntropystoreFile != nil {
+			manager, e := m.wallet.GetEntropyStoreManager(*params.EntropystoreFile)
+			if e != nil {
+				return nil, nil, e
+			}
+			return manager.SignDataWithPassphrase(addr, params.Passphrase, block.Hash.Bytes())
+		}
+
+		_, key, _, e := m.wallet.GlobalFindAddrWithPassphrase(addr, params.Passphrase)
+		if e != nil {
+			return nil, nil, e
+		}
+		return key.SignData(block.Hash.Bytes())
+	})
+}
+
+func (m WalletApi) createTx(params CreateTransferTxParms, signFunc generator.SignFunc) (*types.Hash, error) {
 	if !checkTxToAddressAvailable(params.ToAddr) {
 		return nil, errors.New("ToAddress is invalid")
 	}
@@ -311,21 +335,7 @@ func (m WalletApi) CreateTxWithPassphrase(params CreateTransferTxParms) (*types.
 	if e != nil {
 		return nil, e
 	}
-	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress, func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
-		if params.EntropystoreFile != nil {
-			manager, e := m.wallet.GetEntropyStoreManager(*params.EntropystoreFile)
-			if e != nil {
-				return nil, nil, e
-			}
-			return manager.SignDataWithPassphrase(addr, params.Passphrase, data)
-		}
-
-		_, key, _, e := m.wallet.GlobalFindAddrWithPassphrase(addr, params.Passphrase)
-		if e != nil {
-			return nil, nil, e
-		}
-		return key.SignData(data)
-	})
+	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress, signFunc)
 
 	if e != nil {
 		return nil, e
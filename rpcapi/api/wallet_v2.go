@@ -4,6 +4,8 @@ import (
 	"errors"
 
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/wallet/external"
 )
 
 func (m WalletApi) GetEntropyFilesInStandardDir() ([]string, error) {
@@ -191,3 +193,65 @@ func (m WalletApi) CreateTransaction(params CreateTransactionParms) (*types.Hash
 		Difficulty:       params.Difficulty,
 	})
 }
+
+type SendTransactionParms struct {
+	Address    types.Address     `json:"address"`
+	ToAddress  types.Address     `json:"toAddress"`
+	TokenId    types.TokenTypeId `json:"tokenId"`
+	Amount     string            `json:"amount"`
+	Data       []byte            `json:"data,omitempty"`
+	Difficulty *string           `json:"difficulty,omitempty"`
+}
+
+// SendTransaction signs by the key of address in the unlocked entropy stores or the external signers,
+// the private key or the passphrase is not needed.
+func (m WalletApi) SendTransaction(params SendTransactionParms) (*types.Hash, error) {
+	return m.createTx(CreateTransferTxParms{
+		SelfAddr:    params.Address,
+		ToAddr:      params.ToAddress,
+		TokenTypeId: params.TokenId,
+		Amount:      params.Amount,
+		Data:        params.Data,
+		Difficulty:  params.Difficulty,
+	}, func(addr types.Address, block *ledger.AccountBlock) (signedData, pubkey []byte, err error) {
+		return m.wallet.SignData(addr, block.Hash.Bytes())
+	})
+}
+
+type WalletBackend struct {
+	URL       string          `json:"url"`
+	Addresses []types.Address `json:"addresses"`
+	Error     string          `json:"error,omitempty"`
+}
+
+func (m WalletApi) GetBackends() []*WalletBackend {
+	var result []*WalletBackend
+	for _, b := range m.wallet.Backends() {
+		item := &WalletBackend{URL: b.URL()}
+		addrs, err := b.Addresses()
+		if err != nil {
+			item.Error = err.Error()
+		}
+		item.Addresses = addrs
+		result = append(result, item)
+	}
+	return result
+}
+
+// AddExternalSigner connects to the external signer at url, e.g. "unix:///var/run/signer.sock",
+// and lists the addresses of the first accounts.
+func (m WalletApi) AddExternalSigner(url string, accounts uint32) ([]types.Address, error) {
+	b, err := external.Dial(url, accounts)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.wallet.AddBackend(b); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b.Addresses()
+}
+
+func (m WalletApi) RemoveBackend(url string) error {
+	return m.wallet.RemoveBackend(url)
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/wallet"
+)
+
+// maxWatchActivityCount is the max number of blocks returned by GetWatchSetActivity
+const maxWatchActivityCount = 100
+
+type WatchAccountBalance struct {
+	Address types.Address `json:"address"`
+	Label   string        `json:"label"`
+
+	Balances             map[types.TokenTypeId]string `json:"balances"`
+	UnreceivedBalances   map[types.TokenTypeId]string `json:"unreceivedBalances"`
+	UnreceivedBlockCount string                       `json:"unreceivedBlockCount"`
+}
+
+type WatchSetBalance struct {
+	Name string `json:"name"`
+
+	Balances             map[types.TokenTypeId]string `json:"balances"`
+	UnreceivedBalances   map[types.TokenTypeId]string `json:"unreceivedBalances"`
+	UnreceivedBlockCount string                       `json:"unreceivedBlockCount"`
+
+	Accounts []*WatchAccountBalance `json:"accounts"`
+}
+
+type WatchActivity struct {
+	Label string `json:"label"`
+	*AccountBlock
+}
+
+func (m WalletApi) AddWatchAddresses(set string, addrs []wallet.WatchAddress) error {
+	return m.wallet.AddWatchAddresses(set, addrs)
+}
+
+func (m WalletApi) RemoveWatchAddresses(set string, addrs []types.Address) error {
+	return m.wallet.RemoveWatchAddresses(set, addrs)
+}
+
+func (m WalletApi) RemoveWatchSet(set string) error {
+	return m.wallet.RemoveWatchSet(set)
+}
+
+func (m WalletApi) ListWatchSets() []string {
+	return m.wallet.ListWatchSets()
+}
+
+func (m WalletApi) GetWatchSet(set string) ([]wallet.WatchAddress, error) {
+	return m.wallet.GetWatchSet(set)
+}
+
+// GetWatchSetBalance returns the balances and unreceived amounts of every address in the watch set, and their totals
+func (m WalletApi) GetWatchSetBalance(set string) (*WatchSetBalance, error) {
+	addrs, err := m.wallet.GetWatchSet(set)
+	if err != nil {
+		return nil, err
+	}
+
+	total := make(map[types.TokenTypeId]*big.Int)
+	totalUnreceived := make(map[types.TokenTypeId]*big.Int)
+	totalUnreceivedCount := uint64(0)
+	result := &WatchSetBalance{Name: set, Accounts: make([]*WatchAccountBalance, 0, len(addrs))}
+	for _, a := range addrs {
+		balances, err := m.chain.GetBalanceMap(a.Address)
+		if err != nil {
+			return nil, err
+		}
+		account := &WatchAccountBalance{
+			Address:            a.Address,
+			Label:              a.Label,
+			Balances:           sumBalances(total, balances),
+			UnreceivedBalances: make(map[types.TokenTypeId]string),
+		}
+
+		info, err := m.chain.GetAccountOnRoadInfo(a.Address)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			unreceived := make(map[types.TokenTypeId]*big.Int, len(info.TokenBalanceInfoMap))
+			for tokenId, b := range info.TokenBalanceInfoMap {
+				if b != nil {
+					unreceived[tokenId] = &b.TotalAmount
+				}
+			}
+			account.UnreceivedBalances = sumBalances(totalUnreceived, unreceived)
+			account.UnreceivedBlockCount = Uint64ToString(info.TotalNumber)
+			totalUnreceivedCount += info.TotalNumber
+		} else {
+			account.UnreceivedBlockCount = "0"
+		}
+		result.Accounts = append(result.Accounts, account)
+	}
+
+	result.Balances = sumBalances(nil, total)
+	result.UnreceivedBalances = sumBalances(nil, totalUnreceived)
+	result.UnreceivedBlockCount = Uint64ToString(totalUnreceivedCount)
+	return result, nil
+}
+
+// sumBalances adds the balances into total if not nil, and returns the balances in string
+func sumBalances(total map[types.TokenTypeId]*big.Int, balances map[types.TokenTypeId]*big.Int) map[types.TokenTypeId]string {
+	result := make(map[types.TokenTypeId]string, len(balances))
+	for tokenId, amount := range balances {
+		if amount == nil {
+			continue
+		}
+		result[tokenId] = *bigIntToString(amount)
+		if total == nil {
+			continue
+		}
+		if sum, ok := total[tokenId]; ok {
+			sum.Add(sum, amount)
+		} else {
+			total[tokenId] = new(big.Int).Set(amount)
+		}
+	}
+	return result
+}
+
+// GetWatchSetActivity returns the latest account blocks of the addresses in the watch set,
+// the unconfirmed blocks come first and then the confirmed ones from new to old.
+func (m WalletApi) GetWatchSetActivity(set string, count int) ([]*WatchActivity, error) {
+	if count <= 0 || count > maxWatchActivityCount {
+		return nil, fmt.Errorf("count should be between 1 and %d", maxWatchActivityCount)
+	}
+	addrs, err := m.wallet.GetWatchSet(set)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*WatchActivity, 0)
+	for _, a := range addrs {
+		height, err := m.chain.GetLatestAccountHeight(a.Address)
+		if err != nil {
+			return nil, err
+		}
+		if height == 0 {
+			continue
+		}
+		blocks, err := m.chain.GetAccountBlocksByHeight(a.Address, height, uint64(count))
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range blocks {
+			rpcBlock, err := ledgerToRpcBlock(m.chain, block)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, &WatchActivity{Label: a.Label, AccountBlock: rpcBlock})
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		ti, tj := list[i].Timestamp, list[j].Timestamp
+		if ti == 0 || tj == 0 {
+			return ti == 0 && tj != 0
+		}
+		return ti > tj
+	})
+	if len(list) > count {
+		list = list[:count]
+	}
+	return list, nil
+}
@@ -7,11 +7,12 @@ import (
 	"github.com/vitelabs/go-vite/vite"
 )
 
-func Init(dir, lvl string, testApi_prikey, testApi_tti string, netId uint, dexAvailable *bool) {
+func Init(dir, lvl string, testApi_prikey, testApi_tti string, netId uint, dexAvailable *bool, powLocalSolve bool) {
 	api.InitLog(dir, lvl)
 	api.InitTestAPIParams(testApi_prikey, testApi_tti)
 	api.InitGetTestTokenLimitPolicy()
 	api.InitConfig(netId, dexAvailable)
+	api.InitPow(powLocalSolve)
 }
 
 func GetApi(vite *vite.Vite, apiModule string) rpc.API {
@@ -38,6 +39,20 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewPrivateOnroadApi(vite),
 			Public:    false,
 		}
+	case "private_subscribe":
+		return rpc.API{
+			Namespace: "subscribe",
+			Version:   "1.0",
+			Service:   filters.NewPrivateSubscribeApi(vite),
+			Public:    false,
+		}
+	case "private_pow":
+		return rpc.API{
+			Namespace: "pow",
+			Version:   "1.0",
+			Service:   api.NewPrivatePow(vite),
+			Public:    false,
+		}
 		// public  WS HTTP IPC
 
 	case "pow":
@@ -203,6 +218,20 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewDataApi(vite),
 			Public:    true,
 		}
+	case "txpool":
+		return rpc.API{
+			Namespace: "txpool",
+			Version:   "1.0",
+			Service:   api.NewTxPoolApi(vite),
+			Public:    false,
+		}
+	case "multisig":
+		return rpc.API{
+			Namespace: "multisig",
+			Version:   "1.0",
+			Service:   api.NewMultisigApi(vite),
+			Public:    true,
+		}
 	case "ledgerdebug":
 		return rpc.API{
 			Namespace: "ledgerdebug",
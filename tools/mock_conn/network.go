@@ -0,0 +1,643 @@
+package mock_conn
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Link describe the quality of the connections between two hosts
+type Link struct {
+	// Latency is the one way delay
+	Latency time.Duration
+	// Bandwidth is bytes per second of each direction, 0 means unlimited
+	Bandwidth int
+	// Loss is the probability a write is lost, lost data will be retransmitted after a timeout,
+	// so the stream is still reliable, but slower, just like TCP
+	Loss float64
+}
+
+// minRetransmit is the minimal retransmission timeout of lost data
+const minRetransmit = 200 * time.Millisecond
+
+// maxBuffered is the max bytes can be buffered in one direction of a connection, Write will be blocked if exceeded
+const maxBuffered = 4 << 20
+
+var errUnreachable = errors.New("host unreachable")
+var errRefused = errors.New("connection refused")
+var errAddressInUse = errors.New("address already in use")
+var errListenerClosed = errors.New("listener closed")
+var errConnClosed = errors.New("use of closed connection")
+var errConnReset = errors.New("connection reset by partition")
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+type linkKey [2]string
+
+func makeLinkKey(a, b string) linkKey {
+	if a > b {
+		a, b = b, a
+	}
+	return linkKey{a, b}
+}
+
+// Network is an in-memory network, hosts listen and dial each other by address "ip:port".
+// Links between hosts can be configured with latency, bandwidth and loss, hosts can be partitioned or isolated.
+// Connections across a partition will be reset, and dial across a partition will fail.
+type Network struct {
+	mu        sync.Mutex
+	def       Link
+	links     map[linkKey]Link
+	groups    map[string]int
+	down      map[string]struct{}
+	listeners map[string]*listener
+	conns     map[*conn]struct{}
+	port      int
+	rand      *rand.Rand
+}
+
+// NewNetwork create an in-memory network, def is the link quality between hosts without a specified link
+func NewNetwork(def Link) *Network {
+	return &Network{
+		def:       def,
+		links:     make(map[linkKey]Link),
+		groups:    make(map[string]int),
+		down:      make(map[string]struct{}),
+		listeners: make(map[string]*listener),
+		conns:     make(map[*conn]struct{}),
+		port:      40000,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Host return the view of host ip, it can listen and dial other hosts
+func (nw *Network) Host(ip string) *Host {
+	return &Host{
+		ip: ip,
+		nw: nw,
+	}
+}
+
+// SetLink set the link quality between host a and b
+func (nw *Network) SetLink(a, b string, l Link) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	nw.links[makeLinkKey(a, b)] = l
+}
+
+func (nw *Network) link(a, b string) Link {
+	if l, ok := nw.links[makeLinkKey(a, b)]; ok {
+		return l
+	}
+	return nw.def
+}
+
+// Partition split hosts into groups, hosts can only reach the hosts in the same group,
+// hosts not in any groups are in a same default group.
+func (nw *Network) Partition(groups ...[]string) {
+	nw.mu.Lock()
+	nw.groups = make(map[string]int)
+	for i, group := range groups {
+		for _, ip := range group {
+			nw.groups[ip] = i + 1
+		}
+	}
+	nw.mu.Unlock()
+
+	nw.reset()
+}
+
+// Heal remove all partitions, isolated hosts are not affected
+func (nw *Network) Heal() {
+	nw.mu.Lock()
+	nw.groups = make(map[string]int)
+	nw.mu.Unlock()
+}
+
+// Isolate disconnect host from all other hosts, like the host is offline
+func (nw *Network) Isolate(ip string) {
+	nw.mu.Lock()
+	nw.down[ip] = struct{}{}
+	nw.mu.Unlock()
+
+	nw.reset()
+}
+
+// Rejoin make the isolated host reachable again
+func (nw *Network) Rejoin(ip string) {
+	nw.mu.Lock()
+	delete(nw.down, ip)
+	nw.mu.Unlock()
+}
+
+func (nw *Network) reachable(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if _, ok := nw.down[a]; ok {
+		return false
+	}
+	if _, ok := nw.down[b]; ok {
+		return false
+	}
+	return nw.groups[a] == nw.groups[b]
+}
+
+// reset close connections between unreachable hosts
+func (nw *Network) reset() {
+	var broken []*conn
+
+	nw.mu.Lock()
+	for c := range nw.conns {
+		if !nw.reachable(c.local.IP.String(), c.remote.IP.String()) {
+			broken = append(broken, c)
+		}
+	}
+	nw.mu.Unlock()
+
+	for _, c := range broken {
+		c.abort(errConnReset)
+	}
+}
+
+// lost return true if the data should be retransmitted
+func (nw *Network) lost(l Link) bool {
+	if l.Loss <= 0 {
+		return false
+	}
+
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	return nw.rand.Float64() < l.Loss
+}
+
+func (nw *Network) listen(ip, address string) (*listener, error) {
+	addr, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	// listen on all interfaces
+	if addr.IP == nil || addr.IP.IsUnspecified() {
+		addr.IP = net.ParseIP(ip)
+	}
+	if addr.IP.String() != ip {
+		return nil, fmt.Errorf("cannot listen %s on host %s", address, ip)
+	}
+
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	key := addr.String()
+	if _, ok := nw.listeners[key]; ok {
+		return nil, errAddressInUse
+	}
+
+	ln := &listener{
+		nw:     nw,
+		addr:   addr,
+		accept: make(chan net.Conn, 16),
+		term:   make(chan struct{}),
+	}
+	nw.listeners[key] = ln
+
+	return ln, nil
+}
+
+func (nw *Network) dial(ip, address string, timeout time.Duration) (net.Conn, error) {
+	raddr, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	nw.mu.Lock()
+	if !nw.reachable(ip, raddr.IP.String()) {
+		nw.mu.Unlock()
+		return nil, errUnreachable
+	}
+
+	ln, ok := nw.listeners[raddr.String()]
+	if !ok {
+		nw.mu.Unlock()
+		return nil, errRefused
+	}
+
+	nw.port++
+	laddr := &net.TCPAddr{
+		IP:   net.ParseIP(ip),
+		Port: nw.port,
+	}
+	l := nw.link(ip, raddr.IP.String())
+
+	c1, c2 := newConnPair(nw, laddr, raddr, l)
+	nw.conns[c1] = struct{}{}
+	nw.conns[c2] = struct{}{}
+	nw.mu.Unlock()
+
+	// handshake of TCP
+	time.Sleep(l.Latency)
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case ln.accept <- c2:
+		return c1, nil
+	case <-ln.term:
+	case <-timer:
+	}
+
+	c1.abort(errRefused)
+	return nil, errRefused
+}
+
+func (nw *Network) removeConn(c *conn) {
+	nw.mu.Lock()
+	delete(nw.conns, c)
+	nw.mu.Unlock()
+}
+
+// Host is the view of a host in Network
+type Host struct {
+	ip string
+	nw *Network
+}
+
+// IP return the ip of host
+func (h *Host) IP() string {
+	return h.ip
+}
+
+// Listen on address, if the ip of address is empty or unspecified, it will be the host ip
+func (h *Host) Listen(address string) (net.Listener, error) {
+	ln, err := h.nw.listen(h.ip, address)
+	if err != nil {
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Dial connect to address from the host, timeout 0 means no timeout
+func (h *Host) Dial(address string, timeout time.Duration) (net.Conn, error) {
+	return h.nw.dial(h.ip, address, timeout)
+}
+
+type listener struct {
+	nw     *Network
+	addr   *net.TCPAddr
+	accept chan net.Conn
+	once   sync.Once
+	term   chan struct{}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-l.term:
+		return nil, errListenerClosed
+	}
+}
+
+func (l *listener) Close() error {
+	l.once.Do(func() {
+		close(l.term)
+
+		l.nw.mu.Lock()
+		delete(l.nw.listeners, l.addr.String())
+		l.nw.mu.Unlock()
+	})
+
+	return nil
+}
+
+func (l *listener) Addr() net.Addr {
+	return l.addr
+}
+
+type segment struct {
+	data []byte
+	at   time.Time
+}
+
+// stream is one direction of a connection
+type stream struct {
+	mu       sync.Mutex
+	link     Link
+	segments []segment
+	buffered int
+	// the time when last segment finish transmitting
+	busy time.Time
+	// the time when last segment arrive, segments arrive in order
+	last   time.Time
+	closed bool
+	err    error
+	// readable will be notified when data written, or the stream closed
+	readable chan struct{}
+	// writable will be notified when data read, or the stream closed
+	writable chan struct{}
+}
+
+func newStream(l Link) *stream {
+	return &stream{
+		link:     l,
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+	}
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (s *stream) wake() {
+	notify(s.readable)
+	notify(s.writable)
+}
+
+type conn struct {
+	nw     *Network
+	local  *net.TCPAddr
+	remote *net.TCPAddr
+	read   *stream
+	write  *stream
+	peer   *conn
+
+	mu        sync.Mutex
+	rdeadline time.Time
+	wdeadline time.Time
+}
+
+func newConnPair(nw *Network, a, b *net.TCPAddr, l Link) (c1, c2 *conn) {
+	s1, s2 := newStream(l), newStream(l)
+
+	c1 = &conn{
+		nw:     nw,
+		local:  a,
+		remote: b,
+		read:   s1,
+		write:  s2,
+	}
+	c2 = &conn{
+		nw:     nw,
+		local:  b,
+		remote: a,
+		read:   s2,
+		write:  s1,
+	}
+	c1.peer, c2.peer = c2, c1
+
+	return
+}
+
+func (c *conn) deadlines() (r, w time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rdeadline, c.wdeadline
+}
+
+func wait(ch <-chan struct{}, until time.Time) {
+	if until.IsZero() {
+		<-ch
+		return
+	}
+
+	d := time.Until(until)
+	if d <= 0 {
+		return
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ch:
+	case <-t.C:
+	}
+}
+
+func (c *conn) Read(b []byte) (n int, err error) {
+	s := c.read
+
+	for {
+		deadline, _ := c.deadlines()
+		now := time.Now()
+
+		s.mu.Lock()
+		if s.err != nil {
+			err = s.err
+			s.mu.Unlock()
+			return
+		}
+
+		if len(s.segments) > 0 && !s.segments[0].at.After(now) {
+			for len(s.segments) > 0 && !s.segments[0].at.After(now) && n < len(b) {
+				seg := &s.segments[0]
+				m := copy(b[n:], seg.data)
+				n += m
+				if m == len(seg.data) {
+					s.segments = s.segments[1:]
+				} else {
+					seg.data = seg.data[m:]
+				}
+			}
+			s.buffered -= n
+			s.mu.Unlock()
+			// wake up blocked writer
+			notify(s.writable)
+			return
+		}
+
+		if s.closed && len(s.segments) == 0 {
+			s.mu.Unlock()
+			return 0, io.EOF
+		}
+
+		if !deadline.IsZero() && !now.Before(deadline) {
+			s.mu.Unlock()
+			return 0, timeoutError{}
+		}
+
+		// wait until the first segment arrive, new segment written, or deadline
+		var until = deadline
+		if len(s.segments) > 0 && (until.IsZero() || s.segments[0].at.Before(until)) {
+			until = s.segments[0].at
+		}
+		s.mu.Unlock()
+
+		wait(s.readable, until)
+	}
+}
+
+func (c *conn) Write(b []byte) (n int, err error) {
+	s := c.write
+
+	for {
+		_, deadline := c.deadlines()
+		now := time.Now()
+
+		s.mu.Lock()
+		if s.err != nil {
+			err = s.err
+			s.mu.Unlock()
+			return
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return 0, errConnClosed
+		}
+
+		if s.buffered < maxBuffered {
+			break
+		}
+
+		if !deadline.IsZero() && !now.Before(deadline) {
+			s.mu.Unlock()
+			return 0, timeoutError{}
+		}
+		s.mu.Unlock()
+
+		wait(s.writable, deadline)
+	}
+
+	// s.mu is locked
+	now := time.Now()
+	l := s.link
+
+	start := s.busy
+	if start.Before(now) {
+		start = now
+	}
+	if l.Bandwidth > 0 {
+		s.busy = start.Add(time.Duration(int64(len(b)) * int64(time.Second) / int64(l.Bandwidth)))
+	} else {
+		s.busy = start
+	}
+
+	at := s.busy.Add(l.Latency)
+	for c.nw.lost(l) {
+		rto := 2 * l.Latency
+		if rto < minRetransmit {
+			rto = minRetransmit
+		}
+		at = at.Add(rto)
+	}
+	if at.Before(s.last) {
+		at = s.last
+	}
+	s.last = at
+
+	data := make([]byte, len(b))
+	copy(data, b)
+	s.segments = append(s.segments, segment{data, at})
+	s.buffered += len(data)
+	s.mu.Unlock()
+
+	notify(s.readable)
+
+	return len(b), nil
+}
+
+// close write side, remote can read the buffered data, then EOF
+func (c *conn) Close() error {
+	c.write.mu.Lock()
+	if c.write.closed {
+		c.write.mu.Unlock()
+		return errConnClosed
+	}
+	c.write.closed = true
+	c.write.mu.Unlock()
+	c.write.wake()
+
+	// local can not read any more
+	c.read.mu.Lock()
+	if c.read.err == nil {
+		c.read.err = errConnClosed
+	}
+	c.read.mu.Unlock()
+	c.read.wake()
+
+	c.nw.removeConn(c)
+
+	return nil
+}
+
+// abort both sides immediately, buffered data is dropped
+func (c *conn) abort(err error) {
+	for _, s := range []*stream{c.read, c.write} {
+		s.mu.Lock()
+		if s.err == nil {
+			s.err = err
+		}
+		s.segments = nil
+		s.buffered = 0
+		s.mu.Unlock()
+		s.wake()
+	}
+
+	c.nw.removeConn(c)
+	c.nw.removeConn(c.peer)
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *conn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.rdeadline = t
+	c.wdeadline = t
+	c.mu.Unlock()
+
+	notify(c.read.readable)
+	notify(c.write.writable)
+
+	return nil
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.rdeadline = t
+	c.mu.Unlock()
+
+	notify(c.read.readable)
+
+	return nil
+}
+
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.wdeadline = t
+	c.mu.Unlock()
+
+	notify(c.write.writable)
+
+	return nil
+}
+
+// Addr return "ip:port"
+func Addr(ip string, port int) string {
+	return net.JoinHostPort(ip, strconv.Itoa(port))
+}
@@ -0,0 +1,176 @@
+package mock_conn
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+func acceptOne(t *testing.T, ln net.Listener) <-chan net.Conn {
+	ch := make(chan net.Conn, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			close(ch)
+			return
+		}
+		ch <- c
+	}()
+	return ch
+}
+
+func TestNetwork_Latency(t *testing.T) {
+	nw := NewNetwork(Link{
+		Latency: 50 * time.Millisecond,
+	})
+
+	h1, h2 := nw.Host("10.0.0.1"), nw.Host("10.0.0.2")
+	ln, err := h2.Listen("0.0.0.0:8483")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ch := acceptOne(t, ln)
+	c1, err := h1.Dial("10.0.0.2:8483", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2 := <-ch
+
+	if addr := c2.RemoteAddr().(*net.TCPAddr); addr.IP.String() != "10.0.0.1" {
+		t.Errorf("wrong remote address: %s", addr)
+	}
+
+	start := time.Now()
+	if _, err = c1.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 5)
+	if _, err = io.ReadFull(c2, buf); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("data arrived too early: %s", d)
+	}
+	if string(buf) != "hello" {
+		t.Errorf("wrong data: %s", buf)
+	}
+
+	// read timeout
+	_ = c2.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err = c2.Read(buf); err == nil {
+		t.Error("should timeout")
+	} else if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("should be timeout error: %v", err)
+	}
+
+	// remote can read buffered data after closed
+	_, _ = c1.Write([]byte("bye"))
+	_ = c1.Close()
+	_ = c2.SetReadDeadline(time.Time{})
+	data, err := ioutil.ReadAll(c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "bye" {
+		t.Errorf("wrong data: %s", data)
+	}
+}
+
+func TestNetwork_Bandwidth(t *testing.T) {
+	nw := NewNetwork(Link{})
+	nw.SetLink("10.0.0.1", "10.0.0.2", Link{
+		Bandwidth: 100 << 10,
+		Loss:      0.3,
+	})
+
+	h1, h2 := nw.Host("10.0.0.1"), nw.Host("10.0.0.2")
+	ln, err := h2.Listen("10.0.0.2:8483")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ch := acceptOne(t, ln)
+	c1, err := h1.Dial("10.0.0.2:8483", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2 := <-ch
+
+	var sent []byte
+	start := time.Now()
+	go func() {
+		for i := 0; i < 50; i++ {
+			chunk := bytes.Repeat([]byte{byte(i)}, 1<<10)
+			sent = append(sent, chunk...)
+			_, _ = c1.Write(chunk)
+		}
+		_ = c1.Close()
+	}()
+
+	received, err := ioutil.ReadAll(c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 50KB at 100KB/s
+	if d := time.Since(start); d < 450*time.Millisecond {
+		t.Errorf("transmit too fast: %s", d)
+	}
+	// lost data is retransmitted, stream keeps in order
+	if !bytes.Equal(sent, received) {
+		t.Error("data should be received in order")
+	}
+}
+
+func TestNetwork_Partition(t *testing.T) {
+	nw := NewNetwork(Link{})
+
+	h1, h2, h3 := nw.Host("10.0.0.1"), nw.Host("10.0.0.2"), nw.Host("10.0.0.3")
+	ln, err := h2.Listen("10.0.0.2:8483")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ch := acceptOne(t, ln)
+	c1, err := h1.Dial("10.0.0.2:8483", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+
+	nw.Partition([]string{"10.0.0.1"}, []string{"10.0.0.2", "10.0.0.3"})
+
+	// connection across partition is reset
+	if _, err = c1.Read(make([]byte, 1)); err != errConnReset {
+		t.Errorf("should be reset: %v", err)
+	}
+	if _, err = h1.Dial("10.0.0.2:8483", time.Second); err != errUnreachable {
+		t.Errorf("should be unreachable: %v", err)
+	}
+
+	ch = acceptOne(t, ln)
+	if _, err = h3.Dial("10.0.0.2:8483", time.Second); err != nil {
+		t.Errorf("hosts in the same group should be reachable: %v", err)
+	}
+	<-ch
+
+	nw.Heal()
+	nw.Isolate("10.0.0.3")
+	if _, err = h3.Dial("10.0.0.2:8483", time.Second); err != errUnreachable {
+		t.Errorf("isolated host should be unreachable: %v", err)
+	}
+
+	ch = acceptOne(t, ln)
+	if _, err = h1.Dial("10.0.0.2:8483", time.Second); err != nil {
+		t.Errorf("partition should be healed: %v", err)
+	}
+	<-ch
+}
@@ -3,16 +3,20 @@ package vite
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
 
+	"github.com/vitelabs/go-vite/analytics"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/finality"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/net"
 	"github.com/vitelabs/go-vite/onroad"
@@ -20,7 +24,9 @@ import (
 	"github.com/vitelabs/go-vite/producer"
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/util"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 var (
@@ -38,10 +44,19 @@ type Vite struct {
 	pool          pool.BlockPool
 	consensus     consensus.Consensus
 	onRoad        *onroad.Manager
+	onRoadLockLid int
+	finality      *finality.Gadget
+	analytics     *analytics.Collector
 }
 
 func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err error) {
+	var s signer.Signer = signer.NewLocal(walletManager)
+	if cfg.Producer.RemoteSigner != "" {
+		s = signer.NewRemote(cfg.Producer.RemoteSigner, cfg.Producer.RemoteSignerSecret)
+	}
+
 	var addressContext *producer.AddressContext
+	var voter *types.Address
 	if cfg.Producer.Producer && cfg.Producer.Coinbase != "" {
 		var coinbase *types.Address
 		var index uint32
@@ -50,24 +65,35 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 			log.Error(fmt.Sprintf("coinBase parse fail. %v", cfg.Producer.Coinbase), "err", err)
 			return nil, err
 		}
-		err = walletManager.MatchAddress(cfg.EntropyStorePath, *coinbase, index)
-
-		if err != nil {
-			log.Error(fmt.Sprintf("coinBase is not child of entropyStore, coinBase is : %v", cfg.Producer.Coinbase), "err", err)
-			return nil, err
-		}
-
-		var key *derivation.Key
-		_, key, _, err = walletManager.GlobalFindAddr(*coinbase)
-		if err != nil {
-			return
-		}
 
-		cfg.Net.MineKey, err = key.PrivateKey()
-		if err != nil {
-			return
+		// the key is on the remote signer, so the node can not be identified as SBP by peers
+		if cfg.Producer.RemoteSigner != "" {
+			if !signer.Contains(s, *coinbase) {
+				err = fmt.Errorf("coinBase %v can not be signed by the remote signer", cfg.Producer.Coinbase)
+				log.Error(err.Error())
+				return nil, err
+			}
+		} else {
+			err = walletManager.MatchAddress(cfg.EntropyStorePath, *coinbase, index)
+
+			if err != nil {
+				log.Error(fmt.Sprintf("coinBase is not child of entropyStore, coinBase is : %v", cfg.Producer.Coinbase), "err", err)
+				return nil, err
+			}
+
+			var key *derivation.Key
+			_, key, _, err = walletManager.GlobalFindAddr(*coinbase)
+			if err != nil {
+				return
+			}
+
+			cfg.Net.MineKey, err = key.PrivateKey()
+			if err != nil {
+				return
+			}
 		}
 
+		voter = coinbase
 		addressContext = &producer.AddressContext{
 			EntryPath: cfg.EntropyStorePath,
 			Address:   *coinbase,
@@ -77,6 +103,9 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 
 	// set fork points
 	fork.SetForkPoints(cfg.ForkPoints)
+	if cfg.Genesis != nil {
+		util.SetUnreceivedExpiry(cfg.Genesis.UnreceivedExpiry)
+	}
 
 	// chain
 	chain := chain.NewChain(cfg.DataDir, cfg.Chain, cfg.Genesis)
@@ -91,12 +120,24 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 		return nil, err
 	}
 	// consensus
-	cs := consensus.NewConsensus(chain, pl)
+	var csCfg *config.Consensus
+	if cfg.Genesis != nil {
+		csCfg = cfg.Genesis.Consensus
+	}
+	cs := consensus.NewConsensusWithConfig(chain, pl, csCfg)
 
 	verifier := verifier.NewVerifier2(chain, cs)
 
+	// finality, blocks finalized by votes are irreversible too
+	gadget, err := finality.NewGadget(chain, finality.NewCommittee(cs), s, voter,
+		filepath.Join(cfg.DataDir, config.DefaultFinalityDirName))
+	if err != nil {
+		return nil, err
+	}
+
 	// net
-	net, err := net.New(cfg.Net, chain, verifier, cs, pl)
+	pl.SetFinalityReader(gadget)
+	net, err := net.New(cfg.Net, chain, verifier, cs, gadget.IrreversibleReader(pl))
 	if err != nil {
 		return
 	}
@@ -110,14 +151,38 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 		pool:          pl,
 		consensus:     cs,
 		verifier:      verifier,
+		finality:      gadget,
 	}
 
 	if addressContext != nil {
-		vite.producer = producer.NewProducer(chain, net, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, pl)
+		p := producer.NewProducer(chain, net, addressContext, cs, verifier.GetSnapshotVerifier(), s, pl,
+			filepath.Join(cfg.DataDir, config.DefaultProducerDirName))
+		if cfg.Producer.FailoverLease != "" {
+			if cfg.Producer.FailoverNodeId == "" {
+				return nil, errors.New("FailoverNodeId must be set with FailoverLease")
+			}
+			var lease producer.LeaseBackend
+			lease, err = producer.NewFileLease(cfg.Producer.FailoverLease)
+			if err != nil {
+				return nil, err
+			}
+			p.SetFailover(producer.NewFailover(chain, addressContext.Address, lease, producer.FailoverConfig{
+				NodeId:      cfg.Producer.FailoverNodeId,
+				Standby:     cfg.Producer.FailoverStandby,
+				MissedSlots: cfg.Producer.FailoverMissedSlots,
+				LeaseTTL:    time.Duration(cfg.Producer.FailoverLeaseTTL) * time.Second,
+			}))
+		}
+		vite.producer = p
 	}
 
 	// onroad
-	or := onroad.NewManager(net, pl, vite.producer, vite.consensus, walletManager)
+	or := onroad.NewManager(net, pl, vite.producer, vite.consensus, s)
+	scheduleCfg, err := onroad.NewScheduleConfig(cfg.OnRoad)
+	if err != nil {
+		return nil, err
+	}
+	or.SetScheduleConfig(scheduleCfg)
 
 	// set onroad
 	vite.onRoad = or
@@ -139,11 +204,16 @@ func (v *Vite) Init() (err error) {
 	v.onRoad.Init(v.chain)
 	v.verifier.InitOnRoadPool(v.onRoad)
 
+	v.finality.Init(v.net)
+
 	return nil
 }
 
 func (v *Vite) Start() (err error) {
 	v.onRoad.Start()
+	if v.walletManager != nil {
+		v.onRoadLockLid = v.walletManager.AddLockEventListener(v.onRoad.UnlockEventFunc)
+	}
 
 	v.chain.Start()
 
@@ -162,6 +232,19 @@ func (v *Vite) Start() (err error) {
 	}
 
 	v.pool.Start()
+	v.finality.Start()
+
+	// analytics need the cycles of consensus, so it is created after consensus is initialized
+	if v.config.Analytics != nil && v.config.Analytics.Enabled {
+		sbp := v.consensus.SBPReader()
+		v.analytics, err = analytics.NewCollector(v.chain, v.consensus, sbp.GetDayTimeIndex(),
+			analytics.NewRewardReader(v.chain, sbp), filepath.Join(v.config.DataDir, config.DefaultAnalyticsDirName),
+			v.config.Analytics.Backfill)
+		if err != nil {
+			return err
+		}
+		v.analytics.Start()
+	}
 	if v.producer != nil {
 
 		if err := v.producer.Start(); err != nil {
@@ -174,6 +257,10 @@ func (v *Vite) Start() (err error) {
 
 func (v *Vite) Stop() (err error) {
 
+	if v.analytics != nil {
+		v.analytics.Stop()
+	}
+	v.finality.Stop()
 	v.net.Stop()
 	v.pool.Stop()
 
@@ -185,6 +272,9 @@ func (v *Vite) Stop() (err error) {
 	}
 	v.consensus.Stop()
 	v.chain.Stop()
+	if v.walletManager != nil {
+		v.walletManager.RemoveUnlockChangeChannel(v.onRoadLockLid)
+	}
 	v.onRoad.Stop()
 	return nil
 }
@@ -217,6 +307,15 @@ func (v *Vite) OnRoad() *onroad.Manager {
 	return v.onRoad
 }
 
+func (v *Vite) Finality() *finality.Gadget {
+	return v.finality
+}
+
+// Analytics return nil if analytics is not enabled
+func (v *Vite) Analytics() *analytics.Collector {
+	return v.analytics
+}
+
 func (v *Vite) Config() *config.Config {
 	return v.config
 }
@@ -43,7 +43,15 @@ const (
 		{"type":"function","name":"CancelVote","inputs":[{"name":"gid","type":"gid"}]},
 		{"type":"function","name":"CancelSBPVoting","inputs":[]},
 
-		{"type":"variable","name":"voteInfo","inputs":[{"name":"sbpName","type":"string"}]}
+		{"type":"variable","name":"voteInfo","inputs":[{"name":"sbpName","type":"string"}]},
+
+		{"type":"function","name":"CreateDelegateGroup","inputs":[{"name":"nodeCount","type":"uint8"},{"name":"interval","type":"int64"},{"name":"perCount","type":"int64"},{"name":"randCount","type":"uint8"},{"name":"randRank","type":"uint8"},{"name":"repeat","type":"uint16"},{"name":"checkLevel","type":"uint8"},{"name":"registerStakeHeight","type":"uint64"}]},
+		{"type":"function","name":"UpdateDelegateGroup","inputs":[{"name":"gid","type":"gid"},{"name":"registerStakeHeight","type":"uint64"}]},
+		{"type":"function","name":"CancelDelegateGroup","inputs":[{"name":"gid","type":"gid"}]},
+
+		{"type":"event","name":"createDelegateGroup","inputs":[{"name":"gid","type":"gid","indexed":true},{"name":"owner","type":"address"}]},
+		{"type":"event","name":"updateDelegateGroup","inputs":[{"name":"gid","type":"gid","indexed":true},{"name":"registerStakeHeight","type":"uint64"}]},
+		{"type":"event","name":"cancelDelegateGroup","inputs":[{"name":"gid","type":"gid","indexed":true}]}
 	]`
 
 	VariableNameConsensusGroupInfo = "consensusGroupInfo"
@@ -71,6 +79,13 @@ const (
 	MethodNameCancelVoteV3 = "CancelSBPVoting"
 	VariableNameVoteInfo   = "voteInfo"
 
+	MethodNameCreateDelegateGroup = "CreateDelegateGroup"
+	MethodNameUpdateDelegateGroup = "UpdateDelegateGroup"
+	MethodNameCancelDelegateGroup = "CancelDelegateGroup"
+	EventNameCreateDelegateGroup  = "createDelegateGroup"
+	EventNameUpdateDelegateGroup  = "updateDelegateGroup"
+	EventNameCancelDelegateGroup  = "cancelDelegateGroup"
+
 	groupInfoKeyPrefixSize    = 1
 	voteInfoKeyPrefixSize     = 1
 	consensusGroupInfoKeySize = groupInfoKeyPrefixSize + types.GidSize                    // 11byte, 1 + 10byte gid
@@ -115,6 +130,21 @@ type ParamVote struct {
 	SbpName string
 }
 
+type ParamCreateDelegateGroup struct {
+	NodeCount           uint8
+	Interval            int64
+	PerCount            int64
+	RandCount           uint8
+	RandRank            uint8
+	Repeat              uint16
+	CheckLevel          uint8
+	RegisterStakeHeight uint64
+}
+type ParamUpdateDelegateGroup struct {
+	Gid                 types.Gid
+	RegisterStakeHeight uint64
+}
+
 // GetConsensusGroupInfoKey generate db key for consensus group info
 func GetConsensusGroupInfoKey(gid types.Gid) []byte {
 	return append(groupInfoKeyPrefix, gid.Bytes()...)
@@ -214,6 +244,13 @@ func parseConsensusGroup(data []byte, gid types.Gid) (*types.ConsensusGroupInfo,
 	return nil, err
 }
 
+// IsCancelledConsensusGroup checks whether a delegate consensus group created by governance contract is cancelled.
+// A cancelled group stays in the list, for contracts of the group are still produced by its registered producers,
+// but no one can register to it any more.
+func IsCancelledConsensusGroup(info *types.ConsensusGroupInfo) bool {
+	return !util.IsSnapshotGid(info.Gid) && !util.IsDelegateGid(info.Gid) && info.Owner.IsZero()
+}
+
 // GetRegisterStakeParamOfConsensusGroup decode stake param of register sbp
 func GetRegisterStakeParamOfConsensusGroup(data []byte) (*VariableRegisterStakeParam, error) {
 	stakeParam := new(VariableRegisterStakeParam)
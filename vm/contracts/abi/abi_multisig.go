@@ -0,0 +1,357 @@
+package abi
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/vm/abi"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+const (
+	jsonMultisig = `
+	[
+		{"type":"function","name":"CreateWallet","inputs":[{"name":"owners","type":"address[]"},{"name":"threshold","type":"uint8"}]},
+		{"type":"function","name":"Deposit","inputs":[{"name":"walletId","type":"uint64"}]},
+		{"type":"function","name":"ProposeTransfer","inputs":[{"name":"walletId","type":"uint64"},{"name":"to","type":"address"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"}]},
+		{"type":"function","name":"ProposeOwnerChange","inputs":[{"name":"walletId","type":"uint64"},{"name":"owners","type":"address[]"},{"name":"threshold","type":"uint8"}]},
+		{"type":"function","name":"Approve","inputs":[{"name":"walletId","type":"uint64"},{"name":"proposalId","type":"uint64"}]},
+		{"type":"function","name":"Refund","inputs":[{"name":"id","type":"bytes32"}]},
+
+		{"type":"variable","name":"nextWalletId","inputs":[{"name":"walletId","type":"uint64"}]},
+		{"type":"variable","name":"multisigWallet","inputs":[{"name":"owners","type":"address[]"},{"name":"threshold","type":"uint8"},{"name":"nextProposalId","type":"uint64"}]},
+		{"type":"variable","name":"multisigBalance","inputs":[{"name":"amount","type":"uint256"}]},
+		{"type":"variable","name":"multisigProposal","inputs":[{"name":"proposer","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"},{"name":"approvals","type":"address[]"}]},
+		{"type":"variable","name":"multisigOwners","inputs":[{"name":"owners","type":"address[]"},{"name":"threshold","type":"uint8"}]},
+		{"type":"variable","name":"multisigTransfer","inputs":[{"name":"walletId","type":"uint64"},{"name":"proposalId","type":"uint64"},{"name":"to","type":"address"}]},
+
+		{"type":"event","name":"createWallet","inputs":[{"name":"walletId","type":"uint64","indexed":true},{"name":"creator","type":"address"}]},
+		{"type":"event","name":"deposit","inputs":[{"name":"walletId","type":"uint64","indexed":true},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"},{"name":"from","type":"address"}]},
+		{"type":"event","name":"propose","inputs":[{"name":"walletId","type":"uint64","indexed":true},{"name":"proposalId","type":"uint64"},{"name":"proposer","type":"address"}]},
+		{"type":"event","name":"approve","inputs":[{"name":"walletId","type":"uint64","indexed":true},{"name":"proposalId","type":"uint64"},{"name":"owner","type":"address"}]},
+		{"type":"event","name":"execute","inputs":[{"name":"walletId","type":"uint64","indexed":true},{"name":"proposalId","type":"uint64"}]},
+		{"type":"event","name":"refund","inputs":[{"name":"walletId","type":"uint64","indexed":true},{"name":"proposalId","type":"uint64"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"}]}
+	]`
+
+	MethodNameMultisigCreateWallet       = "CreateWallet"
+	MethodNameMultisigDeposit            = "Deposit"
+	MethodNameMultisigProposeTransfer    = "ProposeTransfer"
+	MethodNameMultisigProposeOwnerChange = "ProposeOwnerChange"
+	MethodNameMultisigApprove            = "Approve"
+	MethodNameMultisigRefund             = "Refund"
+	VariableNameNextWalletId             = "nextWalletId"
+	VariableNameMultisigWallet           = "multisigWallet"
+	VariableNameMultisigBalance          = "multisigBalance"
+	VariableNameMultisigProposal         = "multisigProposal"
+	VariableNameMultisigOwners           = "multisigOwners"
+	VariableNameMultisigTransfer         = "multisigTransfer"
+	EventNameMultisigCreateWallet        = "createWallet"
+	EventNameMultisigDeposit             = "deposit"
+	EventNameMultisigPropose             = "propose"
+	EventNameMultisigApprove             = "approve"
+	EventNameMultisigExecute             = "execute"
+	EventNameMultisigRefund              = "refund"
+)
+
+var (
+	// ABIMultisig is abi definition of multisig contract
+	ABIMultisig, _ = abi.JSONToABIContract(strings.NewReader(jsonMultisig))
+
+	multisigNextWalletIdKey  = []byte{0}
+	multisigWalletKeyPrefix  = byte(1)
+	multisigBalanceKeyPrefix = byte(2)
+	multisigProposalPrefix   = byte(3)
+	multisigTransferPrefix   = byte(4)
+)
+
+// ParamMultisigOwners defines parameters of create wallet and propose owner change methods in multisig contract,
+// it is also the data of an owner change proposal
+type ParamMultisigOwners struct {
+	WalletId  uint64
+	Owners    []types.Address
+	Threshold uint8
+}
+
+// ParamMultisigProposeTransfer defines parameters of propose transfer method in multisig contract
+type ParamMultisigProposeTransfer struct {
+	WalletId uint64
+	To       types.Address
+	TokenId  types.TokenTypeId
+	Amount   *big.Int
+	Data     []byte
+}
+
+// ParamMultisigApprove defines parameters of approve method in multisig contract
+type ParamMultisigApprove struct {
+	WalletId   uint64
+	ProposalId uint64
+}
+
+// MultisigTransfer is the origin of an executed transfer to a contract, kept to credit the refund of a failed call
+// back to the wallet. Transfers received by the contract are never refunded, so their origins are never removed.
+type MultisigTransfer struct {
+	WalletId   uint64
+	ProposalId uint64
+	To         types.Address
+}
+
+// VariableMultisigBalance defines variable of token balance in multisig wallet
+type VariableMultisigBalance struct {
+	Amount *big.Int
+}
+
+// MultisigWallet is the owner set of a multisig wallet
+type MultisigWallet struct {
+	Owners         []types.Address
+	Threshold      uint8
+	NextProposalId uint64
+}
+
+// IsOwner checks whether addr is an owner of the wallet
+func (w *MultisigWallet) IsOwner(addr types.Address) bool {
+	for _, owner := range w.Owners {
+		if owner == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// MultisigProposal is a pending transfer or owner change of a multisig wallet.
+// A proposal to the multisig contract itself changes the owners, its data is packed variable multisigOwners.
+type MultisigProposal struct {
+	Id        uint64
+	Proposer  types.Address
+	To        types.Address
+	TokenId   types.TokenTypeId
+	Amount    *big.Int
+	Data      []byte
+	Approvals []types.Address
+}
+
+// IsOwnerChange checks whether the proposal changes the owners instead of transferring
+func (p *MultisigProposal) IsOwnerChange() bool {
+	return p.To == types.AddressMultisig
+}
+
+// IsApprovedBy checks whether addr has approved the proposal
+func (p *MultisigProposal) IsApprovedBy(addr types.Address) bool {
+	for _, a := range p.Approvals {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalCount counts the approvals of current owners, approvals of removed owners are ignored
+func (p *MultisigProposal) ApprovalCount(w *MultisigWallet) int {
+	count := 0
+	for _, addr := range p.Approvals {
+		if w.IsOwner(addr) {
+			count++
+		}
+	}
+	return count
+}
+
+// GetMultisigNextWalletIdKey generate db key for id of next created wallet
+func GetMultisigNextWalletIdKey() []byte {
+	return multisigNextWalletIdKey
+}
+
+// GetMultisigWalletKey generate db key for multisig wallet
+func GetMultisigWalletKey(walletId uint64) []byte {
+	return helper.JoinBytes([]byte{multisigWalletKeyPrefix}, uint64ToKey(walletId))
+}
+
+// GetMultisigBalanceKey generate db key for balance of a token in multisig wallet
+func GetMultisigBalanceKey(walletId uint64, tokenId types.TokenTypeId) []byte {
+	return helper.JoinBytes([]byte{multisigBalanceKeyPrefix}, uint64ToKey(walletId), tokenId.Bytes())
+}
+
+// GetMultisigProposalKey generate db key for proposal of multisig wallet
+func GetMultisigProposalKey(walletId uint64, proposalId uint64) []byte {
+	return helper.JoinBytes(GetMultisigProposalKeyPrefix(walletId), uint64ToKey(proposalId))
+}
+
+// GetMultisigTransferKey generate db key for origin of an executed transfer by hash of the send block
+func GetMultisigTransferKey(id types.Hash) []byte {
+	return helper.JoinBytes([]byte{multisigTransferPrefix}, id.Bytes())
+}
+
+// GetMultisigProposalKeyPrefix is used for db iterator
+func GetMultisigProposalKeyPrefix(walletId uint64) []byte {
+	return helper.JoinBytes([]byte{multisigProposalPrefix}, uint64ToKey(walletId))
+}
+
+func uint64ToKey(v uint64) []byte {
+	return helper.LeftPadBytes(new(big.Int).SetUint64(v).Bytes(), 8)
+}
+
+// GetMultisigWallet query multisig wallet by id, returns nil if not exists
+func GetMultisigWallet(db StorageDatabase, walletId uint64) (*MultisigWallet, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	v, err := db.GetValue(GetMultisigWalletKey(walletId))
+	if err != nil || len(v) == 0 {
+		return nil, err
+	}
+	wallet := new(MultisigWallet)
+	if err := ABIMultisig.UnpackVariable(wallet, VariableNameMultisigWallet, v); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// GetMultisigWalletBalance query balance of a token in multisig wallet
+func GetMultisigWalletBalance(db StorageDatabase, walletId uint64, tokenId types.TokenTypeId) (*big.Int, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	v, err := db.GetValue(GetMultisigBalanceKey(walletId, tokenId))
+	if err != nil {
+		return nil, err
+	}
+	if len(v) == 0 {
+		return big.NewInt(0), nil
+	}
+	balance := new(VariableMultisigBalance)
+	ABIMultisig.UnpackVariable(balance, VariableNameMultisigBalance, v)
+	return balance.Amount, nil
+}
+
+// GetMultisigProposal query pending proposal by id, returns nil if not exists or executed
+func GetMultisigProposal(db StorageDatabase, walletId uint64, proposalId uint64) (*MultisigProposal, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	v, err := db.GetValue(GetMultisigProposalKey(walletId, proposalId))
+	if err != nil || len(v) == 0 {
+		return nil, err
+	}
+	proposal := new(MultisigProposal)
+	if err := ABIMultisig.UnpackVariable(proposal, VariableNameMultisigProposal, v); err != nil {
+		return nil, err
+	}
+	proposal.Id = proposalId
+	return proposal, nil
+}
+
+// GetMultisigProposalList query pending proposals of multisig wallet, ordered by id
+func GetMultisigProposalList(db StorageDatabase, walletId uint64) ([]*MultisigProposal, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	prefix := GetMultisigProposalKeyPrefix(walletId)
+	iterator, err := db.NewStorageIterator(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+	list := make([]*MultisigProposal, 0)
+	for {
+		if !iterator.Next() {
+			if iterator.Error() != nil {
+				return nil, iterator.Error()
+			}
+			break
+		}
+		if !filterKeyValue(iterator.Key(), iterator.Value(), nil) {
+			continue
+		}
+		proposal := new(MultisigProposal)
+		if err := ABIMultisig.UnpackVariable(proposal, VariableNameMultisigProposal, iterator.Value()); err != nil {
+			continue
+		}
+		proposal.Id = helper.BytesToU64(iterator.Key()[len(prefix):])
+		list = append(list, proposal)
+	}
+	return list, nil
+}
+
+// GetMultisigWalletBalances query balances of all tokens in multisig wallet
+func GetMultisigWalletBalances(db StorageDatabase, walletId uint64) (map[types.TokenTypeId]*big.Int, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	prefix := helper.JoinBytes([]byte{multisigBalanceKeyPrefix}, uint64ToKey(walletId))
+	iterator, err := db.NewStorageIterator(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+	balances := make(map[types.TokenTypeId]*big.Int)
+	for {
+		if !iterator.Next() {
+			if iterator.Error() != nil {
+				return nil, iterator.Error()
+			}
+			break
+		}
+		if !filterKeyValue(iterator.Key(), iterator.Value(), nil) {
+			continue
+		}
+		tokenId, err := types.BytesToTokenTypeId(iterator.Key()[len(prefix):])
+		if err != nil {
+			continue
+		}
+		balance := new(VariableMultisigBalance)
+		if err := ABIMultisig.UnpackVariable(balance, VariableNameMultisigBalance, iterator.Value()); err != nil {
+			continue
+		}
+		balances[tokenId] = balance.Amount
+	}
+	return balances, nil
+}
+
+// GetMultisigWalletsByOwner query ids of multisig wallets owned by addr
+func GetMultisigWalletsByOwner(db StorageDatabase, owner types.Address) ([]uint64, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	iterator, err := db.NewStorageIterator([]byte{multisigWalletKeyPrefix})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+	ids := make([]uint64, 0)
+	for {
+		if !iterator.Next() {
+			if iterator.Error() != nil {
+				return nil, iterator.Error()
+			}
+			break
+		}
+		if !filterKeyValue(iterator.Key(), iterator.Value(), nil) {
+			continue
+		}
+		wallet := new(MultisigWallet)
+		if err := ABIMultisig.UnpackVariable(wallet, VariableNameMultisigWallet, iterator.Value()); err != nil {
+			continue
+		}
+		if wallet.IsOwner(owner) {
+			ids = append(ids, helper.BytesToU64(iterator.Key()[1:]))
+		}
+	}
+	return ids, nil
+}
+
+// GetMultisigTransfer query origin of an executed transfer by hash of the send block, returns nil if not exists
+func GetMultisigTransfer(db StorageDatabase, id types.Hash) (*MultisigTransfer, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	v, err := db.GetValue(GetMultisigTransferKey(id))
+	if err != nil || len(v) == 0 {
+		return nil, err
+	}
+	transfer := new(MultisigTransfer)
+	if err := ABIMultisig.UnpackVariable(transfer, VariableNameMultisigTransfer, v); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
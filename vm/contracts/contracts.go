@@ -53,11 +53,13 @@ type builtinContract struct {
 }
 
 var (
-	simpleContracts   = newSimpleContracts()
-	dexContracts      = newDexContracts()
-	dexAgentContracts = newDexAgentContracts()
-	leafContracts     = newLeafContracts()
-	earthContracts    = newEarthContracts()
+	simpleContracts        = newSimpleContracts()
+	dexContracts           = newDexContracts()
+	dexAgentContracts      = newDexAgentContracts()
+	leafContracts          = newLeafContracts()
+	earthContracts         = newEarthContracts()
+	multisigContracts      = newMultisigContracts()
+	delegateGroupContracts = newDelegateGroupContracts()
 )
 
 func newSimpleContracts() map[types.Address]*builtinContract {
@@ -217,10 +219,38 @@ func newEarthContracts() map[types.Address]*builtinContract {
 	return contracts
 }
 
+func newMultisigContracts() map[types.Address]*builtinContract {
+	contracts := newEarthContracts()
+	contracts[types.AddressMultisig] = &builtinContract{
+		map[string]BuiltinContractMethod{
+			cabi.MethodNameMultisigCreateWallet:       &MethodMultisigCreateWallet{cabi.MethodNameMultisigCreateWallet},
+			cabi.MethodNameMultisigDeposit:            &MethodMultisigDeposit{cabi.MethodNameMultisigDeposit},
+			cabi.MethodNameMultisigProposeTransfer:    &MethodMultisigProposeTransfer{cabi.MethodNameMultisigProposeTransfer},
+			cabi.MethodNameMultisigProposeOwnerChange: &MethodMultisigProposeOwnerChange{cabi.MethodNameMultisigProposeOwnerChange},
+			cabi.MethodNameMultisigApprove:            &MethodMultisigApprove{cabi.MethodNameMultisigApprove},
+			cabi.MethodNameMultisigRefund:             &MethodMultisigRefund{cabi.MethodNameMultisigRefund},
+		},
+		cabi.ABIMultisig,
+	}
+	return contracts
+}
+
+func newDelegateGroupContracts() map[types.Address]*builtinContract {
+	contracts := newMultisigContracts()
+	contracts[types.AddressGovernance].m[cabi.MethodNameCreateDelegateGroup] = &MethodCreateDelegateGroup{cabi.MethodNameCreateDelegateGroup}
+	contracts[types.AddressGovernance].m[cabi.MethodNameUpdateDelegateGroup] = &MethodUpdateDelegateGroup{cabi.MethodNameUpdateDelegateGroup}
+	contracts[types.AddressGovernance].m[cabi.MethodNameCancelDelegateGroup] = &MethodCancelDelegateGroup{cabi.MethodNameCancelDelegateGroup}
+	return contracts
+}
+
 // GetBuiltinContractMethod finds method instance of built-in contract method by address and method id
 func GetBuiltinContractMethod(addr types.Address, methodSelector []byte, sbHeight uint64) (BuiltinContractMethod, bool, error) {
 	var contractsMap map[types.Address]*builtinContract
-	if fork.IsEarthFork(sbHeight) {
+	if fork.IsDelegateGroupFork(sbHeight) {
+		contractsMap = delegateGroupContracts
+	} else if fork.IsMultisigFork(sbHeight) {
+		contractsMap = multisigContracts
+	} else if fork.IsEarthFork(sbHeight) {
 		contractsMap = earthContracts
 	} else if fork.IsLeafFork(sbHeight) {
 		contractsMap = leafContracts
@@ -0,0 +1,241 @@
+package contracts
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// checkDelegateGroupParam checks the schedule of a delegate consensus group, it can not be changed after creation,
+// for consensus caches the group info by gid.
+func checkDelegateGroupParam(param *abi.ParamCreateDelegateGroup) bool {
+	return param.NodeCount >= cgNodeCountMin && param.NodeCount <= cgNodeCountMax &&
+		param.Interval >= cgIntervalMin && param.Interval <= cgIntervalMax &&
+		param.PerCount >= cgPerCountMin && param.PerCount <= cgPerCountMax &&
+		param.Interval*param.PerCount >= cgPerIntervalMin && param.Interval*param.PerCount <= cgPerIntervalMax &&
+		param.RandCount <= param.NodeCount &&
+		param.RandRank >= param.NodeCount &&
+		param.Repeat > 0 &&
+		param.CheckLevel <= 1 &&
+		checkRegisterStakeHeight(param.RegisterStakeHeight)
+}
+
+func checkRegisterStakeHeight(stakeHeight uint64) bool {
+	return stakeHeight >= 1 && stakeHeight <= stakeHeightMax
+}
+
+func packRegisterStakeParam(stakeHeight uint64) []byte {
+	data, _ := abi.ABIGovernance.PackVariable(abi.VariableNameRegisterStakeParam, SbpStakeAmountMainnet, ledger.ViteTokenId, stakeHeight)
+	return data
+}
+
+func setDelegateGroup(db vm_db.VmDb, info *types.ConsensusGroupInfo) {
+	data, _ := abi.ABIGovernance.PackVariable(abi.VariableNameConsensusGroupInfo,
+		info.NodeCount,
+		info.Interval,
+		info.PerCount,
+		info.RandCount,
+		info.RandRank,
+		info.Repeat,
+		info.CheckLevel,
+		info.CountingTokenId,
+		info.RegisterConditionId,
+		info.RegisterConditionParam,
+		info.VoteConditionId,
+		info.VoteConditionParam,
+		info.Owner,
+		info.StakeAmount,
+		info.ExpirationHeight)
+	util.SetValue(db, abi.GetConsensusGroupInfoKey(info.Gid), data)
+}
+
+// getOwnedDelegateGroup returns a delegate consensus group created by owner and not cancelled
+func getOwnedDelegateGroup(db vm_db.VmDb, gid types.Gid, owner types.Address) (*types.ConsensusGroupInfo, error) {
+	if util.IsSnapshotGid(gid) || util.IsDelegateGid(gid) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	info, err := abi.GetConsensusGroup(db, gid)
+	util.DealWithErr(err)
+	if info == nil || abi.IsCancelledConsensusGroup(info) || info.Owner != owner {
+		return nil, util.ErrInvalidMethodParam
+	}
+	return info, nil
+}
+
+type MethodCreateDelegateGroup struct {
+	MethodName string
+}
+
+func (p *MethodCreateDelegateGroup) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (p *MethodCreateDelegateGroup) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+func (p *MethodCreateDelegateGroup) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.CreateDelegateGroupQuota, nil
+}
+func (p *MethodCreateDelegateGroup) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodCreateDelegateGroup) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Cmp(DelegateGroupStakeAmount) != 0 || block.TokenId != ledger.ViteTokenId {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamCreateDelegateGroup)
+	if err := abi.ABIGovernance.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if !checkDelegateGroupParam(param) {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIGovernance.PackMethod(p.MethodName,
+		param.NodeCount, param.Interval, param.PerCount, param.RandCount, param.RandRank, param.Repeat, param.CheckLevel, param.RegisterStakeHeight)
+	return nil
+}
+
+// DoReceive creates a delegate consensus group owned by the sender, the gid is generated by the send block.
+// Producers are voted by VITE and register with the stake amount of a snapshot block producer,
+// the stake of the group is locked for a while and returned when the group is cancelled.
+func (p *MethodCreateDelegateGroup) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamCreateDelegateGroup)
+	abi.ABIGovernance.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	gid := types.DataToGid(sendBlock.AccountAddress.Bytes(), sendBlock.Hash.Bytes())
+	if util.IsSnapshotGid(gid) || util.IsDelegateGid(gid) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	old, err := abi.GetConsensusGroup(db, gid)
+	util.DealWithErr(err)
+	if old != nil {
+		return nil, util.ErrInvalidMethodParam
+	}
+	setDelegateGroup(db, &types.ConsensusGroupInfo{
+		Gid:                    gid,
+		NodeCount:              param.NodeCount,
+		Interval:               param.Interval,
+		PerCount:               param.PerCount,
+		RandCount:              param.RandCount,
+		RandRank:               param.RandRank,
+		Repeat:                 param.Repeat,
+		CheckLevel:             param.CheckLevel,
+		CountingTokenId:        ledger.ViteTokenId,
+		RegisterConditionId:    1,
+		RegisterConditionParam: packRegisterStakeParam(param.RegisterStakeHeight),
+		VoteConditionId:        1,
+		VoteConditionParam:     []byte{},
+		Owner:                  sendBlock.AccountAddress,
+		StakeAmount:            sendBlock.Amount,
+		ExpirationHeight:       vm.GlobalStatus().SnapshotBlock().Height + nodeConfig.params.DelegateGroupStakeHeight,
+	})
+	db.AddLog(NewLog(abi.ABIGovernance, abi.EventNameCreateDelegateGroup, gid, sendBlock.AccountAddress))
+	return nil, nil
+}
+
+type MethodUpdateDelegateGroup struct {
+	MethodName string
+}
+
+func (p *MethodUpdateDelegateGroup) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (p *MethodUpdateDelegateGroup) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+func (p *MethodUpdateDelegateGroup) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.UpdateDelegateGroupQuota, nil
+}
+func (p *MethodUpdateDelegateGroup) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodUpdateDelegateGroup) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() != 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamUpdateDelegateGroup)
+	if err := abi.ABIGovernance.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if util.IsSnapshotGid(param.Gid) || util.IsDelegateGid(param.Gid) || !checkRegisterStakeHeight(param.RegisterStakeHeight) {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIGovernance.PackMethod(p.MethodName, param.Gid, param.RegisterStakeHeight)
+	return nil
+}
+
+// DoReceive updates the register condition of a delegate consensus group, it takes effect on new registrations only
+func (p *MethodUpdateDelegateGroup) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamUpdateDelegateGroup)
+	abi.ABIGovernance.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	info, err := getOwnedDelegateGroup(db, param.Gid, sendBlock.AccountAddress)
+	if err != nil {
+		return nil, err
+	}
+	info.RegisterConditionParam = packRegisterStakeParam(param.RegisterStakeHeight)
+	setDelegateGroup(db, info)
+	db.AddLog(NewLog(abi.ABIGovernance, abi.EventNameUpdateDelegateGroup, param.Gid, param.RegisterStakeHeight))
+	return nil, nil
+}
+
+type MethodCancelDelegateGroup struct {
+	MethodName string
+}
+
+func (p *MethodCancelDelegateGroup) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (p *MethodCancelDelegateGroup) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+func (p *MethodCancelDelegateGroup) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.CancelDelegateGroupQuota, nil
+}
+func (p *MethodCancelDelegateGroup) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodCancelDelegateGroup) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() != 0 {
+		return util.ErrInvalidMethodParam
+	}
+	gid := new(types.Gid)
+	if err := abi.ABIGovernance.UnpackMethod(gid, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if util.IsSnapshotGid(*gid) || util.IsDelegateGid(*gid) {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIGovernance.PackMethod(p.MethodName, *gid)
+	return nil
+}
+
+// DoReceive cancels a delegate consensus group after the stake is unlocked and returns the stake to the owner.
+// The group is kept with a zero owner, registered producers keep producing blocks for contracts of the group.
+func (p *MethodCancelDelegateGroup) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	gid := new(types.Gid)
+	abi.ABIGovernance.UnpackMethod(gid, p.MethodName, sendBlock.Data)
+	info, err := getOwnedDelegateGroup(db, *gid, sendBlock.AccountAddress)
+	if err != nil {
+		return nil, err
+	}
+	if info.ExpirationHeight > vm.GlobalStatus().SnapshotBlock().Height {
+		return nil, util.ErrInvalidMethodParam
+	}
+	amount := info.StakeAmount
+	info.Owner = types.ZERO_ADDRESS
+	info.StakeAmount = helper.Big0
+	setDelegateGroup(db, info)
+	db.AddLog(NewLog(abi.ABIGovernance, abi.EventNameCancelDelegateGroup, *gid))
+	if amount.Sign() > 0 {
+		return []*ledger.AccountBlock{
+			util.MakeRequestBlock(block.AccountAddress, sendBlock.AccountAddress, ledger.BlockTypeSendCall, amount, ledger.ViteTokenId, []byte{}),
+		}, nil
+	}
+	return nil, nil
+}
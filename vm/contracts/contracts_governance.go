@@ -123,7 +123,7 @@ func (p *MethodRegister) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, se
 
 	groupInfo, err := abi.GetConsensusGroup(db, param.Gid)
 	util.DealWithErr(err)
-	if groupInfo == nil {
+	if groupInfo == nil || (fork.IsDelegateGroupFork(sb.Height) && abi.IsCancelledConsensusGroup(groupInfo)) {
 		return nil, util.ErrInvalidMethodParam
 	}
 	stakeParam, _ := abi.GetRegisterStakeParamOfConsensusGroup(groupInfo.RegisterConditionParam)
@@ -0,0 +1,430 @@
+package contracts
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// multisigMaxOwners limits the owners of a multisig wallet, approvals are counted by iterating them
+const multisigMaxOwners = 16
+
+func multisigSendQuota(base uint64, data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	dataCost, err := util.DataQuotaCost(data, gasTable)
+	if err != nil {
+		return 0, err
+	}
+	cost, overflow := helper.SafeAdd(base, dataCost)
+	if overflow {
+		return 0, util.ErrGasUintOverflow
+	}
+	return cost, nil
+}
+
+func checkMultisigOwners(owners []types.Address, threshold uint8) error {
+	if len(owners) == 0 || len(owners) > multisigMaxOwners || threshold == 0 || int(threshold) > len(owners) {
+		return util.ErrInvalidMethodParam
+	}
+	for i, owner := range owners {
+		if owner.IsZero() {
+			return util.ErrInvalidMethodParam
+		}
+		for _, other := range owners[:i] {
+			if other == owner {
+				return util.ErrInvalidMethodParam
+			}
+		}
+	}
+	return nil
+}
+
+func getMultisigWallet(db vm_db.VmDb, walletId uint64) *abi.MultisigWallet {
+	wallet, err := abi.GetMultisigWallet(db, walletId)
+	util.DealWithErr(err)
+	return wallet
+}
+
+func setMultisigWallet(db vm_db.VmDb, walletId uint64, wallet *abi.MultisigWallet) {
+	data, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigWallet, wallet.Owners, wallet.Threshold, wallet.NextProposalId)
+	util.SetValue(db, abi.GetMultisigWalletKey(walletId), data)
+}
+
+func addMultisigBalance(db vm_db.VmDb, walletId uint64, tokenId types.TokenTypeId, amount *big.Int) {
+	balance, err := abi.GetMultisigWalletBalance(db, walletId, tokenId)
+	util.DealWithErr(err)
+	setMultisigBalance(db, walletId, tokenId, balance.Add(balance, amount))
+}
+
+func subMultisigBalance(db vm_db.VmDb, walletId uint64, tokenId types.TokenTypeId, amount *big.Int) error {
+	balance, err := abi.GetMultisigWalletBalance(db, walletId, tokenId)
+	util.DealWithErr(err)
+	if balance.Cmp(amount) < 0 {
+		return util.ErrInsufficientBalance
+	}
+	setMultisigBalance(db, walletId, tokenId, balance.Sub(balance, amount))
+	return nil
+}
+
+func setMultisigBalance(db vm_db.VmDb, walletId uint64, tokenId types.TokenTypeId, balance *big.Int) {
+	key := abi.GetMultisigBalanceKey(walletId, tokenId)
+	if balance.Sign() == 0 {
+		util.SetValue(db, key, nil)
+		return
+	}
+	data, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigBalance, balance)
+	util.SetValue(db, key, data)
+}
+
+func setMultisigProposal(db vm_db.VmDb, walletId uint64, proposal *abi.MultisigProposal) {
+	data, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigProposal,
+		proposal.Proposer, proposal.To, proposal.TokenId, proposal.Amount, proposal.Data, proposal.Approvals)
+	util.SetValue(db, abi.GetMultisigProposalKey(walletId, proposal.Id), data)
+}
+
+// approveMultisigProposal adds the approval of owner, and executes the proposal if approvals of current owners reach the threshold.
+// An owner approved before can only execute the proposal, e.g. after a deposit or a lower threshold.
+// A transfer is returned as a send block from the contract, a contract receiving it sees the multisig contract as the caller.
+// The origin of a transfer to a contract is saved by hash of the send block, a failed call is refunded by method Refund with it.
+func approveMultisigProposal(db vm_db.VmDb, block *ledger.AccountBlock, walletId uint64, wallet *abi.MultisigWallet, proposal *abi.MultisigProposal, owner types.Address) ([]*ledger.AccountBlock, error) {
+	if !proposal.IsApprovedBy(owner) {
+		proposal.Approvals = append(proposal.Approvals, owner)
+		db.AddLog(NewLog(abi.ABIMultisig, abi.EventNameMultisigApprove, walletId, proposal.Id, owner))
+	} else if proposal.ApprovalCount(wallet) < int(wallet.Threshold) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	if proposal.ApprovalCount(wallet) < int(wallet.Threshold) {
+		setMultisigProposal(db, walletId, proposal)
+		return nil, nil
+	}
+
+	util.SetValue(db, abi.GetMultisigProposalKey(walletId, proposal.Id), nil)
+	db.AddLog(NewLog(abi.ABIMultisig, abi.EventNameMultisigExecute, walletId, proposal.Id))
+	if proposal.IsOwnerChange() {
+		param := new(abi.ParamMultisigOwners)
+		if err := abi.ABIMultisig.UnpackVariable(param, abi.VariableNameMultisigOwners, proposal.Data); err != nil {
+			return nil, util.ErrInvalidMethodParam
+		}
+		wallet.Owners, wallet.Threshold = param.Owners, param.Threshold
+		setMultisigWallet(db, walletId, wallet)
+		return nil, nil
+	}
+
+	if err := subMultisigBalance(db, walletId, proposal.TokenId, proposal.Amount); err != nil {
+		return nil, err
+	}
+	transfer := util.MakeRequestBlock(types.AddressMultisig, proposal.To, ledger.BlockTypeSendCall, proposal.Amount, proposal.TokenId, proposal.Data)
+	if types.IsContractAddr(proposal.To) && proposal.Amount.Sign() > 0 {
+		data, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigTransfer, walletId, proposal.Id, proposal.To)
+		util.SetValue(db, abi.GetMultisigTransferKey(util.ComputeSendBlockHash(block, transfer, 0)), data)
+	}
+	return []*ledger.AccountBlock{transfer}, nil
+}
+
+type MethodMultisigCreateWallet struct {
+	MethodName string
+}
+
+func (p *MethodMultisigCreateWallet) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigCreateWallet) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigCreateWallet) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return multisigSendQuota(gasTable.MultisigCreateWalletQuota, data, gasTable)
+}
+
+func (p *MethodMultisigCreateWallet) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+// DoSend checks the owners, amount of the send block is deposited into the created wallet
+func (p *MethodMultisigCreateWallet) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	param := new(abi.ParamMultisigOwners)
+	if err := abi.ABIMultisig.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if err := checkMultisigOwners(param.Owners, param.Threshold); err != nil {
+		return err
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, param.Owners, param.Threshold)
+	return nil
+}
+
+func (p *MethodMultisigCreateWallet) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamMultisigOwners)
+	abi.ABIMultisig.UnpackMethod(param, p.MethodName, sendBlock.Data)
+
+	walletId := uint64(0)
+	if v := util.GetValue(db, abi.GetMultisigNextWalletIdKey()); len(v) > 0 {
+		abi.ABIMultisig.UnpackVariable(&walletId, abi.VariableNameNextWalletId, v)
+	}
+	nextData, _ := abi.ABIMultisig.PackVariable(abi.VariableNameNextWalletId, walletId+1)
+	util.SetValue(db, abi.GetMultisigNextWalletIdKey(), nextData)
+
+	setMultisigWallet(db, walletId, &abi.MultisigWallet{Owners: param.Owners, Threshold: param.Threshold})
+	db.AddLog(NewLog(abi.ABIMultisig, abi.EventNameMultisigCreateWallet, walletId, sendBlock.AccountAddress))
+	if sendBlock.Amount.Sign() > 0 {
+		addMultisigBalance(db, walletId, sendBlock.TokenId, sendBlock.Amount)
+		db.AddLog(NewLog(abi.ABIMultisig, abi.EventNameMultisigDeposit, walletId, sendBlock.TokenId, sendBlock.Amount, sendBlock.AccountAddress))
+	}
+	return nil, nil
+}
+
+type MethodMultisigDeposit struct {
+	MethodName string
+}
+
+func (p *MethodMultisigDeposit) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigDeposit) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigDeposit) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.MultisigDepositQuota, nil
+}
+
+func (p *MethodMultisigDeposit) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodMultisigDeposit) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() <= 0 {
+		return util.ErrInvalidMethodParam
+	}
+	walletId := new(uint64)
+	if err := abi.ABIMultisig.UnpackMethod(walletId, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, *walletId)
+	return nil
+}
+
+func (p *MethodMultisigDeposit) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	walletId := new(uint64)
+	abi.ABIMultisig.UnpackMethod(walletId, p.MethodName, sendBlock.Data)
+	if getMultisigWallet(db, *walletId) == nil {
+		return nil, util.ErrInvalidMethodParam
+	}
+	addMultisigBalance(db, *walletId, sendBlock.TokenId, sendBlock.Amount)
+	db.AddLog(NewLog(abi.ABIMultisig, abi.EventNameMultisigDeposit, *walletId, sendBlock.TokenId, sendBlock.Amount, sendBlock.AccountAddress))
+	return nil, nil
+}
+
+type MethodMultisigProposeTransfer struct {
+	MethodName string
+}
+
+func (p *MethodMultisigProposeTransfer) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigProposeTransfer) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigProposeTransfer) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return multisigSendQuota(gasTable.MultisigProposeQuota, data, gasTable)
+}
+
+func (p *MethodMultisigProposeTransfer) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+// DoSend checks the transfer. Built-in contracts are not valid receivers, the multisig contract itself
+// because a proposal to it changes the owners, others because they return tokens by calls the multisig
+// contract can not receive, e.g. a refund with the data of the failed method.
+func (p *MethodMultisigProposeTransfer) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamMultisigProposeTransfer)
+	if err := abi.ABIMultisig.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if types.IsBuiltinContractAddr(param.To) || param.Amount.Sign() < 0 {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, param.WalletId, param.To, param.TokenId, param.Amount, param.Data)
+	return nil
+}
+
+func (p *MethodMultisigProposeTransfer) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamMultisigProposeTransfer)
+	abi.ABIMultisig.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	return propose(db, block, param.WalletId, &abi.MultisigProposal{
+		Proposer: sendBlock.AccountAddress,
+		To:       param.To,
+		TokenId:  param.TokenId,
+		Amount:   param.Amount,
+		Data:     param.Data,
+	})
+}
+
+type MethodMultisigProposeOwnerChange struct {
+	MethodName string
+}
+
+func (p *MethodMultisigProposeOwnerChange) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigProposeOwnerChange) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigProposeOwnerChange) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return multisigSendQuota(gasTable.MultisigProposeQuota, data, gasTable)
+}
+
+func (p *MethodMultisigProposeOwnerChange) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodMultisigProposeOwnerChange) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamMultisigOwners)
+	if err := abi.ABIMultisig.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if err := checkMultisigOwners(param.Owners, param.Threshold); err != nil {
+		return err
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, param.WalletId, param.Owners, param.Threshold)
+	return nil
+}
+
+func (p *MethodMultisigProposeOwnerChange) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamMultisigOwners)
+	abi.ABIMultisig.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	data, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigOwners, param.Owners, param.Threshold)
+	return propose(db, block, param.WalletId, &abi.MultisigProposal{
+		Proposer: sendBlock.AccountAddress,
+		To:       types.AddressMultisig,
+		TokenId:  ledger.ViteTokenId,
+		Amount:   big.NewInt(0),
+		Data:     data,
+	})
+}
+
+// propose saves a proposal of an owner with the approval of the proposer
+func propose(db vm_db.VmDb, block *ledger.AccountBlock, walletId uint64, proposal *abi.MultisigProposal) ([]*ledger.AccountBlock, error) {
+	wallet := getMultisigWallet(db, walletId)
+	if wallet == nil || !wallet.IsOwner(proposal.Proposer) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	proposal.Id = wallet.NextProposalId
+	wallet.NextProposalId++
+	setMultisigWallet(db, walletId, wallet)
+	db.AddLog(NewLog(abi.ABIMultisig, abi.EventNameMultisigPropose, walletId, proposal.Id, proposal.Proposer))
+	return approveMultisigProposal(db, block, walletId, wallet, proposal, proposal.Proposer)
+}
+
+type MethodMultisigApprove struct {
+	MethodName string
+}
+
+func (p *MethodMultisigApprove) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigApprove) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigApprove) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.MultisigApproveQuota, nil
+}
+
+func (p *MethodMultisigApprove) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodMultisigApprove) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamMultisigApprove)
+	if err := abi.ABIMultisig.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, param.WalletId, param.ProposalId)
+	return nil
+}
+
+// DoReceive fails if the approval executes a transfer larger than the balance of wallet,
+// the approval is reverted and the owner can approve again after a deposit.
+func (p *MethodMultisigApprove) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamMultisigApprove)
+	abi.ABIMultisig.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	wallet := getMultisigWallet(db, param.WalletId)
+	if wallet == nil || !wallet.IsOwner(sendBlock.AccountAddress) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	proposal, err := abi.GetMultisigProposal(db, param.WalletId, param.ProposalId)
+	util.DealWithErr(err)
+	if proposal == nil {
+		return nil, util.ErrInvalidMethodParam
+	}
+	return approveMultisigProposal(db, block, param.WalletId, wallet, proposal, sendBlock.AccountAddress)
+}
+
+type MethodMultisigRefund struct {
+	MethodName string
+}
+
+func (p *MethodMultisigRefund) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigRefund) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigRefund) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.MultisigDepositQuota, nil
+}
+
+func (p *MethodMultisigRefund) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodMultisigRefund) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() <= 0 {
+		return util.ErrInvalidMethodParam
+	}
+	id := new(types.Hash)
+	if err := abi.ABIMultisig.UnpackMethod(id, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, *id)
+	return nil
+}
+
+// DoReceive credits the refund of a failed transfer back to the wallet, only the receiver of the transfer can refund it.
+// The vm sends the refund of a failed or expired call from the multisig contract by this method, see vm.refundOf.
+func (p *MethodMultisigRefund) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	id := new(types.Hash)
+	abi.ABIMultisig.UnpackMethod(id, p.MethodName, sendBlock.Data)
+	transfer, err := abi.GetMultisigTransfer(db, *id)
+	util.DealWithErr(err)
+	if transfer == nil || transfer.To != sendBlock.AccountAddress {
+		return nil, util.ErrInvalidMethodParam
+	}
+	util.SetValue(db, abi.GetMultisigTransferKey(*id), nil)
+	addMultisigBalance(db, transfer.WalletId, sendBlock.TokenId, sendBlock.Amount)
+	db.AddLog(NewLog(abi.ABIMultisig, abi.EventNameMultisigRefund, transfer.WalletId, transfer.ProposalId, sendBlock.TokenId, sendBlock.Amount))
+	return nil, nil
+}
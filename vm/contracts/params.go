@@ -35,23 +35,28 @@ var (
 	rewardPerBlock = big.NewInt(951293759512937595)
 	stakeAmountMin = new(big.Int).Mul(big.NewInt(134), util.AttovPerVite)
 	issueFee       = new(big.Int).Mul(big.NewInt(1e3), util.AttovPerVite)
+	// DelegateGroupStakeAmount defines stake amount of creating a delegate consensus group
+	DelegateGroupStakeAmount = new(big.Int).Mul(big.NewInt(1e6), util.AttovPerVite)
 )
 
 type contractsParams struct {
-	StakeHeight            uint64 // locking height for stake
-	DexVipStakeHeight      uint64 // locking height for dex_fund contract, in order to upgrade to dex vip
-	DexSuperVipStakeHeight uint64 // locking height for dex_fund contract, in order to upgrade to dex super vip
+	StakeHeight              uint64 // locking height for stake
+	DexVipStakeHeight        uint64 // locking height for dex_fund contract, in order to upgrade to dex vip
+	DexSuperVipStakeHeight   uint64 // locking height for dex_fund contract, in order to upgrade to dex super vip
+	DelegateGroupStakeHeight uint64 // locking height for governance contract, in order to create a delegate consensus group
 }
 
 var (
 	contractsParamsTest = contractsParams{
-		StakeHeight:            600,
-		DexVipStakeHeight:      1,
-		DexSuperVipStakeHeight: 1,
+		StakeHeight:              600,
+		DexVipStakeHeight:        1,
+		DexSuperVipStakeHeight:   1,
+		DelegateGroupStakeHeight: 600,
 	}
 	contractsParamsMainNet = contractsParams{
-		StakeHeight:            3600 * 24 * 3,
-		DexVipStakeHeight:      3600 * 24 * 30,
-		DexSuperVipStakeHeight: 3600 * 24 * 30,
+		StakeHeight:              3600 * 24 * 3,
+		DexVipStakeHeight:        3600 * 24 * 30,
+		DexSuperVipStakeHeight:   3600 * 24 * 30,
+		DelegateGroupStakeHeight: 3600 * 24 * 90,
 	}
 )
@@ -9,6 +9,7 @@ import (
 	"github.com/vitelabs/go-vite/consensus/core"
 	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
 	"github.com/vitelabs/go-vite/vm/util"
 	"math/big"
@@ -1385,6 +1386,201 @@ func TestContractsAssetV2(t *testing.T) {
 	db.accountBlockMap[addr2][hash2a] = receiveIssueBlock2.AccountBlock.SendBlockList[0]
 }
 
+func TestContractsMultisig(t *testing.T) {
+	viteTotalSupply := new(big.Int).Mul(big.NewInt(1e9), util.AttovPerVite)
+	db, addr1, _, hash12, _, timestamp := prepareDb(viteTotalSupply)
+	sbTime := time.Unix(timestamp+1, 0)
+	snapshot3 := &ledger.SnapshotBlock{Height: 900, Timestamp: &sbTime, Hash: types.DataHash([]byte{10, 3})}
+	db.snapshotBlockList = append(db.snapshotBlockList, snapshot3)
+	addr2 := types.AddressMultisig
+	addr3, _, _ := types.CreateAddress()
+	addr4, _, _ := types.CreateAddress()
+	db.accountBlockMap[addr2] = make(map[types.Hash]*ledger.AccountBlock)
+
+	// create a wallet of addr1 and addr3 with a deposit
+	deposit := new(big.Int).Mul(big.NewInt(100), util.AttovPerVite)
+	createData, _ := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigCreateWallet, []types.Address{addr1, addr3}, uint8(2))
+	block13 := &ledger.AccountBlock{
+		Height:         3,
+		ToAddress:      addr2,
+		AccountAddress: addr1,
+		BlockType:      ledger.BlockTypeSendCall,
+		PrevHash:       hash12,
+		Amount:         deposit,
+		Fee:            big.NewInt(0),
+		Data:           createData,
+		TokenId:        ledger.ViteTokenId,
+		Hash:           types.DataHash([]byte{1, 3}),
+	}
+	db.addr = addr1
+	sendCreateBlock, isRetry, err := NewVM(nil).RunV2(db, block13, nil, nil)
+	if sendCreateBlock == nil || isRetry || err != nil ||
+		sendCreateBlock.AccountBlock.Quota < util.QuotaTableByHeight(snapshot3.Height).MultisigCreateWalletQuota {
+		t.Fatalf("send create wallet transaction error, %v", err)
+	}
+	db.accountBlockMap[addr1][block13.Hash] = sendCreateBlock.AccountBlock
+
+	receiveHeight := uint64(0)
+	receive := func(sendBlock *ledger.AccountBlock) (*ledger.AccountBlock, error) {
+		receiveHeight++
+		block := &ledger.AccountBlock{
+			Height:         receiveHeight,
+			AccountAddress: addr2,
+			BlockType:      ledger.BlockTypeReceive,
+			FromBlockHash:  sendBlock.Hash,
+			Hash:           types.DataHash([]byte{2, byte(receiveHeight)}),
+		}
+		db.addr = addr2
+		vmBlock, isRetry, err := NewVM(nil).RunV2(db, block, sendBlock, NewTestGlobalStatus(0, snapshot3))
+		if vmBlock == nil || isRetry {
+			t.Fatalf("receive multisig transaction error, retry %v, err %v", isRetry, err)
+		}
+		db.accountBlockMap[addr2][block.Hash] = vmBlock.AccountBlock
+		return vmBlock.AccountBlock, err
+	}
+	sendCall := func(from types.Address, data []byte) *ledger.AccountBlock {
+		return &ledger.AccountBlock{
+			AccountAddress: from,
+			ToAddress:      addr2,
+			BlockType:      ledger.BlockTypeSendCall,
+			Amount:         big.NewInt(0),
+			Fee:            big.NewInt(0),
+			TokenId:        ledger.ViteTokenId,
+			Data:           data,
+			Hash:           types.DataHash(append(from.Bytes(), data...)),
+		}
+	}
+
+	if _, err := receive(sendCreateBlock.AccountBlock); err != nil {
+		t.Fatalf("receive create wallet transaction error, %v", err)
+	}
+	db.addr = addr2
+	wallet, _ := abi.GetMultisigWallet(db, 0)
+	balance, _ := abi.GetMultisigWalletBalance(db, 0, ledger.ViteTokenId)
+	if wallet == nil || len(wallet.Owners) != 2 || wallet.Threshold != 2 || balance.Cmp(deposit) != 0 ||
+		db.balanceMap[addr2][ledger.ViteTokenId].Cmp(deposit) != 0 {
+		t.Fatalf("wallet is not created, %v, %v", wallet, balance)
+	}
+
+	// the proposal of addr1 is pending until addr3 approves
+	amount := new(big.Int).Mul(big.NewInt(40), util.AttovPerVite)
+	proposeData, _ := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigProposeTransfer, uint64(0), addr4, ledger.ViteTokenId, amount, []byte{})
+	if block, err := receive(sendCall(addr1, proposeData)); err != nil || len(block.SendBlockList) != 0 {
+		t.Fatalf("receive propose transaction error, %v", err)
+	}
+	list, _ := abi.GetMultisigProposalList(db, 0)
+	if len(list) != 1 || list[0].Id != 0 || list[0].To != addr4 || len(list[0].Approvals) != 1 || list[0].Approvals[0] != addr1 {
+		t.Fatalf("proposal is not pending, %v", list)
+	}
+
+	approveData, _ := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigApprove, uint64(0), uint64(0))
+	if _, err := receive(sendCall(addr4, approveData)); err == nil {
+		t.Fatalf("approval of non-owner should fail")
+	}
+	if _, err := receive(sendCall(addr1, approveData)); err == nil {
+		t.Fatalf("approval should not be counted twice")
+	}
+	block, err := receive(sendCall(addr3, approveData))
+	if err != nil || len(block.SendBlockList) != 1 ||
+		block.SendBlockList[0].AccountAddress != addr2 ||
+		block.SendBlockList[0].ToAddress != addr4 ||
+		block.SendBlockList[0].Amount.Cmp(amount) != 0 {
+		t.Fatalf("approved proposal is not executed, %v", err)
+	}
+	db.addr = addr2
+	balance, _ = abi.GetMultisigWalletBalance(db, 0, ledger.ViteTokenId)
+	list, _ = abi.GetMultisigProposalList(db, 0)
+	if balance.Cmp(new(big.Int).Sub(deposit, amount)) != 0 || len(list) != 0 {
+		t.Fatalf("balance %v, proposals %v after execution", balance, list)
+	}
+
+	// transfer more than the balance is not executed
+	proposeData, _ = abi.ABIMultisig.PackMethod(abi.MethodNameMultisigProposeTransfer, uint64(0), addr4, ledger.ViteTokenId, deposit, []byte{})
+	receive(sendCall(addr1, proposeData))
+	approveData, _ = abi.ABIMultisig.PackMethod(abi.MethodNameMultisigApprove, uint64(0), uint64(1))
+	if _, err := receive(sendCall(addr3, approveData)); err != util.ErrInsufficientBalance {
+		t.Fatalf("transfer more than balance should fail, %v", err)
+	}
+
+	// owner change lowers the threshold, addr4 replaces addr3
+	changeData, _ := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigProposeOwnerChange, uint64(0), []types.Address{addr1, addr4}, uint8(1))
+	receive(sendCall(addr3, changeData))
+	approveData, _ = abi.ABIMultisig.PackMethod(abi.MethodNameMultisigApprove, uint64(0), uint64(2))
+	if block, err := receive(sendCall(addr1, approveData)); err != nil || len(block.SendBlockList) != 0 {
+		t.Fatalf("receive owner change approval error, %v", err)
+	}
+	db.addr = addr2
+	wallet, _ = abi.GetMultisigWallet(db, 0)
+	if wallet.Threshold != 1 || !wallet.IsOwner(addr4) || wallet.IsOwner(addr3) {
+		t.Fatalf("owners are not changed, %v", wallet)
+	}
+	if ids, _ := abi.GetMultisigWalletsByOwner(db, addr4); len(ids) != 1 || ids[0] != 0 {
+		t.Fatalf("wallets of new owner %v", ids)
+	}
+	if balances, _ := abi.GetMultisigWalletBalances(db, 0); len(balances) != 1 || balances[ledger.ViteTokenId].Cmp(new(big.Int).Sub(deposit, amount)) != 0 {
+		t.Fatalf("wallet balances %v", balances)
+	}
+	if _, err := receive(sendCall(addr3, changeData)); err == nil {
+		t.Fatalf("removed owner should not propose")
+	}
+
+	// built-in contracts can not refund a transfer to the multisig contract
+	proposeData, _ = abi.ABIMultisig.PackMethod(abi.MethodNameMultisigProposeTransfer, uint64(0), types.AddressQuota, ledger.ViteTokenId, amount, []byte{})
+	method, ok, err := contracts.GetBuiltinContractMethod(addr2, proposeData, snapshot3.Height)
+	if !ok || err != nil {
+		t.Fatalf("propose transfer method not found, %v", err)
+	}
+	if err := method.DoSend(db, sendCall(addr1, proposeData)); err != util.ErrInvalidMethodParam {
+		t.Fatalf("transfer to built-in contract should be rejected, %v", err)
+	}
+
+	// a failed call of a user contract is refunded to the wallet by method Refund
+	contractAddr := types.CreateContractAddress([]byte{1, 2, 3})
+	proposeData, _ = abi.ABIMultisig.PackMethod(abi.MethodNameMultisigProposeTransfer, uint64(0), contractAddr, ledger.ViteTokenId, amount, []byte{1})
+	block, err = receive(sendCall(addr1, proposeData))
+	if err != nil || len(block.SendBlockList) != 1 || block.SendBlockList[0].ToAddress != contractAddr {
+		t.Fatalf("transfer to user contract is not executed, %v", err)
+	}
+	transfer := block.SendBlockList[0]
+	transfer.Hash = util.ComputeSendBlockHash(block, transfer, 0)
+	db.addr = addr2
+	if record, _ := abi.GetMultisigTransfer(db, transfer.Hash); record == nil || record.WalletId != 0 || record.ProposalId != 3 || record.To != contractAddr {
+		t.Fatalf("origin of transfer is not saved, %v", record)
+	}
+	refundBlockType, refundData := refundOf(transfer, snapshot3.Height)
+	if refundBlockType != ledger.BlockTypeSendCall {
+		t.Fatalf("refund of multisig transfer should be a call, %v", refundBlockType)
+	}
+	refund := func(from types.Address) *ledger.AccountBlock {
+		block := sendCall(from, refundData)
+		block.Amount = amount
+		return block
+	}
+	if _, err := receive(refund(addr4)); err != util.ErrInvalidMethodParam {
+		t.Fatalf("refund from other address should fail, %v", err)
+	}
+	unknownData, _ := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigRefund, types.DataHash([]byte{1}))
+	unknownRefund := sendCall(contractAddr, unknownData)
+	unknownRefund.Amount = amount
+	if _, err := receive(unknownRefund); err != util.ErrInvalidMethodParam {
+		t.Fatalf("refund of unknown transfer should fail, %v", err)
+	}
+	if _, err := receive(refund(contractAddr)); err != nil {
+		t.Fatalf("receive refund error, %v", err)
+	}
+	db.addr = addr2
+	balance, _ = abi.GetMultisigWalletBalance(db, 0, ledger.ViteTokenId)
+	if balance.Cmp(new(big.Int).Sub(deposit, amount)) != 0 {
+		t.Fatalf("refund is not credited to the wallet, balance %v", balance)
+	}
+	if record, _ := abi.GetMultisigTransfer(db, transfer.Hash); record != nil {
+		t.Fatalf("origin of refunded transfer is not removed, %v", record)
+	}
+	if _, err := receive(refund(contractAddr)); err != util.ErrInvalidMethodParam {
+		t.Fatalf("transfer should not be refunded twice, %v", err)
+	}
+}
+
 func TestCheckTokenName(t *testing.T) {
 	tests := []struct {
 		data string
@@ -1559,3 +1755,156 @@ func (ti timeIndex) Time2Index(t time.Time) uint64 {
 	i := uint64(subSec) / uint64(ti.Interval.Seconds())
 	return i
 }
+
+func TestContractsDelegateGroup(t *testing.T) {
+	viteTotalSupply := new(big.Int).Mul(big.NewInt(1e9), util.AttovPerVite)
+	db, addr1, _, hash12, _, timestamp := prepareDb(viteTotalSupply)
+	sbTime := time.Unix(timestamp+1, 0)
+	snapshot3 := &ledger.SnapshotBlock{Height: 1000, Timestamp: &sbTime, Hash: types.DataHash([]byte{10, 3})}
+	db.snapshotBlockList = append(db.snapshotBlockList, snapshot3)
+	addr2 := types.AddressGovernance
+	addr3, _, _ := types.CreateAddress()
+	db.accountBlockMap[addr2] = make(map[types.Hash]*ledger.AccountBlock)
+
+	// schedule out of range is rejected on send
+	invalidData, _ := abi.ABIGovernance.PackMethod(abi.MethodNameCreateDelegateGroup, uint8(1), int64(1), int64(1), uint8(0), uint8(1), uint16(1), uint8(0), uint64(10))
+	invalidBlock := &ledger.AccountBlock{
+		Height:         3,
+		ToAddress:      addr2,
+		AccountAddress: addr1,
+		BlockType:      ledger.BlockTypeSendCall,
+		PrevHash:       hash12,
+		Amount:         new(big.Int).Set(contracts.DelegateGroupStakeAmount),
+		Fee:            big.NewInt(0),
+		Data:           invalidData,
+		TokenId:        ledger.ViteTokenId,
+		Hash:           types.DataHash([]byte{1, 3}),
+	}
+	db.addr = addr1
+	if _, _, err := NewVM(nil).RunV2(db, invalidBlock, nil, nil); err != util.ErrInvalidMethodParam {
+		t.Fatalf("create group with invalid schedule should fail, %v", err)
+	}
+
+	createData, _ := abi.ABIGovernance.PackMethod(abi.MethodNameCreateDelegateGroup, uint8(3), int64(1), int64(3), uint8(1), uint8(5), uint16(1), uint8(1), uint64(10))
+	block13 := &ledger.AccountBlock{
+		Height:         3,
+		ToAddress:      addr2,
+		AccountAddress: addr1,
+		BlockType:      ledger.BlockTypeSendCall,
+		PrevHash:       hash12,
+		Amount:         new(big.Int).Set(contracts.DelegateGroupStakeAmount),
+		Fee:            big.NewInt(0),
+		Data:           createData,
+		TokenId:        ledger.ViteTokenId,
+		Hash:           types.DataHash([]byte{1, 3}),
+	}
+	db.addr = addr1
+	sendCreateBlock, isRetry, err := NewVM(nil).RunV2(db, block13, nil, nil)
+	if sendCreateBlock == nil || isRetry || err != nil ||
+		sendCreateBlock.AccountBlock.Quota < util.QuotaTableByHeight(snapshot3.Height).CreateDelegateGroupQuota {
+		t.Fatalf("send create group transaction error, %v", err)
+	}
+	db.accountBlockMap[addr1][block13.Hash] = sendCreateBlock.AccountBlock
+
+	receiveHeight := uint64(0)
+	receive := func(sendBlock *ledger.AccountBlock, sb *ledger.SnapshotBlock) (*ledger.AccountBlock, error) {
+		receiveHeight++
+		block := &ledger.AccountBlock{
+			Height:         receiveHeight,
+			AccountAddress: addr2,
+			BlockType:      ledger.BlockTypeReceive,
+			FromBlockHash:  sendBlock.Hash,
+			Hash:           types.DataHash([]byte{2, byte(receiveHeight)}),
+		}
+		db.addr = addr2
+		vmBlock, isRetry, err := NewVM(nil).RunV2(db, block, sendBlock, NewTestGlobalStatus(0, sb))
+		if vmBlock == nil || isRetry {
+			t.Fatalf("receive governance transaction error, retry %v, err %v", isRetry, err)
+		}
+		db.accountBlockMap[addr2][block.Hash] = vmBlock.AccountBlock
+		return vmBlock.AccountBlock, err
+	}
+	sendCall := func(from types.Address, amount *big.Int, data []byte) *ledger.AccountBlock {
+		return &ledger.AccountBlock{
+			AccountAddress: from,
+			ToAddress:      addr2,
+			BlockType:      ledger.BlockTypeSendCall,
+			Amount:         amount,
+			Fee:            big.NewInt(0),
+			TokenId:        ledger.ViteTokenId,
+			Data:           data,
+			Hash:           types.DataHash(append(from.Bytes(), data...)),
+		}
+	}
+
+	if _, err := receive(sendCreateBlock.AccountBlock, snapshot3); err != nil {
+		t.Fatalf("receive create group transaction error, %v", err)
+	}
+	gid := types.DataToGid(addr1.Bytes(), block13.Hash.Bytes())
+	db.addr = addr2
+	group, _ := abi.GetConsensusGroup(db, gid)
+	if group == nil || !group.IsActive() || group.NodeCount != 3 || group.Owner != addr1 ||
+		group.StakeAmount.Cmp(contracts.DelegateGroupStakeAmount) != 0 || abi.IsCancelledConsensusGroup(group) {
+		t.Fatalf("group is not created, %v", group)
+	}
+	if list, _ := abi.GetConsensusGroupList(db); len(list) != 3 {
+		t.Fatalf("group is not listed, %v", list)
+	}
+
+	// only the owner updates the register condition
+	updateData, _ := abi.ABIGovernance.PackMethod(abi.MethodNameUpdateDelegateGroup, gid, uint64(20))
+	if _, err := receive(sendCall(addr3, big.NewInt(0), updateData), snapshot3); err == nil {
+		t.Fatalf("update group of other owner should fail")
+	}
+	if _, err := receive(sendCall(addr1, big.NewInt(0), updateData), snapshot3); err != nil {
+		t.Fatalf("receive update group transaction error, %v", err)
+	}
+	db.addr = addr2
+	group, _ = abi.GetConsensusGroup(db, gid)
+	if param, err := abi.GetRegisterStakeParamOfConsensusGroup(group.RegisterConditionParam); err != nil || param.StakeHeight != 20 || group.NodeCount != 3 {
+		t.Fatalf("group is not updated, %v, %v", param, err)
+	}
+
+	// producers register to the group
+	registerData, _ := abi.ABIGovernance.PackMethod(abi.MethodNameRegister, gid, "node1", addr3)
+	if _, err := receive(sendCall(addr1, new(big.Int).Set(contracts.SbpStakeAmountMainnet), registerData), snapshot3); err != nil {
+		t.Fatalf("register to group error, %v", err)
+	}
+	db.addr = addr2
+	if registration, _ := abi.GetRegistration(db, gid, "node1"); registration == nil || registration.ExpirationHeight != snapshot3.Height+20 {
+		t.Fatalf("registration of group %v", registration)
+	}
+
+	// the stake of the group is locked until the expiration height
+	cancelData, _ := abi.ABIGovernance.PackMethod(abi.MethodNameCancelDelegateGroup, gid)
+	if _, err := receive(sendCall(addr1, big.NewInt(0), cancelData), snapshot3); err == nil || group.ExpirationHeight <= snapshot3.Height {
+		t.Fatalf("cancel group before expiration should fail")
+	}
+	snapshot4 := &ledger.SnapshotBlock{Height: group.ExpirationHeight, Timestamp: &sbTime, Hash: types.DataHash([]byte{10, 4})}
+	if _, err := receive(sendCall(addr3, big.NewInt(0), cancelData), snapshot4); err == nil {
+		t.Fatalf("cancel group of other owner should fail")
+	}
+	block, err := receive(sendCall(addr1, big.NewInt(0), cancelData), snapshot4)
+	if err != nil || len(block.SendBlockList) != 1 ||
+		block.SendBlockList[0].ToAddress != addr1 ||
+		block.SendBlockList[0].Amount.Cmp(contracts.DelegateGroupStakeAmount) != 0 {
+		t.Fatalf("receive cancel group transaction error, %v", err)
+	}
+	db.addr = addr2
+	group, _ = abi.GetConsensusGroup(db, gid)
+	if group == nil || !group.IsActive() || !abi.IsCancelledConsensusGroup(group) || group.StakeAmount.Sign() != 0 {
+		t.Fatalf("group is not cancelled, %v", group)
+	}
+
+	// cancelled group can not be updated, cancelled again or registered to
+	if _, err := receive(sendCall(addr1, big.NewInt(0), updateData), snapshot3); err == nil {
+		t.Fatalf("update cancelled group should fail")
+	}
+	if _, err := receive(sendCall(addr1, big.NewInt(0), cancelData), snapshot4); err == nil {
+		t.Fatalf("cancel group twice should fail")
+	}
+	registerData, _ = abi.ABIGovernance.PackMethod(abi.MethodNameRegister, gid, "node2", addr1)
+	if _, err := receive(sendCall(addr1, new(big.Int).Set(contracts.SbpStakeAmountMainnet), registerData), snapshot3); err == nil {
+		t.Fatalf("register to cancelled group should fail")
+	}
+}
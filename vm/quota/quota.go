@@ -292,6 +292,12 @@ func CalcPoWDifficulty(db quotaDb, quotaRequired uint64, q types.Quota, sbHeight
 	return difficultyByQc, err
 }
 
+// MaxPoWDifficulty returns the difficulty for the max quota of a block without congestion
+func MaxPoWDifficulty() *big.Int {
+	index, _ := getIndexByQuota(quotaLimitForBlock)
+	return new(big.Int).Set(quotaConfig.difficultyList[index])
+}
+
 // CalcStakeAmountByQuota calculate stake amount by expected quota used per second
 func CalcStakeAmountByQuota(q uint64) (*big.Int, error) {
 	if q > getMaxQutoa() {
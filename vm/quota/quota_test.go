@@ -17,13 +17,16 @@ import (
 
 func initForkPointsForQuotaTest() {
 	fork.SetForkPoints(&config.ForkPoints{
-		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
-		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
-		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
-		StemFork:      &config.ForkPoint{Height: 300, Version: 4},
-		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
-		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
-		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7}})
+		SeedFork:          &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:           &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:        &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:          &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:          &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:         &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork:     &config.ForkPoint{Height: 600, Version: 7},
+		ExpiryFork:        &config.ForkPoint{Height: 700, Version: 8},
+		MultisigFork:      &config.ForkPoint{Height: 800, Version: 9},
+		DelegateGroupFork: &config.ForkPoint{Height: 900, Version: 10}})
 	fork.SetActiveChecker(mockActiveChecker{})
 }
 
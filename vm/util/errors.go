@@ -25,6 +25,7 @@ var (
 
 	ErrExecutionReverted = errors.New("execution reverted")
 	ErrDepth             = errors.New("max call depth exceeded")
+	ErrUnreceivedExpired = errors.New("unreceived call expired")
 
 	ErrGasUintOverflow           = errors.New("gas uint64 overflow")
 	ErrStorageModifyLimitReached = errors.New("contract storage modify count limit reached")
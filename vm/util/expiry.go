@@ -0,0 +1,38 @@
+package util
+
+import (
+	"github.com/vitelabs/go-vite/common/fork"
+)
+
+// DefaultUnreceivedExpiry is the count of snapshot blocks, about 30 days, after which
+// a call to a user contract expires if it is still not received.
+const DefaultUnreceivedExpiry = uint64(30 * 24 * 3600)
+
+var unreceivedExpiry = DefaultUnreceivedExpiry
+
+// SetUnreceivedExpiry sets the expiry of calls to user contracts, it is a chain parameter
+// from genesis and must be the same on all nodes.
+func SetUnreceivedExpiry(expiry uint64) {
+	if expiry > 0 {
+		unreceivedExpiry = expiry
+	}
+}
+
+// UnreceivedExpiry returns the count of snapshot blocks after which a call to a user contract expires.
+func UnreceivedExpiry() uint64 {
+	return unreceivedExpiry
+}
+
+// IsUnreceivedExpired checks whether a call confirmed at confirmHeight is expired at snapshot block sbHeight.
+func IsUnreceivedExpired(confirmHeight, sbHeight uint64) bool {
+	return sbHeight > confirmHeight && sbHeight-confirmHeight >= unreceivedExpiry && fork.IsExpiryFork(sbHeight)
+}
+
+// UnreceivedExpirationHeight returns the first snapshot block height at which a call confirmed at confirmHeight expires.
+func UnreceivedExpirationHeight(confirmHeight uint64) uint64 {
+	height := confirmHeight + unreceivedExpiry
+	if forkHeight := fork.GetExpiryForkPoint().Height; height < forkHeight {
+		return forkHeight
+	}
+	return height
+}
@@ -238,11 +238,22 @@ type QuotaTable struct {
 	DexFundCancelStakeByIdQuota               uint64
 	DexFundDelegateStakeCallbackV2Quota       uint64
 	DexFundDelegateCancelStakeCallbackV2Quota uint64
+	MultisigCreateWalletQuota                 uint64
+	MultisigDepositQuota                      uint64
+	MultisigProposeQuota                      uint64
+	MultisigApproveQuota                      uint64
+	CreateDelegateGroupQuota                  uint64
+	UpdateDelegateGroupQuota                  uint64
+	CancelDelegateGroupQuota                  uint64
 }
 
 // QuotaTableByHeight returns different quota table by hard fork version
 func QuotaTableByHeight(sbHeight uint64) *QuotaTable {
-	if fork.IsEarthFork(sbHeight) {
+	if fork.IsDelegateGroupFork(sbHeight) {
+		return &delegateGroupQuotaTable
+	} else if fork.IsMultisigFork(sbHeight) {
+		return &multisigQuotaTable
+	} else if fork.IsEarthFork(sbHeight) {
 		return &earthQuotaTable
 	} else if fork.IsStemFork(sbHeight) {
 		return &dexAgentQuotaTable
@@ -350,9 +361,11 @@ var (
 		GetTokenInfoQuota:                63200,
 	}
 
-	viteQuotaTable     = newViteQuotaTable()
-	dexAgentQuotaTable = newDexAgentQuotaTable()
-	earthQuotaTable    = newEarthQuotaTable()
+	viteQuotaTable          = newViteQuotaTable()
+	dexAgentQuotaTable      = newDexAgentQuotaTable()
+	earthQuotaTable         = newEarthQuotaTable()
+	multisigQuotaTable      = newMultisigQuotaTable()
+	delegateGroupQuotaTable = newDelegateGroupQuotaTable()
 )
 
 func newViteQuotaTable() QuotaTable {
@@ -495,3 +508,20 @@ func newEarthQuotaTable() QuotaTable {
 	gt.DexFundDelegateCancelStakeCallbackV2Quota = 33000
 	return gt
 }
+
+func newMultisigQuotaTable() QuotaTable {
+	gt := newEarthQuotaTable()
+	gt.MultisigCreateWalletQuota = 105000
+	gt.MultisigDepositQuota = 31500
+	gt.MultisigProposeQuota = 84000
+	gt.MultisigApproveQuota = 63000
+	return gt
+}
+
+func newDelegateGroupQuotaTable() QuotaTable {
+	gt := newMultisigQuotaTable()
+	gt.CreateDelegateGroupQuota = 168000
+	gt.UpdateDelegateGroupQuota = 84000
+	gt.CancelDelegateGroupQuota = 126000
+	return gt
+}
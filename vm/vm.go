@@ -25,6 +25,7 @@ import (
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/monitor"
 	"github.com/vitelabs/go-vite/vm/contracts"
+	cabi "github.com/vitelabs/go-vite/vm/contracts/abi"
 	"github.com/vitelabs/go-vite/vm/quota"
 	"github.com/vitelabs/go-vite/vm/util"
 )
@@ -261,6 +262,9 @@ func (vm *VM) RunV2(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger
 		if sendBlock.BlockType == ledger.BlockTypeSendCreate {
 			return vm.receiveCreate(db, blockCopy, sendBlock, contractMeta)
 		} else if sendBlock.BlockType == ledger.BlockTypeSendCall {
+			if contractMeta != nil && isUnreceivedExpired(db, blockCopy, sendBlock, sb.Height) {
+				return vm.receiveExpired(db, blockCopy, sendBlock)
+			}
 			return vm.receiveCall(db, blockCopy, sendBlock, contractMeta)
 		} else if sendBlock.BlockType == ledger.BlockTypeSendReward {
 			if !fork.IsSeedFork(sb.Height) {
@@ -639,7 +643,8 @@ func (vm *VM) receiveCall(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *
 			return nil, retry, err
 		}
 		// Contract receive out of quota, current block is first unconfirmed block, refund with no quota
-		refundFlag := doRefund(vm, db, block, sendBlock, []byte{}, false, ledger.BlockTypeSendRefund)
+		refundBlockType, refundData := refundOf(sendBlock, vm.latestSnapshotHeight)
+		refundFlag := doRefund(vm, db, block, sendBlock, refundData, false, refundBlockType)
 		qStakeUsed, qUsed := util.CalcQuotaUsed(true, quotaTotal, quotaAddition, c.quotaLeft, err)
 		vm.updateBlock(db, block, err, qStakeUsed, qUsed)
 		if refundFlag {
@@ -656,7 +661,8 @@ func (vm *VM) receiveCall(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *
 		return &vm_db.VmAccountBlock{block, db}, noRetry, err
 	}
 
-	refundFlag := doRefund(vm, db, block, sendBlock, []byte{}, false, ledger.BlockTypeSendRefund)
+	refundBlockType, refundData := refundOf(sendBlock, vm.latestSnapshotHeight)
+	refundFlag := doRefund(vm, db, block, sendBlock, refundData, false, refundBlockType)
 	qStakeUsed, qUsed := util.CalcQuotaUsed(true, quotaTotal, quotaAddition, c.quotaLeft, err)
 	vm.updateBlock(db, block, err, qStakeUsed, qUsed)
 	if refundFlag {
@@ -673,6 +679,48 @@ func (vm *VM) receiveCall(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *
 	return &vm_db.VmAccountBlock{block, db}, noRetry, err
 }
 
+// isUnreceivedExpired checks whether a call to a user contract is not received in time.
+func isUnreceivedExpired(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, sbHeight uint64) bool {
+	if types.IsBuiltinContractAddr(block.AccountAddress) || !fork.IsExpiryFork(sbHeight) {
+		return false
+	}
+	confirmSb, err := db.GetConfirmSnapshotHeader(sendBlock.Hash)
+	util.DealWithErr(err)
+	return confirmSb != nil && util.IsUnreceivedExpired(confirmSb.Height, sbHeight)
+}
+
+// receiveExpired receives an expired call as failed without running code and consuming quota,
+// amount and fee are refunded to the caller.
+func (vm *VM) receiveExpired(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock) (*vm_db.VmAccountBlock, bool, error) {
+	defer monitor.LogTimerConsuming([]string{"vm", "receiveExpired"}, time.Now())
+	err := util.ErrUnreceivedExpired
+	refundBlockType, refundData := refundOf(sendBlock, vm.latestSnapshotHeight)
+	refundFlag := doRefund(vm, db, block, sendBlock, refundData, false, refundBlockType)
+	vm.updateBlock(db, block, err, 0, 0)
+	if refundFlag {
+		var refundErr error
+		if db, refundErr = vm.doSendBlockList(db); refundErr != nil {
+			monitor.LogEvent("vm", "impossibleReceiveError")
+			nodeConfig.log.Error("Impossible receive error", "err", refundErr, "fromhash", sendBlock.Hash)
+			return nil, retry, err
+		}
+		block.Data = getReceiveCallData(db, err)
+		return mergeReceiveBlock(db, block, vm.sendBlockList), noRetry, err
+	}
+	block.Data = getReceiveCallData(db, err)
+	return &vm_db.VmAccountBlock{AccountBlock: block, VmDb: db}, noRetry, err
+}
+
+// refundOf returns the block type and data of the refund of a failed call to a user contract.
+// The multisig contract can not receive a plain refund, a transfer from it is refunded by method Refund with the send block hash.
+func refundOf(sendBlock *ledger.AccountBlock, sbHeight uint64) (byte, []byte) {
+	if sendBlock.AccountAddress == types.AddressMultisig && fork.IsMultisigFork(sbHeight) {
+		data, _ := cabi.ABIMultisig.PackMethod(cabi.MethodNameMultisigRefund, sendBlock.Hash)
+		return ledger.BlockTypeSendCall, data
+	}
+	return ledger.BlockTypeSendRefund, []byte{}
+}
+
 func doRefund(vm *VM, db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, refundData []byte, needRefund bool, refundBlockType byte) bool {
 	refundFlag := false
 	if sendBlock.Amount.Sign() > 0 && sendBlock.Fee.Sign() > 0 && sendBlock.TokenId == ledger.ViteTokenId {
@@ -840,7 +888,7 @@ func (vm *VM) updateBlock(db vm_db.VmDb, block *ledger.AccountBlock, err error,
 	block.QuotaUsed = qUsed
 	if block.IsReceiveBlock() {
 		block.LogHash = db.GetLogListHash()
-		if err == util.ErrOutOfQuota {
+		if err == util.ErrOutOfQuota || err == util.ErrUnreceivedExpired {
 			block.BlockType = ledger.BlockTypeReceiveError
 		} else {
 			block.BlockType = ledger.BlockTypeReceive
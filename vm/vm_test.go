@@ -27,13 +27,16 @@ func init() {
 
 func initFork() {
 	fork.SetForkPoints(&config.ForkPoints{
-		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
-		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
-		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
-		StemFork:      &config.ForkPoint{Height: 300, Version: 4},
-		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
-		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
-		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7}})
+		SeedFork:          &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:           &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:        &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:          &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:          &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:         &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork:     &config.ForkPoint{Height: 600, Version: 7},
+		ExpiryFork:        &config.ForkPoint{Height: 700, Version: 8},
+		MultisigFork:      &config.ForkPoint{Height: 800, Version: 9},
+		DelegateGroupFork: &config.ForkPoint{Height: 900, Version: 10}})
 	fork.SetActiveChecker(mockActiveChecker{})
 }
 
@@ -429,6 +432,80 @@ func TestCall(t *testing.T) {
 	db.accountBlockMap[addr3][hash31] = receiveCallBlock2.AccountBlock
 }
 
+// expiryDatabase confirms all send blocks in snapshot block confirmSb
+type expiryDatabase struct {
+	*testDatabase
+	confirmSb *ledger.SnapshotBlock
+}
+
+func (db *expiryDatabase) GetConfirmSnapshotHeader(blockHash types.Hash) (*ledger.SnapshotBlock, error) {
+	return db.confirmSb, nil
+}
+
+func TestReceiveExpired(t *testing.T) {
+	viteTotalSupply := new(big.Int).Mul(big.NewInt(1e9), util.AttovPerVite)
+	tdb, addr1, _, hash12, _, _ := prepareDb(viteTotalSupply)
+
+	// contract2 runs out of gas whatever it receives
+	addr2 := types.Address{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	tdb.codeMap[addr2] = []byte{1, byte(JUMPDEST), byte(PUSH1), 1, byte(JUMP)}
+	tdb.contractMetaMap[addr2] = &ledger.ContractMeta{Gid: types.DELEGATE_GID, SendConfirmedTimes: 0, QuotaRatio: 10}
+	tdb.accountBlockMap[addr2] = make(map[types.Hash]*ledger.AccountBlock)
+
+	util.SetUnreceivedExpiry(100)
+	defer util.SetUnreceivedExpiry(util.DefaultUnreceivedExpiry)
+	sbTime := time.Unix(1536214602, 0)
+	tdb.snapshotBlockList = append(tdb.snapshotBlockList, &ledger.SnapshotBlock{Height: 800, Timestamp: &sbTime, Hash: types.DataHash([]byte{10, 3})})
+	db := &expiryDatabase{tdb, &ledger.SnapshotBlock{Height: 700}}
+	db.addr = addr2
+
+	sendCallBlock := &ledger.AccountBlock{
+		Height:         3,
+		AccountAddress: addr1,
+		BlockType:      ledger.BlockTypeSendCall,
+		PrevHash:       hash12,
+		Amount:         big.NewInt(10),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+		Hash:           types.DataHash([]byte{1, 3}),
+		ToAddress:      addr2,
+	}
+	receiveBlock := &ledger.AccountBlock{
+		Height:         1,
+		AccountAddress: addr2,
+		FromBlockHash:  sendCallBlock.Hash,
+		BlockType:      ledger.BlockTypeReceive,
+		Hash:           types.DataHash([]byte{2, 1}),
+	}
+	vmBlock, isRetry, err := NewVM(nil).RunV2(db, receiveBlock, sendCallBlock, nil)
+	if vmBlock == nil || isRetry || err != util.ErrUnreceivedExpired {
+		t.Fatalf("expired call should be received as failed, retry %v, err %v", isRetry, err)
+	}
+	block := vmBlock.AccountBlock
+	if block.BlockType != ledger.BlockTypeReceiveError ||
+		block.Quota != 0 || block.QuotaUsed != 0 ||
+		len(block.Data) != 33 || block.Data[32] != resultFail ||
+		len(block.SendBlockList) != 1 ||
+		block.SendBlockList[0].BlockType != ledger.BlockTypeSendRefund ||
+		block.SendBlockList[0].ToAddress != addr1 ||
+		block.SendBlockList[0].Amount.Cmp(sendCallBlock.Amount) != 0 ||
+		tdb.balanceMap[addr2][ledger.ViteTokenId].Sign() != 0 {
+		t.Fatalf("expired call should be refunded")
+	}
+
+	// not expired before the expiry passes
+	db.confirmSb = &ledger.SnapshotBlock{Height: 701}
+	if isUnreceivedExpired(db, receiveBlock, sendCallBlock, 800) {
+		t.Fatalf("call should not be expired")
+	}
+	if isUnreceivedExpired(db, &ledger.AccountBlock{AccountAddress: types.AddressQuota}, sendCallBlock, 1000) {
+		t.Fatalf("call to built-in contract should not be expired")
+	}
+	if h := util.UnreceivedExpirationHeight(500); h != 700 {
+		t.Fatalf("expiration height is %d before expiry fork", h)
+	}
+}
+
 func BenchmarkVMTransfer(b *testing.B) {
 	viteTotalSupply := new(big.Int).Mul(big.NewInt(1e9), util.AttovPerVite)
 	db, addr1, _, hash12, _, _ := prepareDb(viteTotalSupply)
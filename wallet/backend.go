@@ -0,0 +1,134 @@
+package wallet
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/walleterrors"
+)
+
+var errBackendExists = errors.New("backend already exists")
+
+// Backend holds keys and signs data by them, the keys may be in the entropy stores or outside the node
+type Backend interface {
+	// URL identifies the backend, e.g. "keystore:///path/to/wallet", "unix:///path/to/signer.sock"
+	URL() string
+	// Addresses returns the addresses which can be signed now
+	Addresses() ([]types.Address, error)
+	SignData(addr types.Address, data []byte) (signature, pubkey []byte, err error)
+	Close() error
+}
+
+// KeystoreBackend signs by the unlocked entropy stores of the manager
+type KeystoreBackend struct {
+	m *Manager
+}
+
+func (b *KeystoreBackend) URL() string {
+	return "keystore://" + b.m.config.DataDir
+}
+
+func (b *KeystoreBackend) Addresses() ([]types.Address, error) {
+	var addrs []types.Address
+	for _, file := range b.m.ListAllEntropyFiles() {
+		manager, err := b.m.GetEntropyStoreManager(file)
+		if err != nil || !manager.IsUnlocked() {
+			continue
+		}
+
+		list, err := manager.ListAddress(0, entropystore.DefaultMaxIndex)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, list...)
+	}
+	return addrs, nil
+}
+
+func (b *KeystoreBackend) SignData(addr types.Address, data []byte) (signature, pubkey []byte, err error) {
+	_, key, _, err := b.m.GlobalFindAddr(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key.SignData(data)
+}
+
+func (b *KeystoreBackend) Close() error {
+	return nil
+}
+
+// AddBackend adds a backend after the entropy stores, the addresses are looked up in the order of backends.
+func (m *Manager) AddBackend(b Backend) error {
+	m.backendMu.Lock()
+	defer m.backendMu.Unlock()
+	for _, v := range m.backends {
+		if v.URL() == b.URL() {
+			return errBackendExists
+		}
+	}
+	m.backends = append(m.backends, b)
+	m.log.Info("add wallet backend", "url", b.URL())
+	return nil
+}
+
+// RemoveBackend closes and removes the backend of url, the entropy stores can not be removed.
+func (m *Manager) RemoveBackend(url string) error {
+	m.backendMu.Lock()
+	defer m.backendMu.Unlock()
+	for i, v := range m.backends {
+		if i > 0 && v.URL() == url {
+			m.backends = append(m.backends[:i:i], m.backends[i+1:]...)
+			return v.Close()
+		}
+	}
+	return walleterrors.ErrStoreNotFound
+}
+
+// Backends returns all backends, the first one is the entropy stores.
+func (m *Manager) Backends() []Backend {
+	m.backendMu.Lock()
+	defer m.backendMu.Unlock()
+	return append([]Backend(nil), m.backends...)
+}
+
+// Addresses returns the addresses can be signed by all backends.
+func (m *Manager) Addresses() ([]types.Address, error) {
+	var addrs []types.Address
+	for _, b := range m.Backends() {
+		list, err := b.Addresses()
+		if err != nil {
+			m.log.Warn("list addresses of wallet backend failed", "url", b.URL(), "err", err)
+			continue
+		}
+		addrs = append(addrs, list...)
+	}
+	return addrs, nil
+}
+
+// FindBackend returns the first backend which can sign addr.
+func (m *Manager) FindBackend(addr types.Address) (Backend, error) {
+	for _, b := range m.Backends() {
+		list, err := b.Addresses()
+		if err != nil {
+			continue
+		}
+		for _, a := range list {
+			if a == addr {
+				return b, nil
+			}
+		}
+	}
+	return nil, walleterrors.ErrAddressNotFound
+}
+
+// SignData signs data by the key of addr, in the entropy stores or in other backends.
+func (m *Manager) SignData(addr types.Address, data []byte) (signature, pubkey []byte, err error) {
+	if _, key, _, err := m.GlobalFindAddr(addr); err == nil {
+		return key.SignData(data)
+	}
+	b, err := m.FindBackend(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.SignData(addr, data)
+}
@@ -2,14 +2,18 @@ package entropystore
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/tyler-smith/go-bip39"
 	"github.com/vitelabs/go-vite/common/types"
 	vcrypto "github.com/vitelabs/go-vite/crypto"
 	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
 	"github.com/vitelabs/go-vite/wallet/walleterrors"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/scrypt"
 	"io/ioutil"
 	"os"
@@ -29,10 +33,69 @@ const (
 	scryptR      = 8
 	scryptKeyLen = 32
 
-	aesMode    = "aes-256-gcm"
-	scryptName = "scrypt"
+	aesMode      = "aes-256-gcm"
+	scryptName   = "scrypt"
+	argon2idName = "argon2id"
+
+	// keyLenV2 is the length of the derived key in version 2, the first half is the aes key
+	// and the second half is the mac key
+	keyLenV2 = 64
+
+	// the upper bounds of kdf params, so that a file can not make us allocate too much memory or spin forever.
+	// scrypt uses 128*N*R bytes memory.
+	maxKDFMemoryKiB = 4 * 1024 * 1024
+	maxScryptN      = 1 << 22
+	maxScryptR      = 32
+	maxScryptP      = 16
+	maxArgon2Time   = 16
 )
 
+// KDFParams selects the key derivation function of the entropy store and its cost.
+// N, R and P are used by scrypt, Time, Memory in KiB and Threads are used by argon2id.
+type KDFParams struct {
+	KDF string `json:"kdf"`
+
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+var (
+	DefaultKDFParams = KDFParams{KDF: scryptName, N: StandardScryptN, R: scryptR, P: StandardScryptP}
+
+	// DefaultArgon2idParams uses 64MB memory, as recommended by RFC 9106 for memory constrained environments
+	DefaultArgon2idParams = KDFParams{KDF: argon2idName, Time: 3, Memory: 64 * 1024, Threads: 4}
+)
+
+func (p KDFParams) check() error {
+	switch p.KDF {
+	case scryptName:
+		if p.N <= 1 || p.N&(p.N-1) != 0 || p.R <= 0 || p.P <= 0 ||
+			p.N > maxScryptN || p.R > maxScryptR || p.P > maxScryptP || int64(p.N)*int64(p.R)/8 > maxKDFMemoryKiB {
+			return fmt.Errorf("scrypt params error : n %v r %v p %v", p.N, p.R, p.P)
+		}
+	case argon2idName:
+		if p.Time == 0 || p.Threads == 0 || p.Memory < 8*uint32(p.Threads) ||
+			p.Time > maxArgon2Time || p.Memory > maxKDFMemoryKiB {
+			return fmt.Errorf("argon2id params error : time %v memory %v threads %v", p.Time, p.Memory, p.Threads)
+		}
+	default:
+		return fmt.Errorf("kdf error : %v", p.KDF)
+	}
+	return nil
+}
+
+func (p KDFParams) deriveKey(passphrase, salt []byte, keyLen int) ([]byte, error) {
+	if p.KDF == argon2idName {
+		return argon2.IDKey(passphrase, salt, p.Time, p.Memory, p.Threads, uint32(keyLen)), nil
+	}
+	return scrypt.Key(passphrase, salt, p.N, p.R, p.P, keyLen)
+}
+
 type CryptoStore struct {
 	EntropyStoreFilename string
 }
@@ -78,13 +141,48 @@ func (ks CryptoStore) StoreEntropy(entropy []byte, primaryAddr types.Address, pa
 	return nil
 }
 
+// ChangePassphrase re-encrypts the entropy by newPassphrase, the version and the kdf of the file are kept
+// if params is nil. Otherwise it is written in version 2 with params, which is used to upgrade a version 1 file
+// with the same passphrase.
+// The file is replaced atomically, so it is either the old one or the new one if interrupted.
+func (ks CryptoStore) ChangePassphrase(passphrase, newPassphrase string, params *KDFParams) error {
+	keyjson, err := ioutil.ReadFile(ks.EntropyStoreFilename)
+	if err != nil {
+		return err
+	}
+	entropy, err := DecryptEntropy(keyjson, passphrase)
+	if err != nil {
+		return err
+	}
+	k, addr, _, _, _, err := parseJson(keyjson)
+	if err != nil {
+		return err
+	}
+	if params == nil {
+		if params, err = kdfParamsOf(k); err != nil {
+			return err
+		}
+		if k.Version == cryptoStoreVersion {
+			keyjson, err = encryptEntropyV1(entropy, *addr, newPassphrase, *params)
+		} else {
+			keyjson, err = EncryptEntropyWithKDF(entropy, *addr, newPassphrase, *params)
+		}
+	} else {
+		keyjson, err = EncryptEntropyWithKDF(entropy, *addr, newPassphrase, *params)
+	}
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(ks.EntropyStoreFilename, keyjson)
+}
+
 func parseJson(keyjson []byte) (k *entropyJSON, kAddress *types.Address, cipherData, nonce, salt []byte, err error) {
 	k = new(entropyJSON)
 	// parse and check entropyJSON params
 	if err := json.Unmarshal(keyjson, k); err != nil {
 		return nil, nil, nil, nil, nil, err
 	}
-	if k.Version != cryptoStoreVersion {
+	if k.Version != cryptoStoreVersion && k.Version != cryptoStoreVersion2 {
 		return nil, nil, nil, nil, nil, fmt.Errorf("version number error : %v", k.Version)
 	}
 
@@ -100,8 +198,12 @@ func parseJson(keyjson []byte) (k *entropyJSON, kAddress *types.Address, cipherD
 	if k.Crypto.CipherName != aesMode {
 		return nil, nil, nil, nil, nil, fmt.Errorf("cipherName  error : %v", k.Crypto.CipherName)
 	}
-	if k.Crypto.KDF != scryptName {
-		return nil, nil, nil, nil, nil, fmt.Errorf("scryptName  error : %v", k.Crypto.KDF)
+	params, err := kdfParamsOf(k)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	if err := params.check(); err != nil {
+		return nil, nil, nil, nil, nil, err
 	}
 	cipherData, err = hex.DecodeString(k.Crypto.CipherText)
 	if err != nil {
@@ -112,9 +214,12 @@ func parseJson(keyjson []byte) (k *entropyJSON, kAddress *types.Address, cipherD
 		return nil, nil, nil, nil, nil, err
 	}
 
-	// parse and check  scryptParams params
-	scryptParams := k.Crypto.ScryptParams
-	salt, err = hex.DecodeString(scryptParams.Salt)
+	// parse and check  kdf params
+	if k.Version == cryptoStoreVersion {
+		salt, err = hex.DecodeString(k.Crypto.ScryptParams.Salt)
+	} else {
+		salt, err = hex.DecodeString(k.Crypto.KDFParams.Salt)
+	}
 	if err != nil {
 		return nil, nil, nil, nil, nil, err
 	}
@@ -122,17 +227,93 @@ func parseJson(keyjson []byte) (k *entropyJSON, kAddress *types.Address, cipherD
 	return k, &addr, cipherData, nonce, salt, nil
 }
 
+// kdfParamsOf returns the kdf of the parsed file, version 1 is always scrypt
+func kdfParamsOf(k *entropyJSON) (*KDFParams, error) {
+	if k.Version == cryptoStoreVersion {
+		if k.Crypto.KDF != scryptName {
+			return nil, fmt.Errorf("scryptName  error : %v", k.Crypto.KDF)
+		}
+		if k.Crypto.ScryptParams == nil {
+			return nil, errors.New("scryptparams missing")
+		}
+		if k.Crypto.ScryptParams.KeyLen != scryptKeyLen {
+			return nil, fmt.Errorf("keylen error : %v", k.Crypto.ScryptParams.KeyLen)
+		}
+		return &KDFParams{
+			KDF: scryptName,
+			N:   k.Crypto.ScryptParams.N,
+			R:   k.Crypto.ScryptParams.R,
+			P:   k.Crypto.ScryptParams.P,
+		}, nil
+	}
+
+	p := k.Crypto.KDFParams
+	if p == nil {
+		return nil, errors.New("kdfparams missing")
+	}
+	if p.KeyLen != keyLenV2 {
+		return nil, fmt.Errorf("keylen error : %v", p.KeyLen)
+	}
+	return &KDFParams{
+		KDF:     k.Crypto.KDF,
+		N:       p.N,
+		R:       p.R,
+		P:       p.P,
+		Time:    p.Time,
+		Memory:  p.Memory,
+		Threads: p.Threads,
+	}, nil
+}
+
+// macOf authenticates all the fields of the file except the mac itself
+func macOf(k *entropyJSON, macKey []byte) ([]byte, error) {
+	metadata := *k
+	metadata.Crypto.MAC = ""
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, macKey)
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
 func DecryptEntropy(entropyJson []byte, passphrase string) ([]byte, error) {
 	k, kAddress, cipherData, nonce, salt, err := parseJson(entropyJson)
 	if err != nil {
 		return nil, err
 	}
-	scryptParams := k.Crypto.ScryptParams
 
 	// begin decrypt
-	derivedKey, err := scrypt.Key([]byte(passphrase), salt, scryptParams.N, scryptParams.R, scryptParams.P, scryptParams.KeyLen)
-	if err != nil {
-		return nil, err
+	var derivedKey []byte
+	if k.Version == cryptoStoreVersion {
+		scryptParams := k.Crypto.ScryptParams
+		derivedKey, err = scrypt.Key([]byte(passphrase), salt, scryptParams.N, scryptParams.R, scryptParams.P, scryptParams.KeyLen)
+		if err != nil {
+			return nil, err
+		}
+		if len(derivedKey) < 32 {
+			return nil, fmt.Errorf("keylen error : %v", scryptParams.KeyLen)
+		}
+	} else {
+		params, _ := kdfParamsOf(k)
+		derivedKey, err = params.deriveKey([]byte(passphrase), salt, keyLenV2)
+		if err != nil {
+			return nil, err
+		}
+
+		mac, err := hex.DecodeString(k.Crypto.MAC)
+		if err != nil {
+			return nil, err
+		}
+		expected, err := macOf(k, derivedKey[32:])
+		if err != nil {
+			return nil, err
+		}
+		// a wrong passphrase derives another mac key, so it can not be told from modified metadata
+		if !hmac.Equal(mac, expected) {
+			return nil, walleterrors.ErrDecryptEntropy
+		}
 	}
 
 	entropy, err := vcrypto.AesGCMDecrypt(derivedKey[:32], cipherData, []byte(nonce))
@@ -159,12 +340,63 @@ func DecryptEntropy(entropyJson []byte, passphrase string) ([]byte, error) {
 	return entropy, nil
 }
 
+// EncryptEntropy encrypts the entropy in version 1 with DefaultKDFParams, so that the file can be read by old versions.
+// Use EncryptEntropyWithKDF for version 2.
 func EncryptEntropy(seed []byte, addr types.Address, passphrase string) ([]byte, error) {
-	n := StandardScryptN
-	p := StandardScryptP
-	pwdArray := []byte(passphrase)
+	return encryptEntropyV1(seed, addr, passphrase, DefaultKDFParams)
+}
+
+// encryptEntropyV1 encrypts the entropy in version 1, params must be scrypt
+func encryptEntropyV1(seed []byte, addr types.Address, passphrase string, params KDFParams) ([]byte, error) {
+	if params.KDF != scryptName {
+		return nil, fmt.Errorf("kdf error : %v", params.KDF)
+	}
+	if err := params.check(); err != nil {
+		return nil, err
+	}
+	salt := vcrypto.GetEntropyCSPRNG(32)
+	derivedKey, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	encryptKey := derivedKey[:32]
+
+	ciphertext, nonce, err := vcrypto.AesGCMEncrypt(encryptKey, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	cryptoJSON := cryptoJSON{
+		CipherName: aesMode,
+		CipherText: hex.EncodeToString(ciphertext),
+		Nonce:      hex.EncodeToString(nonce),
+		KDF:        scryptName,
+		ScryptParams: &scryptParams{
+			N:      params.N,
+			R:      params.R,
+			P:      params.P,
+			KeyLen: scryptKeyLen,
+			Salt:   hex.EncodeToString(salt),
+		},
+	}
+
+	encryptedKeyJSON := entropyJSON{
+		PrimaryAddress: addr.String(),
+		Crypto:         cryptoJSON,
+		Version:        cryptoStoreVersion,
+		Timestamp:      time.Now().UTC().Unix(),
+	}
+
+	return json.Marshal(encryptedKeyJSON)
+}
+
+// EncryptEntropyWithKDF encrypts the entropy in version 2, the key of aes is derived by params from passphrase
+func EncryptEntropyWithKDF(seed []byte, addr types.Address, passphrase string, params KDFParams) ([]byte, error) {
+	if err := params.check(); err != nil {
+		return nil, err
+	}
 	salt := vcrypto.GetEntropyCSPRNG(32)
-	derivedKey, err := scrypt.Key(pwdArray, salt, n, scryptR, p, scryptKeyLen)
+	derivedKey, err := params.deriveKey([]byte(passphrase), salt, keyLenV2)
 	if err != nil {
 		return nil, err
 	}
@@ -175,30 +407,38 @@ func EncryptEntropy(seed []byte, addr types.Address, passphrase string) ([]byte,
 		return nil, err
 	}
 
-	ScryptParams := scryptParams{
-		N:      n,
-		R:      scryptR,
-		P:      p,
-		KeyLen: scryptKeyLen,
-		Salt:   hex.EncodeToString(salt),
+	jsonParams := kdfParams{
+		N:       params.N,
+		R:       params.R,
+		P:       params.P,
+		Time:    params.Time,
+		Memory:  params.Memory,
+		Threads: params.Threads,
+		KeyLen:  keyLenV2,
+		Salt:    hex.EncodeToString(salt),
 	}
 
 	cryptoJSON := cryptoJSON{
-		CipherName:   aesMode,
-		CipherText:   hex.EncodeToString(ciphertext),
-		Nonce:        hex.EncodeToString(nonce),
-		KDF:          scryptName,
-		ScryptParams: ScryptParams,
+		CipherName: aesMode,
+		CipherText: hex.EncodeToString(ciphertext),
+		Nonce:      hex.EncodeToString(nonce),
+		KDF:        params.KDF,
+		KDFParams:  &jsonParams,
 	}
 
 	encryptedKeyJSON := entropyJSON{
-
 		PrimaryAddress: addr.String(),
 		Crypto:         cryptoJSON,
-		Version:        cryptoStoreVersion,
+		Version:        cryptoStoreVersion2,
 		Timestamp:      time.Now().UTC().Unix(),
 	}
 
+	mac, err := macOf(&encryptedKeyJSON, derivedKey[32:])
+	if err != nil {
+		return nil, err
+	}
+	encryptedKeyJSON.Crypto.MAC = hex.EncodeToString(mac)
+
 	return json.Marshal(encryptedKeyJSON)
 }
 
@@ -218,6 +458,11 @@ func writeKeyFile(file string, content []byte) error {
 		os.Remove(f.Name())
 		return err
 	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
 	f.Close()
 	return os.Rename(f.Name(), file)
 }
@@ -3,16 +3,25 @@ package entropystore_test
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/tyler-smith/go-bip39"
 	"github.com/vitelabs/go-vite/common"
 	"github.com/vitelabs/go-vite/wallet/entropystore"
 	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
 	"github.com/vitelabs/go-vite/wallet/walleterrors"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
+// testEntropyStoreV1 is TestEntropy encrypted by 123456 in version 1
+const testEntropyStoreV1 = `{"primaryAddress":"vite_80d446de0b3267b03cba7d9b49afa5e71341c7cd0693651ad1","crypto":{"ciphername":"aes-256-gcm","ciphertext":"50e013da3f86cb7f422fd647517b5ba61061a45142e8c10e74f58909ee0877f99a863b7183a99a855085f4358b332dac","nonce":"3b5ec869f0f47570fce2fc8b","kdf":"scrypt","scryptparams":{"n":262144,"r":8,"p":1,"keylen":32,"salt":"2b81334d4fb076206279b704928e3bbc70243fdd70c63b99905fa109fb0b6c42"}},"seedstoreversion":1,"timestamp":1792373765}`
+
+var testArgon2idParams = entropystore.KDFParams{KDF: "argon2id", Time: 1, Memory: 1024, Threads: 1}
+
 func TestCryptoStore_StoreEntropy(t *testing.T) {
 	entropy, _ := bip39.NewEntropy(256)
 	m, _ := bip39.NewMnemonic(entropy)
@@ -79,6 +88,9 @@ func TestDecryptEntropy(t *testing.T) {
 		t.Fatal(e)
 	}
 	fmt.Println(string(json))
+	if !strings.Contains(string(json), `"seedstoreversion":1`) {
+		t.Fatal("should be version 1 by default")
+	}
 
 	{
 		fmt.Println("success case:")
@@ -102,3 +114,121 @@ func TestDecryptEntropy(t *testing.T) {
 	}
 
 }
+
+func TestDecryptEntropy_V1(t *testing.T) {
+	entropy, e := entropystore.DecryptEntropy([]byte(testEntropyStoreV1), "123456")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if hex.EncodeToString(entropy) != TestEntropy {
+		t.Fatal("not equal")
+	}
+	if _, e = entropystore.DecryptEntropy([]byte(testEntropyStoreV1), "1234567"); e != walleterrors.ErrDecryptEntropy {
+		t.Fatal(e)
+	}
+}
+
+func TestEncryptEntropyWithKDF(t *testing.T) {
+	entropy, _ := hex.DecodeString(TestEntropy)
+	mnemonic, _ := bip39.NewMnemonic(entropy)
+	addr, _ := derivation.GetPrimaryAddress(bip39.NewSeed(mnemonic, ""))
+
+	keyjson, e := entropystore.EncryptEntropyWithKDF(entropy, *addr, "123456", testArgon2idParams)
+	if e != nil {
+		t.Fatal(e)
+	}
+	decrypted, e := entropystore.DecryptEntropy(keyjson, "123456")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Equal(entropy, decrypted) {
+		t.Fatal("not equal")
+	}
+	if _, e = entropystore.DecryptEntropy(keyjson, "1234567"); e != walleterrors.ErrDecryptEntropy {
+		t.Fatal(e)
+	}
+
+	// the metadata is covered by the mac
+	var m map[string]interface{}
+	if e = json.Unmarshal(keyjson, &m); e != nil {
+		t.Fatal(e)
+	}
+	m["timestamp"] = 1
+	tampered, _ := json.Marshal(m)
+	if _, e = entropystore.DecryptEntropy(tampered, "123456"); e != walleterrors.ErrDecryptEntropy {
+		t.Fatal(e)
+	}
+
+	for _, params := range []entropystore.KDFParams{
+		{KDF: "argon2id"},
+		{KDF: "argon2id", Time: 17, Memory: 1024, Threads: 1},
+		{KDF: "argon2id", Time: 1, Memory: 4*1024*1024 + 1, Threads: 1},
+		{KDF: "scrypt", N: 1 << 23, R: 1, P: 1},
+		{KDF: "scrypt", N: 1 << 22, R: 16, P: 1},
+		{KDF: "scrypt", N: 1 << 10, R: 8, P: 17},
+	} {
+		if _, e = entropystore.EncryptEntropyWithKDF(entropy, *addr, "123456", params); e == nil {
+			t.Fatalf("invalid params %v", params)
+		}
+	}
+}
+
+func TestCryptoStore_ChangePassphrase(t *testing.T) {
+	dir, e := ioutil.TempDir("", "entropystore")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "vite_80d446de0b3267b03cba7d9b49afa5e71341c7cd0693651ad1")
+	if e = ioutil.WriteFile(filename, []byte(testEntropyStoreV1), 0600); e != nil {
+		t.Fatal(e)
+	}
+	store := entropystore.CryptoStore{EntropyStoreFilename: filename}
+
+	if e = store.ChangePassphrase("1234567", "abcdef", nil); e != walleterrors.ErrDecryptEntropy {
+		t.Fatal(e)
+	}
+	// version 1 is kept
+	if e = store.ChangePassphrase("123456", "abc", nil); e != nil {
+		t.Fatal(e)
+	}
+	keyjson, _ := ioutil.ReadFile(filename)
+	if !strings.Contains(string(keyjson), `"seedstoreversion":1`) {
+		t.Fatal(string(keyjson))
+	}
+	if e = store.ChangePassphrase("abc", "123456", nil); e != nil {
+		t.Fatal(e)
+	}
+	// upgrade version 1 and the kdf with the same passphrase
+	if e = store.ChangePassphrase("123456", "123456", &testArgon2idParams); e != nil {
+		t.Fatal(e)
+	}
+	keyjson, _ = ioutil.ReadFile(filename)
+	if !strings.Contains(string(keyjson), `"seedstoreversion":2`) || !strings.Contains(string(keyjson), `"kdf":"argon2id"`) {
+		t.Fatal(string(keyjson))
+	}
+
+	// the kdf is kept
+	if e = store.ChangePassphrase("123456", "abcdef", nil); e != nil {
+		t.Fatal(e)
+	}
+	keyjson, _ = ioutil.ReadFile(filename)
+	if !strings.Contains(string(keyjson), `"kdf":"argon2id"`) {
+		t.Fatal(string(keyjson))
+	}
+	if _, e = store.ExtractEntropy("123456"); e != walleterrors.ErrDecryptEntropy {
+		t.Fatal(e)
+	}
+	entropy, e := store.ExtractEntropy("abcdef")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if hex.EncodeToString(entropy) != TestEntropy {
+		t.Fatal("not equal")
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("temp files are left: %d", len(files))
+	}
+}
@@ -223,6 +223,12 @@ func (km Manager) ExtractMnemonic(passphrase string) (string, error) {
 	return bip39.NewMnemonic(entropy)
 }
 
+// ChangePassphrase re-encrypts the entropy store file by newPassphrase, params nil keeps the kdf of the file.
+// The unlocked state is not changed since the entropy is the same.
+func (km *Manager) ChangePassphrase(passphrase, newPassphrase string, params *KDFParams) error {
+	return km.ks.ChangePassphrase(passphrase, newPassphrase, params)
+}
+
 func StoreNewEntropy(storeDir string, mnemonic string, pwd string, maxSearchIndex uint32) (*Manager, error) {
 	entropy, e := bip39.EntropyFromMnemonic(mnemonic)
 	if e != nil {
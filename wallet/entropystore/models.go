@@ -2,6 +2,9 @@ package entropystore
 
 const (
 	cryptoStoreVersion = 1
+
+	// cryptoStoreVersion2 selects the kdf by kdfparams and authenticates the metadata by mac
+	cryptoStoreVersion2 = 2
 )
 
 type entropyJSON struct {
@@ -12,11 +15,17 @@ type entropyJSON struct {
 }
 
 type cryptoJSON struct {
-	CipherName   string       `json:"ciphername"`
-	CipherText   string       `json:"ciphertext"`
-	Nonce        string       `json:"nonce"`
-	KDF          string       `json:"kdf"`
-	ScryptParams scryptParams `json:"scryptparams"`
+	CipherName string `json:"ciphername"`
+	CipherText string `json:"ciphertext"`
+	Nonce      string `json:"nonce"`
+	KDF        string `json:"kdf"`
+
+	// version 1
+	ScryptParams *scryptParams `json:"scryptparams,omitempty"`
+
+	// version 2
+	KDFParams *kdfParams `json:"kdfparams,omitempty"`
+	MAC       string     `json:"mac,omitempty"`
 }
 
 type scryptParams struct {
@@ -26,3 +35,18 @@ type scryptParams struct {
 	KeyLen int    `json:"keylen"`
 	Salt   string `json:"salt"`
 }
+
+type kdfParams struct {
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+
+	// argon2id, memory is in KiB
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+
+	KeyLen int    `json:"keylen"`
+	Salt   string `json:"salt"`
+}
@@ -0,0 +1,175 @@
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+const (
+	// DefaultAccounts is the count of BIP-44 accounts listed from the signer
+	DefaultAccounts = 10
+	// signTimeout is long enough for the user of signer to confirm
+	signTimeout = 2 * time.Minute
+	dialTimeout = 3 * time.Second
+)
+
+// Backend is a wallet.Backend reached over a local socket
+type Backend struct {
+	network string
+	address string
+
+	// callMu serializes the requests, a signature may wait for the user for a long time
+	callMu sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	id     uint64
+
+	mu    sync.Mutex
+	paths map[types.Address]string
+
+	log log15.Logger
+}
+
+// Dial connects to the signer at url, e.g. "unix:///var/run/signer.sock" or "tcp://127.0.0.1:48150",
+// and lists the addresses of the first accounts.
+func Dial(url string, accounts uint32) (*Backend, error) {
+	parts := strings.SplitN(url, "://", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid url of external signer: %s", url)
+	}
+	if accounts == 0 {
+		accounts = DefaultAccounts
+	}
+
+	conn, err := net.DialTimeout(parts[0], parts[1], dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	b := &Backend{
+		network: parts[0],
+		address: parts[1],
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		log:     log15.New("module", "wallet/external", "url", url),
+	}
+	if err = b.Refresh(accounts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Backend) URL() string {
+	return b.network + "://" + b.address
+}
+
+// Refresh lists the addresses of the first accounts again, e.g. after the device is changed.
+func (b *Backend) Refresh(accounts uint32) error {
+	params := &listAddressesParams{}
+	for i := uint32(0); i < accounts; i++ {
+		params.Paths = append(params.Paths, fmt.Sprintf(derivation.ViteAccountPathFormat, i))
+	}
+	result := &listAddressesResult{}
+	if err := b.call(MethodListAddresses, params, result, dialTimeout); err != nil {
+		return err
+	}
+
+	paths := make(map[types.Address]string, len(result.Addresses))
+	for _, v := range result.Addresses {
+		paths[v.Address] = v.Path
+	}
+	b.mu.Lock()
+	b.paths = paths
+	b.mu.Unlock()
+	b.log.Info("list addresses of external signer", "count", len(paths))
+	return nil
+}
+
+func (b *Backend) Addresses() ([]types.Address, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addrs := make([]types.Address, 0, len(b.paths))
+	for addr := range b.paths {
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// SignData blocks until the user of signer confirms or rejects, ErrRejected is returned if rejected.
+func (b *Backend) SignData(addr types.Address, data []byte) (signature, pubkey []byte, err error) {
+	b.mu.Lock()
+	path, ok := b.paths[addr]
+	b.mu.Unlock()
+	if !ok {
+		return nil, nil, errUnknownAddr
+	}
+
+	result := &signDataResult{}
+	if err := b.call(MethodSignData, &signDataParams{Path: path, Address: addr, Data: data}, result, signTimeout); err != nil {
+		return nil, nil, err
+	}
+	if len(result.PublicKey) != ed25519.PublicKeySize || types.PubkeyToAddress(result.PublicKey) != addr {
+		return nil, nil, errWrongAddress
+	}
+	// a faulty device must not make invalid blocks
+	if len(result.Signature) != ed25519.SignatureSize || !ed25519.Verify(result.PublicKey, data, result.Signature) {
+		return nil, nil, errBadSignature
+	}
+	return result.Signature, result.PublicKey, nil
+}
+
+func (b *Backend) Close() error {
+	return b.conn.Close()
+}
+
+func (b *Backend) call(method string, params interface{}, result interface{}, timeout time.Duration) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	b.callMu.Lock()
+	defer b.callMu.Unlock()
+	b.id++
+	req := &request{ID: b.id, Method: method, Params: data}
+
+	if err = b.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if err = json.NewEncoder(b.conn).Encode(req); err != nil {
+		return err
+	}
+	res := &response{}
+	// skip the late responses of the requests timed out before
+	for res.ID < req.ID {
+		line, err := b.reader.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		res = &response{}
+		if err = json.Unmarshal(line, res); err != nil {
+			return err
+		}
+	}
+	if res.ID != req.ID {
+		return fmt.Errorf("response %d does not match request %d", res.ID, req.ID)
+	}
+	if res.Error != "" {
+		if res.Error == ErrRejected.Error() {
+			return ErrRejected
+		}
+		return errors.New(res.Error)
+	}
+	return json.Unmarshal(res.Result, result)
+}
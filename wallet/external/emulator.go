@@ -0,0 +1,158 @@
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tyler-smith/go-bip39"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+// ConfirmFunc is asked before every signature, the signature is rejected if it returns false
+type ConfirmFunc func(path string, data []byte) bool
+
+// Emulator is a software external signer with the keys of a mnemonic, for tests and development
+type Emulator struct {
+	seed    []byte
+	confirm ConfirmFunc
+
+	mu    sync.Mutex
+	ln    net.Listener
+	conns map[net.Conn]struct{}
+
+	log log15.Logger
+}
+
+// NewEmulator creates an emulator of the mnemonic, all signatures are confirmed if confirm is nil.
+func NewEmulator(mnemonic string, confirm ConfirmFunc) (*Emulator, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Emulator{
+		seed:    seed,
+		confirm: confirm,
+		conns:   make(map[net.Conn]struct{}),
+		log:     log15.New("module", "wallet/external/emulator"),
+	}, nil
+}
+
+// Serve accepts connections on ln until Close is called.
+func (e *Emulator) Serve(ln net.Listener) error {
+	e.mu.Lock()
+	e.ln = ln
+	e.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		e.mu.Lock()
+		e.conns[conn] = struct{}{}
+		e.mu.Unlock()
+		go e.serveConn(conn)
+	}
+}
+
+func (e *Emulator) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for conn := range e.conns {
+		conn.Close()
+	}
+	if e.ln != nil {
+		return e.ln.Close()
+	}
+	return nil
+}
+
+func (e *Emulator) serveConn(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		e.mu.Lock()
+		delete(e.conns, conn)
+		e.mu.Unlock()
+	}()
+
+	reader := bufio.NewReader(conn)
+	encoder := json.NewEncoder(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		req := &request{}
+		if err = json.Unmarshal(line, req); err != nil {
+			e.log.Warn("invalid request", "err", err)
+			return
+		}
+
+		res := &response{ID: req.ID}
+		result, err := e.handle(req)
+		if err == nil {
+			res.Result, err = json.Marshal(result)
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		if err = encoder.Encode(res); err != nil {
+			return
+		}
+	}
+}
+
+func (e *Emulator) handle(req *request) (interface{}, error) {
+	switch req.Method {
+	case MethodListAddresses:
+		params := &listAddressesParams{}
+		if err := json.Unmarshal(req.Params, params); err != nil {
+			return nil, err
+		}
+		result := &listAddressesResult{}
+		for _, path := range params.Paths {
+			key, err := derivation.DeriveForPath(path, e.seed)
+			if err != nil {
+				return nil, errUnknownPath
+			}
+			addr, err := key.Address()
+			if err != nil {
+				return nil, err
+			}
+			result.Addresses = append(result.Addresses, PathAddress{Path: path, Address: *addr})
+		}
+		return result, nil
+
+	case MethodSignData:
+		params := &signDataParams{}
+		if err := json.Unmarshal(req.Params, params); err != nil {
+			return nil, err
+		}
+		key, err := derivation.DeriveForPath(params.Path, e.seed)
+		if err != nil {
+			return nil, errUnknownPath
+		}
+		addr, err := key.Address()
+		if err != nil {
+			return nil, err
+		}
+		if *addr != params.Address {
+			return nil, errWrongAddress
+		}
+		if e.confirm != nil && !e.confirm(params.Path, params.Data) {
+			return nil, ErrRejected
+		}
+		signature, pubkey, err := key.SignData(params.Data)
+		if err != nil {
+			return nil, err
+		}
+		return &signDataResult{Signature: signature, PublicKey: pubkey}, nil
+
+	default:
+		return nil, errors.Errorf("unknown method %s", req.Method)
+	}
+}
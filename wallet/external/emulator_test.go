@@ -0,0 +1,185 @@
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+const testMnemonic = "alter meat balance father season shop text figure pitch another fade figure faith chat smooth pottery dilemma pause differ equal shuffle series valve render"
+
+func startEmulator(t *testing.T, confirm ConfirmFunc) (string, func()) {
+	dir, err := ioutil.TempDir("", "external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("unix", filepath.Join(dir, "signer.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emulator, err := NewEmulator(testMnemonic, confirm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go emulator.Serve(ln)
+	return "unix://" + ln.Addr().String(), func() {
+		emulator.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestBackend_SignData(t *testing.T) {
+	rejected := types.Hash{1}
+	url, stop := startEmulator(t, func(path string, data []byte) bool {
+		return string(data) != string(rejected.Bytes())
+	})
+	defer stop()
+
+	b, err := Dial(url, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	addrs, _ := b.Addresses()
+	if len(addrs) != 3 {
+		t.Fatalf("%d addresses are listed", len(addrs))
+	}
+	seed, _ := NewEmulator(testMnemonic, nil)
+	primary, _ := derivation.GetPrimaryAddress(seed.seed)
+	if b.paths[*primary] != derivation.VitePrimaryAccountPath {
+		t.Fatalf("primary address is not listed: %v", b.paths)
+	}
+
+	data := types.Hash{2}.Bytes()
+	signature, pubkey, err := b.SignData(*primary, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pubkey, data, signature) {
+		t.Error("invalid signature")
+	}
+
+	if _, _, err = b.SignData(*primary, rejected.Bytes()); err != ErrRejected {
+		t.Errorf("signature should be rejected: %v", err)
+	}
+	if _, _, err = b.SignData(types.Address{1}, data); err != errUnknownAddr {
+		t.Errorf("unknown address should not be signed: %v", err)
+	}
+}
+
+// startFaultyDevice serves the emulator but corrupts the signatures
+func startFaultyDevice(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("unix", filepath.Join(dir, "signer.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emulator, err := NewEmulator(testMnemonic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		encoder := json.NewEncoder(conn)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			req := &request{}
+			if err = json.Unmarshal(line, req); err != nil {
+				return
+			}
+			result, err := emulator.handle(req)
+			if err != nil {
+				return
+			}
+			if signed, ok := result.(*signDataResult); ok {
+				signed.Signature[0] ^= 1
+			}
+			res := &response{ID: req.ID}
+			res.Result, _ = json.Marshal(result)
+			if err = encoder.Encode(res); err != nil {
+				return
+			}
+		}
+	}()
+	return "unix://" + ln.Addr().String(), func() {
+		ln.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestBackend_SignDataFaulty(t *testing.T) {
+	url, stop := startFaultyDevice(t)
+	defer stop()
+
+	b, err := Dial(url, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	addrs, _ := b.Addresses()
+	if len(addrs) != 1 {
+		t.Fatalf("%d addresses are listed", len(addrs))
+	}
+	if _, _, err = b.SignData(addrs[0], types.Hash{2}.Bytes()); err != errBadSignature {
+		t.Errorf("corrupted signature should be refused: %v", err)
+	}
+}
+
+func TestManager_ExternalBackend(t *testing.T) {
+	url, stop := startEmulator(t, nil)
+	defer stop()
+
+	b, err := Dial(url, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := wallet.New(&wallet.Config{DataDir: os.TempDir()})
+	if err = m.AddBackend(b); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.AddBackend(b); err == nil {
+		t.Error("backend should not be added twice")
+	}
+
+	addrs, _ := m.Addresses()
+	if len(addrs) != 1 {
+		t.Fatalf("addresses %v", addrs)
+	}
+	data := []byte("message")
+	signature, pubkey, err := m.SignData(addrs[0], data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pubkey, data, signature) {
+		t.Error("invalid signature")
+	}
+
+	if err = m.RemoveBackend(url); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = m.SignData(addrs[0], data); err == nil {
+		t.Error("backend is removed")
+	}
+}
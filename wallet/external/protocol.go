@@ -0,0 +1,64 @@
+// Package external talks to a signer holding keys outside the node, e.g. a hardware wallet behind a
+// local daemon. The keys never leave the signer, which asks its user to confirm every signature.
+package external
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// the protocol is newline delimited JSON over a stream socket, a request is answered by a response
+// with the same id, and requests are sent one at a time
+const (
+	MethodListAddresses = "listAddresses"
+	MethodSignData      = "signData"
+)
+
+var (
+	ErrRejected     = errors.New("signature is rejected by the user of signer")
+	errUnknownPath  = errors.New("unknown derivation path")
+	errUnknownAddr  = errors.New("address is not in the signer")
+	errWrongAddress = errors.New("address does not match the path")
+	errBadSignature = errors.New("signature does not match the data")
+)
+
+type request struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// listAddressesParams asks for the addresses of BIP-44 paths, e.g. "m/44'/666666'/0'"
+type listAddressesParams struct {
+	Paths []string `json:"paths"`
+}
+
+type PathAddress struct {
+	Path    string        `json:"path"`
+	Address types.Address `json:"address"`
+}
+
+type listAddressesResult struct {
+	Addresses []PathAddress `json:"addresses"`
+}
+
+// signDataParams carries the address to let the signer check it is derived from the path,
+// data is usually the hash of an account block.
+type signDataParams struct {
+	Path    string        `json:"path"`
+	Address types.Address `json:"address"`
+	Data    []byte        `json:"data"`
+}
+
+type signDataResult struct {
+	Signature []byte `json:"signature"`
+	PublicKey []byte `json:"publicKey"`
+}
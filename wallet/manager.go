@@ -23,6 +23,14 @@ type Manager struct {
 	unlockChangedLis    map[int]func(event entropystore.UnlockEvent)
 	mutex               sync.Mutex
 
+	// backends sign by the keys outside the entropy stores, the first one is the entropy stores.
+	// backendMu is a pointer since some methods of Manager have value receivers.
+	backends  []Backend
+	backendMu *sync.Mutex
+
+	// watch is the watch-only address sets in the data dir
+	watch *watchList
+
 	log log15.Logger
 }
 
@@ -35,13 +43,17 @@ func New(config *Config) *Manager {
 		config.MaxSearchIndex = entropystore.DefaultMaxIndex
 	}
 
-	return &Manager{
+	m := &Manager{
 		config:              config,
 		unlockChangedLis:    make(map[int]func(event entropystore.UnlockEvent)),
 		entropyStoreManager: make(map[string]*entropystore.Manager),
+		backendMu:           &sync.Mutex{},
+		watch:               newWatchList(filepath.Join(config.DataDir, WatchFileName)),
 
 		log: log15.New("module", "wallet"),
 	}
+	m.backends = []Backend{&KeystoreBackend{m: m}}
+	return m
 }
 
 func (m Manager) ListAllEntropyFiles() []string {
@@ -162,6 +174,16 @@ func (m Manager) ExtractMnemonic(entropyStore string, passphrase string) (string
 	return manager.ExtractMnemonic(passphrase)
 
 }
+
+// ChangePassphrase changes the passphrase of the entropy store, or upgrades its kdf params if params is not nil
+func (m *Manager) ChangePassphrase(entropyStore, passphrase, newPassphrase string, params *entropystore.KDFParams) error {
+	manager, e := m.GetEntropyStoreManager(entropyStore)
+	if e != nil {
+		return e
+	}
+	return manager.ChangePassphrase(passphrase, newPassphrase, params)
+}
+
 func (m *Manager) GetEntropyStoreManager(entropyStore string) (*entropystore.Manager, error) {
 	absPath := entropyStore
 	if !filepath.IsAbs(absPath) {
@@ -252,6 +274,10 @@ func (m Manager) GetDataDir() string {
 }
 
 func (m *Manager) Start() error {
+	if e := m.watch.load(); e != nil {
+		m.log.Error("wallet start load watch sets", "err", e)
+		return e
+	}
 	m.entropyStoreManager = make(map[string]*entropystore.Manager)
 	files, e := m.ListEntropyFilesInStandardDir()
 	if e != nil {
@@ -273,6 +299,12 @@ func (m *Manager) Stop() {
 		em.RemoveUnlockChangeChannel()
 	}
 	m.entropyStoreManager = nil
+
+	for _, b := range m.Backends()[1:] {
+		if err := m.RemoveBackend(b.URL()); err != nil {
+			m.log.Warn("close wallet backend failed", "url", b.URL(), "err", err)
+		}
+	}
 }
 
 func (m Manager) AddLockEventListener(lis func(event entropystore.UnlockEvent)) int {
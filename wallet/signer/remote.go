@@ -0,0 +1,217 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var errBadSignature = errors.New("remote signer: signature is not of the address")
+
+// the remote protocol is JSON over HTTP, every request is authenticated by HMAC-SHA256 of a shared secret
+const (
+	PathAddresses         = "/v1/addresses"
+	PathSignSnapshotBlock = "/v1/sign/snapshotBlock"
+	PathSignAccountBlock  = "/v1/sign/accountBlock"
+	PathSignFinalityVote  = "/v1/sign/finalityVote"
+
+	HeaderTimestamp = "X-Signer-Timestamp"
+	HeaderNonce     = "X-Signer-Nonce"
+	HeaderAuth      = "X-Signer-Auth"
+
+	// maxAuthDrift is the max difference between the timestamp of request and local time
+	maxAuthDrift = 30 * time.Second
+	// maxRequestSize is the max size of request body
+	maxRequestSize = 4 << 20
+	// nonceSize is the bytes of a random nonce, a nonce can be used only once
+	nonceSize = 16
+
+	remoteTimeout = 3 * time.Second
+	// addressesTTL is how long the addresses of the remote signer are cached, including a failed query
+	addressesTTL = 10 * time.Second
+)
+
+type addressesResponse struct {
+	Addresses []types.Address `json:"addresses"`
+}
+
+// signRequest carry the serialized block or vote, the signer will check it and compute hash by itself
+type signRequest struct {
+	Address types.Address `json:"address"`
+	Block   []byte        `json:"block"`
+}
+
+type signResponse struct {
+	Signature []byte `json:"signature"`
+	PublicKey []byte `json:"publicKey"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// authCode is HMAC-SHA256 of method, path, timestamp, nonce and body
+func authCode(secret []byte, method, path, timestamp, nonce string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n"))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Remote sign by a signer server on another host
+type Remote struct {
+	url    string
+	secret []byte
+	client *http.Client
+
+	mu sync.Mutex
+	// addresses and the error of last query, queried again after addressesTTL
+	addrs     []types.Address
+	addrsErr  error
+	addrsTime time.Time
+}
+
+func NewRemote(url string, secret string) *Remote {
+	return &Remote{
+		url:    strings.TrimRight(url, "/"),
+		secret: []byte(secret),
+		client: &http.Client{
+			Timeout: remoteTimeout,
+		},
+	}
+}
+
+func (r *Remote) call(method, path string, req interface{}, res interface{}) error {
+	var body []byte
+	if req != nil {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return err
+		}
+	}
+
+	request, err := http.NewRequest(method, r.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonceStr := hex.EncodeToString(nonce)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderTimestamp, timestamp)
+	request.Header.Set(HeaderNonce, nonceStr)
+	request.Header.Set(HeaderAuth, hex.EncodeToString(authCode(r.secret, method, path, timestamp, nonceStr, body)))
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "remote signer")
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrap(err, "remote signer")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return fmt.Errorf("remote signer: %s", e.Error)
+		}
+		return fmt.Errorf("remote signer: %s", response.Status)
+	}
+
+	return json.Unmarshal(data, res)
+}
+
+// Addresses is called every slot, so the result is cached for addressesTTL
+func (r *Remote) Addresses() ([]types.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.addrsTime.IsZero() || time.Since(r.addrsTime) > addressesTTL {
+		var res addressesResponse
+		r.addrsErr = r.call(http.MethodGet, PathAddresses, nil, &res)
+		r.addrs = res.Addresses
+		r.addrsTime = time.Now()
+	}
+
+	if r.addrsErr != nil {
+		return nil, r.addrsErr
+	}
+	return r.addrs, nil
+}
+
+// sign asks the remote signer to sign block by addr, the signature of hash is verified before used
+func (r *Remote) sign(path string, addr types.Address, block []byte, hash types.Hash) (signature, pubkey []byte, err error) {
+	var res signResponse
+	if err = r.call(http.MethodPost, path, signRequest{Address: addr, Block: block}, &res); err != nil {
+		return nil, nil, err
+	}
+
+	if ok, _ := crypto.VerifySig(res.PublicKey, hash.Bytes(), res.Signature); !ok || types.PubkeyToAddress(res.PublicKey) != addr {
+		return nil, nil, errBadSignature
+	}
+	return res.Signature, res.PublicKey, nil
+}
+
+func (r *Remote) SignSnapshotBlock(addr types.Address, block *ledger.SnapshotBlock) (signature, pubkey []byte, err error) {
+	data, err := block.Serialize()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.sign(PathSignSnapshotBlock, addr, data, block.Hash)
+}
+
+func (r *Remote) SignAccountBlock(addr types.Address, block *ledger.AccountBlock) (signature, pubkey []byte, err error) {
+	data, err := block.Serialize()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.sign(PathSignAccountBlock, addr, data, block.Hash)
+}
+
+func (r *Remote) SignFinalityVote(addr types.Address, vote *ledger.FinalityVote) (signature, pubkey []byte, err error) {
+	return r.sign(PathSignFinalityVote, addr, encodeFinalityVote(vote), vote.DataHash())
+}
+
+// encodeFinalityVote is height and hash, the vote is not signed yet
+func encodeFinalityVote(vote *ledger.FinalityVote) []byte {
+	buf := make([]byte, 8+types.HashSize)
+	binary.BigEndian.PutUint64(buf, vote.Height)
+	copy(buf[8:], vote.Hash.Bytes())
+	return buf
+}
+
+func decodeFinalityVote(buf []byte) (*ledger.FinalityVote, error) {
+	if len(buf) != 8+types.HashSize {
+		return nil, errors.New("malformed finality vote")
+	}
+
+	vote := &ledger.FinalityVote{Height: binary.BigEndian.Uint64(buf)}
+	vote.Hash, _ = types.BytesToHash(buf[8:])
+	return vote, nil
+}
@@ -0,0 +1,290 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+var (
+	errUnauthorized     = errors.New("unauthorized")
+	errReplayed         = errors.New("request is replayed")
+	errAddressForbidden = errors.New("address is not allowed")
+	errHashMismatch     = errors.New("hash of block mismatch")
+	errTimeDrift        = errors.New("timestamp of snapshot block is too far from now")
+	errSnapshotConflict = errors.New("snapshot block conflicts with a signed block")
+	errSendForbidden    = errors.New("send block is not allowed")
+	errWrongAccount     = errors.New("block does not belong to the address")
+	errVoteConflict     = errors.New("finality vote conflicts with a signed vote")
+)
+
+// Policy is the checks before a block is signed
+type Policy struct {
+	// Addresses can be signed, all addresses of the signer if empty
+	Addresses []types.Address
+	// MaxTimeDrift is the max difference between the timestamp of snapshot block and local time, 0 means no check
+	MaxTimeDrift time.Duration
+	// AllowSendBlocks allow to sign send blocks of user accounts, only receive blocks are signed otherwise
+	AllowSendBlocks bool
+}
+
+// Server serve the remote protocol by a local signer, with policy checks
+type Server struct {
+	signer Signer
+	secret []byte
+	policy Policy
+
+	mu sync.Mutex
+	// last signed snapshot block of every address, refuse to sign an older one or another one in the same slot
+	last map[types.Address]*ledger.SnapshotBlock
+	// last signed finality vote of every address, refuse to sign a lower one or another one at the same height
+	lastVote map[types.Address]*ledger.FinalityVote
+
+	nonceMu sync.Mutex
+	// nonces of authorized requests and when they can be forgotten, a request older than maxAuthDrift is refused anyway
+	nonces map[string]time.Time
+
+	log log15.Logger
+}
+
+func NewServer(signer Signer, secret string, policy Policy) *Server {
+	return &Server{
+		signer:   signer,
+		secret:   []byte(secret),
+		policy:   policy,
+		last:     make(map[types.Address]*ledger.SnapshotBlock),
+		lastVote: make(map[types.Address]*ledger.FinalityVote),
+		nonces:   make(map[string]time.Time),
+		log:      log15.New("module", "signer/server"),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = s.authorize(r, body); err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var res interface{}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == PathAddresses:
+		var addrs []types.Address
+		if addrs, err = s.addresses(); err == nil {
+			res = addressesResponse{Addresses: addrs}
+		}
+	case r.Method == http.MethodPost && r.URL.Path == PathSignSnapshotBlock:
+		res, err = s.signSnapshotBlock(body)
+	case r.Method == http.MethodPost && r.URL.Path == PathSignAccountBlock:
+		res, err = s.signAccountBlock(body)
+	case r.Method == http.MethodPost && r.URL.Path == PathSignFinalityVote:
+		res, err = s.signFinalityVote(body)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown request %s %s", r.Method, r.URL.Path))
+		return
+	}
+
+	if err != nil {
+		s.log.Warn("refuse to sign", "path", r.URL.Path, "remote", r.RemoteAddr, "err", err)
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
+
+func (s *Server) authorize(r *http.Request, body []byte) error {
+	timestamp := r.Header.Get(HeaderTimestamp)
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errUnauthorized
+	}
+	if d := time.Since(time.Unix(t, 0)); d > maxAuthDrift || d < -maxAuthDrift {
+		return errUnauthorized
+	}
+
+	nonce := r.Header.Get(HeaderNonce)
+	if n, err := hex.DecodeString(nonce); err != nil || len(n) != nonceSize {
+		return errUnauthorized
+	}
+
+	code, err := hex.DecodeString(r.Header.Get(HeaderAuth))
+	if err != nil {
+		return errUnauthorized
+	}
+	if !hmac.Equal(code, authCode(s.secret, r.Method, r.URL.Path, timestamp, nonce, body)) {
+		return errUnauthorized
+	}
+
+	return s.useNonce(nonce, time.Unix(t, 0).Add(maxAuthDrift))
+}
+
+// useNonce remember the nonce until expiration, refuse it if it has been used
+func (s *Server) useNonce(nonce string, expiration time.Time) error {
+	s.nonceMu.Lock()
+	defer s.nonceMu.Unlock()
+
+	now := time.Now()
+	for n, exp := range s.nonces {
+		if now.After(exp) {
+			delete(s.nonces, n)
+		}
+	}
+
+	if _, ok := s.nonces[nonce]; ok {
+		return errReplayed
+	}
+	s.nonces[nonce] = expiration
+	return nil
+}
+
+func (s *Server) addresses() ([]types.Address, error) {
+	if len(s.policy.Addresses) > 0 {
+		return s.policy.Addresses, nil
+	}
+
+	return s.signer.Addresses()
+}
+
+func (s *Server) allowed(addr types.Address) bool {
+	if len(s.policy.Addresses) == 0 {
+		return true
+	}
+
+	for _, a := range s.policy.Addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) signSnapshotBlock(body []byte) (*signResponse, error) {
+	var req signRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	if !s.allowed(req.Address) {
+		return nil, errAddressForbidden
+	}
+
+	block := &ledger.SnapshotBlock{}
+	if err := block.Deserialize(req.Block); err != nil {
+		return nil, err
+	}
+	if block.ComputeHash() != block.Hash {
+		return nil, errHashMismatch
+	}
+	if s.policy.MaxTimeDrift > 0 {
+		if d := time.Since(*block.Timestamp); d > s.policy.MaxTimeDrift || d < -s.policy.MaxTimeDrift {
+			return nil, errTimeDrift
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.last[req.Address]; ok && last.Hash != block.Hash {
+		if !block.Timestamp.After(*last.Timestamp) {
+			return nil, errSnapshotConflict
+		}
+	}
+
+	signature, pubkey, err := s.signer.SignSnapshotBlock(req.Address, block)
+	if err != nil {
+		return nil, err
+	}
+	s.last[req.Address] = block
+
+	return &signResponse{Signature: signature, PublicKey: pubkey}, nil
+}
+
+func (s *Server) signAccountBlock(body []byte) (*signResponse, error) {
+	var req signRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	if !s.allowed(req.Address) {
+		return nil, errAddressForbidden
+	}
+
+	block := &ledger.AccountBlock{}
+	if err := block.Deserialize(req.Block); err != nil {
+		return nil, err
+	}
+	if block.ComputeHash() != block.Hash {
+		return nil, errHashMismatch
+	}
+
+	// blocks of contracts are signed by the producer
+	if !types.IsContractAddr(block.AccountAddress) {
+		if block.AccountAddress != req.Address {
+			return nil, errWrongAccount
+		}
+		if block.IsSendBlock() && !s.policy.AllowSendBlocks {
+			return nil, errSendForbidden
+		}
+	}
+
+	signature, pubkey, err := s.signer.SignAccountBlock(req.Address, block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signResponse{Signature: signature, PublicKey: pubkey}, nil
+}
+
+func (s *Server) signFinalityVote(body []byte) (*signResponse, error) {
+	var req signRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	if !s.allowed(req.Address) {
+		return nil, errAddressForbidden
+	}
+
+	vote, err := decodeFinalityVote(req.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.lastVote[req.Address]; ok && last.Hash != vote.Hash {
+		if vote.Height <= last.Height {
+			return nil, errVoteConflict
+		}
+	}
+
+	signature, pubkey, err := s.signer.SignFinalityVote(req.Address, vote)
+	if err != nil {
+		return nil, err
+	}
+	s.lastVote[req.Address] = vote
+
+	return &signResponse{Signature: signature, PublicKey: pubkey}, nil
+}
@@ -0,0 +1,106 @@
+// Package signer signs snapshot blocks, account blocks and finality votes for producers,
+// the keys can be in the local keystore or on a remote host.
+package signer
+
+import (
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+)
+
+// Signer sign blocks by the key of addr, the hash of block must be computed before signing
+type Signer interface {
+	// Addresses return all addresses can be signed, it is called every slot so it should be cheap
+	Addresses() ([]types.Address, error)
+	SignSnapshotBlock(addr types.Address, block *ledger.SnapshotBlock) (signature, pubkey []byte, err error)
+	SignAccountBlock(addr types.Address, block *ledger.AccountBlock) (signature, pubkey []byte, err error)
+	SignFinalityVote(addr types.Address, vote *ledger.FinalityVote) (signature, pubkey []byte, err error)
+}
+
+// Contains return true if addr can be signed by s
+func Contains(s Signer, addr types.Address) bool {
+	addrs, err := s.Addresses()
+	if err != nil {
+		return false
+	}
+
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Local sign by the unlocked entropy stores of wallet only, the other backends of wallet
+// may wait for a confirmation of user which is much longer than a slot
+type Local struct {
+	wt *wallet.Manager
+
+	mu sync.Mutex
+	// addresses of the unlocked entropy stores, derived once after unlocked
+	cache map[*entropystore.Manager][]types.Address
+}
+
+func NewLocal(wt *wallet.Manager) *Local {
+	return &Local{
+		wt:    wt,
+		cache: make(map[*entropystore.Manager][]types.Address),
+	}
+}
+
+// Addresses return the addresses of unlocked entropy stores, the locked ones are removed from cache
+func (l *Local) Addresses() ([]types.Address, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var addrs []types.Address
+	unlocked := make(map[*entropystore.Manager]struct{}, len(l.cache))
+	for _, file := range l.wt.ListAllEntropyFiles() {
+		em, err := l.wt.GetEntropyStoreManager(file)
+		if err != nil || !em.IsUnlocked() {
+			continue
+		}
+
+		list, ok := l.cache[em]
+		if !ok {
+			// locked meanwhile
+			if list, err = em.ListAddress(0, entropystore.DefaultMaxIndex); err != nil {
+				continue
+			}
+			l.cache[em] = list
+		}
+		unlocked[em] = struct{}{}
+		addrs = append(addrs, list...)
+	}
+
+	for em := range l.cache {
+		if _, ok := unlocked[em]; !ok {
+			delete(l.cache, em)
+		}
+	}
+	return addrs, nil
+}
+
+func (l *Local) sign(addr types.Address, data []byte) (signature, pubkey []byte, err error) {
+	_, key, _, err := l.wt.GlobalFindAddr(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key.SignData(data)
+}
+
+func (l *Local) SignSnapshotBlock(addr types.Address, block *ledger.SnapshotBlock) (signature, pubkey []byte, err error) {
+	return l.sign(addr, block.Hash.Bytes())
+}
+
+func (l *Local) SignAccountBlock(addr types.Address, block *ledger.AccountBlock) (signature, pubkey []byte, err error) {
+	return l.sign(addr, block.Hash.Bytes())
+}
+
+func (l *Local) SignFinalityVote(addr types.Address, vote *ledger.FinalityVote) (signature, pubkey []byte, err error) {
+	return l.sign(addr, vote.DataHash().Bytes())
+}
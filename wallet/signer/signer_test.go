@@ -0,0 +1,347 @@
+package signer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/wallet"
+)
+
+func init() {
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:          &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:           &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:        &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:          &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:          &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:         &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork:     &config.ForkPoint{Height: 600, Version: 7},
+		ExpiryFork:        &config.ForkPoint{Height: 700, Version: 8},
+		MultisigFork:      &config.ForkPoint{Height: 800, Version: 9},
+		DelegateGroupFork: &config.ForkPoint{Height: 900, Version: 10}})
+}
+
+type keySigner struct {
+	addr types.Address
+	key  ed25519.PrivateKey
+}
+
+func newKeySigner(t *testing.T) *keySigner {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &keySigner{addr: types.PubkeyToAddress(key.PubByte()), key: key}
+}
+
+func (s *keySigner) Addresses() ([]types.Address, error) {
+	return []types.Address{s.addr}, nil
+}
+
+func (s *keySigner) SignSnapshotBlock(addr types.Address, block *ledger.SnapshotBlock) (signature, pubkey []byte, err error) {
+	return ed25519.Sign(s.key, block.Hash.Bytes()), s.key.PubByte(), nil
+}
+
+func (s *keySigner) SignAccountBlock(addr types.Address, block *ledger.AccountBlock) (signature, pubkey []byte, err error) {
+	return ed25519.Sign(s.key, block.Hash.Bytes()), s.key.PubByte(), nil
+}
+
+func (s *keySigner) SignFinalityVote(addr types.Address, vote *ledger.FinalityVote) (signature, pubkey []byte, err error) {
+	return ed25519.Sign(s.key, vote.DataHash().Bytes()), s.key.PubByte(), nil
+}
+
+// wrongSigner signs the data other than the hash
+type wrongSigner struct {
+	*keySigner
+}
+
+func (s wrongSigner) SignAccountBlock(addr types.Address, block *ledger.AccountBlock) (signature, pubkey []byte, err error) {
+	return ed25519.Sign(s.key, block.PrevHash.Bytes()), s.key.PubByte(), nil
+}
+
+func newSnapshotBlock(height uint64, timestamp time.Time) *ledger.SnapshotBlock {
+	block := &ledger.SnapshotBlock{
+		Height:    height,
+		Timestamp: &timestamp,
+	}
+	block.Hash = block.ComputeHash()
+	return block
+}
+
+func TestRemote_SignSnapshotBlock(t *testing.T) {
+	local := newKeySigner(t)
+	server := httptest.NewServer(NewServer(local, "secret", Policy{MaxTimeDrift: time.Minute}))
+	defer server.Close()
+
+	remote := NewRemote(server.URL, "secret")
+	if !Contains(remote, local.addr) {
+		t.Fatal("address should be listed")
+	}
+
+	now := time.Now()
+	block := newSnapshotBlock(10, now)
+	signature, pubkey, err := remote.SignSnapshotBlock(local.addr, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pubkey, block.Hash.Bytes(), signature) {
+		t.Error("wrong signature")
+	}
+
+	// the same block can be signed again
+	if _, _, err = remote.SignSnapshotBlock(local.addr, block); err != nil {
+		t.Errorf("failed to sign again: %v", err)
+	}
+	// another block in the same slot
+	if _, _, err = remote.SignSnapshotBlock(local.addr, newSnapshotBlock(11, now)); err == nil {
+		t.Error("should refuse conflicting block")
+	}
+	if _, _, err = remote.SignSnapshotBlock(local.addr, newSnapshotBlock(12, now.Add(-time.Hour))); err == nil {
+		t.Error("should refuse block out of time")
+	}
+	if _, _, err = remote.SignSnapshotBlock(local.addr, newSnapshotBlock(12, now.Add(time.Second))); err != nil {
+		t.Errorf("failed to sign next block: %v", err)
+	}
+	// the server signs by a key not of the address
+	if _, _, err = remote.SignSnapshotBlock(types.Address{1}, newSnapshotBlock(12, now.Add(time.Second))); err != errBadSignature {
+		t.Errorf("should refuse signature of other address: %v", err)
+	}
+
+	// tampered hash
+	block = newSnapshotBlock(13, now.Add(2*time.Second))
+	block.Hash = types.Hash{1}
+	if _, _, err = remote.SignSnapshotBlock(local.addr, block); err == nil {
+		t.Error("should refuse wrong hash")
+	}
+
+	if _, err = NewRemote(server.URL, "wrong").Addresses(); err == nil {
+		t.Error("should refuse wrong secret")
+	}
+}
+
+func TestRemote_SignAccountBlock(t *testing.T) {
+	local := newKeySigner(t)
+	server := httptest.NewServer(NewServer(local, "secret", Policy{Addresses: []types.Address{local.addr}}))
+	defer server.Close()
+
+	remote := NewRemote(server.URL, "secret")
+
+	newBlock := func(blockType byte, addr types.Address) *ledger.AccountBlock {
+		block := &ledger.AccountBlock{
+			BlockType:      blockType,
+			AccountAddress: addr,
+			Height:         2,
+			Amount:         big.NewInt(1),
+			Fee:            big.NewInt(0),
+		}
+		block.Hash = block.ComputeHash()
+		return block
+	}
+
+	block := newBlock(ledger.BlockTypeReceive, local.addr)
+	signature, pubkey, err := remote.SignAccountBlock(local.addr, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pubkey, block.Hash.Bytes(), signature) {
+		t.Error("wrong signature")
+	}
+
+	// receive blocks of contracts are signed by producer
+	contract := types.CreateContractAddress([]byte("contract"))
+	if _, _, err = remote.SignAccountBlock(local.addr, newBlock(ledger.BlockTypeReceive, contract)); err != nil {
+		t.Errorf("failed to sign contract block: %v", err)
+	}
+
+	if _, _, err = remote.SignAccountBlock(local.addr, newBlock(ledger.BlockTypeSendCall, local.addr)); err == nil {
+		t.Error("should refuse send block")
+	}
+	if _, _, err = remote.SignAccountBlock(local.addr, newBlock(ledger.BlockTypeReceive, types.Address{1})); err == nil {
+		t.Error("should refuse block of other account")
+	}
+	if _, _, err = remote.SignAccountBlock(types.Address{1}, newBlock(ledger.BlockTypeReceive, types.Address{1})); err == nil {
+		t.Error("should refuse address not allowed")
+	}
+
+	wrong := httptest.NewServer(NewServer(wrongSigner{local}, "secret", Policy{}))
+	defer wrong.Close()
+	if _, _, err = NewRemote(wrong.URL, "secret").SignAccountBlock(local.addr, block); err != errBadSignature {
+		t.Errorf("should refuse wrong signature: %v", err)
+	}
+}
+
+func TestRemote_SignFinalityVote(t *testing.T) {
+	local := newKeySigner(t)
+	server := httptest.NewServer(NewServer(local, "secret", Policy{}))
+	defer server.Close()
+
+	remote := NewRemote(server.URL, "secret")
+
+	vote := &ledger.FinalityVote{Height: 10, Hash: types.Hash{1}}
+	signature, pubkey, err := remote.SignFinalityVote(local.addr, vote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vote.Signature, vote.PublicKey = signature, pubkey
+	if !vote.VerifySignature() || vote.Voter() != local.addr {
+		t.Error("wrong signature")
+	}
+
+	if _, _, err = remote.SignFinalityVote(local.addr, &ledger.FinalityVote{Height: 10, Hash: types.Hash{1}}); err != nil {
+		t.Errorf("failed to sign again: %v", err)
+	}
+	if _, _, err = remote.SignFinalityVote(local.addr, &ledger.FinalityVote{Height: 10, Hash: types.Hash{2}}); err == nil {
+		t.Error("should refuse conflicting vote")
+	}
+	if _, _, err = remote.SignFinalityVote(local.addr, &ledger.FinalityVote{Height: 9, Hash: types.Hash{3}}); err == nil {
+		t.Error("should refuse lower vote")
+	}
+	if _, _, err = remote.SignFinalityVote(local.addr, &ledger.FinalityVote{Height: 11, Hash: types.Hash{4}}); err != nil {
+		t.Errorf("failed to sign next vote: %v", err)
+	}
+}
+
+func TestServer_replay(t *testing.T) {
+	local := newKeySigner(t)
+	server := httptest.NewServer(NewServer(local, "secret", Policy{}))
+	defer server.Close()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := hex.EncodeToString(make([]byte, nonceSize))
+	send := func(nonce string) int {
+		request, err := http.NewRequest(http.MethodGet, server.URL+PathAddresses, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set(HeaderTimestamp, timestamp)
+		request.Header.Set(HeaderNonce, nonce)
+		request.Header.Set(HeaderAuth, hex.EncodeToString(authCode([]byte("secret"), http.MethodGet, PathAddresses, timestamp, nonce, nil)))
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		return response.StatusCode
+	}
+
+	if code := send(nonce); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if code := send(nonce); code != http.StatusUnauthorized {
+		t.Errorf("replayed request should be refused, status %d", code)
+	}
+	if code := send(""); code != http.StatusUnauthorized {
+		t.Errorf("request without nonce should be refused, status %d", code)
+	}
+}
+
+type countSigner struct {
+	*keySigner
+	count int
+}
+
+func (s *countSigner) Addresses() ([]types.Address, error) {
+	s.count++
+	return s.keySigner.Addresses()
+}
+
+func TestRemote_Addresses_cached(t *testing.T) {
+	local := &countSigner{keySigner: newKeySigner(t)}
+	server := httptest.NewServer(NewServer(local, "secret", Policy{}))
+	defer server.Close()
+
+	remote := NewRemote(server.URL, "secret")
+	for i := 0; i < 3; i++ {
+		if !Contains(remote, local.addr) {
+			t.Fatal("address should be listed")
+		}
+	}
+	if local.count != 1 {
+		t.Errorf("addresses should be queried once, got %d", local.count)
+	}
+}
+
+// backend is a wallet backend outside the entropy stores
+type backend struct {
+	*keySigner
+}
+
+func (b *backend) URL() string {
+	return "test://backend"
+}
+
+func (b *backend) SignData(addr types.Address, data []byte) (signature, pubkey []byte, err error) {
+	return ed25519.Sign(b.key, data), b.key.PubByte(), nil
+}
+
+func (b *backend) Close() error {
+	return nil
+}
+
+func TestLocal_Addresses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wt := wallet.New(&wallet.Config{DataDir: dir})
+	if err = wt.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer wt.Stop()
+
+	_, em, err := wt.NewMnemonicAndEntropyStore("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := em.GetPrimaryAddr()
+
+	external := &backend{keySigner: newKeySigner(t)}
+	if err = wt.AddBackend(external); err != nil {
+		t.Fatal(err)
+	}
+
+	local := NewLocal(wt)
+	if Contains(local, external.addr) {
+		t.Error("address of other backends should not be listed")
+	}
+	if _, _, err = local.SignSnapshotBlock(external.addr, newSnapshotBlock(1, time.Now())); err == nil {
+		t.Error("should not sign by other backends")
+	}
+	if Contains(local, addr) {
+		t.Fatal("address of locked store should not be listed")
+	}
+
+	if err = wt.Unlock(em.GetEntropyStoreFile(), "123456"); err != nil {
+		t.Fatal(err)
+	}
+	if !Contains(local, addr) {
+		t.Fatal("address of unlocked store should be listed")
+	}
+	if len(local.cache) != 1 {
+		t.Errorf("addresses of unlocked store should be cached")
+	}
+
+	em.Lock()
+	if Contains(local, addr) {
+		t.Error("address of locked store should not be listed")
+	}
+	if len(local.cache) != 0 {
+		t.Errorf("addresses of locked store should be removed from cache")
+	}
+}
@@ -9,4 +9,6 @@ var (
 	ErrDecryptEntropy  = errors.New("error decrypt store")
 	ErrEmptyStore      = errors.New("error empty store")
 	ErrStoreNotFound   = errors.New("error given store not found ")
+
+	ErrWatchSetNotFound = errors.New("watch set not found")
 )
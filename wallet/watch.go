@@ -0,0 +1,227 @@
+package wallet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/wallet/walleterrors"
+)
+
+// WatchFileName is the file of watch-only address sets in the data dir of wallet
+const WatchFileName = "watch.json"
+
+// WatchAddress is a watch-only address with its label, it can be queried and watched but not signed
+type WatchAddress struct {
+	Address types.Address `json:"address"`
+	Label   string        `json:"label"`
+}
+
+// watchList keeps the named sets of watch-only addresses, every change is saved to the file atomically,
+// and discarded if failed to save
+type watchList struct {
+	filename string
+	sets     map[string]map[types.Address]string
+	mu       sync.RWMutex
+}
+
+func newWatchList(filename string) *watchList {
+	return &watchList{
+		filename: filename,
+		sets:     make(map[string]map[types.Address]string),
+	}
+}
+
+func (w *watchList) load() error {
+	data, err := ioutil.ReadFile(w.filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var file map[string][]WatchAddress
+	if err = json.Unmarshal(data, &file); err != nil {
+		return errors.Wrap(err, "parse "+w.filename)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sets = make(map[string]map[types.Address]string, len(file))
+	for name, list := range file {
+		set := make(map[types.Address]string, len(list))
+		for _, a := range list {
+			set[a.Address] = a.Label
+		}
+		w.sets[name] = set
+	}
+	return nil
+}
+
+// save writes and syncs a temp file and renames it, the caller must hold the write lock
+func (w *watchList) save() error {
+	file := make(map[string][]WatchAddress, len(w.sets))
+	for name := range w.sets {
+		file[name] = w.list(name)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(w.filename), "."+filepath.Base(w.filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	// flush before renaming, or a crash may leave an empty file in place of the list
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), w.filename)
+}
+
+// list returns the addresses of the set sorted by address, the caller must hold the lock
+func (w *watchList) list(name string) []WatchAddress {
+	set := w.sets[name]
+	list := make([]WatchAddress, 0, len(set))
+	for addr, label := range set {
+		list = append(list, WatchAddress{Address: addr, Label: label})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Address.String() < list[j].Address.String() })
+	return list
+}
+
+// copySet returns a copy of the set to be changed, so that the change can be discarded if failed to save
+func (w *watchList) copySet(name string) map[types.Address]string {
+	set := make(map[types.Address]string, len(w.sets[name]))
+	for addr, label := range w.sets[name] {
+		set[addr] = label
+	}
+	return set
+}
+
+// replace the set and save, the old set is restored if failed to save, nil set means removing
+func (w *watchList) replace(name string, set map[types.Address]string) error {
+	old, existed := w.sets[name]
+	if set == nil {
+		delete(w.sets, name)
+	} else {
+		w.sets[name] = set
+	}
+
+	if err := w.save(); err != nil {
+		if existed {
+			w.sets[name] = old
+		} else {
+			delete(w.sets, name)
+		}
+		return err
+	}
+	return nil
+}
+
+func (w *watchList) add(name string, addrs []WatchAddress) error {
+	if name == "" {
+		return errors.New("empty watch set name")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	set := w.copySet(name)
+	for _, a := range addrs {
+		set[a.Address] = a.Label
+	}
+	return w.replace(name, set)
+}
+
+func (w *watchList) remove(name string, addrs []types.Address) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.sets[name]; !ok {
+		return walleterrors.ErrWatchSetNotFound
+	}
+	set := w.copySet(name)
+	for _, addr := range addrs {
+		delete(set, addr)
+	}
+	return w.replace(name, set)
+}
+
+func (w *watchList) removeSet(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.sets[name]; !ok {
+		return walleterrors.ErrWatchSetNotFound
+	}
+	return w.replace(name, nil)
+}
+
+func (w *watchList) names() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	names := make([]string, 0, len(w.sets))
+	for name := range w.sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (w *watchList) get(name string) ([]WatchAddress, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if _, ok := w.sets[name]; !ok {
+		return nil, walleterrors.ErrWatchSetNotFound
+	}
+	return w.list(name), nil
+}
+
+func (w *watchList) contains(name string, addr types.Address) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	_, ok := w.sets[name][addr]
+	return ok
+}
+
+// AddWatchAddresses adds the addresses to the watch set, the set is created if not exists.
+// The label of an address already in the set is replaced.
+func (m *Manager) AddWatchAddresses(set string, addrs []WatchAddress) error {
+	return m.watch.add(set, addrs)
+}
+
+func (m *Manager) RemoveWatchAddresses(set string, addrs []types.Address) error {
+	return m.watch.remove(set, addrs)
+}
+
+func (m *Manager) RemoveWatchSet(set string) error {
+	return m.watch.removeSet(set)
+}
+
+// ListWatchSets returns the names of watch sets in order
+func (m *Manager) ListWatchSets() []string {
+	return m.watch.names()
+}
+
+// GetWatchSet returns the addresses and labels of the watch set, sorted by address
+func (m *Manager) GetWatchSet(set string) ([]WatchAddress, error) {
+	return m.watch.get(set)
+}
+
+func (m *Manager) IsWatched(set string, addr types.Address) bool {
+	return m.watch.contains(set, addr)
+}
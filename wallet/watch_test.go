@@ -0,0 +1,112 @@
+package wallet_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/walleterrors"
+)
+
+func TestManager_WatchSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cold, deposit := types.Address{1}, types.Address{2}
+	m := wallet.New(&wallet.Config{DataDir: dir})
+	if err = m.AddWatchAddresses("cold", []wallet.WatchAddress{{Address: cold, Label: "cold 1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.AddWatchAddresses("deposit", []wallet.WatchAddress{{Address: deposit, Label: "alice"}}); err != nil {
+		t.Fatal(err)
+	}
+	// relabel
+	if err = m.AddWatchAddresses("deposit", []wallet.WatchAddress{{Address: deposit, Label: "bob"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !m.IsWatched("cold", cold) || m.IsWatched("cold", deposit) {
+		t.Fatal("wrong watch set")
+	}
+
+	// reload from the data dir
+	m = wallet.New(&wallet.Config{DataDir: dir})
+	if err = m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	if names := m.ListWatchSets(); len(names) != 2 || names[0] != "cold" || names[1] != "deposit" {
+		t.Fatalf("watch sets %v", names)
+	}
+	set, err := m.GetWatchSet("deposit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 1 || set[0].Address != deposit || set[0].Label != "bob" {
+		t.Fatalf("deposit set %v", set)
+	}
+
+	if err = m.RemoveWatchAddresses("deposit", []types.Address{deposit}); err != nil {
+		t.Fatal(err)
+	}
+	if m.IsWatched("deposit", deposit) {
+		t.Fatal("address is removed")
+	}
+	if err = m.RemoveWatchSet("cold"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.GetWatchSet("cold"); err != walleterrors.ErrWatchSetNotFound {
+		t.Fatal(err)
+	}
+	if err = m.RemoveWatchSet("cold"); err != walleterrors.ErrWatchSetNotFound {
+		t.Fatal(err)
+	}
+}
+
+func TestManager_WatchSet_saveFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cold := types.Address{1}
+	m := wallet.New(&wallet.Config{DataDir: dir})
+	if err = m.AddWatchAddresses("cold", []wallet.WatchAddress{{Address: cold, Label: "cold 1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file can not be replaced by a directory, so every save fails
+	file := filepath.Join(dir, wallet.WatchFileName)
+	if err = os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Join(file, "x"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.AddWatchAddresses("cold", []wallet.WatchAddress{{Address: types.Address{2}}}); err == nil {
+		t.Fatal("save should fail")
+	}
+	if err = m.AddWatchAddresses("deposit", []wallet.WatchAddress{{Address: types.Address{3}}}); err == nil {
+		t.Fatal("save should fail")
+	}
+	if err = m.RemoveWatchAddresses("cold", []types.Address{cold}); err == nil {
+		t.Fatal("save should fail")
+	}
+	if err = m.RemoveWatchSet("cold"); err == nil {
+		t.Fatal("save should fail")
+	}
+
+	if names := m.ListWatchSets(); len(names) != 1 || names[0] != "cold" {
+		t.Fatalf("watch sets %v", names)
+	}
+	if set, err := m.GetWatchSet("cold"); err != nil || len(set) != 1 || set[0].Address != cold || set[0].Label != "cold 1" {
+		t.Fatalf("cold set %v, %v", set, err)
+	}
+}